@@ -0,0 +1,328 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"log/slog"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// SlogHandler returns a slog.Handler that feeds records into this package,
+// so they get its levels, module overrides, sampling, redaction and
+// output. It is the zero-dependency way to hand the logger to libraries
+// that want a logr.Logger (controller-runtime, client-go):
+//
+//	ctrl.SetLogger(logr.FromSlogHandler(logger.SlogHandler()))
+//
+// logr names become the module (see Named) and V(n) maps to slog level -n,
+// so V(1) to V(4) log at Debug and V(5) and up at Trace.
+//
+// This package implements neither logr.LogSink nor hclog.Logger itself,
+// which would make it depend on those modules; SlogHandler and HclogOutput
+// are the adapters until such implementations are added in a contrib
+// package.
+func SlogHandler() slog.Handler {
+	return &slogBridge{}
+}
+
+// slogBridge is the handler returned by SlogHandler
+type slogBridge struct {
+	module string
+	fields []any
+	prefix string
+}
+
+// Enabled is permissive: the module, and so its level, may only be known
+// once the record arrives
+func (h *slogBridge) Enabled(_ context.Context, level slog.Level) bool {
+	return minLevel(*loadConfig()) <= level
+}
+
+func (h *slogBridge) Handle(_ context.Context, r slog.Record) error {
+	module := h.module
+	keyValues := slices.Clip(h.fields)
+	r.Attrs(func(a slog.Attr) bool {
+		if name, ok := bridgeModule(h.prefix, a); ok {
+			module = name
+		}
+		keyValues = appendFlatAttr(keyValues, h.prefix, a)
+		return true
+	})
+
+	cfg := *loadConfig()
+	level := logLevelFromSlog(r.Level)
+	if !admitLog(cfg, module, level, r.Message, keyValues) {
+		return nil
+	}
+
+	var pc uintptr
+	if cfg.EnableCaller {
+		pc = r.PC
+	}
+	dispatchLog(cfg, level, r.Message, pc, keyValues)
+	return nil
+}
+
+func (h *slogBridge) WithAttrs(attrs []slog.Attr) slog.Handler {
+	clone := *h
+	clone.fields = slices.Clip(h.fields)
+	for _, a := range attrs {
+		if name, ok := bridgeModule(h.prefix, a); ok {
+			clone.module = name
+		}
+		clone.fields = appendFlatAttr(clone.fields, h.prefix, a)
+	}
+	return &clone
+}
+
+func (h *slogBridge) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	clone := *h
+	clone.prefix = h.prefix + name + "."
+	return &clone
+}
+
+// bridgeModule reports the module named by a top-level "logger" attr, which
+// is how logr.FromSlogHandler passes WithName ("a/b" becomes "a.b")
+func bridgeModule(prefix string, a slog.Attr) (string, bool) {
+	if prefix != "" || a.Key != "logger" || a.Value.Kind() != slog.KindString {
+		return "", false
+	}
+	return strings.ReplaceAll(a.Value.String(), "/", "."), true
+}
+
+// appendFlatAttr appends a as key/value pairs, flattening groups into
+// dotted keys
+func appendFlatAttr(keyValues []any, prefix string, a slog.Attr) []any {
+	v := a.Value.Resolve()
+	if v.Kind() == slog.KindGroup {
+		if a.Key != "" {
+			prefix += a.Key + "."
+		}
+		for _, ga := range v.Group() {
+			keyValues = appendFlatAttr(keyValues, prefix, ga)
+		}
+		return keyValues
+	}
+	if a.Key == "" {
+		return keyValues
+	}
+	return append(keyValues, prefix+a.Key, v.Any())
+}
+
+// HclogOutput returns a writer for hclog's JSON output that re-logs each
+// line through this package. Use it as the output of the hclog.Logger you
+// hand to HashiCorp libraries and Terraform providers:
+//
+//	hclog.New(&hclog.LoggerOptions{
+//		Output:     logger.HclogOutput(),
+//		JSONFormat: true,
+//		Level:      hclog.Trace, // Let Config.Level and ModuleLevels filter
+//	})
+//
+// "@module" becomes the module (see Named). Lines that are not JSON are
+// logged at Info as-is.
+func HclogOutput() io.Writer {
+	return &hclogWriter{}
+}
+
+// hclogWriter splits hclog output into lines and logs each one
+type hclogWriter struct {
+	mu      sync.Mutex
+	partial []byte
+}
+
+func (w *hclogWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.partial = append(w.partial, p...)
+	for {
+		i := bytes.IndexByte(w.partial, '\n')
+		if i < 0 {
+			break
+		}
+		logHclogLine(w.partial[:i])
+		w.partial = w.partial[i+1:]
+	}
+	if len(w.partial) == 0 {
+		w.partial = nil
+	}
+	return len(p), nil
+}
+
+// hclogLevels maps hclog's "@level" values
+var hclogLevels = map[string]LogLevel{
+	"trace": Trace,
+	"debug": Debug,
+	"info":  Info,
+	"warn":  Warn,
+	"error": Error,
+}
+
+// logHclogLine logs one line of hclog JSON output
+func logHclogLine(line []byte) {
+	line = bytes.TrimSpace(line)
+	if len(line) == 0 {
+		return
+	}
+
+	var fields map[string]any
+	dec := json.NewDecoder(bytes.NewReader(line))
+	dec.UseNumber()
+	if err := dec.Decode(&fields); err != nil {
+		if cfg := *loadConfig(); admitLog(cfg, "", Info, string(line), nil) {
+			dispatchLog(cfg, Info, string(line), 0, nil)
+		}
+		return
+	}
+
+	level, ok := hclogLevels[strings.ToLower(stringField(fields, "@level"))]
+	if !ok {
+		level = Info
+	}
+	message := stringField(fields, "@message")
+	module := stringField(fields, "@module")
+
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		if !strings.HasPrefix(k, "@") {
+			keys = append(keys, k)
+		}
+	}
+	slices.Sort(keys)
+
+	keyValues := make([]any, 0, 2*len(keys)+2)
+	if module != "" {
+		keyValues = append(keyValues, "logger", module)
+	}
+	for _, k := range keys {
+		value := fields[k]
+		if n, ok := value.(json.Number); ok {
+			if i, err := n.Int64(); err == nil {
+				value = i
+			} else if f, err := n.Float64(); err == nil {
+				value = f
+			}
+		}
+		keyValues = append(keyValues, k, value)
+	}
+
+	cfg := *loadConfig()
+	if admitLog(cfg, module, level, message, keyValues) {
+		dispatchLog(cfg, level, message, 0, keyValues)
+	}
+}
+
+// stringField returns fields[key] when it is a string
+func stringField(fields map[string]any, key string) string {
+	s, _ := fields[key].(string)
+	return s
+}
+
+// StdLogger returns a *log.Logger whose output is logged through this
+// package at level, for APIs that only take the stdlib logger:
+//
+//	srv := &http.Server{ErrorLog: logger.StdLogger(logger.Error)}
+//
+// A leading level tag in the line ("[WARN] ", "error: ", ...) overrides level
+// and is removed from the message.
+func StdLogger(level LogLevel) *log.Logger {
+	return log.New(&stdLogWriter{level: level}, "", 0)
+}
+
+// RedirectStdLog sends output of the stdlib log package (log.Printf, and
+// slog's default handler until slog.SetDefault is called) through this
+// package at level. It returns a function that restores the previous
+// output, flags and prefix.
+func RedirectStdLog(level LogLevel) (restore func()) {
+	out, flags, prefix := log.Writer(), log.Flags(), log.Prefix()
+	log.SetOutput(&stdLogWriter{level: level})
+	log.SetFlags(0)
+	log.SetPrefix("")
+	return func() {
+		log.SetOutput(out)
+		log.SetFlags(flags)
+		log.SetPrefix(prefix)
+	}
+}
+
+// stdLogWriter logs each write from a *log.Logger as one entry. The log
+// package writes exactly one formatted line per call.
+type stdLogWriter struct {
+	level LogLevel
+}
+
+func (w *stdLogWriter) Write(p []byte) (int, error) {
+	message := strings.TrimSuffix(string(p), "\n")
+	level := w.level
+	if l, rest, ok := cutLevelTag(message); ok {
+		level, message = l, rest
+	}
+
+	cfg := *loadConfig()
+	if !admitLog(cfg, "", level, message, nil) {
+		return len(p), nil
+	}
+	var pc uintptr
+	if cfg.EnableCaller {
+		pc = stdLogCaller()
+	}
+	dispatchLog(cfg, level, message, pc, nil)
+	return len(p), nil
+}
+
+// stdLogLevelTags are the level prefixes recognized by cutLevelTag
+var stdLogLevelTags = map[string]LogLevel{
+	"trace":   Trace,
+	"debug":   Debug,
+	"info":    Info,
+	"notice":  Notice,
+	"warn":    Warn,
+	"warning": Warn,
+	"error":   Error,
+	"err":     Error,
+}
+
+// cutLevelTag strips a leading "[LEVEL] " or "LEVEL: " tag
+func cutLevelTag(message string) (LogLevel, string, bool) {
+	var tag, rest string
+	if strings.HasPrefix(message, "[") {
+		end := strings.IndexByte(message, ']')
+		if end < 0 {
+			return 0, message, false
+		}
+		tag, rest = message[1:end], message[end+1:]
+	} else {
+		var ok bool
+		if tag, rest, ok = strings.Cut(message, ":"); !ok {
+			return 0, message, false
+		}
+	}
+	level, ok := stdLogLevelTags[strings.ToLower(tag)]
+	if !ok {
+		return 0, message, false
+	}
+	return level, strings.TrimLeft(rest, " "), true
+}
+
+// stdLogCaller returns the PC of the first frame outside the log package
+// and this writer
+func stdLogCaller() uintptr {
+	var pcs [8]uintptr
+	n := runtime.Callers(3, pcs[:])
+	for _, pc := range pcs[:n] {
+		if fn := runtime.FuncForPC(pc - 1); fn != nil && !strings.HasPrefix(fn.Name(), "log.") {
+			return pc
+		}
+	}
+	return 0
+}
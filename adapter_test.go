@@ -0,0 +1,125 @@
+package logger
+
+import (
+	"bytes"
+	"io"
+	"log"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestSlogHandler(t *testing.T) {
+	var buf bytes.Buffer
+	SetConfig(Config{
+		Output:       &buf,
+		Level:        slog.LevelInfo,
+		LevelSet:     true,
+		CompactJSON:  true,
+		ModuleLevels: map[string]slog.Level{"controller": slog.LevelDebug},
+	})
+	defer SetConfig(Config{Output: io.Discard, Level: LevelTrace})
+
+	l := slog.New(SlogHandler()).With("component", "sync")
+	l.Info("login", "password", "hunter2")
+	l.WithGroup("req").Info("reconciled", "id", 7)
+	l.Debug("hidden at info")
+	// logr.FromSlogHandler passes WithName as a "logger" attr on each record
+	slog.New(SlogHandler()).Debug("module debug", "logger", "controller/pods")
+
+	out := buf.String()
+	for _, want := range []string{"reconciled", `"component":"sync"`, `"req.id":7`, `"password":"***"`, "module debug"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %s in output:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "hidden at info") {
+		t.Errorf("expected Debug to be filtered at Info:\n%s", out)
+	}
+}
+
+func TestHclogOutput(t *testing.T) {
+	var buf bytes.Buffer
+	SetConfig(Config{
+		Output:      &buf,
+		Level:       slog.LevelInfo,
+		LevelSet:    true,
+		CompactJSON: true,
+	})
+	defer SetConfig(Config{Output: io.Discard, Level: LevelTrace})
+
+	w := HclogOutput()
+	_, _ = io.WriteString(w, `{"@level":"warn","@message":"plugin exited","@module":"provider.aws","@timestamp":"2026-01-01T00:00:00Z","pid":4`)
+	_, _ = io.WriteString(w, "2}\n")
+	_, _ = io.WriteString(w, `{"@level":"debug","@message":"handshake","@module":"provider"}`+"\n")
+	_, _ = io.WriteString(w, "plain text line\n")
+
+	out := buf.String()
+	for _, want := range []string{"WARN", "plugin exited", `"logger":"provider.aws"`, `"pid":42`, "plain text line"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %s in output:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "handshake") || strings.Contains(out, "@timestamp") {
+		t.Errorf("unexpected content in output:\n%s", out)
+	}
+}
+
+func TestStdLogger(t *testing.T) {
+	var buf bytes.Buffer
+	SetConfig(Config{
+		Output:       &buf,
+		Level:        slog.LevelInfo,
+		LevelSet:     true,
+		CompactJSON:  true,
+		EnableCaller: true,
+	})
+	defer SetConfig(Config{Output: io.Discard, Level: LevelTrace})
+
+	l := StdLogger(Error)
+	l.Printf("http: TLS handshake error from %s", "10.0.0.1:5000")
+	l.Print("[WARN] slow client")
+	l.Print("debug: hidden at info")
+
+	out := buf.String()
+	for _, want := range []string{"ERROR [adapter_test.go:80] http: TLS handshake error from 10.0.0.1:5000", "WARN [adapter_test.go:81] slow client"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in output:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "hidden") {
+		t.Errorf("expected tagged debug line to be filtered:\n%s", out)
+	}
+}
+
+func TestRedirectStdLog(t *testing.T) {
+	var buf bytes.Buffer
+	SetConfig(Config{
+		Output:      &buf,
+		Level:       slog.LevelInfo,
+		LevelSet:    true,
+		CompactJSON: true,
+	})
+	defer SetConfig(Config{Output: io.Discard, Level: LevelTrace})
+
+	restore := RedirectStdLog(Info)
+	log.Printf("from stdlib %d", 1)
+	restore()
+
+	if !strings.Contains(buf.String(), "INFO from stdlib 1") {
+		t.Errorf("expected stdlib output to be redirected:\n%s", buf.String())
+	}
+	if _, ok := log.Writer().(*stdLogWriter); ok {
+		t.Error("expected restore to reset the stdlib log output")
+	}
+}
+
+// Test that logr verbosity, passed by logr.FromSlogHandler as slog level
+// -V, maps to Debug up to V(4) and to Trace beyond
+func TestSlogHandlerVerbosity(t *testing.T) {
+	for v, want := range map[int]LogLevel{0: Info, 1: Debug, 4: Debug, 5: Trace, 8: Trace} {
+		if got := logLevelFromSlog(slog.Level(-v)); got != want {
+			t.Errorf("V(%d): got %s, want %s", v, levelToString(got), levelToString(want))
+		}
+	}
+}
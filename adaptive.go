@@ -0,0 +1,63 @@
+package logger
+
+import (
+	"math/rand/v2"
+	"sync"
+	"time"
+)
+
+// adaptiveSampler keeps Info and lower records within Config.SampleBudget
+// records per second. Each second's keep rate comes from the volume of the
+// second before, so sampling engages during a burst and lifts once the
+// volume drops back under the budget.
+type adaptiveSampler struct {
+	budget int64
+	mu     sync.Mutex
+	second int64   // Unix second being counted
+	seen   int64   // Records offered in that second, at any level
+	kept   int64   // Info and lower records kept in that second
+	rate   float64 // Keep rate of Info and lower records
+}
+
+// newAdaptiveSampler returns a sampler for budget records per second
+func newAdaptiveSampler(budget int) *adaptiveSampler {
+	return &adaptiveSampler{budget: int64(budget), rate: 1}
+}
+
+// admit counts a record and reports whether to keep it. Notice and above
+// are always kept but count toward the volume.
+func (a *adaptiveSampler) admit(level LogLevel, now time.Time) bool {
+	a.mu.Lock()
+	var engaged, lifted bool
+	var volume int64
+	if sec := now.Unix(); sec != a.second {
+		prev := a.rate
+		if sec == a.second+1 {
+			volume = a.seen // Otherwise a quiet second went by
+		}
+		a.rate = 1
+		if volume > a.budget {
+			a.rate = float64(a.budget) / float64(volume)
+		}
+		engaged, lifted = prev == 1 && a.rate < 1, prev < 1 && a.rate == 1
+		a.second, a.seen, a.kept = sec, 0, 0
+	}
+	a.seen++
+	keep := level > Info || a.kept < a.budget && (a.rate == 1 || rand.Float64() < a.rate)
+	if keep && level <= Info {
+		a.kept++
+	}
+	rate := a.rate
+	a.mu.Unlock()
+
+	// Written directly, past sampling, after the lock is released
+	switch {
+	case engaged:
+		logInternalSync(Warn, "Adaptive sampling engaged", 0,
+			"records_per_second", volume, "sample_budget", a.budget, "sample_rate", rate)
+	case lifted:
+		logInternalSync(Notice, "Adaptive sampling lifted", 0,
+			"records_per_second", volume, "sample_budget", a.budget)
+	}
+	return keep
+}
@@ -0,0 +1,79 @@
+package logger
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAdaptiveSampling(t *testing.T) {
+	defer SetConfig(Config{Output: io.Discard, Level: LevelTrace})
+	sw := newSyncWriter()
+	SetConfig(Config{Output: sw, Level: LevelTrace, CompactJSON: true, Format: FormatJSON})
+
+	a := newAdaptiveSampler(100)
+	base := time.Unix(1_700_000_000, 0)
+	offer := func(sec int64, n int, level LogLevel) (kept int) {
+		for range n {
+			if a.admit(level, base.Add(time.Duration(sec)*time.Second)) {
+				kept++
+			}
+		}
+		return kept
+	}
+
+	// Under budget everything passes; a burst second is capped at the budget
+	if kept := offer(0, 50, Info); kept != 50 {
+		t.Errorf("expected all 50 records under budget, got %d", kept)
+	}
+	if kept := offer(1, 1000, Info); kept != 100 {
+		t.Errorf("expected the budget of 100 in the burst second, got %d", kept)
+	}
+	if strings.Contains(sw.String(), "Adaptive sampling") {
+		t.Errorf("expected no notice before sampling engages: %s", sw.String())
+	}
+
+	// The next second samples at budget/volume and warns once
+	if kept := offer(2, 1000, Info); kept < 50 || kept > 100 {
+		t.Errorf("expected about 100 of 1000 at rate 0.1, got %d", kept)
+	}
+	if kept := offer(2, 20, Error); kept != 20 {
+		t.Errorf("expected Error records to bypass sampling, got %d", kept)
+	}
+	out := sw.String()
+	if strings.Count(out, "Adaptive sampling engaged") != 1 || !strings.Contains(out, `"sample_rate":0.1`) {
+		t.Errorf("expected one engaged warning at rate 0.1: %s", out)
+	}
+
+	// A quiet second lifts sampling
+	offer(3, 1020, Info)
+	if kept := offer(5, 10, Info); kept != 10 {
+		t.Errorf("expected all records after a quiet second, got %d", kept)
+	}
+	if !strings.Contains(sw.String(), "Adaptive sampling lifted") {
+		t.Errorf("expected a lifted notice: %s", sw.String())
+	}
+}
+
+func TestSampleBudgetConfig(t *testing.T) {
+	defer SetConfig(Config{Output: io.Discard, Level: LevelTrace})
+
+	if err := SetConfigE(Config{Output: io.Discard, SampleBudget: -1}); err == nil {
+		t.Error("expected an error for a negative SampleBudget")
+	}
+
+	SetConfig(Config{Output: io.Discard, Level: LevelTrace, SampleBudget: 10})
+	a := loadState().adaptive
+	if a == nil {
+		t.Fatal("expected an adaptive sampler with SampleBudget set")
+	}
+	SetConfig(Config{Output: io.Discard, Level: LevelInfo, SampleBudget: 10})
+	if loadState().adaptive != a {
+		t.Error("expected the sampler to survive an unrelated change")
+	}
+	SetConfig(Config{Output: io.Discard, Level: LevelTrace})
+	if loadState().adaptive != nil {
+		t.Error("expected no sampler without SampleBudget")
+	}
+}
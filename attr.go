@@ -0,0 +1,67 @@
+package logger
+
+import (
+	"log/slog"
+	"time"
+)
+
+// Typed attribute constructors. The Log* functions accept the slog.Attr they
+// return in place of a key/value pair, skipping key formatting and the
+// type switch in convertToSlogAttr:
+//
+//	logger.LogInfo("served", logger.String("path", p), logger.Int("status", 200), logger.Dur("took", d))
+
+// String returns a string attribute
+func String(key, value string) slog.Attr {
+	return slog.String(key, value)
+}
+
+// Int returns an int attribute
+func Int(key string, value int) slog.Attr {
+	return slog.Int(key, value)
+}
+
+// Int64 returns an int64 attribute
+func Int64(key string, value int64) slog.Attr {
+	return slog.Int64(key, value)
+}
+
+// Uint64 returns a uint64 attribute
+func Uint64(key string, value uint64) slog.Attr {
+	return slog.Uint64(key, value)
+}
+
+// Float64 returns a float64 attribute
+func Float64(key string, value float64) slog.Attr {
+	return slog.Float64(key, value)
+}
+
+// Bool returns a bool attribute
+func Bool(key string, value bool) slog.Attr {
+	return slog.Bool(key, value)
+}
+
+// Dur returns a time.Duration attribute
+func Dur(key string, value time.Duration) slog.Attr {
+	return slog.Duration(key, value)
+}
+
+// Time returns a time.Time attribute
+func Time(key string, value time.Time) slog.Attr {
+	return slog.Time(key, value)
+}
+
+// Err returns an "error" attribute holding err's message, or "<nil>"
+func Err(err error) slog.Attr {
+	if err == nil {
+		return slog.String("error", "<nil>")
+	}
+	return slog.String("error", err.Error())
+}
+
+// Any returns an attribute for value converted the same way as a plain
+// key/value pair, so structs, maps and slices are rendered as JSON unless
+// StrictTypes is set
+func Any(key string, value any) slog.Attr {
+	return convertToSlogAttr(key, resolveLazy(value), loadConfig().StrictTypes)
+}
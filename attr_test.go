@@ -0,0 +1,52 @@
+package logger
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTypedAttrs(t *testing.T) {
+	var buf bytes.Buffer
+	SetConfig(Config{
+		Output:      &buf,
+		Level:       slog.LevelInfo,
+		LevelSet:    true,
+		CompactJSON: true,
+		RedactKeys:  []string{"token"},
+	})
+	defer SetConfig(Config{Output: io.Discard, Level: LevelTrace})
+
+	LogInfo("served",
+		String("path", "/users"),
+		Int("status", 200),
+		Int64("size", 1<<40),
+		Uint64("id", 7),
+		Float64("ratio", 0.5),
+		Bool("cached", true),
+		Dur("took", 1500*time.Millisecond),
+		Time("at", time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)),
+		Err(errors.New("boom")),
+		Any("user", struct {
+			Name string `json:"name"`
+		}{"alice"}),
+		String("token", "secret"),
+		"legacy", "pair",
+	)
+	LogInfo("nil error", Err(nil))
+
+	out := buf.String()
+	for _, want := range []string{
+		`"path":"/users"`, `"status":200`, `"size":1099511627776`, `"id":7`, `"ratio":0.5`,
+		`"cached":true`, `"took":1500000000`, `"at":"2026-01-02T03:04:05Z"`, `"error":"boom"`,
+		`"user":{"name":"alice"}`, `"token":"***"`, `"legacy":"pair"`, `"error":"\u003cnil\u003e"`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %s in:\n%s", want, out)
+		}
+	}
+}
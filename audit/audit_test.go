@@ -660,3 +660,58 @@ func TestTypedErrorsWithAsType(t *testing.T) {
 		t.Error("errors.AsType[*StoreError] should find the StoreError")
 	}
 }
+
+func TestEventBuilder(t *testing.T) {
+	at := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	event, err := NewEvent(AuditDataModify, "invoice.update").
+		Actor("user-42", "user").
+		ActorIP("10.0.0.1").
+		Resource("invoice", "inv-7").
+		Outcome(OutcomeSuccess).
+		Reason("customer request").
+		At(at).
+		Meta("tenant", "acme").
+		Build("resource.id", "reason", "metadata.tenant")
+	if err != nil {
+		t.Fatalf("Build() error: %v", err)
+	}
+	if event.Resource.ID != "inv-7" || event.Actor.IP != "10.0.0.1" || !event.Timestamp.Equal(at) {
+		t.Errorf("unexpected event %+v", event)
+	}
+
+	if _, err := NewEvent(AuditAuth, "login").Build(); !errors.Is(err, ErrMissingActor) {
+		t.Errorf("expected ErrMissingActor, got %v", err)
+	}
+	_, err = NewEvent(AuditAuth, "login").Actor("u1", "user").Build("actor.ip")
+	if !errors.Is(err, ErrMissingField) {
+		t.Errorf("expected ErrMissingField, got %v", err)
+	}
+	if _, err = NewEvent(AuditAuth, "login").Actor("u1", "user").Build("actor.phone"); !errors.Is(err, ErrUnknownField) {
+		t.Errorf("expected ErrUnknownField, got %v", err)
+	}
+}
+
+func TestLoggerRequiredFields(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Output = &bytes.Buffer{}
+	cfg.RequiredFields = []string{"actor.unknown"}
+	if _, err := NewLogger(cfg); !errors.Is(err, ErrUnknownField) {
+		t.Fatalf("expected ErrUnknownField, got %v", err)
+	}
+
+	cfg.RequiredFields = []string{"resource.id"}
+	l, err := NewLogger(cfg)
+	if err != nil {
+		t.Fatalf("NewLogger() error: %v", err)
+	}
+	defer func() { _ = l.Close() }()
+
+	if err := l.LogSync(context.Background(), validEvent()); !errors.Is(err, ErrMissingField) {
+		t.Errorf("expected ErrMissingField, got %v", err)
+	}
+	event := validEvent()
+	event.Resource = &AuditResource{ID: "doc-1", Type: "document"}
+	if err := l.LogSync(context.Background(), event); err != nil {
+		t.Errorf("LogSync() error: %v", err)
+	}
+}
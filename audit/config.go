@@ -26,6 +26,7 @@ type Config struct {
 	DeadLetterPath   string
 	MaxRetries       int
 	RetryBackoff     time.Duration
+	RequiredFields   []string // Extra fields every event must set; see AuditEvent.Require
 }
 
 // HashChainConfig configures tamper detection
@@ -137,6 +138,9 @@ func (c *Config) Validate() error {
 	if c.RateLimit != nil && c.RateLimit.EventsPerSecond <= 0 {
 		return ErrInvalidRateLimit
 	}
+	if err := ValidateRequiredFields(c.RequiredFields); err != nil {
+		return err
+	}
 	return nil
 }
 
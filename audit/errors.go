@@ -10,6 +10,8 @@ var (
 	ErrMissingEventType = errors.New("audit: event type is required")
 	ErrMissingAction    = errors.New("audit: action is required")
 	ErrMissingActor     = errors.New("audit: actor ID or type is required")
+	ErrMissingField     = errors.New("audit: required field is missing")
+	ErrUnknownField     = errors.New("audit: unknown required field")
 )
 
 // Configuration errors
@@ -0,0 +1,184 @@
+package audit
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// EventBuilder assembles an AuditEvent so every call site produces the same
+// shape of entry:
+//
+//	event, err := audit.NewEvent(audit.AuditDataModify, "invoice.update").
+//		Actor("user-42", "user").
+//		Resource("invoice", "inv-7").
+//		Outcome(audit.OutcomeSuccess).
+//		Build()
+type EventBuilder struct {
+	event AuditEvent
+}
+
+// NewEvent starts an event of the given type and action
+func NewEvent(eventType AuditEventType, action string) *EventBuilder {
+	return &EventBuilder{event: AuditEvent{Type: eventType, Action: action}}
+}
+
+// Actor sets who performed the action
+func (b *EventBuilder) Actor(id, actorType string) *EventBuilder {
+	b.event.Actor.ID = id
+	b.event.Actor.Type = actorType
+	return b
+}
+
+// ActorIP sets the actor's IP address
+func (b *EventBuilder) ActorIP(ip string) *EventBuilder {
+	b.event.Actor.IP = ip
+	return b
+}
+
+// Resource sets what the action affected
+func (b *EventBuilder) Resource(resourceType, id string) *EventBuilder {
+	b.event.Resource = &AuditResource{ID: id, Type: resourceType}
+	return b
+}
+
+// Outcome sets the result of the action
+func (b *EventBuilder) Outcome(outcome AuditOutcome) *EventBuilder {
+	b.event.Outcome = outcome
+	return b
+}
+
+// Reason records why the action was taken or denied
+func (b *EventBuilder) Reason(reason string) *EventBuilder {
+	b.event.Reason = reason
+	return b
+}
+
+// Description sets a human-readable summary
+func (b *EventBuilder) Description(description string) *EventBuilder {
+	b.event.Description = description
+	return b
+}
+
+// CorrelationID links the event to related events
+func (b *EventBuilder) CorrelationID(id string) *EventBuilder {
+	b.event.CorrelationID = id
+	return b
+}
+
+// Changes records the state before and after a modification
+func (b *EventBuilder) Changes(before, after map[string]any) *EventBuilder {
+	b.event.Changes = &AuditChanges{Before: before, After: after}
+	return b
+}
+
+// At sets when the action happened (default: when it is logged)
+func (b *EventBuilder) At(t time.Time) *EventBuilder {
+	b.event.Timestamp = t
+	return b
+}
+
+// Meta adds a metadata key/value pair
+func (b *EventBuilder) Meta(key string, value any) *EventBuilder {
+	if b.event.Metadata == nil {
+		b.event.Metadata = make(map[string]any)
+	}
+	b.event.Metadata[key] = value
+	return b
+}
+
+// Event returns the event without validating it
+func (b *EventBuilder) Event() AuditEvent {
+	return b.event
+}
+
+// Build validates the event and returns it. required lists extra fields
+// that must be set; see AuditEvent.Require.
+func (b *EventBuilder) Build(required ...string) (AuditEvent, error) {
+	event := b.event
+	if err := event.Validate(); err != nil {
+		return event, err
+	}
+	if err := event.Require(required...); err != nil {
+		return event, err
+	}
+	return event, nil
+}
+
+// Require reports an error wrapping ErrMissingField for the first field in
+// fields that is empty. Fields use the JSON names, dotted for nesting:
+// "actor.ip", "resource.id", "reason", "correlation_id", "metadata.tenant", ...
+func (e *AuditEvent) Require(fields ...string) error {
+	for _, field := range fields {
+		set, known := e.hasField(field)
+		if !known {
+			return fmt.Errorf("%w %q", ErrUnknownField, field)
+		}
+		if !set {
+			return fmt.Errorf("%w: %s", ErrMissingField, field)
+		}
+	}
+	return nil
+}
+
+// ValidateRequiredFields checks that every name in fields is one Require understands
+func ValidateRequiredFields(fields []string) error {
+	var e AuditEvent
+	for _, field := range fields {
+		if _, known := e.hasField(field); !known {
+			return fmt.Errorf("%w %q", ErrUnknownField, field)
+		}
+	}
+	return nil
+}
+
+// hasField reports whether the named field is set and whether the name is known
+func (e *AuditEvent) hasField(field string) (set, known bool) {
+	if key, ok := strings.CutPrefix(field, "metadata."); ok && key != "" {
+		_, set = e.Metadata[key]
+		return set, true
+	}
+
+	switch field {
+	case "type":
+		return e.Type != "", true
+	case "action":
+		return e.Action != "", true
+	case "outcome":
+		return e.Outcome != "", true
+	case "actor.id":
+		return e.Actor.ID != "", true
+	case "actor.type":
+		return e.Actor.Type != "", true
+	case "actor.name":
+		return e.Actor.Name != "", true
+	case "actor.email":
+		return e.Actor.Email != "", true
+	case "actor.ip":
+		return e.Actor.IP != "", true
+	case "actor.user_agent":
+		return e.Actor.UserAgent != "", true
+	case "actor.session_id":
+		return e.Actor.SessionID != "", true
+	case "resource":
+		return e.Resource != nil, true
+	case "resource.id":
+		return e.Resource != nil && e.Resource.ID != "", true
+	case "resource.type":
+		return e.Resource != nil && e.Resource.Type != "", true
+	case "resource.name":
+		return e.Resource != nil && e.Resource.Name != "", true
+	case "description":
+		return e.Description != "", true
+	case "reason":
+		return e.Reason != "", true
+	case "changes":
+		return e.Changes != nil, true
+	case "correlation_id":
+		return e.CorrelationID != "", true
+	case "timestamp":
+		return !e.Timestamp.IsZero(), true
+	default:
+		return false, false
+	}
+}
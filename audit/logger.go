@@ -83,6 +83,9 @@ func (l *Logger) Log(ctx context.Context, event AuditEvent) error {
 	if err := event.Validate(); err != nil {
 		return err
 	}
+	if err := event.Require(l.cfg.RequiredFields...); err != nil {
+		return err
+	}
 
 	l.mu.RLock()
 	closed := l.closed
@@ -126,6 +129,9 @@ func (l *Logger) LogSync(ctx context.Context, event AuditEvent) error {
 	if err := event.Validate(); err != nil {
 		return err
 	}
+	if err := event.Require(l.cfg.RequiredFields...); err != nil {
+		return err
+	}
 
 	l.mu.RLock()
 	if l.closed {
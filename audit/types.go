@@ -76,6 +76,7 @@ type AuditEvent struct {
 	Reason        string         `json:"reason,omitempty"`
 	Changes       *AuditChanges  `json:"changes,omitempty"`
 	CorrelationID string         `json:"correlation_id,omitempty"`
+	Timestamp     time.Time      `json:"timestamp,omitzero"` // When the action happened, if different from when it was logged
 	Metadata      map[string]any `json:"metadata,omitempty"`
 }
 
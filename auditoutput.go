@@ -0,0 +1,105 @@
+package logger
+
+import (
+	"context"
+	"io"
+	"log/slog"
+)
+
+// AuditFormat selects how Audit-level records are encoded on Config.AuditOutput
+type AuditFormat int
+
+const (
+	// AuditFormatText uses the main log line format, without color (default)
+	AuditFormatText AuditFormat = iota
+	// AuditFormatJSON writes one JSON object per line (time, level, msg and
+	// attributes), ready for SIEM ingestion
+	AuditFormatJSON
+)
+
+// String returns the format name
+func (f AuditFormat) String() string {
+	switch f {
+	case AuditFormatText:
+		return "text"
+	case AuditFormatJSON:
+		return "json"
+	default:
+		return "unknown"
+	}
+}
+
+// auditSplitHandler sends Audit-level records to audit and everything else to main
+type auditSplitHandler struct {
+	main  slog.Handler
+	audit slog.Handler
+}
+
+func (h *auditSplitHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	if level == LevelAudit {
+		return h.audit.Enabled(ctx, level)
+	}
+	return h.main.Enabled(ctx, level)
+}
+
+func (h *auditSplitHandler) Handle(ctx context.Context, record slog.Record) error {
+	if record.Level == LevelAudit {
+		return h.audit.Handle(ctx, record)
+	}
+	return h.main.Handle(ctx, record)
+}
+
+func (h *auditSplitHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &auditSplitHandler{main: h.main.WithAttrs(attrs), audit: h.audit.WithAttrs(attrs)}
+}
+
+func (h *auditSplitHandler) WithGroup(name string) slog.Handler {
+	return &auditSplitHandler{main: h.main.WithGroup(name), audit: h.audit.WithGroup(name)}
+}
+
+// newAuditHandler builds the handler that writes Audit records to
+// cfg.AuditOutput, or to out when only signing is configured
+func newAuditHandler(cfg Config, out io.Writer) slog.Handler {
+	opts := slog.HandlerOptions{Level: LevelAudit, AddSource: cfg.EnableCaller}
+
+	if cfg.AuditOutput != nil {
+		out = cfg.AuditOutput
+	}
+	if cfg.AuditSigning != nil {
+		out = &signingWriter{out: out, signer: cfg.AuditSigning}
+		cfg.AuditFormat = AuditFormatJSON
+	}
+
+	if cfg.AuditFormat == AuditFormatJSON {
+		values := newValueRedactor(cfg)
+		opts.ReplaceAttr = func(groups []string, a slog.Attr) slog.Attr {
+			if len(groups) == 0 && a.Key == slog.LevelKey {
+				if level, ok := a.Value.Any().(slog.Level); ok {
+					a = slog.String(slog.LevelKey, LevelString(level))
+				}
+			}
+			if len(groups) == 0 && len(cfg.FieldProcessors) > 0 && isBuiltinKey(a.Key) {
+				// Record attributes were processed before reaching the handler
+				if processed, ok := processAttr(cfg.FieldProcessors, a); ok {
+					return processed
+				}
+				return slog.Attr{}
+			}
+			if a.Value.Kind() == slog.KindString {
+				return slog.String(a.Key, values.redact(a.Value.String()))
+			}
+			return a
+		}
+		return slog.NewJSONHandler(out, &opts)
+	}
+
+	// Audit trails end up in files and SIEMs, so never write ANSI colors
+	cfg.EnableColor = false
+	cfg.ColorizeJSON = false
+	return newPrettyHandler(out, prettyHandlerOptions{SlogOpts: opts, Config: cfg})
+}
+
+// isBuiltinKey reports whether key is one of slog's built-in record keys
+func isBuiltinKey(key string) bool {
+	return key == slog.TimeKey || key == slog.LevelKey || key == slog.MessageKey || key == slog.SourceKey
+}
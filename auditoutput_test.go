@@ -0,0 +1,115 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/jozefvalachovic/logger/v4/audit"
+)
+
+func TestAuditOutputSeparatesAuditRecords(t *testing.T) {
+	var main, audit bytes.Buffer
+	SetConfig(Config{
+		Output:       &main,
+		AuditOutput:  &audit,
+		Level:        slog.LevelInfo,
+		LevelSet:     true,
+		CompactJSON:  true,
+		EnableColor:  true,
+		AsyncMode:    true,
+		AsyncWorkers: 1,
+	})
+	defer SetConfig(Config{Output: io.Discard, Level: LevelTrace})
+
+	LogInfo("app started")
+	LogAudit("action", "login", "user", "alice")
+
+	// Audit records bypass the async queue, so they are already written
+	got := audit.String()
+	if !strings.Contains(got, "AUDIT") || !strings.Contains(got, `"action":"login"`) {
+		t.Errorf("expected audit record in audit output:\n%s", got)
+	}
+	if strings.Contains(got, "\x1b[") {
+		t.Errorf("audit output must not contain ANSI colors: %q", got)
+	}
+	if strings.Contains(got, "app started") {
+		t.Errorf("app record leaked into audit output:\n%s", got)
+	}
+
+	SetConfig(Config{Output: io.Discard, Level: LevelTrace}) // Drains the async queue
+	if strings.Contains(main.String(), "login") || !strings.Contains(main.String(), "app started") {
+		t.Errorf("expected only the app record in main output:\n%s", main.String())
+	}
+}
+
+func TestAuditOutputJSON(t *testing.T) {
+	var audit bytes.Buffer
+	SetConfig(Config{
+		Output:         io.Discard,
+		AuditOutput:    &audit,
+		AuditFormat:    AuditFormatJSON,
+		Level:          slog.LevelInfo,
+		LevelSet:       true,
+		RedactPatterns: []string{`^\d{4}-\d{4}-\d{4}-\d{4}$`},
+	})
+	defer SetConfig(Config{Output: io.Discard, Level: LevelTrace})
+
+	Named("auth").With("tenant", "acme").LogAudit("action", "pay", "card", "4111-1111-1111-1111")
+
+	var record map[string]any
+	if err := json.Unmarshal(audit.Bytes(), &record); err != nil {
+		t.Fatalf("expected one JSON object, got %q: %v", audit.String(), err)
+	}
+	want := map[string]any{"level": "AUDIT", "action": "pay", "tenant": "acme", "logger": "auth", "card": "***"}
+	for k, v := range want {
+		if record[k] != v {
+			t.Errorf("%s = %v, want %v", k, record[k], v)
+		}
+	}
+}
+
+func TestAuditFormatValidate(t *testing.T) {
+	cfg := defaultConfig
+	cfg.AuditFormat = AuditFormat(7)
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected invalid AuditFormat to fail validation")
+	}
+	if AuditFormatJSON.String() != "json" || AuditFormatText.String() != "text" {
+		t.Error("unexpected AuditFormat names")
+	}
+}
+
+func TestLogAuditEventRequiredFields(t *testing.T) {
+	var buf bytes.Buffer
+	SetConfig(Config{
+		Output:              &buf,
+		Level:               slog.LevelInfo,
+		LevelSet:            true,
+		CompactJSON:         true,
+		AuditRequiredFields: []string{"actor.ip"},
+	})
+	defer SetConfig(Config{Output: io.Discard, Level: LevelTrace})
+
+	event := audit.NewEvent(audit.AuditAuth, "login").Actor("u1", "user").Outcome(audit.OutcomeSuccess)
+	if err := LogAuditEvent(context.Background(), event.Event()); !errors.Is(err, audit.ErrMissingField) {
+		t.Fatalf("expected ErrMissingField, got %v", err)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("rejected event was logged: %q", buf.String())
+	}
+
+	if err := LogAuditEvent(context.Background(), event.ActorIP("10.0.0.1").CorrelationID("c-1").Event()); err != nil {
+		t.Fatalf("LogAuditEvent() error: %v", err)
+	}
+	for _, want := range []string{`"actor_ip":"10.0.0.1"`, `"correlation_id":"c-1"`} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("expected %s in output:\n%s", want, buf.String())
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package logger
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestAuditSigningEd25519(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	SetConfig(Config{
+		Output:       &buf,
+		Level:        slog.LevelInfo,
+		LevelSet:     true,
+		CompactJSON:  true,
+		AuditSigning: &AuditSigning{Ed25519Key: priv},
+	})
+	defer SetConfig(Config{Output: io.Discard, Level: LevelTrace})
+
+	LogInfo("not signed")
+	LogAudit("action", "login", "user", "alice")
+	LogAudit("action", "logout", "user", "alice")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected 3 lines, got %d:\n%s", len(lines), buf.String())
+	}
+	var record map[string]any
+	if err := json.Unmarshal([]byte(lines[1]), &record); err != nil {
+		t.Fatalf("signed record is not JSON: %v", err)
+	}
+	if record["sig"] == nil || record["action"] != "login" {
+		t.Errorf("unexpected signed record %v", record)
+	}
+
+	n, err := VerifyAuditLog(strings.NewReader(strings.Join(lines[1:], "\n")), pub)
+	if err != nil || n != 2 {
+		t.Fatalf("VerifyAuditLog() = %d, %v", n, err)
+	}
+
+	tampered := strings.Replace(lines[1], "alice", "mallory", 1)
+	if err := VerifyAuditLine([]byte(tampered), pub); !errors.Is(err, ErrInvalidAuditSignature) {
+		t.Errorf("expected ErrInvalidAuditSignature for tampered line, got %v", err)
+	}
+	if err := VerifyAuditLine([]byte(lines[0]), pub); err == nil {
+		t.Error("expected an error for an unsigned line")
+	}
+}
+
+func TestAuditSigningHMAC(t *testing.T) {
+	key := []byte("audit-secret")
+
+	var audit bytes.Buffer
+	SetConfig(Config{
+		Output:       io.Discard,
+		AuditOutput:  &audit,
+		AuditSigning: &AuditSigning{HMACKey: key},
+	})
+	defer SetConfig(Config{Output: io.Discard, Level: LevelTrace})
+
+	LogAudit("action", "delete", "resource", "doc-1")
+
+	if err := VerifyAuditLine(audit.Bytes(), key); err != nil {
+		t.Fatalf("VerifyAuditLine() error: %v", err)
+	}
+	if err := VerifyAuditLine(audit.Bytes(), []byte("other")); !errors.Is(err, ErrInvalidAuditSignature) {
+		t.Errorf("expected ErrInvalidAuditSignature for wrong key, got %v", err)
+	}
+}
+
+func TestAuditSigningValidate(t *testing.T) {
+	cases := []*AuditSigning{
+		{},
+		{Ed25519Key: make(ed25519.PrivateKey, 10)},
+		{Ed25519Key: make(ed25519.PrivateKey, ed25519.PrivateKeySize), HMACKey: []byte("k")},
+	}
+	for _, signing := range cases {
+		cfg := defaultConfig
+		cfg.AuditSigning = signing
+		if err := cfg.Validate(); err == nil {
+			t.Errorf("expected validation error for %+v", signing)
+		}
+	}
+}
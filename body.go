@@ -0,0 +1,192 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"maps"
+	"mime"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// ReadBody reads up to limit bytes of the body of r for logging and puts
+// them back in front of the rest, so the handler still reads the whole
+// body; truncated reports whether there was more (exported for middleware)
+func ReadBody(r *http.Request, limit int64) (body []byte, truncated bool, err error) {
+	if r.Body == nil || r.Body == http.NoBody {
+		return nil, false, nil
+	}
+	body, err = io.ReadAll(io.LimitReader(r.Body, limit+1))
+	r.Body = replayBody{io.MultiReader(bytes.NewReader(body), r.Body), r.Body}
+	if int64(len(body)) > limit {
+		body, truncated = body[:limit], true
+	}
+	return body, truncated, err
+}
+
+// replayBody is a request body whose start was read for logging
+type replayBody struct {
+	io.Reader
+	io.Closer
+}
+
+// LoggableBody reports whether a body of contentType is text worth logging:
+// JSON, XML, forms or text/*. A body without a Content-Type is sniffed with
+// http.DetectContentType (exported for middleware).
+func LoggableBody(contentType string, body []byte) bool {
+	if contentType == "" {
+		if len(body) == 0 {
+			return false
+		}
+		contentType = http.DetectContentType(body)
+	}
+	contentType = strings.ToLower(contentType)
+	for _, allowed := range []string{"application/json", "+json", "application/xml", "+xml", "text/", "application/x-www-form-urlencoded"} {
+		if strings.Contains(contentType, allowed) {
+			return true
+		}
+	}
+	return false
+}
+
+// BodyToKeyValues converts a body to key-value pairs for logInternal (exported for middleware)
+func BodyToKeyValues(key string, body []byte) []any {
+	return bodyToKeyValues(key, "", body)
+}
+
+// BodyToKeyValuesWithType is BodyToKeyValues for a body of the given
+// Content-Type, which also parses form bodies (exported for middleware)
+func BodyToKeyValuesWithType(key, contentType string, body []byte) []any {
+	return bodyToKeyValues(key, contentType, body)
+}
+
+// bodyToKeyValues converts a JSON or form body into a group attribute under
+// key, with Redactor, RedactRules and RedactKeys applied to every field and
+// RedactPatterns and DetectPII applied to its strings at any depth, so a body can be
+// logged without its password or card_number. Other bodies are logged as a
+// string under key.
+func bodyToKeyValues(key, contentType string, body []byte) []any {
+	cfg := loadConfig()
+	r := bodyRedactor{cfg: cfg, values: newValueRedactor(*cfg)}
+
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	if mediaType == "application/x-www-form-urlencoded" {
+		if form, err := url.ParseQuery(string(body)); err == nil {
+			return []any{slog.Attr{Key: key, Value: slog.GroupValue(r.form(form)...)}}
+		}
+	}
+
+	var obj any
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	if err := dec.Decode(&obj); err == nil && !dec.More() {
+		switch v := obj.(type) {
+		case map[string]any:
+			return []any{slog.Attr{Key: key, Value: slog.GroupValue(r.object(v, 0)...)}}
+		case []any:
+			return []any{key, r.value(v, 0)}
+		}
+	}
+	// Not JSON or a form: return as [key, string(body)]
+	return []any{key, r.string(string(body))}
+}
+
+// bodyRedactor masks the sensitive fields of a parsed body
+type bodyRedactor struct {
+	cfg    *Config
+	values valueRedactor
+}
+
+// object returns the fields of a JSON object as attributes, sorted by key
+func (r bodyRedactor) object(obj map[string]any, depth int) []slog.Attr {
+	attrs := make([]slog.Attr, 0, len(obj))
+	for _, k := range slices.Sorted(maps.Keys(obj)) {
+		if v, keep, redacted := redactField(k, obj[k], r.cfg); redacted {
+			if keep {
+				attrs = append(attrs, slog.Any(k, v))
+			}
+			continue
+		}
+		if nested, ok := obj[k].(map[string]any); ok && depth < maxConvertDepth {
+			attrs = append(attrs, slog.Attr{Key: k, Value: slog.GroupValue(r.object(nested, depth+1)...)})
+			continue
+		}
+		attrs = append(attrs, slog.Any(k, r.value(obj[k], depth+1)))
+	}
+	return attrs
+}
+
+// value returns a JSON value with its sensitive fields masked
+func (r bodyRedactor) value(v any, depth int) any {
+	if depth > maxConvertDepth {
+		return maxDepthMarker
+	}
+	switch v := v.(type) {
+	case map[string]any:
+		out := make(map[string]any, len(v))
+		for k, e := range v {
+			if v, keep, redacted := redactField(k, e, r.cfg); redacted {
+				if keep {
+					out[k] = v
+				}
+				continue
+			}
+			out[k] = r.value(e, depth+1)
+		}
+		return out
+	case []any:
+		out := make([]any, len(v))
+		for i, e := range v {
+			out[i] = r.value(e, depth+1)
+		}
+		return out
+	case string:
+		return r.string(v)
+	case json.Number:
+		// Decoded with UseNumber so large IDs keep their precision
+		if n, err := v.Int64(); err == nil {
+			return n
+		}
+		if f, err := v.Float64(); err == nil {
+			return f
+		}
+		return v.String()
+	default:
+		return v
+	}
+}
+
+// form returns the fields of a form as attributes, sorted by key; fields
+// given more than once are lists
+func (r bodyRedactor) form(form url.Values) []slog.Attr {
+	attrs := make([]slog.Attr, 0, len(form))
+	for _, k := range slices.Sorted(maps.Keys(form)) {
+		vs := form[k]
+		if v, keep, redacted := redactField(k, strings.Join(vs, ","), r.cfg); redacted {
+			if keep {
+				attrs = append(attrs, slog.Any(k, v))
+			}
+			continue
+		}
+		switch {
+		case len(vs) == 1:
+			attrs = append(attrs, slog.String(k, r.string(vs[0])))
+		default:
+			list := make([]string, len(vs))
+			for i, v := range vs {
+				list[i] = r.string(v)
+			}
+			attrs = append(attrs, slog.Any(k, list))
+		}
+	}
+	return attrs
+}
+
+// string applies RedactPatterns and DetectPII to s
+func (r bodyRedactor) string(s string) string {
+	return r.values.redact(s)
+}
@@ -0,0 +1,95 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestBodyRedaction(t *testing.T) {
+	defer SetConfig(Config{Output: io.Discard, Level: LevelTrace})
+
+	var buf bytes.Buffer
+	logBody := func(contentType, body string) map[string]any {
+		t.Helper()
+		buf.Reset()
+		SetConfig(Config{Output: &buf, Level: LevelTrace, CompactJSON: true, Format: FormatJSON,
+			RedactKeys: []string{"password", "card_number"}, RedactPatterns: []string{`^sk_live_`}})
+		LogInfo("body", BodyToKeyValuesWithType("request_body", contentType, []byte(body))...)
+		var rec map[string]any
+		if err := json.Unmarshal(buf.Bytes(), &rec); err != nil {
+			t.Fatalf("invalid JSON %q: %v", buf.String(), err)
+		}
+		return rec
+	}
+
+	rec := logBody("application/json", `{"user":"bob","password":"hunter2","id":12345678901234567,
+		"payment":{"card_number":"4111111111111111","amount":9.5},"keys":[{"password":"x"},"sk_live_abc"]}`)
+	body, _ := rec["request_body"].(map[string]any)
+	if body == nil {
+		t.Fatalf("expected a request_body object: %v", rec)
+	}
+	if body["user"] != "bob" || body["password"] != "***" {
+		t.Errorf("expected password masked and user kept: %v", body)
+	}
+	if payment, _ := body["payment"].(map[string]any); payment["card_number"] != "***" || payment["amount"] != 9.5 {
+		t.Errorf("expected the nested card_number masked: %v", body["payment"])
+	}
+	if keys, _ := body["keys"].([]any); len(keys) != 2 || keys[1] != "***" || keys[0].(map[string]any)["password"] != "***" {
+		t.Errorf("expected fields and patterns masked inside arrays: %v", body["keys"])
+	}
+	if !strings.Contains(buf.String(), `"id":12345678901234567`) {
+		t.Errorf("expected large numbers to keep their precision: %v", body["id"])
+	}
+
+	rec = logBody("application/x-www-form-urlencoded; charset=utf-8", "user=bob&password=hunter2&tag=a&tag=b")
+	form, _ := rec["request_body"].(map[string]any)
+	if form["user"] != "bob" || form["password"] != "***" || len(form["tag"].([]any)) != 2 {
+		t.Errorf("expected form fields parsed and masked: %v", rec["request_body"])
+	}
+
+	rec = logBody("text/plain", "not json")
+	if rec["request_body"] != "not json" {
+		t.Errorf("expected other bodies logged as a string: %v", rec)
+	}
+}
+
+func TestLogHttpRequestBody(t *testing.T) {
+	defer SetConfig(Config{Output: io.Discard, Level: LevelTrace})
+
+	var buf bytes.Buffer
+	SetConfig(Config{Output: &buf, Level: LevelTrace, CompactJSON: true, Format: FormatJSON, MaxBodySize: 8})
+
+	long := strings.Repeat("a", 20)
+	r := newTestRequest(long, 200)
+	r.Header.Set("Content-Type", "text/plain")
+	LogHttpRequest(r)
+	if !strings.Contains(buf.String(), `"body":"aaaaaaaa..."`) {
+		t.Errorf("expected the body truncated to MaxBodySize: %s", buf.String())
+	}
+	if b, _ := io.ReadAll(r.Body); string(b) != long {
+		t.Errorf("expected the whole body left to read, got %q", b)
+	}
+
+	buf.Reset()
+	r = newTestRequest("\x00\x01\x02", 200)
+	r.Header.Set("Content-Type", "application/octet-stream")
+	LogHttpRequest(r)
+	if strings.Contains(buf.String(), `"body"`) {
+		t.Errorf("expected a binary body not logged: %s", buf.String())
+	}
+	if b, _ := io.ReadAll(r.Body); string(b) != "\x00\x01\x02" {
+		t.Errorf("expected the binary body left to read, got %q", b)
+	}
+
+	for ct, want := range map[string]bool{"application/problem+json": true, "TEXT/CSV": true, "image/png": false} {
+		if LoggableBody(ct, nil) != want {
+			t.Errorf("LoggableBody(%q) = %v", ct, !want)
+		}
+	}
+	if !LoggableBody("", []byte(`{"a":1}`)) || LoggableBody("", []byte("\x89PNG\r\n\x1a\n")) {
+		t.Error("expected untyped bodies sniffed")
+	}
+}
@@ -100,3 +100,76 @@ func (h *LevelFilterHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
 func (h *LevelFilterHandler) WithGroup(name string) slog.Handler {
 	return &LevelFilterHandler{minLevel: h.minLevel, inner: h.inner.WithGroup(name)}
 }
+
+// UseHandler routes the package-level functions, Named and child loggers
+// and the middleware through h (zap's slog bridge, otelslog, tint, ...)
+// instead of the built-in formatter. Levels, sampling, RedactKeys and
+// RedactPatterns still apply; Output and the formatting options do not.
+// Pass nil to restore the built-in formatter.
+func UseHandler(h slog.Handler) {
+	configWriteMu.Lock()
+	defer configWriteMu.Unlock()
+
+	st := *loadState()
+	cfg := st.config
+	cfg.Handler = h
+	publishState(cfg, st)
+}
+
+// redactHandler applies RedactPatterns and DetectPII to string attributes
+// before passing records to a user-supplied handler
+type redactHandler struct {
+	inner  slog.Handler
+	values valueRedactor
+}
+
+// newRedactHandler wraps h when cfg redacts string values
+func newRedactHandler(h slog.Handler, cfg Config) slog.Handler {
+	values := newValueRedactor(cfg)
+	if !values.enabled() {
+		return h
+	}
+	return &redactHandler{inner: h, values: values}
+}
+
+func (h *redactHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.inner.Enabled(ctx, level)
+}
+
+func (h *redactHandler) Handle(ctx context.Context, record slog.Record) error {
+	redacted := slog.NewRecord(record.Time, record.Level, record.Message, record.PC)
+	record.Attrs(func(a slog.Attr) bool {
+		redacted.AddAttrs(h.redact(a))
+		return true
+	})
+	return h.inner.Handle(ctx, redacted)
+}
+
+func (h *redactHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	redacted := make([]slog.Attr, len(attrs))
+	for i, a := range attrs {
+		redacted[i] = h.redact(a)
+	}
+	return &redactHandler{inner: h.inner.WithAttrs(redacted), values: h.values}
+}
+
+func (h *redactHandler) WithGroup(name string) slog.Handler {
+	return &redactHandler{inner: h.inner.WithGroup(name), values: h.values}
+}
+
+// redact masks a string value, descending into groups
+func (h *redactHandler) redact(a slog.Attr) slog.Attr {
+	v := a.Value.Resolve()
+	switch v.Kind() {
+	case slog.KindString:
+		return slog.String(a.Key, h.values.redact(v.String()))
+	case slog.KindGroup:
+		group := v.Group()
+		redacted := make([]slog.Attr, len(group))
+		for i, ga := range group {
+			redacted[i] = h.redact(ga)
+		}
+		return slog.Attr{Key: a.Key, Value: slog.GroupValue(redacted...)}
+	}
+	return slog.Attr{Key: a.Key, Value: v}
+}
@@ -0,0 +1,48 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestUseHandler(t *testing.T) {
+	var builtin, external bytes.Buffer
+	SetConfig(Config{
+		Output:         &builtin,
+		Level:          slog.LevelInfo,
+		LevelSet:       true,
+		CompactJSON:    true,
+		RedactPatterns: []string{`^\d{3}-\d{2}-\d{4}$`},
+	})
+	defer SetConfig(Config{Output: io.Discard, Level: LevelTrace})
+
+	UseHandler(slog.NewJSONHandler(&external, &slog.HandlerOptions{Level: LevelTrace}))
+
+	LogDebug("below the configured level")
+	Named("db").LogInfo("query", "password", "hunter2", "ssn", "123-45-6789", "rows", 3)
+
+	if builtin.Len() != 0 {
+		t.Errorf("built-in formatter should be bypassed, got %q", builtin.String())
+	}
+	var record map[string]any
+	if err := json.Unmarshal(external.Bytes(), &record); err != nil {
+		t.Fatalf("expected one JSON record, got %q: %v", external.String(), err)
+	}
+	want := map[string]any{"msg": "query", "logger": "db", "password": "***", "ssn": "***", "rows": float64(3)}
+	for k, v := range want {
+		if record[k] != v {
+			t.Errorf("%s = %v, want %v", k, record[k], v)
+		}
+	}
+
+	UseHandler(nil)
+	external.Reset()
+	LogInfo("back to built-in")
+	if external.Len() != 0 || !strings.Contains(builtin.String(), "back to built-in") {
+		t.Errorf("expected UseHandler(nil) to restore the built-in formatter")
+	}
+}
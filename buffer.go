@@ -0,0 +1,163 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// DefaultRecordBufferSize is how many records a RecordBuffer holds when
+// NewBufferContext is given no size
+const DefaultRecordBufferSize = 1000
+
+// RecordBuffer holds the records logged through the logger of a context
+// from NewBufferContext, so all lines of one request or job can be written
+// together at its end, or dropped when it succeeded:
+//
+//	ctx, buf := logger.NewBufferContext(ctx, 0)
+//	err := handle(ctx) // logs through logger.FromContext(ctx)
+//	if err != nil {
+//		buf.Flush()
+//	} else {
+//		buf.Discard()
+//	}
+//
+// Records are admitted when they are logged, so level, filter and sampling
+// decisions do not depend on the outcome, and Flush writes them with the
+// time they were logged at. Audit records are never held. Once flushed or
+// discarded, the buffer lets records through as they are logged.
+type RecordBuffer struct {
+	mu      sync.Mutex
+	records []bufferedRecord
+	size    int
+	dropped int  // Records over size, reported by Flush
+	done    bool // Flushed or discarded
+}
+
+// bufferedRecord is an admitted record waiting in a RecordBuffer
+type bufferedRecord struct {
+	time      time.Time
+	level     LogLevel
+	message   string
+	pc        uintptr
+	keyValues []any
+}
+
+// recordTimeKey carries the time a buffered record was logged at to
+// logInternalSyncContext
+type recordTimeKey struct{}
+
+// NewBufferContext returns a copy of ctx whose logger holds its records in
+// the returned buffer; size <= 0 selects DefaultRecordBufferSize. Fields and
+// the level of a logger already stored with NewContext are kept. A custom
+// Logger implementation cannot be buffered: its records are written as
+// they are logged and the buffer stays empty.
+func NewBufferContext(ctx context.Context, size int) (context.Context, *RecordBuffer) {
+	if size <= 0 {
+		size = DefaultRecordBufferSize
+	}
+	b := &RecordBuffer{size: size}
+	switch l := FromContext(ctx).(type) {
+	case *defaultLoggerImpl:
+		ctx = NewContext(ctx, &childLogger{buffer: b})
+	case *childLogger:
+		ctx = NewContext(ctx, &childLogger{name: l.name, level: l.level, fields: l.fields, buffer: b})
+	}
+	return ctx, b
+}
+
+// add holds an admitted record, and reports false when it is to be written
+// right away instead
+func (b *RecordBuffer) add(level LogLevel, message string, pc uintptr, keyValues []any, cfg *Config) bool {
+	if level == Audit {
+		return false
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.done {
+		return false
+	}
+	if len(b.records) >= b.size {
+		b.dropped++
+		return true
+	}
+	// Copy keyValues, which may live on the caller's stack, and evaluate
+	// lazy values while what they capture is current
+	b.records = append(b.records, bufferedRecord{
+		time:      time.Now(),
+		level:     level,
+		message:   message,
+		pc:        pc,
+		keyValues: resolveLazyValues(slices.Clone(keyValues), cfg),
+	})
+	return true
+}
+
+// take ends buffering and returns the held records
+func (b *RecordBuffer) take() ([]bufferedRecord, int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	records, dropped := b.records, b.dropped
+	b.records, b.dropped, b.done = nil, 0, true
+	return records, dropped
+}
+
+// Len returns the number of records held
+func (b *RecordBuffer) Len() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.records)
+}
+
+// Flush writes the held records in the order they were logged, followed
+// by a Warn record when some did not fit the buffer
+func (b *RecordBuffer) Flush() {
+	records, dropped := b.take()
+	for _, r := range records {
+		ctx := context.WithValue(context.Background(), recordTimeKey{}, r.time)
+		logInternalSyncContext(ctx, r.level, r.message, r.pc, r.keyValues...)
+	}
+	if dropped > 0 {
+		logInternal(Warn, "Record buffer full", "dropped_records", dropped)
+	}
+}
+
+// FlushGrouped writes the held records as one record at level, with
+// message and keyValues, and the records under "records", each a group of
+// its "time", "level", "msg" and attributes keyed by its position:
+//
+//	{"msg":"GET /orders [500]","records":{"1":{"level":"DEBUG","msg":"cache miss",...},"2":{...}}}
+//
+// It reports false and writes nothing when no record is held.
+func (b *RecordBuffer) FlushGrouped(level LogLevel, message string, keyValues ...any) bool {
+	records, dropped := b.take()
+	if len(records) == 0 {
+		return false
+	}
+	// Zero-padded positions keep the records in order when keys are sorted
+	width := len(strconv.Itoa(len(records)))
+	group := make([]any, 0, len(records))
+	for i, r := range records {
+		kv := append([]any{
+			slog.Time("time", r.time),
+			slog.String("level", LevelString(slogLevelFromLogLevel(r.level))),
+			slog.String("msg", r.message),
+		}, r.keyValues...)
+		group = append(group, Group(fmt.Sprintf("%0*d", width, i+1), kv...))
+	}
+	kv := append(slices.Clone(keyValues), Group("records", group...))
+	if dropped > 0 {
+		kv = append(kv, "dropped_records", dropped)
+	}
+	logInternal(level, message, kv...)
+	return true
+}
+
+// Discard drops the held records
+func (b *RecordBuffer) Discard() {
+	b.take()
+}
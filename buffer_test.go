@@ -0,0 +1,62 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRecordBuffer(t *testing.T) {
+	defer SetConfig(Config{Output: io.Discard, Level: LevelTrace})
+
+	var buf bytes.Buffer
+	SetConfig(Config{Output: &buf, Level: LevelInfo, LevelSet: true, Format: FormatJSON, CompactJSON: true, TimeFormat: time.RFC3339Nano})
+
+	ctx := NewContext(context.Background(), With("job", "sync"))
+	ctx, held := NewBufferContext(ctx, 2)
+
+	FromContext(ctx).LogInfo("first")
+	logged := time.Now()
+	time.Sleep(10 * time.Millisecond)
+	LogDebugWithContext(ctx, "below the level")
+	LogWarnWithContext(ctx, "second")
+	LogErrorWithContext(ctx, "over the size")
+	FromContext(ctx).LogAudit("action", "login")
+	if held.Len() != 2 || !strings.Contains(buf.String(), `"action":"login"`) || strings.Contains(buf.String(), "first") {
+		t.Fatalf("expected 2 records held and audit written, got %d: %s", held.Len(), buf.String())
+	}
+
+	buf.Reset()
+	held.Flush()
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 || !strings.Contains(lines[2], `"dropped_records":1`) {
+		t.Fatalf("expected 2 records and a dropped count:\n%s", buf.String())
+	}
+	var first struct {
+		Time time.Time `json:"time"`
+		Msg  string    `json:"msg"`
+		Job  string    `json:"job"`
+	}
+	if err := json.Unmarshal([]byte(lines[0]), &first); err != nil {
+		t.Fatal(err)
+	}
+	if first.Msg != "first" || first.Job != "sync" || first.Time.After(logged) {
+		t.Errorf("expected the logger's fields and the time of logging, got %+v", first)
+	}
+
+	// Flushed buffers let records through
+	buf.Reset()
+	FromContext(ctx).LogInfo("late")
+	if !strings.Contains(buf.String(), "late") || held.Len() != 0 {
+		t.Errorf("expected records written after Flush: %s", buf.String())
+	}
+
+	_, held = NewBufferContext(context.Background(), 0)
+	if held.FlushGrouped(Info, "done") || held.Len() != 0 {
+		t.Error("expected FlushGrouped to write nothing without records")
+	}
+}
@@ -0,0 +1,125 @@
+package logger
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// burstSampler logs the first SampleFirst records of each (level, message)
+// per SampleInterval and every SampleThereafter-th one after that, in the
+// manner of zap's sampler. When an interval ends, the number of records it
+// dropped is logged once.
+type burstSampler struct {
+	first      int
+	thereafter int
+	interval   time.Duration
+
+	mu      sync.Mutex
+	entries map[burstKey]*burstEntry
+	stopCh  chan struct{}
+}
+
+type burstKey struct {
+	level   LogLevel
+	message string
+}
+
+type burstEntry struct {
+	count   int // Records seen this interval
+	dropped int // Records dropped this interval
+	start   time.Time
+}
+
+// newBurstSampler creates a sampler and starts its reporting goroutine
+func newBurstSampler(first, thereafter int, interval time.Duration) *burstSampler {
+	b := &burstSampler{
+		first:      first,
+		thereafter: thereafter,
+		interval:   interval,
+		entries:    make(map[burstKey]*burstEntry),
+		stopCh:     make(chan struct{}),
+	}
+	go b.cleanup()
+	return b
+}
+
+// admit counts a record and reports whether to keep it
+func (b *burstSampler) admit(level LogLevel, msg string, now time.Time) bool {
+	key := burstKey{level, msg}
+	b.mu.Lock()
+	e, ok := b.entries[key]
+	var expired int
+	if !ok {
+		e = &burstEntry{start: now}
+		b.entries[key] = e
+	} else if now.Sub(e.start) >= b.interval {
+		// The reporting goroutine has not got to it yet
+		expired = e.dropped
+		*e = burstEntry{start: now}
+	}
+	e.count++
+	n := e.count - b.first
+	keep := n <= 0 || b.thereafter > 0 && n%b.thereafter == 0
+	if !keep {
+		e.dropped++
+	}
+	b.mu.Unlock()
+
+	if expired > 0 {
+		b.report(key, expired)
+	}
+	return keep
+}
+
+// Flush reports and forgets every interval, ended or not
+func (b *burstSampler) Flush() {
+	b.flush(func(*burstEntry) bool { return true })
+}
+
+func (b *burstSampler) cleanup() {
+	ticker := time.NewTicker(b.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			now := time.Now()
+			b.flush(func(e *burstEntry) bool { return now.Sub(e.start) >= b.interval })
+		case <-b.stopCh:
+			return
+		}
+	}
+}
+
+// flush removes the entries done reports true for and reports their drops
+func (b *burstSampler) flush(done func(*burstEntry) bool) {
+	b.mu.Lock()
+	expired := make(map[burstKey]int)
+	for k, e := range b.entries {
+		if done(e) {
+			expired[k] = e.dropped
+			delete(b.entries, k)
+		}
+	}
+	b.mu.Unlock()
+	for k, dropped := range expired {
+		if dropped > 0 {
+			b.report(k, dropped)
+		}
+	}
+}
+
+// report logs the number of records of key dropped in one interval. It is
+// written directly, past sampling, and must be called without b.mu held.
+func (b *burstSampler) report(key burstKey, dropped int) {
+	logInternalSync(key.level, fmt.Sprintf("%s (sampled out %d times)", key.message, dropped), 0,
+		"sampled_out", dropped, "sample_interval", b.interval)
+}
+
+func (b *burstSampler) Stop() {
+	select {
+	case <-b.stopCh:
+	default:
+		close(b.stopCh)
+	}
+}
@@ -0,0 +1,67 @@
+package logger
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBurstSampling(t *testing.T) {
+	defer SetConfig(Config{Output: io.Discard, Level: LevelTrace})
+	sw := newSyncWriter()
+	SetConfig(Config{Output: sw, Level: LevelTrace, CompactJSON: true, Format: FormatJSON})
+
+	b := newBurstSampler(3, 10, time.Minute)
+	defer b.Stop()
+	now := time.Now()
+	kept := 0
+	for range 43 {
+		if b.admit(Info, "hot path", now) {
+			kept++
+		}
+	}
+	// 3 first, then the 13th, 23rd, 33rd and 43rd
+	if kept != 7 {
+		t.Errorf("expected 7 of 43 kept, got %d", kept)
+	}
+	if !b.admit(Warn, "hot path", now) {
+		t.Error("expected another level to be counted separately")
+	}
+
+	// The next interval starts over and reports the previous one's drops
+	if !b.admit(Info, "hot path", now.Add(time.Minute)) {
+		t.Error("expected the first record of a new interval to be kept")
+	}
+	out := sw.String()
+	if !strings.Contains(out, `"msg":"hot path (sampled out 36 times)"`) || !strings.Contains(out, `"sampled_out":36`) {
+		t.Errorf("expected a report of 36 sampled out records: %s", out)
+	}
+}
+
+func TestBurstSamplingConfig(t *testing.T) {
+	defer SetConfig(Config{Output: io.Discard, Level: LevelTrace})
+	sw := newSyncWriter()
+	SetConfig(Config{Output: sw, Level: LevelTrace, CompactJSON: true, Format: FormatJSON,
+		SampleFirst: 2, SampleInterval: time.Hour})
+
+	for range 5 {
+		LogInfo("repeated")
+	}
+	if n := strings.Count(sw.String(), `"msg":"repeated"`); n != 2 {
+		t.Errorf("expected 2 records with SampleThereafter 0, got %d", n)
+	}
+
+	// Shutdown reports the open interval
+	if err := Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(sw.String(), "repeated (sampled out 3 times)") {
+		t.Errorf("expected the drops reported on shutdown: %s", sw.String())
+	}
+
+	if err := SetConfigE(Config{Output: io.Discard, SampleFirst: -1}); err == nil {
+		t.Error("expected an error for a negative SampleFirst")
+	}
+}
@@ -0,0 +1,165 @@
+package logger
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"runtime/debug"
+	"strings"
+	"time"
+)
+
+// Flags holds the values of the logging flags registered by RegisterFlags
+type Flags struct {
+	Level  string
+	Format string
+	File   string
+
+	file *RotatingWriter
+}
+
+// RegisterFlags registers --log-level, --log-format and --log-file on fs
+// (flag.CommandLine if nil). Call Apply after parsing. Cobra and other
+// pflag-based CLIs add them with AddGoFlagSet:
+//
+//	fs := flag.NewFlagSet("log", flag.ContinueOnError)
+//	logFlags := logger.RegisterFlags(fs)
+//	root.PersistentFlags().AddGoFlagSet(fs)
+//	root.PersistentPreRunE = func(*cobra.Command, []string) error { return logFlags.Apply() }
+func RegisterFlags(fs *flag.FlagSet) *Flags {
+	if fs == nil {
+		fs = flag.CommandLine
+	}
+	f := &Flags{}
+	fs.StringVar(&f.Level, "log-level", "", "log level: trace, debug, info, notice, warn, error, audit")
+	fs.StringVar(&f.Format, "log-format", "", "log format: pretty, compact, json, logfmt, console")
+	fs.StringVar(&f.File, "log-file", "", "write logs to this file, rotated at 100MB, instead of stdout")
+	return f
+}
+
+// Apply applies the flags that were given to the current configuration.
+// Flags left empty keep their configured (or LOG_* environment) values.
+func (f *Flags) Apply() error {
+	cfg := GetConfig()
+	if f.Level != "" {
+		level, err := ParseLevel(f.Level)
+		if err != nil {
+			return fmt.Errorf("--log-level: %w", err)
+		}
+		cfg.Level = level
+		cfg.LevelSet = true
+	}
+	if f.Format != "" {
+		format, compact, ok := parseFormatString(f.Format)
+		if !ok {
+			return fmt.Errorf("--log-format: unknown format %q", f.Format)
+		}
+		cfg.Format = format
+		cfg.CompactJSON = cfg.CompactJSON || compact
+	}
+	if f.File != "" {
+		w, err := NewRotatingWriter(f.File, nil)
+		if err != nil {
+			return fmt.Errorf("--log-file: %w", err)
+		}
+		if f.file != nil {
+			_ = f.file.Close()
+		}
+		f.file = w
+		cfg.Output = w
+		cfg.SplitStdStreams = false
+	}
+	return SetConfigE(cfg)
+}
+
+// Close closes the --log-file output, if any. Call it on exit, after
+// Shutdown when logging asynchronously.
+func (f *Flags) Close() error {
+	if f.file == nil {
+		return nil
+	}
+	return f.file.Close()
+}
+
+// RunCommand runs a CLI command, logging its name and arguments at Debug
+// when it starts and its exit status and duration when it ends: at Info
+// for 0, at Error with the error otherwise. It returns the exit status:
+// 0, the error's ExitCode() if it has one (as exec.ExitError does), 1 for
+// other errors and 2 for a recovered panic.
+//
+// Values of flags named after RedactKeys, e.g. --password=x or
+// --db-token x, are masked.
+//
+//	func main() {
+//		os.Exit(logger.RunCommand("mytool", os.Args[1:], root.Execute))
+//	}
+func RunCommand(name string, args []string, run func() error) (code int) {
+	cfg := GetConfig()
+	l := DefaultLogger().With("command", name)
+	start := time.Now()
+	l.LogDebug(fmt.Sprintf("Command %s started", name), "args", redactArgs(args, cfg))
+
+	defer func() {
+		if r := recover(); r != nil {
+			code = 2
+			l.LogError(fmt.Sprintf("Command %s panicked", name),
+				"exit_status", code, "duration", time.Since(start),
+				"panic", fmt.Sprint(r), "panic_type", fmt.Sprintf("%T", r), "stack", string(debug.Stack()))
+		}
+	}()
+
+	err := run()
+	if err == nil {
+		l.LogInfo(fmt.Sprintf("Command %s finished", name), "exit_status", 0, "duration", time.Since(start))
+		return 0
+	}
+	code = 1
+	var exitErr interface{ ExitCode() int }
+	if errors.As(err, &exitErr) && exitErr.ExitCode() > 0 {
+		code = exitErr.ExitCode()
+	}
+	l.LogError(fmt.Sprintf("Command %s failed", name), "exit_status", code, "duration", time.Since(start), "__error", err)
+	return code
+}
+
+// redactArgs masks the values of sensitive flags in a command line
+func redactArgs(args []string, cfg Config) []string {
+	out := make([]string, len(args))
+	copy(out, args)
+	for i := 0; i < len(out); i++ {
+		arg := out[i]
+		if arg == "--" {
+			break
+		}
+		if !strings.HasPrefix(arg, "-") {
+			continue
+		}
+		name, _, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		if !isSensitiveFlag(name, cfg.RedactKeys) {
+			continue
+		}
+		if hasValue {
+			out[i] = arg[:strings.IndexByte(arg, '=')+1] + cfg.RedactMask
+		} else if i+1 < len(out) && !strings.HasPrefix(out[i+1], "-") {
+			i++
+			out[i] = cfg.RedactMask
+		}
+	}
+	return out
+}
+
+// isSensitiveFlag reports whether a flag name is, or ends with, a redact
+// key: "password", "db-password" and "db_password" all match "password"
+func isSensitiveFlag(name string, redactKeys []string) bool {
+	if isSensitiveKey(name, redactKeys) {
+		return true
+	}
+	for _, k := range redactKeys {
+		if len(name) > len(k) && strings.EqualFold(name[len(name)-len(k):], k) {
+			if sep := name[len(name)-len(k)-1]; sep == '-' || sep == '_' {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,89 @@
+package logger
+
+import (
+	"errors"
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestRegisterFlags(t *testing.T) {
+	SetConfig(Config{Output: io.Discard, Level: LevelInfo, LevelSet: true})
+	defer SetConfig(Config{Output: io.Discard, Level: LevelTrace})
+
+	path := filepath.Join(t.TempDir(), "app.log")
+	fs := flag.NewFlagSet("tool", flag.ContinueOnError)
+	f := RegisterFlags(fs)
+	if err := fs.Parse([]string{"--log-level", "debug", "--log-format=json", "--log-file", path}); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Apply(); err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = f.Close() }()
+
+	cfg := GetConfig()
+	if cfg.Level != LevelDebug || cfg.Format != FormatJSON {
+		t.Errorf("Expected debug and json, got %v and %v", cfg.Level, cfg.Format)
+	}
+	LogDebug("to the file")
+	if data, _ := os.ReadFile(path); !strings.Contains(string(data), `"msg":"to the file"`) {
+		t.Errorf("Expected the record in --log-file, got %q", data)
+	}
+
+	for _, args := range [][]string{{"--log-level=loud"}, {"--log-format=xml"}} {
+		fs := flag.NewFlagSet("tool", flag.ContinueOnError)
+		f := RegisterFlags(fs)
+		_ = fs.Parse(args)
+		if err := f.Apply(); err == nil {
+			t.Errorf("Expected an error for %v", args)
+		}
+	}
+}
+
+type exitError int
+
+func (e exitError) Error() string { return "exit" }
+func (e exitError) ExitCode() int { return int(e) }
+
+func TestRunCommand(t *testing.T) {
+	sw := newSyncWriter()
+	SetConfig(Config{Output: sw, Level: LevelTrace, CompactJSON: true, Format: FormatJSON})
+	defer SetConfig(Config{Output: io.Discard, Level: LevelTrace})
+
+	if code := RunCommand("deploy", []string{"--db-password", "hunter2", "--api-key=abc", "prod"}, func() error { return nil }); code != 0 {
+		t.Errorf("Expected exit status 0, got %d", code)
+	}
+	if code := RunCommand("deploy", nil, func() error { return errors.New("no access") }); code != 1 {
+		t.Errorf("Expected exit status 1, got %d", code)
+	}
+	if code := RunCommand("deploy", nil, func() error { return exitError(3) }); code != 3 {
+		t.Errorf("Expected the error's exit code, got %d", code)
+	}
+	if code := RunCommand("deploy", nil, func() error { panic("boom") }); code != 2 {
+		t.Errorf("Expected exit status 2 for a panic, got %d", code)
+	}
+
+	out := sw.String()
+	if strings.Contains(out, "hunter2") || strings.Contains(out, "abc") {
+		t.Errorf("Expected secret flags to be masked:\n%s", out)
+	}
+	for _, want := range []string{`"msg":"Command deploy finished"`, `"exit_status":3`, `"msg":"Command deploy panicked"`, `"command":"deploy"`} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected %s in:\n%s", want, out)
+		}
+	}
+}
+
+func TestRedactArgs(t *testing.T) {
+	cfg := GetConfig()
+	got := redactArgs([]string{"-v", "--token", "t1", "--db_secret=s", "--name", "bob", "--", "--password", "x"}, cfg)
+	want := []string{"-v", "--token", cfg.RedactMask, "--db_secret=" + cfg.RedactMask, "--name", "bob", "--", "--password", "x"}
+	if !slices.Equal(got, want) {
+		t.Errorf("redactArgs = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,108 @@
+package logger
+
+import (
+	"io"
+	"os"
+	"strings"
+)
+
+// ColorMode controls ANSI colors in the pretty format
+type ColorMode int
+
+const (
+	// ColorDefault follows EnableColor, so existing configs keep working
+	ColorDefault ColorMode = iota
+	// ColorAuto colors only when Output is a terminal, honoring the NO_COLOR
+	// and FORCE_COLOR conventions and TERM=dumb
+	ColorAuto
+	// ColorAlways colors regardless of Output and environment
+	ColorAlways
+	// ColorNever never writes ANSI escapes
+	ColorNever
+)
+
+// String returns the mode name
+func (m ColorMode) String() string {
+	switch m {
+	case ColorDefault:
+		return "default"
+	case ColorAuto:
+		return "auto"
+	case ColorAlways:
+		return "always"
+	case ColorNever:
+		return "never"
+	default:
+		return "unknown"
+	}
+}
+
+// colorEnabled resolves cfg's color setting for records written to out.
+// On Windows it also turns on ANSI processing for console outputs, and
+// reports false when an old console cannot interpret escape codes.
+func colorEnabled(cfg Config, out io.Writer) bool {
+	switch cfg.Color {
+	case ColorAlways:
+		return prepareTerminal(out)
+	case ColorNever:
+		return false
+	case ColorAuto:
+		// https://no-color.org and https://force-color.org
+		if os.Getenv("NO_COLOR") != "" {
+			return false
+		}
+		if v, ok := os.LookupEnv("FORCE_COLOR"); ok {
+			switch strings.ToLower(v) {
+			case "0", "false", "no", "off":
+				return false
+			default:
+				return true
+			}
+		}
+		if os.Getenv("TERM") == "dumb" {
+			return false
+		}
+		return isTerminal(out) && prepareTerminal(out)
+	default:
+		return cfg.EnableColor && prepareTerminal(out)
+	}
+}
+
+// prepareTerminal enables ANSI escape processing for every console out
+// writes to and reports whether all of them can show colors
+func prepareTerminal(out io.Writer) bool {
+	switch w := out.(type) {
+	case *os.File:
+		return enableVirtualTerminal(w)
+	case *LevelRouter:
+		ok := prepareTerminal(w.def)
+		for _, route := range w.routes {
+			ok = prepareTerminal(route.out) && ok
+		}
+		return ok
+	default:
+		return true
+	}
+}
+
+// isTerminal reports whether out is a character device. A LevelRouter is a
+// terminal only when every writer it routes to is one.
+func isTerminal(out io.Writer) bool {
+	switch w := out.(type) {
+	case *os.File:
+		info, err := w.Stat()
+		return err == nil && info.Mode()&os.ModeCharDevice != 0
+	case *LevelRouter:
+		if !isTerminal(w.def) {
+			return false
+		}
+		for _, route := range w.routes {
+			if !isTerminal(route.out) {
+				return false
+			}
+		}
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,11 @@
+//go:build !windows
+
+package logger
+
+import "os"
+
+// enableVirtualTerminal is a no-op: terminals outside Windows interpret
+// ANSI escapes natively
+func enableVirtualTerminal(*os.File) bool {
+	return true
+}
@@ -0,0 +1,105 @@
+package logger
+
+import (
+	"bytes"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestColorEnabled(t *testing.T) {
+	t.Setenv("NO_COLOR", "")
+	t.Setenv("TERM", "xterm-256color")
+	t.Setenv("FORCE_COLOR", "")
+	os.Unsetenv("FORCE_COLOR")
+
+	var buf bytes.Buffer
+	for _, tc := range []struct {
+		name string
+		cfg  Config
+		env  map[string]string
+		want bool
+	}{
+		{"default follows EnableColor", Config{EnableColor: true}, nil, true},
+		{"default without EnableColor", Config{}, nil, false},
+		{"always", Config{Color: ColorAlways}, map[string]string{"NO_COLOR": "1"}, true},
+		{"never", Config{Color: ColorNever, EnableColor: true}, nil, false},
+		{"auto on a buffer", Config{Color: ColorAuto, EnableColor: true}, nil, false},
+		{"auto with FORCE_COLOR", Config{Color: ColorAuto}, map[string]string{"FORCE_COLOR": "1"}, true},
+		{"auto with FORCE_COLOR=0", Config{Color: ColorAuto}, map[string]string{"FORCE_COLOR": "0"}, false},
+		{"NO_COLOR beats FORCE_COLOR", Config{Color: ColorAuto}, map[string]string{"NO_COLOR": "1", "FORCE_COLOR": "1"}, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			for k, v := range tc.env {
+				t.Setenv(k, v)
+			}
+			if got := colorEnabled(tc.cfg, &buf); got != tc.want {
+				t.Errorf("colorEnabled = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestColorAutoPipedOutput(t *testing.T) {
+	t.Setenv("NO_COLOR", "")
+	t.Setenv("FORCE_COLOR", "")
+	os.Unsetenv("FORCE_COLOR")
+
+	f, err := os.CreateTemp(t.TempDir(), "log")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if isTerminal(f) {
+		t.Fatal("a regular file is not a terminal")
+	}
+
+	SetConfig(Config{Output: f, Color: ColorAuto, EnableColor: true, CompactJSON: true})
+	defer SetConfig(Config{Output: io.Discard, Level: LevelTrace})
+	LogInfo("to a file", "k", "v")
+	SetConfig(Config{Output: f, Color: ColorAlways})
+	LogInfo("forced")
+
+	data, err := os.ReadFile(f.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines, got %q", data)
+	}
+	if strings.Contains(lines[0], "\x1b[") {
+		t.Errorf("unexpected ANSI escapes with ColorAuto: %q", lines[0])
+	}
+	if !strings.Contains(lines[1], "\x1b[") {
+		t.Errorf("expected ANSI escapes with ColorAlways: %q", lines[1])
+	}
+}
+
+func TestColorEnv(t *testing.T) {
+	for v, want := range map[string]ColorMode{"auto": ColorAuto, "true": ColorAlways, "0": ColorNever} {
+		t.Setenv("LOG_COLOR", v)
+		if got := ConfigFromEnv().Color; got != want {
+			t.Errorf("LOG_COLOR=%s: Color = %v, want %v", v, got, want)
+		}
+	}
+	if err := (&Config{TimeFormat: "x", Color: ColorNever + 1}).Validate(); err == nil {
+		t.Error("expected an error for an unknown Color")
+	}
+}
+
+func TestPrepareTerminal(t *testing.T) {
+	f, err := os.CreateTemp(t.TempDir(), "log")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	// Files and pipes are never consoles, so escapes pass through on every platform
+	router := NewLevelRouter(f, map[slog.Level]io.Writer{LevelWarn: &bytes.Buffer{}})
+	if !prepareTerminal(f) || !prepareTerminal(router) || !prepareTerminal(io.Discard) {
+		t.Error("expected non-console outputs to accept ANSI escapes")
+	}
+}
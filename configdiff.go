@@ -0,0 +1,182 @@
+package logger
+
+import (
+	"fmt"
+	"log/slog"
+	"reflect"
+)
+
+// diffConfig returns the names of the Config fields that differ between old
+// and cfg, in declaration order. Writers, handlers and functions compare by
+// identity; everything else by value.
+func diffConfig(old, cfg Config) []string {
+	var changed []string
+	a, b := reflect.ValueOf(old), reflect.ValueOf(cfg)
+	for i := range a.NumField() {
+		name := a.Type().Field(i).Name
+		if name == "LevelSet" || name == "SampleRateSet" {
+			// Always true once SetConfig has filled in the defaults
+			continue
+		}
+		if !sameValue(a.Field(i), b.Field(i), 0) {
+			changed = append(changed, name)
+		}
+	}
+	return changed
+}
+
+// asyncPipelineFields are the Config fields the async workers are started
+// with
+var asyncPipelineFields = []string{
+	"Output", "SplitStdStreams", "BufferSize", "FlushTimeout", "AsyncOverflowPolicy",
+	"SpillPath", "SpillMaxBytes", "AsyncBatchSize", "AsyncBatchInterval",
+	"AsyncWorkers", "AsyncUnordered",
+}
+
+// asyncSettingsChanged reports whether cfg changes any field the running
+// async pipeline was started with
+func asyncSettingsChanged(old, cfg Config) bool {
+	a, b := reflect.ValueOf(old), reflect.ValueOf(cfg)
+	for _, name := range asyncPipelineFields {
+		if !sameValue(a.FieldByName(name), b.FieldByName(name), 0) {
+			return true
+		}
+	}
+	return false
+}
+
+// auditConfigChange logs the old and new values of the changed fields at
+// Audit when old or cfg enables AuditConfigChanges
+func auditConfigChange(old, cfg Config, changed []string) {
+	if len(changed) == 0 || !old.AuditConfigChanges && !cfg.AuditConfigChanges {
+		return
+	}
+	a, b := reflect.ValueOf(old), reflect.ValueOf(cfg)
+	changes := make([]slog.Attr, 0, len(changed))
+	for _, name := range changed {
+		changes = append(changes, slog.Group(name,
+			slog.Any("old", configValue(a.FieldByName(name), cfg.RedactMask, 0)),
+			slog.Any("new", configValue(b.FieldByName(name), cfg.RedactMask, 0))))
+	}
+	logInternal(Audit, "Logger configuration changed", "fields", changed,
+		slog.Attr{Key: "changes", Value: slog.GroupValue(changes...)})
+}
+
+// configValue renders a Config field for auditConfigChange. Nothing behind
+// a pointer or interface is shown, so writers, handlers and signing keys
+// appear by type only, and byte slices (RedactHashKey) as mask.
+func configValue(v reflect.Value, mask string, depth int) any {
+	if depth > 3 {
+		return maxDepthMarker
+	}
+	switch v.Kind() {
+	case reflect.Func, reflect.Chan, reflect.UnsafePointer:
+		if v.IsNil() {
+			return nil
+		}
+		return "set"
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return fmt.Sprintf("%T", v.Interface())
+	case reflect.Slice:
+		if v.IsNil() {
+			return nil
+		}
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return mask
+		}
+		fallthrough
+	case reflect.Array:
+		list := make([]any, v.Len())
+		for i := range list {
+			list[i] = configValue(v.Index(i), mask, depth+1)
+		}
+		return list
+	case reflect.Map:
+		if v.IsNil() {
+			return nil
+		}
+		m := make(map[string]any, v.Len())
+		for k, e := range v.Seq2() {
+			m[fmt.Sprint(configValue(k, mask, depth+1))] = configValue(e, mask, depth+1)
+		}
+		return m
+	case reflect.Struct:
+		fields := make(map[string]any, v.NumField())
+		for field, fv := range v.Fields() {
+			if field.IsExported() {
+				fields[field.Name] = configValue(fv, mask, depth+1)
+			}
+		}
+		return fields
+	}
+	if level, ok := v.Interface().(slog.Level); ok {
+		return LevelString(level)
+	}
+	if s, ok := v.Interface().(fmt.Stringer); ok {
+		return s.String() // Durations, formats, modes
+	}
+	return v.Interface()
+}
+
+// sameValue compares two values of the same type for diffConfig
+func sameValue(a, b reflect.Value, depth int) bool {
+	if depth > 10 {
+		return false
+	}
+	switch a.Kind() {
+	case reflect.Func, reflect.Chan, reflect.UnsafePointer:
+		return a.Pointer() == b.Pointer()
+	case reflect.Interface:
+		if a.IsNil() || b.IsNil() {
+			return a.IsNil() == b.IsNil()
+		}
+		if a.Elem().Type() != b.Elem().Type() {
+			return false
+		}
+		if a.Elem().Comparable() {
+			return a.Elem().Equal(b.Elem())
+		}
+		return sameValue(a.Elem(), b.Elem(), depth+1)
+	case reflect.Pointer:
+		if a.Pointer() == b.Pointer() {
+			return true
+		}
+		if a.IsNil() || b.IsNil() {
+			return false
+		}
+		return sameValue(a.Elem(), b.Elem(), depth+1)
+	case reflect.Slice, reflect.Array:
+		if a.Len() != b.Len() {
+			return false
+		}
+		for i := range a.Len() {
+			if !sameValue(a.Index(i), b.Index(i), depth+1) {
+				return false
+			}
+		}
+		return true
+	case reflect.Map:
+		if a.Len() != b.Len() {
+			return false
+		}
+		for _, k := range a.MapKeys() {
+			bv := b.MapIndex(k)
+			if !bv.IsValid() || !sameValue(a.MapIndex(k), bv, depth+1) {
+				return false
+			}
+		}
+		return true
+	case reflect.Struct:
+		for i := range a.NumField() {
+			if !sameValue(a.Field(i), b.Field(i), depth+1) {
+				return false
+			}
+		}
+		return true
+	default:
+		return a.Equal(b)
+	}
+}
@@ -0,0 +1,101 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSetConfigEReturnsValidationError(t *testing.T) {
+	var buf bytes.Buffer
+	SetConfig(Config{Output: &buf, Level: LevelInfo, LevelSet: true})
+	defer SetConfig(Config{Output: io.Discard, Level: LevelTrace})
+
+	err := SetConfigE(Config{Output: io.Discard, Format: OutputFormat(42)})
+	if err == nil || !strings.Contains(err.Error(), "invalid Format") {
+		t.Fatalf("Expected an invalid Format error, got %v", err)
+	}
+	if cfg := GetConfig(); cfg.Output != &buf || cfg.Level != LevelInfo {
+		t.Error("Expected the previous configuration to be kept")
+	}
+	if buf.Len() != 0 {
+		t.Errorf("Expected SetConfigE not to log the error, got %q", buf.String())
+	}
+}
+
+func TestDiffConfig(t *testing.T) {
+	fn := func(error) {}
+	old := NewConfig(WithOutput(io.Discard), WithErrorHandler(fn), WithRedactKeys("pin"))
+
+	same := old
+	same.RedactKeys = slices.Clone(old.RedactKeys)
+	same.ErrorHandler = fn
+	if changed := diffConfig(old, same); len(changed) != 0 {
+		t.Errorf("Expected no changes, got %v", changed)
+	}
+
+	next := old
+	next.Level = LevelWarn
+	next.Output = &bytes.Buffer{}
+	next.RedactKeys = []string{"pin"}
+	next.ErrorHandler = func(error) {}
+	want := []string{"Output", "Level", "RedactKeys", "ErrorHandler"}
+	if changed := diffConfig(old, next); !slices.Equal(changed, want) {
+		t.Errorf("Expected %v, got %v", want, changed)
+	}
+}
+
+func TestLogConfigChanges(t *testing.T) {
+	var buf bytes.Buffer
+	SetConfig(Config{Output: &buf, Level: LevelTrace, CompactJSON: true})
+	defer SetConfig(Config{Output: io.Discard, Level: LevelTrace})
+
+	SetConfig(Config{Output: &buf, Level: LevelTrace, CompactJSON: true, LogConfigChanges: true, EnableCaller: true})
+
+	out := buf.String()
+	if !strings.Contains(out, "Logger configuration changed") {
+		t.Fatalf("Expected a config change record, got %q", out)
+	}
+	if !strings.Contains(out, `"EnableCaller"`) || !strings.Contains(out, `"LogConfigChanges"`) {
+		t.Errorf("Expected the changed fields to be listed, got %q", out)
+	}
+	if strings.Contains(out, `"Output"`) {
+		t.Errorf("Expected the unchanged Output not to be listed, got %q", out)
+	}
+}
+
+func TestAuditConfigChanges(t *testing.T) {
+	var buf bytes.Buffer
+	SetConfig(Config{Output: &buf, Level: LevelInfo, LevelSet: true, Format: FormatJSON, CompactJSON: true, AuditConfigChanges: true})
+	defer SetConfig(Config{Output: io.Discard, Level: LevelTrace})
+	buf.Reset()
+
+	SetConfig(Config{Output: &buf, Level: LevelInfo, LevelSet: true, Format: FormatJSON, CompactJSON: true, AuditConfigChanges: true,
+		SampleInterval: 2 * time.Second, RedactHashKey: []byte("pepper"), ErrorHandler: func(error) {}})
+	SetLevel(LevelDebug)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected two audit records, got %q", buf.String())
+	}
+	var rec struct {
+		Level   string
+		Changes map[string]struct{ Old, New any }
+	}
+	if err := json.Unmarshal([]byte(lines[0]), &rec); err != nil {
+		t.Fatalf("invalid JSON %q: %v", lines[0], err)
+	}
+	if rec.Level != "AUDIT" || rec.Changes["SampleInterval"].New != "2s" || rec.Changes["ErrorHandler"].New != "set" {
+		t.Errorf("Expected an Audit record with the old and new values, got %s", lines[0])
+	}
+	if strings.Contains(lines[0], "pepper") || rec.Changes["RedactHashKey"].New != "***" {
+		t.Errorf("Expected the hash key masked, got %s", lines[0])
+	}
+	if !strings.Contains(lines[1], `"Level":{"new":"DEBUG","old":"INFO"}`) {
+		t.Errorf("Expected SetLevel audited, got %s", lines[1])
+	}
+}
@@ -0,0 +1,123 @@
+package logger
+
+import (
+	"log/slog"
+	"slices"
+	"unicode/utf8"
+)
+
+// ConsoleTimeFormat is the time-of-day timestamp of FormatConsole
+const ConsoleTimeFormat = "15:04:05.000"
+
+// consoleMessageWidth pads short messages so attributes start in one column
+const consoleMessageWidth = 40
+
+// consoleLevelLabels are the fixed-width level column of FormatConsole
+var consoleLevelLabels = map[slog.Level]string{
+	LevelTrace:  "TRC",
+	LevelDebug:  "DBG",
+	LevelInfo:   "INF",
+	LevelNotice: "NTC",
+	LevelWarn:   "WRN",
+	LevelError:  "ERR",
+	LevelAudit:  "AUD",
+}
+
+// faint is the console style for timestamps, sources and keys
+var faint = Style{Faint: true}
+
+// consoleLevelLabel returns the three-letter label of level; levels between
+// the named ones get an offset, e.g. INF+1
+func consoleLevelLabel(level slog.Level) string {
+	if label, ok := consoleLevelLabels[level]; ok {
+		return label
+	}
+	name := level.String() // e.g. INFO+1
+	base := level
+	switch {
+	case level < LevelDebug:
+		base = LevelTrace
+	case level < LevelInfo:
+		base = LevelDebug
+	case level < LevelWarn:
+		base = LevelInfo
+	case level < LevelError:
+		base = LevelWarn
+	default:
+		base = LevelError
+	}
+	if i := len(plainLevelLabels[base]); base != LevelTrace && len(name) > i {
+		return consoleLevelLabels[base] + name[i:]
+	}
+	return name
+}
+
+// appendConsoleLine appends "15:04:05.000 INF [file:line] message   key=value ..."
+func (handler *prettyHandler) appendConsoleLine(buf []byte, state *handleState, record slog.Record) ([]byte, error) {
+	color := handler.config.EnableColor
+	timeStyle := handler.theme.Timestamp
+	if timeStyle.isZero() {
+		timeStyle = faint
+	}
+
+	if color {
+		buf = timeStyle.appendStyled(buf, record.Time.Format(ConsoleTimeFormat))
+	} else {
+		buf = record.Time.AppendFormat(buf, ConsoleTimeFormat)
+	}
+
+	buf = append(buf, ' ')
+	label := consoleLevelLabel(record.Level)
+	if color {
+		style, ok := handler.theme.Levels[record.Level]
+		if !ok {
+			style = handler.theme.UnknownLevel
+		}
+		buf = style.appendStyled(buf, label)
+	} else {
+		buf = append(buf, label...)
+	}
+
+	if handler.config.EnableCaller && record.PC != 0 {
+		buf = append(buf, ' ')
+		if color {
+			buf = faint.appendStyled(buf, string(appendSource(nil, record.PC)))
+		} else {
+			buf = appendSource(buf, record.PC)
+		}
+	}
+
+	buf = append(buf, ' ')
+	if color {
+		buf = handler.theme.Message.appendStyled(buf, record.Message)
+	} else {
+		buf = append(buf, record.Message...)
+	}
+
+	if record.NumAttrs() == 0 {
+		return buf, nil
+	}
+	for n := utf8.RuneCountInString(record.Message); n < consoleMessageWidth; n++ {
+		buf = append(buf, ' ')
+	}
+	return handler.appendLogfmtAttrs(buf, "", sortedRecordAttrs(state, record))
+}
+
+// stylePair styles the key=value pair written at buf[keyStart:]: pairs
+// listed in ConsoleDimKeys are faint as a whole, otherwise the key uses the
+// console or theme key style and the value the theme value style
+func (handler *prettyHandler) stylePair(buf []byte, keyStart, valueStart int, key string) []byte {
+	if slices.Contains(handler.config.ConsoleDimKeys, key) {
+		pair := string(buf[keyStart:])
+		return faint.appendStyled(buf[:keyStart], pair)
+	}
+
+	keyStyle := handler.theme.Key
+	if handler.config.Format == FormatConsole {
+		keyStyle = faint
+	}
+	keyPart := string(buf[keyStart:valueStart])
+	value := string(buf[valueStart:])
+	buf = keyStyle.appendStyled(buf[:keyStart], keyPart)
+	return handler.theme.Value.appendStyled(buf, value)
+}
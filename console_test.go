@@ -0,0 +1,55 @@
+package logger
+
+import (
+	"bytes"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestConsoleLevelLabel(t *testing.T) {
+	for level, want := range map[slog.Level]string{
+		LevelInfo:           "INF",
+		LevelAudit:          "AUD",
+		slog.Level(1):       "INF+1",
+		LevelWarn + 2:       "WRN+2",
+		LevelTrace - 1:      "DEBUG-5",
+		LevelDebug - 1:      "DEBUG-1",
+		LevelError + 4:      "ERR+4",
+		LevelNotice:         "NTC",
+		slog.LevelDebug + 1: "DBG+1",
+	} {
+		if got := consoleLevelLabel(level); got != want {
+			t.Errorf("consoleLevelLabel(%d) = %q, want %q", level, got, want)
+		}
+	}
+}
+
+func TestConsoleColors(t *testing.T) {
+	var buf bytes.Buffer
+	SetConfig(Config{
+		Output:         &buf,
+		Level:          slog.LevelInfo,
+		LevelSet:       true,
+		Format:         FormatConsole,
+		Color:          ColorAlways,
+		ConsoleDimKeys: []string{"request_id"},
+	})
+	defer SetConfig(Config{Output: io.Discard, Level: LevelTrace})
+
+	LogWarn("disk low", "free", "2GB", "request_id", "r-1")
+	got := buf.String()
+	for _, want := range []string{
+		"\x1b[33mWRN\x1b[0m \x1b[36mdisk low\x1b[0m",
+		"\x1b[2mfree=\x1b[0m2GB",
+		"\x1b[2mrequest_id=r-1\x1b[0m",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("expected %q in %q", want, got)
+		}
+	}
+	if !strings.HasPrefix(got, "\x1b[2m") {
+		t.Errorf("expected a faint timestamp in %q", got)
+	}
+}
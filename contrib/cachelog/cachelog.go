@@ -0,0 +1,302 @@
+// Package cachelog logs cache and Redis operations through the logger: the
+// command, the key (reduced to a pattern or hashed, never the raw value),
+// hit or miss, latency and errors. Slow operations are escalated to Warn
+// and failed ones are logged at Error.
+//
+// It has no dependencies. go-redis is hooked with a few lines of glue,
+// since its hook interface uses its own types:
+//
+//	cl := cachelog.New(cachelog.WithMissErrors(redis.Nil))
+//	rdb.AddHook(redisHook{cl})
+//
+//	type redisHook struct{ *cachelog.CacheLogger }
+//
+//	func (h redisHook) DialHook(next redis.DialHook) redis.DialHook { return next }
+//
+//	func (h redisHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+//		return func(ctx context.Context, cmd redis.Cmder) error {
+//			return h.Process(ctx, cmd, func() error { return next(ctx, cmd) })
+//		}
+//	}
+//
+//	func (h redisHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+//		return func(ctx context.Context, cmds []redis.Cmder) error {
+//			return cachelog.ProcessPipeline(h.CacheLogger, ctx, cmds, func() error { return next(ctx, cmds) })
+//		}
+//	}
+//
+// Other caches use Get and Do, and HTTP caches Transport.
+package cachelog
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/jozefvalachovic/logger/v4"
+)
+
+// KeyMode selects how keys appear in records
+type KeyMode int
+
+const (
+	// KeyPattern replaces the variable segments of a key with "*", e.g.
+	// user:4711:session becomes user:*:session (default)
+	KeyPattern KeyMode = iota
+	// KeyHash logs a short SHA-256 of the key, equal for equal keys
+	KeyHash
+	// KeyRaw logs keys unchanged
+	KeyRaw
+	// KeyNone omits keys
+	KeyNone
+)
+
+// Options configures cache logging
+type Options struct {
+	// Logger receives the records (default: logger.FromContext of each
+	// operation's context)
+	Logger logger.Logger
+	// Level of successful operations, hits and misses (default: Debug)
+	Level logger.LogLevel
+	// SlowThreshold escalates slower operations to Warn (default: 50ms, 0 disables)
+	SlowThreshold time.Duration
+	// KeyMode selects how keys are logged (default: KeyPattern)
+	KeyMode KeyMode
+	// KeyFunc, if set, replaces KeyMode with a custom key rewrite
+	KeyFunc func(key string) string
+	// MissErrors are cache misses rather than failures, e.g. redis.Nil
+	// (matched with errors.Is)
+	MissErrors []error
+}
+
+// Option is a functional option for configuring cache logging
+type Option func(*Options)
+
+// DefaultOptions returns the default options
+func DefaultOptions() *Options {
+	return &Options{
+		Level:         logger.Debug,
+		SlowThreshold: 50 * time.Millisecond,
+		KeyMode:       KeyPattern,
+	}
+}
+
+// WithLogger sends records to l instead of the context's logger
+func WithLogger(l logger.Logger) Option {
+	return func(o *Options) {
+		o.Logger = l
+	}
+}
+
+// WithLevel sets the level of successful operations
+func WithLevel(level logger.LogLevel) Option {
+	return func(o *Options) {
+		o.Level = level
+	}
+}
+
+// WithSlowThreshold escalates operations slower than d to Warn (0 disables)
+func WithSlowThreshold(d time.Duration) Option {
+	return func(o *Options) {
+		o.SlowThreshold = d
+	}
+}
+
+// WithKeyMode selects how keys are logged
+func WithKeyMode(mode KeyMode) Option {
+	return func(o *Options) {
+		o.KeyMode = mode
+	}
+}
+
+// WithKeyFunc rewrites keys with fn before they are logged
+func WithKeyFunc(fn func(key string) string) Option {
+	return func(o *Options) {
+		o.KeyFunc = fn
+	}
+}
+
+// WithMissErrors treats errs as cache misses
+func WithMissErrors(errs ...error) Option {
+	return func(o *Options) {
+		o.MissErrors = append(o.MissErrors, errs...)
+	}
+}
+
+// CacheLogger writes cache operation records
+type CacheLogger struct {
+	opts Options
+}
+
+// New returns a CacheLogger with opts applied to the defaults
+func New(opts ...Option) *CacheLogger {
+	options := DefaultOptions()
+	for _, opt := range opts {
+		opt(options)
+	}
+	return &CacheLogger{opts: *options}
+}
+
+// Get runs a cache lookup and logs it with its hit or miss. fn reports
+// whether the key was found.
+//
+//	hit, err := cl.Get(ctx, "user:"+id, func() (bool, error) {
+//		return cache.Get(ctx, "user:"+id, &user)
+//	})
+func (l *CacheLogger) Get(ctx context.Context, key string, fn func() (bool, error)) (bool, error) {
+	start := time.Now()
+	hit, err := fn()
+	if l.isMiss(err) {
+		hit = false
+	}
+	l.log(ctx, op{name: "get", keys: []string{key}, hit: &hit, duration: time.Since(start), err: err})
+	return hit, err
+}
+
+// Do runs any other cache operation, e.g. "set" or "delete", and logs it
+func (l *CacheLogger) Do(ctx context.Context, name, key string, fn func() error) error {
+	start := time.Now()
+	err := fn()
+	l.log(ctx, op{name: name, keys: []string{key}, duration: time.Since(start), err: err})
+	return err
+}
+
+// op is one cache operation
+type op struct {
+	name     string
+	keys     []string
+	hit      *bool // nil when the operation has no hit or miss
+	count    int   // Commands in a pipeline, 0 otherwise
+	attrs    []any // Client-specific fields
+	duration time.Duration
+	err      error
+}
+
+// log writes o at the level its outcome calls for
+func (l *CacheLogger) log(ctx context.Context, o op) {
+	level := l.opts.Level
+	slow := l.opts.SlowThreshold > 0 && o.duration >= l.opts.SlowThreshold
+	failed := o.err != nil && !l.isMiss(o.err)
+	switch {
+	case failed:
+		level = logger.Error
+	case slow:
+		level = max(level, logger.Warn)
+	}
+
+	kv := []any{"cache_operation", o.name}
+	if keys := l.keys(o.keys); len(keys) == 1 {
+		kv = append(kv, "key", keys[0])
+	} else if len(keys) > 1 {
+		kv = append(kv, "keys", keys)
+	}
+	kv = append(kv, o.attrs...)
+	if o.hit != nil {
+		kv = append(kv, "hit", *o.hit)
+	}
+	if o.count > 0 {
+		kv = append(kv, "commands", o.count)
+	}
+	kv = append(kv, "duration", o.duration)
+	if slow {
+		kv = append(kv, "slow", true)
+	}
+	if failed {
+		kv = append(kv, "__error", o.err)
+	}
+
+	msg := "Cache " + o.name
+	if slow {
+		msg = "Slow cache " + o.name
+	}
+	l.logger(ctx).Log(level, msg, kv...)
+}
+
+// logger returns the configured logger or the context's
+func (l *CacheLogger) logger(ctx context.Context) logger.Logger {
+	if l.opts.Logger != nil {
+		return l.opts.Logger
+	}
+	return logger.FromContext(ctx)
+}
+
+// isMiss reports whether err is one of MissErrors
+func (l *CacheLogger) isMiss(err error) bool {
+	if err == nil {
+		return false
+	}
+	for _, target := range l.opts.MissErrors {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// keys rewrites keys for logging, dropping duplicates
+func (l *CacheLogger) keys(keys []string) []string {
+	if l.opts.KeyMode == KeyNone && l.opts.KeyFunc == nil {
+		return nil
+	}
+	out := make([]string, 0, len(keys))
+	seen := make(map[string]bool, len(keys))
+	for _, k := range keys {
+		k = l.key(k)
+		if !seen[k] {
+			seen[k] = true
+			out = append(out, k)
+		}
+	}
+	return out
+}
+
+// key rewrites one key according to KeyFunc or KeyMode
+func (l *CacheLogger) key(key string) string {
+	if l.opts.KeyFunc != nil {
+		return l.opts.KeyFunc(key)
+	}
+	switch l.opts.KeyMode {
+	case KeyHash:
+		sum := sha256.Sum256([]byte(key))
+		return hex.EncodeToString(sum[:8])
+	case KeyRaw:
+		return key
+	default:
+		return KeyPatternOf(key)
+	}
+}
+
+// KeyPatternOf replaces the variable segments of key with "*". Segments
+// are separated by ':', '/' or '|'; a segment is variable when it contains
+// a digit, '@' or '=', or is longer than 32 bytes. Version segments such as
+// "v2" are kept.
+func KeyPatternOf(key string) string {
+	var b strings.Builder
+	start := 0
+	for i := 0; i <= len(key); i++ {
+		if i < len(key) && key[i] != ':' && key[i] != '/' && key[i] != '|' {
+			continue
+		}
+		if seg := key[start:i]; isVariable(seg) {
+			b.WriteByte('*')
+		} else {
+			b.WriteString(seg)
+		}
+		if i < len(key) {
+			b.WriteByte(key[i])
+		}
+		start = i + 1
+	}
+	return b.String()
+}
+
+// isVariable reports whether a key segment looks like an ID or a value
+func isVariable(seg string) bool {
+	if len(seg) >= 2 && len(seg) <= 3 && (seg[0] == 'v' || seg[0] == 'V') && strings.Trim(seg[1:], "0123456789") == "" {
+		return false
+	}
+	return len(seg) > 32 || strings.ContainsAny(seg, "0123456789@=")
+}
@@ -0,0 +1,160 @@
+package cachelog
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jozefvalachovic/logger/v4"
+	"github.com/jozefvalachovic/logger/v4/logtest"
+)
+
+// fakeCmd mirrors go-redis's Cmder
+type fakeCmd []any
+
+func (c fakeCmd) Name() string { return c[0].(string) }
+func (c fakeCmd) Args() []any  { return c }
+
+var errNil = errors.New("redis: nil")
+
+func setup(t *testing.T) *logtest.Recorder {
+	t.Helper()
+	logger.SetConfig(logger.Config{Output: io.Discard, Level: logger.LevelTrace})
+	return logtest.Capture(t)
+}
+
+func TestProcess(t *testing.T) {
+	rec := setup(t)
+	l := New(WithMissErrors(errNil))
+	ctx := context.Background()
+
+	_ = l.Process(ctx, fakeCmd{"set", "session:9f8e7d6c", "secret-token", "ex", 60}, func() error { return nil })
+	_ = l.Process(ctx, fakeCmd{"get", "user:4711:profile"}, func() error { return errNil })
+	_ = l.Process(ctx, fakeCmd{"auth", "hunter2"}, func() error { return errors.New("WRONGPASS") })
+
+	entries := rec.Entries()
+	if len(entries) != 3 {
+		t.Fatalf("Expected 3 records, got %v", entries)
+	}
+	if k, _ := entries[0].Attr("key"); k != "session:*" {
+		t.Errorf("Expected the key pattern session:*, got %v", k)
+	}
+	if entries[1].Level != logger.LevelDebug || entries[1].Message != "Cache get" {
+		t.Errorf("Expected a miss to be logged as a success, got %v", entries[1])
+	}
+	if entries[2].Level != logger.LevelError {
+		t.Errorf("Expected a failed command at Error, got %v", entries[2])
+	}
+	if _, ok := entries[2].Attr("key"); ok {
+		t.Errorf("Expected no key for auth, got %v", entries[2])
+	}
+	for _, e := range entries {
+		for _, v := range e.Attrs {
+			if s, ok := v.(string); ok && (strings.Contains(s, "secret") || strings.Contains(s, "hunter2")) {
+				t.Errorf("Expected no values in the log, got %v", e)
+			}
+		}
+	}
+}
+
+func TestProcessPipeline(t *testing.T) {
+	rec := setup(t)
+	l := New(WithKeyMode(KeyRaw))
+
+	cmds := []fakeCmd{{"mget", "a", "b"}, {"mset", "c", "1", "d", "2"}, {"eval", "return 1", 1, "e", "argv"}}
+	_ = ProcessPipeline(l, context.Background(), cmds, func() error { return nil })
+
+	e, ok := rec.LastEntry()
+	if !ok || e.Message != "Cache pipeline" {
+		t.Fatalf("Expected a pipeline record, got %v", rec.Entries())
+	}
+	if keys, _ := e.Attr("keys"); !slices.Equal(keys.([]any), []any{"a", "b", "c", "d", "e"}) {
+		t.Errorf("Unexpected keys %v", keys)
+	}
+	if n, _ := e.Attr("commands"); n != int64(3) {
+		t.Errorf("Expected 3 commands, got %v", n)
+	}
+}
+
+func TestGetAndDo(t *testing.T) {
+	rec := setup(t)
+	l := New(WithKeyMode(KeyHash), WithSlowThreshold(time.Millisecond))
+	ctx := context.Background()
+
+	hit, _ := l.Get(ctx, "user:1", func() (bool, error) { return true, nil })
+	_ = l.Do(ctx, "set", "user:1", func() error {
+		time.Sleep(2 * time.Millisecond)
+		return nil
+	})
+
+	entries := rec.Entries()
+	if !hit || len(entries) != 2 {
+		t.Fatalf("Expected 2 records and a hit, got %v", entries)
+	}
+	if h, _ := entries[0].Attr("hit"); h != true {
+		t.Errorf("Expected hit=true, got %v", entries[0])
+	}
+	k0, _ := entries[0].Attr("key")
+	k1, _ := entries[1].Attr("key")
+	if k0 != k1 || k0 == "user:1" || len(k0.(string)) != 16 {
+		t.Errorf("Expected equal 16-char hashes, got %v and %v", k0, k1)
+	}
+	if entries[1].Level != logger.LevelWarn || entries[1].Message != "Slow cache set" {
+		t.Errorf("Expected a slow set warning, got %v", entries[1])
+	}
+}
+
+func TestTransport(t *testing.T) {
+	rec := setup(t)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/assets/app.css" {
+			w.Header().Set("CF-Cache-Status", "HIT")
+		} else {
+			w.Header().Set("Cache-Status", "Origin; hit, CDN; fwd=miss")
+		}
+	}))
+	defer srv.Close()
+
+	client := &http.Client{Transport: Transport(nil)}
+	for _, path := range []string{"/assets/app.css", "/users/42?token=abc"} {
+		resp, err := client.Get(srv.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_ = resp.Body.Close()
+	}
+
+	entries := rec.Entries()
+	if len(entries) != 2 {
+		t.Fatalf("Expected 2 records, got %v", entries)
+	}
+	if h, _ := entries[0].Attr("hit"); h != true {
+		t.Errorf("Expected a CDN hit, got %v", entries[0])
+	}
+	if h, _ := entries[1].Attr("hit"); h != false {
+		t.Errorf("Expected the closest cache's miss, got %v", entries[1])
+	}
+	if k, _ := entries[1].Attr("key"); k != "/users/*" {
+		t.Errorf("Expected the path pattern without the query, got %v", k)
+	}
+}
+
+func TestKeyPatternOf(t *testing.T) {
+	tests := map[string]string{
+		"user:4711:profile":          "user:*:profile",
+		"rate-limit|bob@example.com": "rate-limit|*",
+		"feature_flags":              "feature_flags",
+		"/v1/items/abc123":           "/v1/items/*",
+	}
+	for key, want := range tests {
+		if got := KeyPatternOf(key); got != want {
+			t.Errorf("KeyPatternOf(%q) = %q, want %q", key, got, want)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package cachelog
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Transport returns an http.RoundTripper that logs each request through
+// next (http.DefaultTransport if nil) with the cache status reported by the
+// response: Cache-Status (RFC 9211), X-Cache, X-Cache-Status,
+// CF-Cache-Status or, failing those, Age. The key is the URL path; the
+// query string is never logged.
+func Transport(next http.RoundTripper, opts ...Option) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &transport{next: next, log: New(opts...)}
+}
+
+// transport logs the round trips of next
+type transport struct {
+	next http.RoundTripper
+	log  *CacheLogger
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := t.next.RoundTrip(req)
+	o := op{name: "http", keys: []string{req.URL.Path}, duration: time.Since(start), err: err}
+	o.attrs = []any{"method", req.Method, "host", req.URL.Host}
+	if resp != nil {
+		o.attrs = append(o.attrs, "status", resp.StatusCode)
+		if status, hit, ok := cacheStatus(resp.Header); ok {
+			o.attrs = append(o.attrs, "cache_status", status)
+			o.hit = &hit
+		}
+	}
+	t.log.log(req.Context(), o)
+	return resp, err
+}
+
+// cacheStatus returns the cache status reported by a response and whether
+// it is a hit; ok is false when the response carries none
+func cacheStatus(h http.Header) (status string, hit bool, ok bool) {
+	if v := h.Get("Cache-Status"); v != "" {
+		// The last cache is closest to the client, e.g. "Origin; fwd=miss, CDN; hit"
+		entries := strings.Split(v, ",")
+		last := strings.ToLower(entries[len(entries)-1])
+		for param := range strings.SplitSeq(last, ";") {
+			if strings.TrimSpace(param) == "hit" {
+				return "HIT", true, true
+			}
+		}
+		return "MISS", false, true
+	}
+	for _, name := range []string{"CF-Cache-Status", "X-Cache-Status", "X-Cache"} {
+		if v := h.Get(name); v != "" {
+			status, _, _ := strings.Cut(strings.TrimSpace(v), " ")
+			status = strings.ToUpper(status)
+			return status, status == "HIT" || status == "STALE" || status == "REVALIDATED", true
+		}
+	}
+	if v := h.Get("Age"); v != "" {
+		if age, err := strconv.Atoi(v); err == nil {
+			if age > 0 {
+				return "HIT", true, true
+			}
+			return "MISS", false, true
+		}
+	}
+	return "", false, false
+}
@@ -0,0 +1,88 @@
+package cachelog
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Command is a Redis command. go-redis's Cmder satisfies it.
+type Command interface {
+	// Name is the lowercase command name, e.g. "get"
+	Name() string
+	// Args are the command name followed by its arguments
+	Args() []any
+}
+
+// Process runs a Redis command and logs its name, keys, latency and error.
+// Only keys are taken from the arguments; values, scripts and passwords
+// never reach the log.
+func (l *CacheLogger) Process(ctx context.Context, cmd Command, fn func() error) error {
+	start := time.Now()
+	err := fn()
+	l.log(ctx, op{name: cmd.Name(), keys: commandKeys(cmd.Args()), duration: time.Since(start), err: err})
+	return err
+}
+
+// ProcessPipeline runs a Redis pipeline and logs it as one record with the
+// command count and the keys of all commands. It is generic so a
+// []redis.Cmder can be passed as is.
+func ProcessPipeline[C Command](l *CacheLogger, ctx context.Context, cmds []C, fn func() error) error {
+	start := time.Now()
+	err := fn()
+	var keys []string
+	for _, cmd := range cmds {
+		keys = append(keys, commandKeys(cmd.Args())...)
+	}
+	l.log(ctx, op{name: "pipeline", keys: keys, count: len(cmds), duration: time.Since(start), err: err})
+	return err
+}
+
+// keylessCommands take no key; their arguments are never logged
+var keylessCommands = map[string]bool{
+	"auth": true, "hello": true, "ping": true, "echo": true, "select": true,
+	"info": true, "config": true, "client": true, "cluster": true, "command": true,
+	"dbsize": true, "flushdb": true, "flushall": true, "time": true, "multi": true,
+	"exec": true, "discard": true, "unwatch": true, "script": true, "function": true,
+	"scan": true, "keys": true, "randomkey": true, "quit": true, "save": true,
+	"bgsave": true, "subscribe": true, "psubscribe": true, "unsubscribe": true,
+	"punsubscribe": true, "wait": true, "debug": true, "memory": true, "acl": true,
+}
+
+// commandKeys returns the keys among the arguments of a Redis command
+func commandKeys(args []any) []string {
+	if len(args) < 2 {
+		return nil
+	}
+	name := strings.ToLower(fmt.Sprint(args[0]))
+	switch name {
+	case "del", "unlink", "exists", "touch", "mget", "watch", "sinter", "sunion", "sdiff", "pfcount":
+		return argStrings(args[1:], 1)
+	case "mset", "msetnx":
+		return argStrings(args[1:], 2)
+	case "eval", "evalsha", "eval_ro", "evalsha_ro", "fcall", "fcall_ro":
+		if len(args) < 3 {
+			return nil
+		}
+		n, err := strconv.Atoi(fmt.Sprint(args[2]))
+		if err != nil || n <= 0 {
+			return nil
+		}
+		return argStrings(args[3:min(3+n, len(args))], 1)
+	}
+	if keylessCommands[name] {
+		return nil
+	}
+	return argStrings(args[1:2], 1)
+}
+
+// argStrings returns every step-th argument as a string
+func argStrings(args []any, step int) []string {
+	out := make([]string, 0, (len(args)+step-1)/step)
+	for i := 0; i < len(args); i += step {
+		out = append(out, fmt.Sprint(args[i]))
+	}
+	return out
+}
@@ -0,0 +1,86 @@
+package dblog
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jozefvalachovic/logger/v4"
+)
+
+// pgx tracelog levels, from github.com/jackc/pgx/v5/tracelog
+const (
+	pgxLevelTrace = 6
+	pgxLevelDebug = 5
+	pgxLevelInfo  = 4
+	pgxLevelWarn  = 3
+	pgxLevelError = 2
+)
+
+// LogPgx is a pgx v5 tracelog.LoggerFunc body; pass int(level). Statements
+// ("sql" in data) are logged like database/sql queries, other events at
+// their pgx level. See the package example.
+func (l *QueryLogger) LogPgx(ctx context.Context, level int, msg string, data map[string]any) {
+	if sqlText, ok := data["sql"].(string); ok {
+		q := query{op: strings.ToLower(msg), sql: sqlText, rows: -1}
+		q.args, _ = data["args"].([]any)
+		q.duration, _ = data["time"].(time.Duration)
+		q.err, _ = data["err"].(error)
+		if tag, ok := data["commandTag"].(interface{ RowsAffected() int64 }); ok {
+			q.rows = tag.RowsAffected()
+		}
+		l.log(ctx, q)
+		return
+	}
+
+	kv := make([]any, 0, 2*len(data))
+	for _, k := range slices.Sorted(maps.Keys(data)) {
+		kv = append(kv, k, data[k])
+	}
+	l.logger(ctx).Log(pgxLevel(level), msg, kv...)
+}
+
+// pgxLevel maps a pgx tracelog level onto a LogLevel
+func pgxLevel(level int) logger.LogLevel {
+	switch {
+	case level >= pgxLevelTrace:
+		return logger.Trace
+	case level == pgxLevelDebug:
+		return logger.Debug
+	case level == pgxLevelInfo:
+		return logger.Info
+	case level == pgxLevelWarn:
+		return logger.Warn
+	case level == pgxLevelError:
+		return logger.Error
+	default:
+		return logger.Info
+	}
+}
+
+// Trace implements the GORM logger.Interface method that logs each
+// statement. GORM inlines the arguments, so sensitive values are masked in
+// the SQL text itself.
+func (l *QueryLogger) Trace(ctx context.Context, begin time.Time, fc func() (sql string, rowsAffected int64), err error) {
+	sqlText, rows := fc()
+	op, _, _ := strings.Cut(strings.TrimSpace(sqlText), " ")
+	l.log(ctx, query{op: strings.ToLower(op), sql: sqlText, rows: rows, duration: time.Since(begin), err: err})
+}
+
+// Info implements the GORM logger.Interface method of the same name
+func (l *QueryLogger) Info(ctx context.Context, msg string, data ...any) {
+	l.logger(ctx).LogInfo(fmt.Sprintf(msg, data...))
+}
+
+// Warn implements the GORM logger.Interface method of the same name
+func (l *QueryLogger) Warn(ctx context.Context, msg string, data ...any) {
+	l.logger(ctx).LogWarn(fmt.Sprintf(msg, data...))
+}
+
+// Error implements the GORM logger.Interface method of the same name
+func (l *QueryLogger) Error(ctx context.Context, msg string, data ...any) {
+	l.logger(ctx).LogError(fmt.Sprintf(msg, data...))
+}
@@ -0,0 +1,259 @@
+// Package dblog logs database queries through the logger: the statement,
+// its arguments (with sensitive columns masked), rows affected and
+// duration. Queries slower than a threshold are escalated to Warn and
+// failed ones are logged at Error.
+//
+// It has no dependencies. database/sql drivers are wrapped directly; pgx
+// and GORM are adapted with a few lines of glue, since their logger
+// interfaces use their own level types:
+//
+//	// database/sql
+//	db, err := dblog.Open("postgres", dsn, dblog.WithSlowThreshold(100*time.Millisecond))
+//
+//	// pgx v5
+//	ql := dblog.New()
+//	cfg.Tracer = &tracelog.TraceLog{
+//		Logger: tracelog.LoggerFunc(func(ctx context.Context, level tracelog.LogLevel, msg string, data map[string]any) {
+//			ql.LogPgx(ctx, int(level), msg, data)
+//		}),
+//		LogLevel: tracelog.LogLevelInfo,
+//	}
+//
+//	// GORM
+//	type gormLogger struct{ *dblog.QueryLogger }
+//	func (l gormLogger) LogMode(gormlogger.LogLevel) gormlogger.Interface { return l }
+//	db, err := gorm.Open(dialector, &gorm.Config{
+//		Logger: gormLogger{dblog.New(dblog.WithIgnoreErrors(gorm.ErrRecordNotFound))},
+//	})
+package dblog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jozefvalachovic/logger/v4"
+)
+
+// Options configures query logging
+type Options struct {
+	// Logger receives the records (default: logger.FromContext of each
+	// query's context, so request fields and levels carry over)
+	Logger logger.Logger
+	// Level of successful queries (default: Debug)
+	Level logger.LogLevel
+	// SlowThreshold escalates slower queries to Warn (default: 200ms, 0 disables)
+	SlowThreshold time.Duration
+	// LogArgs logs the query arguments (default: true)
+	LogArgs bool
+	// RedactColumns masks the values bound to these columns, in addition to
+	// logger.Config.RedactKeys (case-insensitive)
+	RedactColumns []string
+	// MaxQueryLength truncates longer statements (default: 2048, 0 disables)
+	MaxQueryLength int
+	// IgnoreErrors are not treated as failures, e.g. sql.ErrNoRows or
+	// gorm.ErrRecordNotFound (matched with errors.Is)
+	IgnoreErrors []error
+}
+
+// Option is a functional option for configuring query logging
+type Option func(*Options)
+
+// DefaultOptions returns the default options
+func DefaultOptions() *Options {
+	return &Options{
+		Level:          logger.Debug,
+		SlowThreshold:  200 * time.Millisecond,
+		LogArgs:        true,
+		MaxQueryLength: 2048,
+	}
+}
+
+// WithLogger sends records to l instead of the context's logger
+func WithLogger(l logger.Logger) Option {
+	return func(o *Options) {
+		o.Logger = l
+	}
+}
+
+// WithLevel sets the level of successful queries
+func WithLevel(level logger.LogLevel) Option {
+	return func(o *Options) {
+		o.Level = level
+	}
+}
+
+// WithSlowThreshold escalates queries slower than d to Warn (0 disables)
+func WithSlowThreshold(d time.Duration) Option {
+	return func(o *Options) {
+		o.SlowThreshold = d
+	}
+}
+
+// WithArgs enables or disables logging query arguments
+func WithArgs(enabled bool) Option {
+	return func(o *Options) {
+		o.LogArgs = enabled
+	}
+}
+
+// WithRedactColumns masks the values bound to columns
+func WithRedactColumns(columns ...string) Option {
+	return func(o *Options) {
+		o.RedactColumns = append(o.RedactColumns, columns...)
+	}
+}
+
+// WithMaxQueryLength truncates statements longer than n bytes (0 disables)
+func WithMaxQueryLength(n int) Option {
+	return func(o *Options) {
+		o.MaxQueryLength = n
+	}
+}
+
+// WithIgnoreErrors treats errs as successes
+func WithIgnoreErrors(errs ...error) Option {
+	return func(o *Options) {
+		o.IgnoreErrors = append(o.IgnoreErrors, errs...)
+	}
+}
+
+// QueryLogger writes query records. It backs the database/sql wrapper and
+// the pgx and GORM adapters.
+type QueryLogger struct {
+	opts Options
+}
+
+// New returns a QueryLogger with opts applied to the defaults
+func New(opts ...Option) *QueryLogger {
+	options := DefaultOptions()
+	for _, opt := range opts {
+		opt(options)
+	}
+	return &QueryLogger{opts: *options}
+}
+
+// query is one executed statement
+type query struct {
+	op       string // exec, query, prepare, ...
+	sql      string
+	args     []any
+	names    []string // Named argument names, parallel to args ("" for positional)
+	rows     int64    // Rows affected, -1 if unknown
+	duration time.Duration
+	err      error
+}
+
+// log writes q at the level its outcome calls for
+func (l *QueryLogger) log(ctx context.Context, q query) {
+	level := l.opts.Level
+	slow := l.opts.SlowThreshold > 0 && q.duration >= l.opts.SlowThreshold
+	failed := q.err != nil && !l.ignored(q.err)
+	switch {
+	case failed:
+		level = logger.Error
+	case slow:
+		level = max(level, logger.Warn)
+	}
+
+	cfg := logger.GetConfig()
+	// Statements with inlined values (GORM) are masked like arguments
+	stmt := redactSQL(q.sql, func(column string) bool { return l.sensitive(column, cfg) }, cfg.RedactMask)
+	if l.opts.MaxQueryLength > 0 && len(stmt) > l.opts.MaxQueryLength {
+		stmt = stmt[:l.opts.MaxQueryLength] + "..."
+	}
+	kv := []any{"db_operation", q.op, "query", stmt}
+	if l.opts.LogArgs && len(q.args) > 0 {
+		kv = append(kv, "args", l.redactArgs(q, cfg))
+	}
+	if q.rows >= 0 {
+		kv = append(kv, "rows_affected", q.rows)
+	}
+	kv = append(kv, "duration", q.duration)
+	if slow {
+		kv = append(kv, "slow", true)
+	}
+	if failed {
+		kv = append(kv, "__error", q.err)
+	}
+
+	msg := "SQL " + q.op
+	if slow {
+		msg = "Slow SQL " + q.op
+	}
+	l.logger(ctx).Log(level, msg, kv...)
+}
+
+// logger returns the configured logger or the context's
+func (l *QueryLogger) logger(ctx context.Context) logger.Logger {
+	if l.opts.Logger != nil {
+		return l.opts.Logger
+	}
+	return logger.FromContext(ctx)
+}
+
+// ignored reports whether err is one of IgnoreErrors
+func (l *QueryLogger) ignored(err error) bool {
+	for _, target := range l.opts.IgnoreErrors {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// sensitive reports whether values of column must be masked
+func (l *QueryLogger) sensitive(column string, cfg logger.Config) bool {
+	for _, keys := range [][]string{l.opts.RedactColumns, cfg.RedactKeys} {
+		for _, k := range keys {
+			if strings.EqualFold(k, column) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// redactArgs returns the arguments of q with those bound to sensitive
+// columns, or named after one, replaced by the redact mask
+func (l *QueryLogger) redactArgs(q query, cfg logger.Config) []any {
+	params := make(map[string]string) // Placeholder ordinal or name -> column
+	for _, t := range scanSQL(q.sql) {
+		if t.kind != tokParam || t.column == "" {
+			continue
+		}
+		if t.name != "" {
+			params[t.name] = t.column
+		} else {
+			params[fmt.Sprint(t.ordinal)] = t.column
+		}
+	}
+
+	args := make([]any, len(q.args))
+	for i, v := range q.args {
+		name := ""
+		if i < len(q.names) {
+			name = q.names[i]
+		}
+		column := params[fmt.Sprint(i+1)]
+		if name != "" {
+			column = params[name]
+		}
+		if l.sensitive(column, cfg) || name != "" && l.sensitive(name, cfg) {
+			args[i] = cfg.RedactMask
+			continue
+		}
+		args[i] = argValue(v)
+	}
+	return args
+}
+
+// argValue keeps binary arguments out of the log
+func argValue(v any) any {
+	if b, ok := v.([]byte); ok {
+		return fmt.Sprintf("<%d bytes>", len(b))
+	}
+	return v
+}
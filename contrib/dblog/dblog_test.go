@@ -0,0 +1,242 @@
+package dblog
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/jozefvalachovic/logger/v4"
+	"github.com/jozefvalachovic/logger/v4/logtest"
+)
+
+// fakeDriver accepts any statement; queries return no rows and "FAIL"
+// statements fail. legacy connections only support Prepare.
+type fakeDriver struct {
+	legacy bool
+	delay  time.Duration
+}
+
+func (d *fakeDriver) Open(string) (driver.Conn, error) {
+	if d.legacy {
+		return &legacyConn{d: d}, nil
+	}
+	return &fakeConn{legacyConn{d: d}}, nil
+}
+
+type legacyConn struct{ d *fakeDriver }
+
+func (c *legacyConn) Prepare(query string) (driver.Stmt, error) {
+	return &fakeStmt{query: query, d: c.d}, nil
+}
+func (c *legacyConn) Close() error              { return nil }
+func (c *legacyConn) Begin() (driver.Tx, error) { return fakeTx{}, nil }
+
+type fakeConn struct{ legacyConn }
+
+func (c *fakeConn) ExecContext(_ context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	time.Sleep(c.d.delay)
+	if query == "FAIL" {
+		return nil, errors.New("syntax error")
+	}
+	return driver.RowsAffected(2), nil
+}
+
+func (c *fakeConn) QueryContext(_ context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	return &fakeRows{}, nil
+}
+
+type fakeStmt struct {
+	query string
+	d     *fakeDriver
+}
+
+func (s *fakeStmt) Close() error  { return nil }
+func (s *fakeStmt) NumInput() int { return -1 }
+func (s *fakeStmt) Exec([]driver.Value) (driver.Result, error) {
+	return driver.RowsAffected(1), nil
+}
+func (s *fakeStmt) Query([]driver.Value) (driver.Rows, error) { return &fakeRows{}, nil }
+
+type fakeRows struct{}
+
+func (*fakeRows) Columns() []string         { return []string{"id"} }
+func (*fakeRows) Close() error              { return nil }
+func (*fakeRows) Next([]driver.Value) error { return io.EOF }
+
+func init() {
+	sql.Register("dblog-legacy", &fakeDriver{legacy: true})
+}
+
+type fakeTx struct{}
+
+func (fakeTx) Commit() error   { return nil }
+func (fakeTx) Rollback() error { return nil }
+
+func setup(t *testing.T) *logtest.Recorder {
+	t.Helper()
+	logger.SetConfig(logger.Config{Output: io.Discard, Level: logger.LevelTrace})
+	return logtest.Capture(t)
+}
+
+func TestExecAndQuery(t *testing.T) {
+	rec := setup(t)
+	db := sql.OpenDB(WrapConnector(&dsnConnector{driver: &fakeDriver{}}, WithRedactColumns("ssn")))
+	defer func() { _ = db.Close() }()
+
+	_, err := db.Exec("INSERT INTO users (email, password, ssn) VALUES ($1, $2, $3)", "a@b.c", "hunter2", "123-45-6789")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows, err := db.Query("SELECT id FROM users WHERE email = ?", "a@b.c")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = rows.Close()
+
+	entries := rec.Entries()
+	if len(entries) != 2 {
+		t.Fatalf("Expected 2 records, got %v", entries)
+	}
+	exec := entries[0]
+	if exec.Message != "SQL exec" || exec.Level != logger.LevelDebug {
+		t.Errorf("Unexpected exec record %v", exec)
+	}
+	args, _ := exec.Attr("args")
+	if want := []any{"a@b.c", "***", "***"}; !slices.Equal(args.([]any), want) {
+		t.Errorf("Expected password and ssn to be masked, got %v", args)
+	}
+	if n, _ := exec.Attr("rows_affected"); n != int64(2) {
+		t.Errorf("Expected rows_affected 2, got %v", n)
+	}
+	if op, _ := entries[1].Attr("db_operation"); op != "query" {
+		t.Errorf("Expected a query record, got %v", entries[1])
+	}
+}
+
+func TestSlowAndFailedQueries(t *testing.T) {
+	rec := setup(t)
+	db := sql.OpenDB(WrapConnector(&dsnConnector{driver: &fakeDriver{delay: 5 * time.Millisecond}}, WithSlowThreshold(time.Millisecond)))
+	defer func() { _ = db.Close() }()
+
+	_, _ = db.Exec("UPDATE jobs SET done = true")
+	if _, err := db.Exec("FAIL"); err == nil {
+		t.Fatal("Expected the driver error")
+	}
+
+	entries := rec.Entries()
+	if len(entries) != 2 {
+		t.Fatalf("Expected 2 records, got %v", entries)
+	}
+	if entries[0].Level != logger.LevelWarn || entries[0].Message != "Slow SQL exec" {
+		t.Errorf("Expected a slow query warning, got %v", entries[0])
+	}
+	if entries[1].Level != logger.LevelError {
+		t.Errorf("Expected a failed query at Error, got %v", entries[1])
+	}
+}
+
+func TestPreparedStatements(t *testing.T) {
+	rec := setup(t)
+	db, err := Open("dblog-legacy", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = db.Close() }()
+
+	if _, err := db.Exec("UPDATE users SET token = ? WHERE id = ?", "abc", 7); err != nil {
+		t.Fatal(err)
+	}
+	e, ok := rec.LastEntry()
+	if !ok || e.Message != "SQL exec" {
+		t.Fatalf("Expected the prepared statement to be logged, got %v", rec.Entries())
+	}
+	if args, _ := e.Attr("args"); !slices.Equal(args.([]any), []any{"***", int64(7)}) {
+		t.Errorf("Expected token to be masked, got %v", args)
+	}
+}
+
+func TestGormTrace(t *testing.T) {
+	rec := setup(t)
+	l := New(WithIgnoreErrors(sql.ErrNoRows))
+
+	l.Trace(context.Background(), time.Now(), func() (string, int64) {
+		return `UPDATE "users" SET "password"='it''s secret',"name"='bob' WHERE "id" = 3`, 1
+	}, nil)
+	l.Trace(context.Background(), time.Now(), func() (string, int64) {
+		return "SELECT * FROM users WHERE id = 9", 0
+	}, sql.ErrNoRows)
+
+	entries := rec.Entries()
+	if len(entries) != 2 {
+		t.Fatalf("Expected 2 records, got %v", entries)
+	}
+	want := `UPDATE "users" SET "password"='***',"name"='bob' WHERE "id" = 3`
+	if q, _ := entries[0].Attr("query"); q != want {
+		t.Errorf("Expected %s, got %v", want, q)
+	}
+	if entries[0].Message != "SQL update" {
+		t.Errorf("Unexpected message %q", entries[0].Message)
+	}
+	if entries[1].Level != logger.LevelDebug {
+		t.Errorf("Expected an ignored error to be logged as a success, got %v", entries[1])
+	}
+}
+
+type commandTag string
+
+func (commandTag) RowsAffected() int64 { return 4 }
+
+func TestLogPgx(t *testing.T) {
+	rec := setup(t)
+	l := New()
+
+	l.LogPgx(context.Background(), pgxLevelInfo, "Query", map[string]any{
+		"sql":        "DELETE FROM sessions WHERE user_id = $1 AND secret = $2",
+		"args":       []any{5, "s3"},
+		"time":       3 * time.Millisecond,
+		"commandTag": commandTag("DELETE 4"),
+	})
+	l.LogPgx(context.Background(), pgxLevelWarn, "Connect", map[string]any{"host": "db"})
+
+	entries := rec.Entries()
+	if len(entries) != 2 {
+		t.Fatalf("Expected 2 records, got %v", entries)
+	}
+	if args, _ := entries[0].Attr("args"); !slices.Equal(args.([]any), []any{int64(5), "***"}) {
+		t.Errorf("Expected secret to be masked, got %v", args)
+	}
+	if n, _ := entries[0].Attr("rows_affected"); n != int64(4) {
+		t.Errorf("Expected rows_affected from the command tag, got %v", n)
+	}
+	if entries[1].Level != logger.LevelWarn || entries[1].Message != "Connect" {
+		t.Errorf("Expected other events at their pgx level, got %v", entries[1])
+	}
+}
+
+func TestScanSQLColumns(t *testing.T) {
+	tests := []struct {
+		query string
+		want  []string // Columns of placeholders and literals, in order
+	}{
+		{"SELECT * FROM t WHERE a = ? AND t.b > $2 AND ? = c", []string{"a", "b", "c"}},
+		{"INSERT INTO t (a, b) VALUES (?, lower(?)), (?, ?)", []string{"a", "b", "a", "b"}},
+		{"UPDATE t SET a = :a, b = 'x' -- c = ?\nWHERE d LIKE @d", []string{"a", "b", "d"}},
+		{"SELECT ?::text, x FROM t LIMIT 10", []string{"", ""}},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, tok := range scanSQL(tt.query) {
+			if tok.kind == tokParam || tok.kind == tokLiteral {
+				got = append(got, tok.column)
+			}
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("scanSQL(%q) columns = %q, want %q", tt.query, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,283 @@
+package dblog
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"time"
+)
+
+// Open opens a database like sql.Open with every statement logged
+func Open(driverName, dsn string, opts ...Option) (*sql.DB, error) {
+	// sql.Open only looks the driver up; no connection is made
+	db, err := sql.Open(driverName, dsn)
+	if err != nil {
+		return nil, err
+	}
+	d := db.Driver()
+	_ = db.Close()
+
+	l := New(opts...)
+	if dc, ok := d.(driver.DriverContext); ok {
+		c, err := dc.OpenConnector(dsn)
+		if err != nil {
+			return nil, err
+		}
+		return sql.OpenDB(&connector{Connector: c, log: l}), nil
+	}
+	return sql.OpenDB(&dsnConnector{dsn: dsn, driver: &wrappedDriver{Driver: d, log: l}}), nil
+}
+
+// WrapDriver returns a driver that logs every statement run through d, for
+// sql.Register
+func WrapDriver(d driver.Driver, opts ...Option) driver.Driver {
+	return &wrappedDriver{Driver: d, log: New(opts...)}
+}
+
+// WrapConnector returns a connector that logs every statement run through
+// c, for sql.OpenDB
+func WrapConnector(c driver.Connector, opts ...Option) driver.Connector {
+	return &connector{Connector: c, log: New(opts...)}
+}
+
+// wrappedDriver wraps the connections of a driver
+type wrappedDriver struct {
+	driver.Driver
+	log *QueryLogger
+}
+
+func (d *wrappedDriver) Open(name string) (driver.Conn, error) {
+	c, err := d.Driver.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return &conn{Conn: c, log: d.log}, nil
+}
+
+func (d *wrappedDriver) OpenConnector(name string) (driver.Connector, error) {
+	if dc, ok := d.Driver.(driver.DriverContext); ok {
+		c, err := dc.OpenConnector(name)
+		if err != nil {
+			return nil, err
+		}
+		return &connector{Connector: c, log: d.log}, nil
+	}
+	return &dsnConnector{dsn: name, driver: d}, nil
+}
+
+// connector wraps the connections of a driver.Connector
+type connector struct {
+	driver.Connector
+	log *QueryLogger
+}
+
+func (c *connector) Connect(ctx context.Context) (driver.Conn, error) {
+	dc, err := c.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &conn{Conn: dc, log: c.log}, nil
+}
+
+func (c *connector) Driver() driver.Driver {
+	return &wrappedDriver{Driver: c.Connector.Driver(), log: c.log}
+}
+
+// Close closes the underlying connector if it needs closing
+func (c *connector) Close() error {
+	if closer, ok := c.Connector.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// dsnConnector is a Connector for drivers without DriverContext
+type dsnConnector struct {
+	dsn    string
+	driver driver.Driver
+}
+
+func (c *dsnConnector) Connect(context.Context) (driver.Conn, error) {
+	return c.driver.Open(c.dsn)
+}
+
+func (c *dsnConnector) Driver() driver.Driver {
+	return c.driver
+}
+
+// conn logs the statements run on a connection. Optional interfaces the
+// underlying connection lacks fall back the way database/sql would.
+type conn struct {
+	driver.Conn
+	log *QueryLogger
+}
+
+var (
+	_ driver.ExecerContext      = (*conn)(nil)
+	_ driver.QueryerContext     = (*conn)(nil)
+	_ driver.ConnPrepareContext = (*conn)(nil)
+	_ driver.ConnBeginTx        = (*conn)(nil)
+	_ driver.Pinger             = (*conn)(nil)
+	_ driver.SessionResetter    = (*conn)(nil)
+	_ driver.Validator          = (*conn)(nil)
+	_ driver.NamedValueChecker  = (*conn)(nil)
+)
+
+func (c *conn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	execer, ok := c.Conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip // database/sql prepares the statement instead
+	}
+	start := time.Now()
+	res, err := execer.ExecContext(ctx, query, args)
+	if !errors.Is(err, driver.ErrSkip) {
+		c.log.log(ctx, newQuery("exec", query, args, res, time.Since(start), err))
+	}
+	return res, err
+}
+
+func (c *conn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	queryer, ok := c.Conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	start := time.Now()
+	rows, err := queryer.QueryContext(ctx, query, args)
+	if !errors.Is(err, driver.ErrSkip) {
+		c.log.log(ctx, newQuery("query", query, args, nil, time.Since(start), err))
+	}
+	return rows, err
+}
+
+func (c *conn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	var s driver.Stmt
+	var err error
+	if p, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		s, err = p.PrepareContext(ctx, query)
+	} else {
+		s, err = c.Conn.Prepare(query)
+	}
+	if err != nil {
+		c.log.log(ctx, newQuery("prepare", query, nil, nil, 0, err))
+		return nil, err
+	}
+	return &stmt{Stmt: s, query: query, log: c.log}, nil
+}
+
+func (c *conn) Prepare(query string) (driver.Stmt, error) {
+	return c.PrepareContext(context.Background(), query)
+}
+
+func (c *conn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if b, ok := c.Conn.(driver.ConnBeginTx); ok {
+		return b.BeginTx(ctx, opts)
+	}
+	if opts.Isolation != 0 || opts.ReadOnly {
+		return nil, errors.New("dblog: driver does not support non-default transaction options")
+	}
+	return c.Conn.Begin() // Drivers without BeginTx
+}
+
+func (c *conn) Ping(ctx context.Context) error {
+	if p, ok := c.Conn.(driver.Pinger); ok {
+		return p.Ping(ctx)
+	}
+	return nil
+}
+
+func (c *conn) ResetSession(ctx context.Context) error {
+	if r, ok := c.Conn.(driver.SessionResetter); ok {
+		return r.ResetSession(ctx)
+	}
+	return nil
+}
+
+func (c *conn) IsValid() bool {
+	if v, ok := c.Conn.(driver.Validator); ok {
+		return v.IsValid()
+	}
+	return true
+}
+
+func (c *conn) CheckNamedValue(nv *driver.NamedValue) error {
+	if checker, ok := c.Conn.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip // database/sql applies its default conversion
+}
+
+// stmt logs the executions of a prepared statement
+type stmt struct {
+	driver.Stmt
+	query string
+	log   *QueryLogger
+}
+
+var (
+	_ driver.StmtExecContext   = (*stmt)(nil)
+	_ driver.StmtQueryContext  = (*stmt)(nil)
+	_ driver.NamedValueChecker = (*stmt)(nil)
+)
+
+func (s *stmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	start := time.Now()
+	var res driver.Result
+	var err error
+	if e, ok := s.Stmt.(driver.StmtExecContext); ok {
+		res, err = e.ExecContext(ctx, args)
+	} else {
+		res, err = s.Stmt.Exec(values(args)) // Drivers without ExecContext
+	}
+	s.log.log(ctx, newQuery("exec", s.query, args, res, time.Since(start), err))
+	return res, err
+}
+
+func (s *stmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	start := time.Now()
+	var rows driver.Rows
+	var err error
+	if q, ok := s.Stmt.(driver.StmtQueryContext); ok {
+		rows, err = q.QueryContext(ctx, args)
+	} else {
+		rows, err = s.Stmt.Query(values(args)) // Drivers without QueryContext
+	}
+	s.log.log(ctx, newQuery("query", s.query, args, nil, time.Since(start), err))
+	return rows, err
+}
+
+func (s *stmt) CheckNamedValue(nv *driver.NamedValue) error {
+	if checker, ok := s.Stmt.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip
+}
+
+// newQuery describes a statement run through database/sql
+func newQuery(op, sqlText string, args []driver.NamedValue, res driver.Result, d time.Duration, err error) query {
+	q := query{op: op, sql: sqlText, rows: -1, duration: d, err: err}
+	if len(args) > 0 {
+		q.args = make([]any, len(args))
+		q.names = make([]string, len(args))
+		for i, a := range args {
+			q.args[i] = a.Value
+			q.names[i] = a.Name
+		}
+	}
+	if res != nil && err == nil {
+		if n, err := res.RowsAffected(); err == nil {
+			q.rows = n
+		}
+	}
+	return q
+}
+
+// values drops the names of args for the pre-context driver interfaces
+func values(args []driver.NamedValue) []driver.Value {
+	v := make([]driver.Value, len(args))
+	for i, a := range args {
+		v[i] = a.Value
+	}
+	return v
+}
@@ -0,0 +1,242 @@
+package dblog
+
+import "strings"
+
+// tokenKind classifies the SQL tokens dblog cares about
+type tokenKind int
+
+const (
+	tokIdent   tokenKind = iota // Identifier or keyword, unquoted
+	tokParam                    // Placeholder: ?, $1, :name, @name
+	tokLiteral                  // String or number literal
+	tokOp                       // Comparison operator
+	tokPunct                    // Any other single character
+)
+
+// sqlToken is one token of a statement, with its byte range
+type sqlToken struct {
+	kind       tokenKind
+	text       string
+	start, end int
+	ordinal    int    // Placeholder position, 1-based (0 for named placeholders)
+	name       string // Named placeholder without its prefix
+	column     string // Column the value is compared with or inserted into
+}
+
+// scanSQL splits query into tokens, skipping whitespace and comments, and
+// resolves the column of every placeholder and literal. It is a lexer for
+// logging, not a parser: values it cannot attribute get no column.
+func scanSQL(query string) []sqlToken {
+	var tokens []sqlToken
+	seq := 0
+	for i := 0; i < len(query); {
+		c := query[i]
+		start := i
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+			continue
+		case c == '-' && strings.HasPrefix(query[i:], "--"):
+			if n := strings.IndexByte(query[i:], '\n'); n >= 0 {
+				i += n + 1
+			} else {
+				i = len(query)
+			}
+			continue
+		case c == '/' && strings.HasPrefix(query[i:], "/*"):
+			if n := strings.Index(query[i+2:], "*/"); n >= 0 {
+				i += n + 4
+			} else {
+				i = len(query)
+			}
+			continue
+		case c == '\'':
+			i = skipQuoted(query, i, '\'')
+			tokens = append(tokens, sqlToken{kind: tokLiteral, text: query[start:i], start: start, end: i})
+		case c == '"' || c == '`':
+			i = skipQuoted(query, i, c)
+			tokens = append(tokens, sqlToken{kind: tokIdent, text: strings.Trim(query[start:i], string(c)), start: start, end: i})
+		case c == '?':
+			i++
+			seq++
+			tokens = append(tokens, sqlToken{kind: tokParam, text: "?", start: start, end: i, ordinal: seq})
+		case c == '$' && i+1 < len(query) && isDigit(query[i+1]):
+			i++
+			n := 0
+			for i < len(query) && isDigit(query[i]) {
+				n = n*10 + int(query[i]-'0')
+				i++
+			}
+			tokens = append(tokens, sqlToken{kind: tokParam, text: query[start:i], start: start, end: i, ordinal: n})
+		case (c == ':' || c == '@') && i+1 < len(query) && isIdentStart(query[i+1]) && (i == 0 || query[i-1] != ':'):
+			i++
+			for i < len(query) && isIdentPart(query[i]) {
+				i++
+			}
+			tokens = append(tokens, sqlToken{kind: tokParam, text: query[start:i], start: start, end: i, name: query[start+1 : i]})
+		case isDigit(c):
+			for i < len(query) && (isDigit(query[i]) || query[i] == '.' || query[i] == 'e' || query[i] == 'E') {
+				i++
+			}
+			tokens = append(tokens, sqlToken{kind: tokLiteral, text: query[start:i], start: start, end: i})
+		case isIdentStart(c):
+			for i < len(query) && (isIdentPart(query[i]) || query[i] == '.') {
+				i++
+			}
+			tokens = append(tokens, sqlToken{kind: tokIdent, text: query[start:i], start: start, end: i})
+		case c == '=' || c == '<' || c == '>' || c == '!':
+			for i < len(query) && strings.IndexByte("=<>!", query[i]) >= 0 {
+				i++
+			}
+			tokens = append(tokens, sqlToken{kind: tokOp, text: query[start:i], start: start, end: i})
+		default:
+			i++
+			tokens = append(tokens, sqlToken{kind: tokPunct, text: query[start:i], start: start, end: i})
+		}
+	}
+	resolveColumns(tokens)
+	return tokens
+}
+
+// resolveColumns sets the column of values in INSERT ... VALUES tuples by
+// position and of other values by the comparison they appear in
+func resolveColumns(tokens []sqlToken) {
+	var columns []string
+	if len(tokens) > 0 && tokens[0].kind == tokIdent && (keyword(tokens[0], "INSERT") || keyword(tokens[0], "REPLACE")) {
+		columns = insertColumns(tokens)
+	}
+
+	inValues, depth, pos := false, 0, 0
+	for k := range tokens {
+		t := &tokens[k]
+		if columns != nil {
+			switch {
+			case t.kind == tokIdent && (keyword(*t, "VALUES") || keyword(*t, "VALUE")) && depth == 0:
+				inValues = true
+				continue
+			case inValues && t.text == "(":
+				if depth == 0 {
+					pos = 0
+				}
+				depth++
+				continue
+			case inValues && t.text == ")":
+				depth--
+				continue
+			case inValues && depth == 1 && t.text == ",":
+				pos++
+				continue
+			case inValues && depth == 0 && t.text != ",":
+				inValues = false
+			}
+		}
+		if t.kind != tokParam && t.kind != tokLiteral {
+			continue
+		}
+		if inValues && depth > 0 {
+			if pos < len(columns) {
+				t.column = columns[pos]
+			}
+			continue
+		}
+		t.column = comparedColumn(tokens, k)
+	}
+}
+
+// insertColumns returns the column list of an INSERT INTO t (a, b, ...)
+func insertColumns(tokens []sqlToken) []string {
+	var columns []string
+	open := false
+	for _, t := range tokens {
+		switch {
+		case t.kind == tokIdent && (keyword(t, "VALUES") || keyword(t, "VALUE") || keyword(t, "SELECT")):
+			return columns
+		case t.text == "(":
+			if open {
+				return nil // Not a plain column list
+			}
+			open = true
+		case t.text == ")":
+			if open {
+				return columns
+			}
+		case open && t.kind == tokIdent:
+			columns = append(columns, lastSegment(t.text))
+		}
+	}
+	return nil
+}
+
+// comparedColumn returns the column the value at k is compared with, as in
+// col = ?, col LIKE ? or ? = col
+func comparedColumn(tokens []sqlToken, k int) string {
+	if k >= 2 && isComparison(tokens[k-1]) && tokens[k-2].kind == tokIdent {
+		return lastSegment(tokens[k-2].text)
+	}
+	if k+2 < len(tokens) && isComparison(tokens[k+1]) && tokens[k+2].kind == tokIdent {
+		return lastSegment(tokens[k+2].text)
+	}
+	return ""
+}
+
+// isComparison reports whether t compares two values
+func isComparison(t sqlToken) bool {
+	return t.kind == tokOp || t.kind == tokIdent && (keyword(t, "LIKE") || keyword(t, "ILIKE"))
+}
+
+// redactSQL replaces the literals of sensitive columns in query with mask
+func redactSQL(query string, sensitive func(column string) bool, mask string) string {
+	var b strings.Builder
+	last := 0
+	for _, t := range scanSQL(query) {
+		if t.kind != tokLiteral || t.column == "" || !sensitive(t.column) {
+			continue
+		}
+		b.WriteString(query[last:t.start])
+		b.WriteString("'" + mask + "'")
+		last = t.end
+	}
+	if last == 0 {
+		return query
+	}
+	b.WriteString(query[last:])
+	return b.String()
+}
+
+// skipQuoted returns the index after the quoted token starting at i;
+// a doubled quote is an escaped one
+func skipQuoted(s string, i int, quote byte) int {
+	for i++; i < len(s); i++ {
+		if s[i] == quote {
+			if i+1 < len(s) && s[i+1] == quote {
+				i++
+				continue
+			}
+			return i + 1
+		}
+	}
+	return len(s)
+}
+
+func keyword(t sqlToken, kw string) bool {
+	return strings.EqualFold(t.text, kw)
+}
+
+func lastSegment(ident string) string {
+	if i := strings.LastIndexByte(ident, '.'); i >= 0 {
+		return strings.Trim(ident[i+1:], "\"`")
+	}
+	return ident
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+func isIdentPart(c byte) bool {
+	return isIdentStart(c) || isDigit(c) || c == '$'
+}
@@ -1,11 +1,14 @@
 package logger
 
 import (
+	"encoding"
 	"encoding/json"
 	"fmt"
 	"log/slog"
 	"reflect"
+	"slices"
 	"strings"
+	"time"
 )
 
 // Helper functions to convert different integer types to int64
@@ -56,96 +59,348 @@ func toFloat64(v any) float64 {
 	}
 }
 
-// BodyToKeyValues converts a body to key-value pairs for logInternal (exported for middleware)
-func BodyToKeyValues(key string, body []byte) []any {
-	return bodyToKeyValues(key, body)
+// LazyValue is a log value computed only when the record is actually written.
+// It also implements slog.LogValuer for use with other slog handlers.
+type LazyValue func() any
+
+// Lazy defers an expensive value until the record has passed the level,
+// sampling and deduplication checks:
+//
+//	logger.LogDebug("state", "snapshot", logger.Lazy(func() any { return dumpState() }))
+//
+// A plain func() any value is treated the same way.
+func Lazy(fn func() any) LazyValue {
+	return LazyValue(fn)
 }
 
-// bodyToKeyValues converts a body to key-value pairs for logInternal (internal)
-func bodyToKeyValues(key string, body []byte) []any {
-	var obj map[string]any
-	if err := json.Unmarshal(body, &obj); err == nil {
-		// Valid JSON: flatten to key-value pairs under "body." prefix
-		// to prevent collision with internal keys like __method, __status.
-		keyValues := make([]any, 0, len(obj)*2)
-		for k, v := range obj {
-			keyValues = append(keyValues, "body."+k, v)
-		}
-		return keyValues
+// LogValue evaluates the deferred value
+func (f LazyValue) LogValue() slog.Value {
+	return slog.AnyValue(f())
+}
+
+// resolveLazy evaluates LazyValue and func() any values, returning others unchanged
+func resolveLazy(v any) any {
+	switch fn := v.(type) {
+	case LazyValue:
+		return fn()
+	case func() any:
+		return fn()
+	default:
+		return v
 	}
-	// Not JSON: return as [key, string(body)]
-	return []any{key, string(body)}
 }
 
-// handleStruct converts struct to JSON-like representation
-func handleStruct(key string, value any) slog.Attr {
-	// Try JSON marshaling first (respects json tags)
-	if jsonData, err := json.Marshal(value); err == nil {
-		var result map[string]any
-		if json.Unmarshal(jsonData, &result) == nil {
-			return slog.Any(key, result)
+// resolveLazyValues evaluates every lazy value in keyValues in place,
+// except those whose redaction under cfg never looks at them, which the
+// synchronous path does not evaluate either
+func resolveLazyValues(keyValues []any, cfg *Config) []any {
+	for i := 0; i+1 < len(keyValues); i += pairLen(keyValues, i) {
+		if _, ok := keyValues[i].(slog.Attr); !ok && !redactIgnoresValue(attrKey(keyValues[i]), cfg) {
+			keyValues[i+1] = resolveLazy(keyValues[i+1])
 		}
 	}
+	return keyValues
+}
 
-	// Fallback to reflection
-	rv := reflect.ValueOf(value)
+// Limits for converting structs, maps, slices and arrays. Values beyond
+// them are replaced with a marker so a self-referential or huge value can
+// never hang the logger or blow the stack.
+const (
+	maxConvertDepth    = 10   // Nesting levels below the attribute
+	maxConvertElements = 1000 // Elements kept per slice, array or map
+)
 
-	fields := make(map[string]any)
-	for field, fieldValue := range rv.Fields() {
-		// Skip unexported fields
-		if !fieldValue.CanInterface() {
-			continue
+// Markers written in place of values the conversion limits cut off
+const (
+	cycleMarker     = "[cycle]"
+	maxDepthMarker  = "[max depth]"
+	truncatedMarker = "…" // Map key, or slice element prefix, for dropped elements
+)
+
+// convertComposite converts a struct, map, slice, array or pointer to
+// JSON-encodable maps, slices and scalars
+func convertComposite(v reflect.Value) any {
+	var c converter
+	return c.convert(v, 0)
+}
+
+// converter turns arbitrary values into JSON-encodable maps, slices and
+// scalars, enforcing the depth, cycle and element limits
+type converter struct {
+	visiting map[visitKey]struct{} // Pointers, maps and slices on the current path
+}
+
+// visitKey identifies a reference value for cycle detection; slices sharing
+// an array but differing in length are distinct values
+type visitKey struct {
+	ptr uintptr
+	typ reflect.Type
+	len int
+}
+
+var (
+	jsonMarshalerType = reflect.TypeFor[json.Marshaler]()
+	textMarshalerType = reflect.TypeFor[encoding.TextMarshaler]()
+	errorType         = reflect.TypeFor[error]()
+)
+
+// convert returns a JSON-encodable form of v at the given nesting depth
+func (c *converter) convert(v reflect.Value, depth int) any {
+	if !v.IsValid() {
+		return nil
+	}
+	if depth > maxConvertDepth {
+		return maxDepthMarker
+	}
+
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return nil
 		}
+	}
+	if out, ok := c.convertSelf(v); ok {
+		return out
+	}
 
-		// Use json tag if available, otherwise use field name
-		fieldName := field.Name
-		if jsonTag := field.Tag.Get("json"); jsonTag != "" && jsonTag != "-" {
-			if commaIdx := strings.Index(jsonTag, ","); commaIdx > 0 {
-				fieldName = jsonTag[:commaIdx]
-			} else {
-				fieldName = jsonTag
-			}
+	switch v.Kind() {
+	case reflect.Pointer:
+		if !c.enter(v, 0) {
+			return cycleMarker
+		}
+		defer c.leave(v, 0)
+		return c.convert(v.Elem(), depth)
+	case reflect.Interface:
+		return c.convert(v.Elem(), depth)
+	case reflect.Struct:
+		return c.convertStruct(v, depth)
+	case reflect.Map:
+		if v.IsNil() {
+			return nil
+		}
+		if !c.enter(v, 0) {
+			return cycleMarker
+		}
+		defer c.leave(v, 0)
+		return c.convertMap(v, depth)
+	case reflect.Slice:
+		if v.IsNil() {
+			return nil
 		}
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return v.Bytes() // Encoded as base64, like encoding/json
+		}
+		if !c.enter(v, v.Len()) {
+			return cycleMarker
+		}
+		defer c.leave(v, v.Len())
+		return c.convertList(v, depth)
+	case reflect.Array:
+		return c.convertList(v, depth)
+	case reflect.String:
+		return v.String()
+	case reflect.Bool:
+		return v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint()
+	case reflect.Float32:
+		return float32(v.Float())
+	case reflect.Float64:
+		return v.Float()
+	default:
+		// Funcs, channels, complex numbers and unsafe pointers have no JSON form
+		return v.Type().String()
+	}
+}
 
-		fields[fieldName] = fieldValue.Interface()
+// convertSelf uses the value's own json.Marshaler, encoding.TextMarshaler
+// or error representation when it has one
+func (c *converter) convertSelf(v reflect.Value) (any, bool) {
+	t := v.Type()
+	if !v.CanInterface() || !(t.Implements(jsonMarshalerType) || t.Implements(textMarshalerType) || t.Implements(errorType)) {
+		return nil, false
+	}
+	switch x := v.Interface().(type) {
+	case json.Marshaler:
+		if data, err := x.MarshalJSON(); err == nil && json.Valid(data) {
+			return json.RawMessage(data), true
+		}
+	case encoding.TextMarshaler:
+		if text, err := x.MarshalText(); err == nil {
+			return string(text), true
+		}
+	case error:
+		return x.Error(), true
 	}
+	return nil, false
+}
 
-	return slog.Any(key, fields)
+// convertStruct converts exported fields, honoring json tag names, "-",
+// omitempty and omitzero, and inlining embedded structs like encoding/json
+func (c *converter) convertStruct(v reflect.Value, depth int) map[string]any {
+	fields := make(map[string]any, v.NumField())
+	c.appendFields(fields, v, depth)
+	return fields
 }
 
-// handleSliceOrArray converts slices and arrays
-func handleSliceOrArray(key string, value any) slog.Attr {
-	rv := reflect.ValueOf(value)
+func (c *converter) appendFields(fields map[string]any, v reflect.Value, depth int) {
+	for field, fieldValue := range v.Fields() {
+		name, opts, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" && opts == "" {
+			continue
+		}
+		if field.Anonymous && name == "" {
+			inner := fieldValue
+			if inner.Kind() == reflect.Pointer {
+				if inner.IsNil() {
+					continue
+				}
+				inner = inner.Elem()
+			}
+			if inner.Kind() == reflect.Struct {
+				c.appendFields(fields, inner, depth)
+				continue
+			}
+		}
+		// Skip unexported fields
+		if !field.IsExported() {
+			continue
+		}
+		if (strings.Contains(opts, "omitempty") && isEmptyValue(fieldValue)) ||
+			(strings.Contains(opts, "omitzero") && fieldValue.IsZero()) {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		if _, exists := fields[name]; !exists {
+			fields[name] = c.convert(fieldValue, depth+1)
+		}
+	}
+}
 
-	// Empty slice/array
-	if rv.Len() == 0 {
-		return slog.Any(key, []any{})
+// convertMap converts a map, keeping at most maxConvertElements entries in
+// key order and counting the rest under the truncation marker
+func (c *converter) convertMap(v reflect.Value, depth int) map[string]any {
+	n := v.Len()
+	result := make(map[string]any, min(n, maxConvertElements+1))
+	if n <= maxConvertElements {
+		for mapKey, mapValue := range v.Seq2() {
+			result[mapKeyString(mapKey)] = c.convert(mapValue, depth+1)
+		}
+		return result
 	}
 
-	// Convert to slice of any
-	result := make([]any, rv.Len())
-	for i := 0; i < rv.Len(); i++ {
-		result[i] = rv.Index(i).Interface()
+	keys := make([]string, 0, n)
+	values := make(map[string]reflect.Value, n)
+	for mapKey, mapValue := range v.Seq2() {
+		k := mapKeyString(mapKey)
+		keys = append(keys, k)
+		values[k] = mapValue
+	}
+	slices.Sort(keys)
+	for _, k := range keys[:maxConvertElements] {
+		result[k] = c.convert(values[k], depth+1)
 	}
+	result[truncatedMarker] = fmt.Sprintf("%d more", n-maxConvertElements)
+	return result
+}
 
-	return slog.Any(key, result)
+// convertList converts a slice or array, keeping at most maxConvertElements
+// elements followed by a marker counting the rest
+func (c *converter) convertList(v reflect.Value, depth int) []any {
+	n := v.Len()
+	result := make([]any, 0, min(n, maxConvertElements+1))
+	for i := range min(n, maxConvertElements) {
+		result = append(result, c.convert(v.Index(i), depth+1))
+	}
+	if n > maxConvertElements {
+		result = append(result, fmt.Sprintf("%s%d more", truncatedMarker, n-maxConvertElements))
+	}
+	return result
 }
 
-// handleMap converts maps
-func handleMap(key string, value any) slog.Attr {
-	rv := reflect.ValueOf(value)
+// mapKeyString renders a map key: through encoding.TextMarshaler when the
+// type implements it (like encoding/json), pointers by the value they point
+// to and anything else with fmt.Sprint, so fmt.Stringer is honored too
+func mapKeyString(k reflect.Value) string {
+	if !k.CanInterface() {
+		if k.Kind() == reflect.String {
+			return k.String()
+		}
+		return k.Type().String()
+	}
+	switch k.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if k.IsNil() {
+			return "<nil>"
+		}
+	}
+	switch key := k.Interface().(type) {
+	case string:
+		return key
+	case encoding.TextMarshaler:
+		if text, err := key.MarshalText(); err == nil {
+			return string(text)
+		}
+	case fmt.Stringer:
+		return key.String()
+	}
+	switch k.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		return mapKeyString(k.Elem())
+	}
+	return fmt.Sprint(k.Interface())
+}
 
-	if rv.Len() == 0 {
-		return slog.Any(key, map[string]any{})
+// enter records a reference value on the current path and reports false if
+// it is already there, i.e. the value contains itself
+func (c *converter) enter(v reflect.Value, n int) bool {
+	k := visitKey{ptr: v.Pointer(), typ: v.Type(), len: n}
+	if c.visiting == nil {
+		c.visiting = make(map[visitKey]struct{})
 	}
+	if _, ok := c.visiting[k]; ok {
+		return false
+	}
+	c.visiting[k] = struct{}{}
+	return true
+}
+
+// leave removes a value recorded by enter
+func (c *converter) leave(v reflect.Value, n int) {
+	delete(c.visiting, visitKey{ptr: v.Pointer(), typ: v.Type(), len: n})
+}
 
-	result := make(map[string]any)
-	for mapKey, mapValue := range rv.Seq2() {
-		keyStr := fmt.Sprintf("%v", mapKey.Interface())
-		result[keyStr] = mapValue.Interface()
+// isEmptyValue reports whether v is empty in the encoding/json omitempty sense
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64, reflect.Interface, reflect.Pointer:
+		return v.IsZero()
 	}
+	return false
+}
+
+// rejectedMarker is written, with the type name, in place of a composite
+// value under Config.StrictTypes
+const rejectedMarker = "[rejected %s]"
 
-	return slog.Any(key, result)
+// strictAttr converts a composite value under Config.StrictTypes: a
+// json.Marshaler by its own encoding, anything else to rejectedMarker
+func strictAttr(key string, rv reflect.Value) slog.Attr {
+	if rv.Type().Implements(jsonMarshalerType) && !(rv.Kind() == reflect.Pointer && rv.IsNil()) {
+		var c converter
+		if out, ok := c.convertSelf(rv); ok {
+			return slog.Any(key, out)
+		}
+	}
+	return slog.String(key, fmt.Sprintf(rejectedMarker, rv.Type()))
 }
 
 // marshalAsJSON fallback to JSON marshaling
@@ -157,8 +412,9 @@ func marshalAsJSON(key string, value any) slog.Attr {
 	return slog.String(key, reflect.TypeOf(value).String())
 }
 
-// convertToSlogAttr converts any value to appropriate slog.Attr
-func convertToSlogAttr(key string, value any) slog.Attr {
+// convertToSlogAttr converts any value to appropriate slog.Attr. strict
+// applies Config.StrictTypes.
+func convertToSlogAttr(key string, value any, strict bool) slog.Attr {
 	switch v := value.(type) {
 	case string:
 		return slog.String(key, v)
@@ -170,31 +426,84 @@ func convertToSlogAttr(key string, value any) slog.Attr {
 		return slog.Float64(key, toFloat64(v))
 	case bool:
 		return slog.Bool(key, v)
+	case time.Duration:
+		return slog.Duration(key, v)
+	case time.Time:
+		return slog.Time(key, v)
+	case []byte:
+		return slog.Any(key, v) // Encoded per Config.BytesFormat
 	case nil:
 		return slog.String(key, "<nil>")
-	default:
-		// Handle complex types (structs, arrays, slices, maps)
-		return handleComplexType(key, v)
 	}
+
+	// Let types control their own representation before reflection
+	switch v := value.(type) {
+	case slog.LogValuer:
+		if isNilPointer(v) {
+			return slog.String(key, "<nil>")
+		}
+		resolved := slog.AnyValue(v).Resolve()
+		if resolved.Kind() == slog.KindAny {
+			if _, ok := resolved.Any().(slog.LogValuer); !ok {
+				return convertToSlogAttr(key, resolved.Any(), strict)
+			}
+		}
+		return slog.Attr{Key: key, Value: resolved}
+	case error:
+		if isNilPointer(v) {
+			return slog.String(key, "<nil>")
+		}
+		return slog.String(key, v.Error())
+	case encoding.TextMarshaler:
+		if isNilPointer(v) {
+			return slog.String(key, "<nil>")
+		}
+		if text, err := v.MarshalText(); err == nil {
+			return slog.String(key, string(text))
+		}
+	case fmt.Stringer:
+		if isNilPointer(v) {
+			return slog.String(key, "<nil>")
+		}
+		return slog.String(key, v.String())
+	}
+
+	// Handle complex types (structs, arrays, slices, maps)
+	return handleComplexType(key, value, strict)
+}
+
+// isNilPointer reports whether v holds a nil pointer, whose methods may panic
+func isNilPointer(v any) bool {
+	rv := reflect.ValueOf(v)
+	return rv.Kind() == reflect.Pointer && rv.IsNil()
 }
 
-// handleComplexType processes structs, arrays, slices, and maps
-func handleComplexType(key string, value any) slog.Attr {
+// handleComplexType processes structs, arrays, slices, and maps. In strict
+// mode only json.Marshaler implementations are converted; other composite
+// values are written as rejectedMarker.
+func handleComplexType(key string, value any, strict bool) slog.Attr {
 	rv := reflect.ValueOf(value)
 
 	switch rv.Kind() {
-	case reflect.Struct:
-		return handleStruct(key, value)
-	case reflect.Slice, reflect.Array:
-		return handleSliceOrArray(key, value)
-	case reflect.Map:
-		return handleMap(key, value)
+	case reflect.Struct, reflect.Slice, reflect.Array, reflect.Map:
+		if strict {
+			return strictAttr(key, rv)
+		}
+		return slog.Any(key, convertComposite(rv))
 	case reflect.Pointer:
 		if rv.IsNil() {
 			return slog.String(key, "<nil>")
 		}
+		switch rv.Elem().Kind() {
+		case reflect.Struct, reflect.Map, reflect.Slice, reflect.Array, reflect.Pointer:
+			if strict {
+				return strictAttr(key, rv)
+			}
+			// Keep the pointer so a value pointing back to itself is detected
+			return slog.Any(key, convertComposite(rv))
+		}
 		// Dereference pointer and process the underlying value
-		return convertToSlogAttr(key, rv.Elem().Interface())
+		return convertToSlogAttr(key, rv.Elem().Interface(), strict)
 	default:
 		// For any other type, try JSON marshaling
 		return marshalAsJSON(key, value)
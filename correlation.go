@@ -0,0 +1,51 @@
+package logger
+
+import (
+	"context"
+	"time"
+)
+
+// CorrelationIDKey is the attribute carrying the correlation ID of a context
+const CorrelationIDKey = "correlation_id"
+
+// correlationCtxKey is the private context key of the correlation ID
+type correlationCtxKey struct{}
+
+// WithCorrelation returns ctx carrying a correlation ID, which the
+// LogXxxWithContext functions add to their records as "correlation_id" and
+// LogAuditEvent to events without one. The ID ctx already carries is kept,
+// so calling it at every entry point propagates the ID through nested
+// calls; otherwise a new UUIDv7 is created:
+//
+//	func (w *Worker) handle(ctx context.Context, msg Message) {
+//		ctx = logger.WithCorrelation(ctx)
+//		logger.LogInfoWithContext(ctx, "processing", "msg_id", msg.ID) // correlation_id=0190...
+//		go w.notify(ctx, msg)                                         // Same ID
+//	}
+func WithCorrelation(ctx context.Context) context.Context {
+	if CorrelationID(ctx) != "" {
+		return ctx
+	}
+	return WithCorrelationID(ctx, newLogID(LogIDUUIDv7, time.Now()))
+}
+
+// WithCorrelationID returns ctx carrying id, e.g. one received in a message
+// header or from the connection's peer
+func WithCorrelationID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, correlationCtxKey{}, id)
+}
+
+// CorrelationID returns the correlation ID of ctx, "" if it has none
+func CorrelationID(ctx context.Context) string {
+	id, _ := ctx.Value(correlationCtxKey{}).(string)
+	return id
+}
+
+// correlate returns keyValues with the correlation ID of ctx, if any. The
+// caller's slice is never appended to in place.
+func correlate(ctx context.Context, keyValues []any) []any {
+	if id := CorrelationID(ctx); id != "" {
+		return append(keyValues[:len(keyValues):len(keyValues)], CorrelationIDKey, id)
+	}
+	return keyValues
+}
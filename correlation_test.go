@@ -0,0 +1,54 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/jozefvalachovic/logger/v4/audit"
+)
+
+func TestWithCorrelation(t *testing.T) {
+	defer SetConfig(Config{Output: io.Discard, Level: LevelTrace})
+
+	var buf bytes.Buffer
+	SetConfig(Config{Output: &buf, Level: LevelTrace, Format: FormatJSON, CompactJSON: true})
+
+	ctx := WithCorrelation(NewContext(context.Background(), With("worker", "mailer")))
+	id := CorrelationID(ctx)
+	if len(id) != 36 || CorrelationID(WithCorrelation(ctx)) != id {
+		t.Fatalf("expected a UUID kept by nested calls, got %q", id)
+	}
+
+	kv := make([]any, 2, 4)
+	kv[0], kv[1] = "to", "bob"
+	LogInfoWithContext(ctx, "deprecated", kv...)
+	LogWarnWithContext(ctx, "retrying", kv...)
+	FromContext(ctx).LogErrorWithContext(ctx, "failed")
+	DefaultLogger().LogDebugWithContext(ctx, "debug")
+	if err := LogAuditEvent(ctx, audit.AuditEvent{Type: audit.AuditDataModify, Action: "send", Outcome: audit.OutcomeSuccess}); err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 5 {
+		t.Fatalf("expected 5 records, got:\n%s", buf.String())
+	}
+	for _, line := range lines {
+		if !strings.Contains(line, `"correlation_id":"`+id+`"`) {
+			t.Errorf("expected the correlation ID in %s", line)
+		}
+	}
+	if kv[:4][2] != nil {
+		t.Error("expected the caller's slice left unchanged")
+	}
+
+	buf.Reset()
+	LogInfoWithContext(WithCorrelationID(context.Background(), "from-header"), "received")
+	LogInfoWithContext(context.Background(), "plain")
+	if out := buf.String(); !strings.Contains(out, `"correlation_id":"from-header"`) || strings.Count(out, "correlation_id") != 1 {
+		t.Errorf("expected the given ID only where set: %s", out)
+	}
+}
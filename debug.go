@@ -0,0 +1,258 @@
+package logger
+
+import (
+	"cmp"
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DebugInfo is a snapshot of the logger's internals, served by DebugHandler
+// and published by PublishExpvar
+type DebugInfo struct {
+	Version  string          `json:"version"`
+	Config   DebugConfig     `json:"config"`
+	Metrics  map[string]any  `json:"metrics,omitempty"`
+	Async    *DebugAsync     `json:"async,omitempty"`
+	Outputs  []DebugOutput   `json:"outputs"`
+	Audit    *DebugAuditInfo `json:"audit,omitempty"`
+	Captured time.Time       `json:"captured"`
+}
+
+// DebugConfig is the active configuration with writers, handlers and keys
+// reduced to descriptions, so it is safe to expose
+type DebugConfig struct {
+	Level             string            `json:"level"`
+	ModuleLevels      map[string]string `json:"module_levels,omitempty"`
+	TimeFormat        string            `json:"time_format"`
+	EnableColor       bool              `json:"enable_color"`
+	Color             string            `json:"color"`
+	EnableCaller      bool              `json:"enable_caller"`
+	Format            string            `json:"format"`
+	FormatVersion     string            `json:"format_version"`
+	CompactJSON       bool              `json:"compact_json"`
+	RedactKeys        []string          `json:"redact_keys,omitempty"`
+	RedactPatterns    int               `json:"redact_patterns"`
+	RedactQuery       []string          `json:"redact_query_params,omitempty"`
+	DetectPII         string            `json:"detect_pii,omitempty"`
+	StrictTypes       bool              `json:"strict_types"`
+	RedactRules       map[string]string `json:"redact_rules,omitempty"`
+	Redactor          bool              `json:"redactor"`
+	SampleRate        float64           `json:"sample_rate"`
+	SampleMode        string            `json:"sample_mode"`
+	SampleKey         string            `json:"sample_key,omitempty"`
+	SampleBudget      int               `json:"sample_budget,omitempty"`
+	SampleFirst       int               `json:"sample_first,omitempty"`
+	SampleThereafter  int               `json:"sample_thereafter,omitempty"`
+	SampleInterval    string            `json:"sample_interval,omitempty"`
+	EnableDedup       bool              `json:"enable_dedup"`
+	EnableMetrics     bool              `json:"enable_metrics"`
+	Filters           int               `json:"filters"`
+	FieldProcessors   int               `json:"field_processors"`
+	MaxAttrs          int               `json:"max_attrs"`
+	MaxValueBytes     int               `json:"max_value_bytes"`
+	AllowControlChars bool              `json:"allow_control_chars"`
+	TailSize          int               `json:"tail_size"`
+	Handler           string            `json:"handler,omitempty"`
+	Additional        []string          `json:"additional_handlers,omitempty"`
+	AsyncMode         bool              `json:"async_mode"`
+	AuditFormat       string            `json:"audit_format"`
+	AuditSigning      string            `json:"audit_signing,omitempty"`
+	EnterpriseAudit   bool              `json:"enterprise_audit"`
+}
+
+// DebugAsync describes the async queue
+type DebugAsync struct {
+	Running       bool   `json:"running"`
+	QueueDepth    int    `json:"queue_depth"`
+	QueueCapacity int    `json:"queue_capacity"`
+	Workers       int    `json:"workers"`
+	BatchSize     int    `json:"batch_size"`
+	Overflow      string `json:"overflow_policy"`
+	Dropped       int64  `json:"dropped"`
+	SpillBytes    int64  `json:"spill_bytes,omitempty"`
+}
+
+// DebugOutput describes a configured writer; rotation and network state
+// are filled in for RotatingWriter and NetWriter
+type DebugOutput struct {
+	Role     string          `json:"role"` // "output" or "audit"
+	Type     string          `json:"type"`
+	Rotation *DebugRotation  `json:"rotation,omitempty"`
+	Network  *NetWriterStats `json:"network,omitempty"`
+}
+
+// DebugRotation is the state of a RotatingWriter
+type DebugRotation struct {
+	Filename string    `json:"filename"`
+	Size     int64     `json:"size"`
+	MaxSize  int64     `json:"max_size"`
+	Opened   time.Time `json:"opened"`
+	Backups  int       `json:"backups"`
+}
+
+// DebugAuditInfo is the state of the enterprise audit logger
+type DebugAuditInfo struct {
+	Sequence   int64 `json:"sequence"`
+	BufferSize int   `json:"buffer_size"`
+	BufferUsed int   `json:"buffer_used"`
+	Closed     bool  `json:"closed"`
+}
+
+// DebugSnapshot returns a snapshot of the logger's internals
+func DebugSnapshot() DebugInfo {
+	cfg := *loadConfig()
+	info := DebugInfo{
+		Version:  Version,
+		Config:   debugConfig(cfg),
+		Captured: time.Now(),
+	}
+
+	if cfg.EnableMetrics {
+		info.Metrics = GetMetrics()
+	}
+
+	if cfg.AsyncMode {
+		asyncMu.RLock()
+		info.Async = &DebugAsync{
+			Running:       asyncRunning,
+			QueueDepth:    len(logChan),
+			QueueCapacity: cap(logChan),
+			Workers:       max(cfg.AsyncWorkers, 1),
+			BatchSize:     max(cfg.AsyncBatchSize, 1),
+			Overflow:      cfg.AsyncOverflowPolicy.String(),
+		}
+		if s := asyncSpill.Load(); s != nil {
+			info.Async.SpillBytes = s.size.Load()
+		}
+		asyncMu.RUnlock()
+		if m := currentMetrics(); m != nil {
+			info.Async.Dropped = m.droppedCount()
+		}
+	}
+
+	info.Outputs = append(info.Outputs, debugOutput("output", cfg.Output))
+	if cfg.AuditOutput != nil {
+		info.Outputs = append(info.Outputs, debugOutput("audit", cfg.AuditOutput))
+	}
+
+	if al := loadState().audit; al != nil {
+		stats := al.GetStats()
+		info.Audit = &DebugAuditInfo{
+			Sequence:   stats.Sequence,
+			BufferSize: stats.BufferSize,
+			BufferUsed: stats.BufferUsed,
+			Closed:     stats.Closed,
+		}
+	}
+	return info
+}
+
+// debugConfig describes cfg without exposing writers or key material
+func debugConfig(cfg Config) DebugConfig {
+	dc := DebugConfig{
+		Level:             strings.ToLower(LevelString(cfg.Level)),
+		TimeFormat:        cfg.TimeFormat,
+		EnableColor:       cfg.EnableColor,
+		Color:             cfg.Color.String(),
+		EnableCaller:      cfg.EnableCaller,
+		Format:            cfg.Format.String(),
+		FormatVersion:     cfg.FormatVersion.String(),
+		CompactJSON:       cfg.CompactJSON,
+		RedactKeys:        cfg.RedactKeys,
+		RedactPatterns:    len(cfg.RedactPatterns),
+		RedactQuery:       cfg.RedactQueryParams,
+		DetectPII:         cfg.DetectPII.String(),
+		StrictTypes:       cfg.StrictTypes,
+		Redactor:          cfg.Redactor != nil,
+		SampleRate:        cfg.SampleRate,
+		SampleMode:        cfg.SampleMode.String(),
+		SampleKey:         cfg.SampleKey,
+		SampleBudget:      cfg.SampleBudget,
+		SampleFirst:       cfg.SampleFirst,
+		SampleThereafter:  cfg.SampleThereafter,
+		EnableDedup:       cfg.EnableDedup,
+		EnableMetrics:     cfg.EnableMetrics,
+		Filters:           len(cfg.Filters),
+		FieldProcessors:   len(cfg.FieldProcessors),
+		MaxAttrs:          cfg.MaxAttrs,
+		MaxValueBytes:     cfg.MaxValueBytes,
+		AllowControlChars: cfg.AllowControlChars,
+		TailSize:          cfg.TailSize,
+		AsyncMode:         cfg.AsyncMode,
+		AuditFormat:       cfg.AuditFormat.String(),
+		EnterpriseAudit:   cfg.Audit != nil,
+	}
+	if len(cfg.ModuleLevels) > 0 {
+		dc.ModuleLevels = make(map[string]string, len(cfg.ModuleLevels))
+		for name, level := range cfg.ModuleLevels {
+			dc.ModuleLevels[name] = strings.ToLower(LevelString(level))
+		}
+	}
+	if len(cfg.RedactRules) > 0 {
+		dc.RedactRules = make(map[string]string, len(cfg.RedactRules))
+		for key, s := range cfg.RedactRules {
+			dc.RedactRules[key] = s.String()
+		}
+	}
+	if cfg.SampleFirst > 0 {
+		dc.SampleInterval = cmp.Or(cfg.SampleInterval, time.Second).String()
+	}
+	if cfg.Handler != nil {
+		dc.Handler = fmt.Sprintf("%T", cfg.Handler)
+	}
+	for _, h := range cfg.AdditionalHandlers {
+		dc.Additional = append(dc.Additional, fmt.Sprintf("%T", h))
+	}
+	if s := cfg.AuditSigning; s != nil {
+		dc.AuditSigning = "hmac"
+		if s.Ed25519Key != nil {
+			dc.AuditSigning = "ed25519"
+		}
+	}
+	return dc
+}
+
+// debugOutput describes a writer
+func debugOutput(role string, w io.Writer) DebugOutput {
+	out := DebugOutput{Role: role, Type: fmt.Sprintf("%T", w)}
+	switch w := w.(type) {
+	case *RotatingWriter:
+		out.Rotation = w.debugState()
+	case *NetWriter:
+		stats := w.Stats()
+		out.Network = &stats
+	}
+	return out
+}
+
+// DebugHandler returns an http.Handler serving DebugSnapshot as JSON. Like
+// LevelHandler, mount it on an internal/admin port only.
+func DebugHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			writeLevelError(w, http.StatusMethodNotAllowed, fmt.Errorf("logger: method %s not allowed", r.Method))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		_ = enc.Encode(DebugSnapshot())
+	})
+}
+
+var expvarOnce sync.Once
+
+// PublishExpvar publishes DebugSnapshot as the "logger" expvar, served by the
+// expvar package on /debug/vars. Calling it more than once is a no-op.
+func PublishExpvar() {
+	expvarOnce.Do(func() {
+		expvar.Publish("logger", expvar.Func(func() any { return DebugSnapshot() }))
+	})
+}
@@ -0,0 +1,89 @@
+package logger
+
+import (
+	"crypto/ed25519"
+	"encoding/json"
+	"expvar"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDebugHandler(t *testing.T) {
+	w, err := NewRotatingWriter(filepath.Join(t.TempDir(), "app.log"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = w.Close() }()
+
+	_, key, _ := ed25519.GenerateKey(nil)
+	SetConfig(Config{
+		Output:        w,
+		AuditOutput:   io.Discard,
+		AuditSigning:  &AuditSigning{Ed25519Key: key},
+		Level:         slog.LevelInfo,
+		LevelSet:      true,
+		ModuleLevels:  map[string]slog.Level{"db": slog.LevelDebug},
+		EnableMetrics: true,
+		AsyncMode:     true,
+		BufferSize:    16,
+	})
+	defer SetConfig(Config{Output: io.Discard, Level: LevelTrace})
+	LogInfo("hello")
+
+	rec := httptest.NewRecorder()
+	DebugHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/logger", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d", rec.Code)
+	}
+	if strings.Contains(rec.Body.String(), "Ed25519Key") {
+		t.Fatal("debug output must not contain key material")
+	}
+
+	var info DebugInfo
+	if err := json.Unmarshal(rec.Body.Bytes(), &info); err != nil {
+		t.Fatalf("invalid JSON %q: %v", rec.Body.String(), err)
+	}
+	if info.Version != Version || info.Config.Level != "info" || info.Config.ModuleLevels["db"] != "debug" {
+		t.Errorf("unexpected config: %+v", info.Config)
+	}
+	if info.Config.AuditSigning != "ed25519" {
+		t.Errorf("AuditSigning = %q, want ed25519", info.Config.AuditSigning)
+	}
+	if info.Async == nil || !info.Async.Running || info.Async.QueueCapacity != 16 {
+		t.Errorf("unexpected async state: %+v", info.Async)
+	}
+	if info.Metrics == nil {
+		t.Error("expected metrics")
+	}
+	if len(info.Outputs) != 2 || info.Outputs[0].Rotation == nil || info.Outputs[1].Role != "audit" {
+		t.Errorf("unexpected outputs: %+v", info.Outputs)
+	}
+
+	rec = httptest.NewRecorder()
+	DebugHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/debug/logger", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("POST status = %d, want 405", rec.Code)
+	}
+}
+
+func TestPublishExpvar(t *testing.T) {
+	PublishExpvar()
+	PublishExpvar() // Second call must not panic
+
+	v := expvar.Get("logger")
+	if v == nil {
+		t.Fatal("expected a logger expvar")
+	}
+	var info DebugInfo
+	if err := json.Unmarshal([]byte(v.String()), &info); err != nil {
+		t.Fatalf("invalid expvar JSON: %v", err)
+	}
+	if info.Version != Version {
+		t.Errorf("Version = %q, want %q", info.Version, Version)
+	}
+}
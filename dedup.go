@@ -20,25 +20,15 @@ type dedupEntry struct {
 	firstSeen time.Time
 }
 
-var dedupMgr *dedupManager
-
-func startDedup(window time.Duration) {
-	if dedupMgr != nil {
-		dedupMgr.Stop()
-	}
-	dedupMgr = &dedupManager{
+// newDedupManager creates a manager and starts its cleanup goroutine
+func newDedupManager(window time.Duration) *dedupManager {
+	d := &dedupManager{
 		entries: make(map[string]*dedupEntry),
 		window:  window,
 		stopCh:  make(chan struct{}),
 	}
-	go dedupMgr.cleanup()
-}
-
-func stopDedup() {
-	if dedupMgr != nil {
-		dedupMgr.Stop()
-		dedupMgr = nil
-	}
+	go d.cleanup()
+	return d
 }
 
 func (d *dedupManager) ShouldLog(level LogLevel, msg string) bool {
@@ -0,0 +1,266 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Dump logs value at Debug as an indented, type-annotated tree under
+// label, as a supported replacement for fmt.Printf debugging:
+//
+//	logger.Dump("user", user)
+//	// DEBUG user
+//	// *main.User {
+//	//   ID: int(42)
+//	//   Email: string("bob@example.com")
+//	//   Password: ***
+//	//   Roles: []string(len=2) [
+//	//     string("admin")
+//	//     string("ops")
+//	//   ]
+//	// }
+//
+// Redactor, RedactRules and RedactKeys apply to struct fields and map
+// keys, and RedactPatterns and DetectPII to strings. Unexported fields
+// are shown; values with an Error or String method are shown through it. The pretty and console
+// formats color the tree when colors are enabled.
+func Dump(label string, value any) {
+	cfg := GetConfig()
+	if cfg.Level > LevelDebug {
+		return // Skip rendering
+	}
+	d := dumper{
+		cfg:      cfg,
+		values:   newValueRedactor(cfg),
+		visiting: make(map[uintptr]bool),
+	}
+	if cfg.Format == FormatPretty || cfg.Format == FormatConsole {
+		d.color = colorEnabled(cfg, cfg.Output)
+	}
+	d.value(reflect.ValueOf(value), 0)
+
+	// The tree keeps its line breaks and colors, so it bypasses the
+	// control character escaping of messages and is written synchronously
+	message := safeText(label) + "\n" + d.buf.String()
+	kv := []any{"type", fmt.Sprintf("%T", value)}
+	if !admitLog(cfg, "", Debug, message, kv) {
+		return
+	}
+	var pc uintptr
+	if cfg.EnableCaller {
+		var pcs [1]uintptr
+		runtime.Callers(2, pcs[:])
+		pc = pcs[0]
+	}
+	ctx := context.WithValue(context.Background(), rawMessageKey{}, true)
+	logInternalSyncContext(ctx, Debug, message, pc, kv...)
+}
+
+// Styles of the parts of a dump
+var (
+	dumpTypeStyle   = Style{Color: Gray}
+	dumpKeyStyle    = Style{Color: Blue}
+	dumpStringStyle = Style{Color: Green}
+	dumpNumberStyle = Style{Color: Yellow}
+	dumpMaskStyle   = Style{Color: Red}
+)
+
+// dumper renders one value for Dump
+type dumper struct {
+	cfg      Config
+	values   valueRedactor
+	color    bool
+	visiting map[uintptr]bool // Pointers on the current path
+	buf      strings.Builder
+}
+
+// write appends text in style s when colors are on
+func (d *dumper) write(s Style, text string) {
+	if d.color {
+		d.buf.WriteString(s.render(text))
+		return
+	}
+	d.buf.WriteString(text)
+}
+
+// typed appends "type(text)"
+func (d *dumper) typed(t reflect.Type, s Style, text string) {
+	d.write(dumpTypeStyle, t.String()+"(")
+	d.write(s, text)
+	d.write(dumpTypeStyle, ")")
+}
+
+// indent starts a line at depth
+func (d *dumper) indent(depth int) {
+	d.buf.WriteByte('\n')
+	d.buf.WriteString(strings.Repeat("  ", depth))
+}
+
+// value appends v at the given nesting depth
+func (d *dumper) value(v reflect.Value, depth int) {
+	if !v.IsValid() {
+		d.write(dumpTypeStyle, "nil")
+		return
+	}
+	if depth > maxConvertDepth {
+		d.write(dumpTypeStyle, maxDepthMarker)
+		return
+	}
+	t := v.Type()
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface, reflect.Map, reflect.Slice, reflect.Func, reflect.Chan:
+		if v.IsNil() {
+			d.typed(t, dumpTypeStyle, "nil")
+			return
+		}
+	}
+	if v.CanInterface() {
+		switch x := v.Interface().(type) {
+		case error:
+			d.typed(t, dumpStringStyle, strconv.Quote(x.Error()))
+			return
+		case fmt.Stringer:
+			d.typed(t, dumpStringStyle, strconv.Quote(x.String()))
+			return
+		}
+	}
+
+	switch v.Kind() {
+	case reflect.Pointer:
+		if d.visiting[v.Pointer()] {
+			d.typed(t, dumpTypeStyle, cycleMarker)
+			return
+		}
+		d.visiting[v.Pointer()] = true
+		defer delete(d.visiting, v.Pointer())
+		d.write(dumpTypeStyle, "*")
+		d.value(v.Elem(), depth)
+	case reflect.Interface:
+		d.value(v.Elem(), depth)
+	case reflect.Struct:
+		d.write(dumpTypeStyle, t.String()+" {")
+		for field, fv := range v.Fields() {
+			name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+			out, keep, redacted := d.redact(fv, field.Name, name)
+			if !keep {
+				continue
+			}
+			d.indent(depth + 1)
+			d.write(dumpKeyStyle, field.Name)
+			d.buf.WriteString(": ")
+			if redacted {
+				d.write(dumpMaskStyle, fmt.Sprint(out))
+				continue
+			}
+			d.value(fv, depth+1)
+		}
+		d.closeBlock(depth, v.NumField(), "}")
+	case reflect.Map:
+		d.write(dumpTypeStyle, fmt.Sprintf("%s(len=%d) {", t, v.Len()))
+		keys := v.MapKeys()
+		slices.SortFunc(keys, func(a, b reflect.Value) int { return strings.Compare(mapKeyString(a), mapKeyString(b)) })
+		for i, k := range keys {
+			if i == maxConvertElements {
+				d.indent(depth + 1)
+				d.write(dumpTypeStyle, fmt.Sprintf("... %d more", len(keys)-i))
+				break
+			}
+			key := mapKeyString(k)
+			out, keep, redacted := d.redact(v.MapIndex(k), key)
+			if !keep {
+				continue
+			}
+			d.indent(depth + 1)
+			d.write(dumpKeyStyle, strconv.Quote(key))
+			d.buf.WriteString(": ")
+			if redacted {
+				d.write(dumpMaskStyle, fmt.Sprint(out))
+				continue
+			}
+			d.value(v.MapIndex(k), depth+1)
+		}
+		d.closeBlock(depth, len(keys), "}")
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			d.typed(t, dumpStringStyle, fmt.Sprintf("len=%d %q", v.Len(), d.bytes(v)))
+			return
+		}
+		d.write(dumpTypeStyle, fmt.Sprintf("%s(len=%d) [", t, v.Len()))
+		for i := range v.Len() {
+			d.indent(depth + 1)
+			if i == maxConvertElements {
+				d.write(dumpTypeStyle, fmt.Sprintf("... %d more", v.Len()-i))
+				break
+			}
+			d.value(v.Index(i), depth+1)
+		}
+		d.closeBlock(depth, v.Len(), "]")
+	case reflect.String:
+		s := v.String()
+		switch redacted := d.values.redact(s); redacted {
+		case s:
+			d.typed(t, dumpStringStyle, strconv.Quote(s))
+		case d.cfg.RedactMask:
+			d.typed(t, dumpMaskStyle, redacted)
+		default:
+			d.typed(t, dumpMaskStyle, strconv.Quote(redacted))
+		}
+	case reflect.Bool:
+		d.typed(t, dumpNumberStyle, strconv.FormatBool(v.Bool()))
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		d.typed(t, dumpNumberStyle, strconv.FormatInt(v.Int(), 10))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		d.typed(t, dumpNumberStyle, strconv.FormatUint(v.Uint(), 10))
+	case reflect.Float32, reflect.Float64:
+		d.typed(t, dumpNumberStyle, strconv.FormatFloat(v.Float(), 'g', -1, t.Bits()))
+	case reflect.Complex64, reflect.Complex128:
+		d.typed(t, dumpNumberStyle, strconv.FormatComplex(v.Complex(), 'g', -1, t.Bits()))
+	default:
+		// Funcs, channels and unsafe pointers
+		d.typed(t, dumpTypeStyle, fmt.Sprintf("%#x", v.Pointer()))
+	}
+}
+
+// redact applies redactField to a struct field or map entry under the
+// first of its names (Go and JSON) that is redacted
+func (d *dumper) redact(v reflect.Value, names ...string) (out any, keep, redacted bool) {
+	var value any = v
+	if v.CanInterface() {
+		value = v.Interface()
+	}
+	for _, name := range names {
+		if name == "" {
+			continue
+		}
+		if out, keep, redacted = redactField(name, value, &d.cfg); redacted {
+			return out, keep, true
+		}
+	}
+	return nil, true, false
+}
+
+// closeBlock ends a struct, map or slice of n elements
+func (d *dumper) closeBlock(depth, n int, closing string) {
+	if n > 0 {
+		d.indent(depth)
+	}
+	d.write(dumpTypeStyle, closing)
+}
+
+// bytes returns a byte slice or array, up to maxConvertElements bytes
+func (d *dumper) bytes(v reflect.Value) []byte {
+	n := min(v.Len(), maxConvertElements)
+	if v.Kind() == reflect.Slice {
+		return v.Bytes()[:n]
+	}
+	b := make([]byte, n)
+	for i := range n {
+		b[i] = byte(v.Index(i).Uint())
+	}
+	return b
+}
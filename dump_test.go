@@ -0,0 +1,56 @@
+package logger
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+type dumpUser struct {
+	ID       int
+	Email    string
+	Token    string `json:"token"`
+	Roles    []string
+	Meta     map[string]any
+	internal bool
+	self     *dumpUser
+}
+
+func TestDump(t *testing.T) {
+	var buf bytes.Buffer
+	SetConfig(Config{Output: &buf, Level: LevelTrace, RedactPatterns: []string{`^\d{4}-\d{4}$`}})
+	defer SetConfig(Config{Output: io.Discard, Level: LevelTrace})
+
+	u := &dumpUser{ID: 7, Email: "bob@example.com", Token: "t0k3n", Roles: []string{"admin"}, Meta: map[string]any{"password": "x", "card": "1234-5678", "n": 1.5}, internal: true}
+	u.self = u
+	Dump("user", u)
+
+	out := buf.String()
+	for _, want := range []string{
+		"DEBUG user\n*logger.dumpUser {\n",
+		`  ID: int(7)`,
+		`  Email: string("bob@example.com")`,
+		`  Token: ***`,
+		"  Roles: []string(len=1) [\n    string(\"admin\")\n  ]",
+		`    "card": string(***)`,
+		`    "n": float64(1.5)`,
+		`    "password": ***`,
+		`  internal: bool(true)`,
+		`  self: *logger.dumpUser([cycle])`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected %q in:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "t0k3n") || strings.Contains(out, "\033[") {
+		t.Errorf("Expected a masked, uncolored dump:\n%s", out)
+	}
+
+	buf.Reset()
+	SetConfig(Config{Output: &buf, Level: LevelInfo, LevelSet: true})
+	Dump("user", u)
+	if buf.Len() != 0 {
+		t.Errorf("Expected no dump above Debug, got %q", buf.String())
+	}
+}
@@ -0,0 +1,172 @@
+package logger
+
+import (
+	"encoding/json"
+	"log/slog"
+	"math"
+	"strconv"
+	"time"
+	"unicode/utf8"
+)
+
+// Allocation-free JSON encoding helpers used by prettyHandler. The output
+// matches encoding/json for the same values so the log format is unchanged.
+
+const hexDigits = "0123456789abcdef"
+
+// appendJSONString appends s as a quoted JSON string, escaping like
+// encoding/json (including HTML-sensitive characters and U+2028/U+2029)
+func appendJSONString(buf []byte, s string) []byte {
+	buf = append(buf, '"')
+	start := 0
+	for i := 0; i < len(s); {
+		if b := s[i]; b < utf8.RuneSelf {
+			if b >= 0x20 && b != '"' && b != '\\' && b != '<' && b != '>' && b != '&' {
+				i++
+				continue
+			}
+			buf = append(buf, s[start:i]...)
+			switch b {
+			case '\\', '"':
+				buf = append(buf, '\\', b)
+			case '\b':
+				buf = append(buf, '\\', 'b')
+			case '\f':
+				buf = append(buf, '\\', 'f')
+			case '\n':
+				buf = append(buf, '\\', 'n')
+			case '\r':
+				buf = append(buf, '\\', 'r')
+			case '\t':
+				buf = append(buf, '\\', 't')
+			default:
+				buf = append(buf, '\\', 'u', '0', '0', hexDigits[b>>4], hexDigits[b&0xF])
+			}
+			i++
+			start = i
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && size == 1 {
+			buf = append(buf, s[start:i]...)
+			buf = append(buf, "\ufffd"...)
+			i += size
+			start = i
+			continue
+		}
+		if r == '\u2028' || r == '\u2029' {
+			buf = append(buf, s[start:i]...)
+			buf = append(buf, '\\', 'u', '2', '0', '2', hexDigits[r&0xF])
+			i += size
+			start = i
+			continue
+		}
+		i += size
+	}
+	buf = append(buf, s[start:]...)
+	return append(buf, '"')
+}
+
+// appendSafeText appends s for writing unquoted, with line breaks, ANSI
+// escape sequences and other control characters escaped Go style (\n,
+// \x1b, \u009b), so attacker-controlled text cannot forge a log line or
+// restyle the terminal. Tabs are kept.
+func appendSafeText(buf []byte, s string) []byte {
+	start := 0
+	for i := 0; i < len(s); {
+		b := s[i]
+		switch {
+		case b < 0x20 && b != '\t' || b == 0x7f:
+			buf = append(buf, s[start:i]...)
+			switch b {
+			case '\n':
+				buf = append(buf, '\\', 'n')
+			case '\r':
+				buf = append(buf, '\\', 'r')
+			default:
+				buf = append(buf, '\\', 'x', hexDigits[b>>4], hexDigits[b&0xF])
+			}
+			i++
+			start = i
+		case b == 0xc2 && i+1 < len(s) && s[i+1] >= 0x80 && s[i+1] <= 0x9f:
+			// C1 controls, such as the single-byte CSI U+009B
+			buf = append(buf, s[start:i]...)
+			buf = append(buf, '\\', 'u', '0', '0', hexDigits[s[i+1]>>4], hexDigits[s[i+1]&0xF])
+			i += 2
+			start = i
+		case b == 0xe2 && i+2 < len(s) && s[i+1] == 0x80 && (s[i+2] == 0xa8 || s[i+2] == 0xa9):
+			// U+2028 and U+2029 break lines in some viewers
+			buf = append(buf, s[start:i]...)
+			buf = append(buf, '\\', 'u', '2', '0', '2', hexDigits[s[i+2]&0xF])
+			i += 3
+			start = i
+		default:
+			i++
+		}
+	}
+	return append(buf, s[start:]...)
+}
+
+// safeText returns s with appendSafeText applied, allocating only when s
+// has a byte that may need escaping
+func safeText(s string) string {
+	for i := 0; i < len(s); i++ {
+		if b := s[i]; b < 0x20 && b != '\t' || b == 0x7f || b == 0xc2 || b == 0xe2 {
+			return string(appendSafeText(make([]byte, 0, len(s)+8), s))
+		}
+	}
+	return s
+}
+
+// appendJSONFloat appends f the way encoding/json formats float64 values.
+// NaN and ±Inf have no JSON representation and are written as strings.
+func appendJSONFloat(buf []byte, f float64) []byte {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return appendJSONString(buf, strconv.FormatFloat(f, 'g', -1, 64))
+	}
+	abs := math.Abs(f)
+	format := byte('f')
+	if abs != 0 && (abs < 1e-6 || abs >= 1e21) {
+		format = 'e'
+	}
+	start := len(buf)
+	buf = strconv.AppendFloat(buf, f, format, -1, 64)
+	if format == 'e' {
+		// Clean up e-09 to e-9
+		n := len(buf) - start
+		if n >= 4 && buf[len(buf)-4] == 'e' && buf[len(buf)-3] == '-' && buf[len(buf)-2] == '0' {
+			buf[len(buf)-2] = buf[len(buf)-1]
+			buf = buf[:len(buf)-1]
+		}
+	}
+	return buf
+}
+
+// appendJSONValue appends the JSON encoding of v. Scalar kinds are written
+// directly; anything else falls back to json.Marshal.
+func appendJSONValue(buf []byte, v slog.Value) ([]byte, error) {
+	switch v.Kind() {
+	case slog.KindString:
+		return appendJSONString(buf, v.String()), nil
+	case slog.KindInt64:
+		return strconv.AppendInt(buf, v.Int64(), 10), nil
+	case slog.KindUint64:
+		return strconv.AppendUint(buf, v.Uint64(), 10), nil
+	case slog.KindFloat64:
+		return appendJSONFloat(buf, v.Float64()), nil
+	case slog.KindBool:
+		return strconv.AppendBool(buf, v.Bool()), nil
+	case slog.KindDuration:
+		return strconv.AppendInt(buf, int64(v.Duration()), 10), nil
+	case slog.KindTime:
+		buf = append(buf, '"')
+		buf = v.Time().AppendFormat(buf, time.RFC3339Nano)
+		return append(buf, '"'), nil
+	default:
+		data, err := json.Marshal(v.Any())
+		if err != nil {
+			return buf, err
+		}
+		return append(buf, data...), nil
+	}
+}
@@ -0,0 +1,120 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+// raceEnabled is set by race_test.go; sync.Pool drops items under the race
+// detector, so allocation counts are meaningless there
+var raceEnabled bool
+
+func TestAppendJSONValueMatchesEncodingJSON(t *testing.T) {
+	values := []any{
+		"plain",
+		"quote \" backslash \\ newline \n tab \t",
+		"<html> & ampersand",
+		"control \x01 char",
+		"line separator",
+		"invalid \xff utf8",
+		"unicode ✓ 日本",
+		int64(-42),
+		uint64(math.MaxUint64),
+		0.5,
+		1e21,
+		1e-7,
+		123456789.0,
+		true,
+		time.Date(2024, 1, 2, 3, 4, 5, 6, time.UTC),
+		map[string]any{"nested": []any{1, "two"}},
+	}
+
+	for _, v := range values {
+		want, err := json.Marshal(v)
+		if err != nil {
+			t.Fatalf("json.Marshal(%v): %v", v, err)
+		}
+		got, err := appendJSONValue(nil, slog.AnyValue(v))
+		if err != nil {
+			t.Fatalf("appendJSONValue(%v): %v", v, err)
+		}
+		if string(got) != string(want) {
+			t.Errorf("appendJSONValue(%#v) = %s, want %s", v, got, want)
+		}
+	}
+}
+
+func TestAppendJSONFloatNonFinite(t *testing.T) {
+	if got := string(appendJSONFloat(nil, math.NaN())); got != `"NaN"` {
+		t.Errorf("Expected NaN to be quoted, got %s", got)
+	}
+	if got := string(appendJSONFloat(nil, math.Inf(-1))); got != `"-Inf"` {
+		t.Errorf("Expected -Inf to be quoted, got %s", got)
+	}
+}
+
+func TestSafeText(t *testing.T) {
+	tests := []struct{ in, want string }{
+		{"plain → text… ©", "plain → text… ©"},
+		{"tab\tkept", "tab\tkept"},
+		{"ok\n2026-10-16 12:00:00 INFO admin logged in", `ok\n2026-10-16 12:00:00 INFO admin logged in`},
+		{"a\r\nb", `a\r\nb`},
+		{"\x1b[2J\x1b[31mred", `\x1b[2J\x1b[31mred`},
+		{"del\x7f nul\x00", `del\x7f nul\x00`},
+		{"csi \u009b31m", `csi \u009b31m`},
+		{"line\u2028sep", `line\u2028sep`},
+	}
+	for _, tt := range tests {
+		if got := safeText(tt.in); got != tt.want {
+			t.Errorf("safeText(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestMessageControlChars(t *testing.T) {
+	defer SetConfig(Config{Output: io.Discard, Level: LevelTrace})
+
+	var buf bytes.Buffer
+	SetConfig(Config{Output: &buf, Level: LevelTrace, CompactJSON: true})
+	LogInfo("login failed for bob\n2026-10-16 12:00:00 INFO login ok for \x1b[1madmin", "user", "eve\nINFO fake")
+	if out := buf.String(); strings.Count(out, "\n") != 1 || strings.Contains(out, "\x1b") ||
+		!strings.Contains(out, `bob\n2026`) || !strings.Contains(out, `"user":"eve\nINFO fake"`) {
+		t.Errorf("expected one escaped line, got %q", out)
+	}
+
+	buf.Reset()
+	SetConfig(Config{Output: &buf, Level: LevelTrace, AllowControlChars: true})
+	LogInfo("line one\nline two")
+	if !strings.Contains(buf.String(), "line one\nline two") {
+		t.Errorf("expected the message as given, got %q", buf.String())
+	}
+}
+
+func TestLogScalarAttrsAllocs(t *testing.T) {
+	if raceEnabled {
+		t.Skip("allocation counts are unreliable under the race detector")
+	}
+
+	for _, compact := range []bool{true, false} {
+		SetConfig(Config{
+			Output:      io.Discard,
+			Level:       LevelInfo,
+			EnableColor: false,
+			CompactJSON: compact,
+			TimeFormat:  "15:04:05",
+		})
+
+		allocs := testing.AllocsPerRun(100, func() {
+			LogInfo("alloc check", "user", "alice", "attempt", 3, "ratio", 0.5, "ok", true)
+		})
+		if allocs >= 2 {
+			t.Errorf("CompactJSON=%v: expected < 2 allocs/op, got %.1f", compact, allocs)
+		}
+	}
+}
@@ -9,10 +9,12 @@ import (
 // ConfigFromEnv returns a Config populated from environment variables.
 // Recognized variables:
 //   - LOG_LEVEL: trace, debug, info, notice, warn, error, audit
-//   - LOG_COLOR: true, false, 1, 0
+//   - LOG_LEVELS: per-module levels for Named loggers, e.g. "db=debug,http=warn"
+//   - LOG_COLOR: auto, true, false, 1, 0 (sets Color)
 //   - LOG_CALLER: true, false, 1, 0
-//   - LOG_FORMAT: compact (sets CompactJSON)
+//   - LOG_FORMAT: pretty, json, logfmt, console, compact (pretty with CompactJSON)
 //   - LOG_REDACT_KEYS: comma-separated additional keys to redact
+//   - LOG_SPLIT_STREAMS: true, false, 1, 0 (sets SplitStdStreams)
 func ConfigFromEnv() Config {
 	cfg := defaultConfig
 	applyEnvOverrides(&cfg)
@@ -24,16 +26,37 @@ func applyEnvOverrides(cfg *Config) {
 		cfg.Level = parseLevelString(v)
 		cfg.LevelSet = true
 	}
+	if v := os.Getenv("LOG_LEVELS"); v != "" {
+		cfg.ModuleLevels = make(map[string]slog.Level)
+		for pair := range strings.SplitSeq(v, ",") {
+			name, level, ok := strings.Cut(pair, "=")
+			if name = strings.TrimSpace(name); ok && name != "" {
+				cfg.ModuleLevels[name] = parseLevelString(strings.TrimSpace(level))
+			}
+		}
+	}
 	if v := os.Getenv("LOG_COLOR"); v != "" {
-		cfg.EnableColor = parseBoolEnv(v)
+		switch {
+		case strings.EqualFold(strings.TrimSpace(v), "auto"):
+			cfg.Color = ColorAuto
+		case parseBoolEnv(v):
+			cfg.EnableColor = true
+			cfg.Color = ColorAlways
+		default:
+			cfg.EnableColor = false
+			cfg.Color = ColorNever
+		}
 	}
 	if v := os.Getenv("LOG_CALLER"); v != "" {
 		cfg.EnableCaller = parseBoolEnv(v)
 	}
+	if v := os.Getenv("LOG_SPLIT_STREAMS"); v != "" {
+		cfg.SplitStdStreams = parseBoolEnv(v)
+	}
 	if v := os.Getenv("LOG_FORMAT"); v != "" {
-		switch strings.ToLower(v) {
-		case "compact", "json":
-			cfg.CompactJSON = true
+		if format, compact, ok := parseFormatString(v); ok {
+			cfg.Format = format
+			cfg.CompactJSON = cfg.CompactJSON || compact
 		}
 	}
 	if v := os.Getenv("LOG_REDACT_KEYS"); v != "" {
@@ -46,24 +69,29 @@ func applyEnvOverrides(cfg *Config) {
 }
 
 func parseLevelString(s string) slog.Level {
-	switch strings.ToLower(strings.TrimSpace(s)) {
-	case "trace":
-		return LevelTrace
-	case "debug":
-		return slog.LevelDebug
-	case "info":
-		return slog.LevelInfo
-	case "notice":
-		return LevelNotice
-	case "warn", "warning":
-		return slog.LevelWarn
-	case "error":
-		return slog.LevelError
-	case "audit":
-		return LevelAudit
-	default:
+	level, err := ParseLevel(s)
+	if err != nil {
 		return slog.LevelInfo
 	}
+	return level
+}
+
+// parseFormatString parses a LOG_FORMAT or --log-format value; compact is
+// pretty with CompactJSON
+func parseFormatString(s string) (format OutputFormat, compact bool, ok bool) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "pretty":
+		return FormatPretty, false, true
+	case "compact":
+		return FormatPretty, true, true
+	case "json":
+		return FormatJSON, false, true
+	case "logfmt":
+		return FormatLogfmt, false, true
+	case "console":
+		return FormatConsole, false, true
+	}
+	return FormatPretty, false, false
 }
 
 func parseBoolEnv(s string) bool {
@@ -0,0 +1,70 @@
+package logger
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"sync/atomic"
+)
+
+// failoverHandler writes a record through fallback when primary fails
+type failoverHandler struct {
+	primary  slog.Handler
+	fallback slog.Handler
+}
+
+func (h *failoverHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.primary.Enabled(ctx, level)
+}
+
+func (h *failoverHandler) Handle(ctx context.Context, record slog.Record) error {
+	err := h.primary.Handle(ctx, record)
+	if err != nil && h.fallback.Handle(ctx, record) == nil {
+		recordFallbackWrite()
+	}
+	return err
+}
+
+func (h *failoverHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &failoverHandler{primary: h.primary.WithAttrs(attrs), fallback: h.fallback.WithAttrs(attrs)}
+}
+
+func (h *failoverHandler) WithGroup(name string) slog.Handler {
+	return &failoverHandler{primary: h.primary.WithGroup(name), fallback: h.fallback.WithGroup(name)}
+}
+
+// newFallbackHandler renders records for cfg.FallbackOutput in the main
+// format, colored only when the fallback itself warrants it
+func newFallbackHandler(cfg Config, opts prettyHandlerOptions) slog.Handler {
+	opts.Config.EnableColor = colorEnabled(cfg, cfg.FallbackOutput)
+	return newPrettyHandler(cfg.FallbackOutput, opts)
+}
+
+// reportWriteError counts a failed write and passes err to
+// Config.ErrorHandler
+func reportWriteError(cfg Config, err error) {
+	if m := currentMetrics(); cfg.EnableMetrics && m != nil {
+		atomic.AddInt64(&m.WriteErrors, 1)
+	}
+	if cfg.ErrorHandler != nil {
+		cfg.ErrorHandler(err)
+	}
+}
+
+// writeFallback copies already encoded records that out failed to accept
+// to Config.FallbackOutput
+func writeFallback(cfg Config, out io.Writer, p []byte) {
+	if cfg.FallbackOutput == nil || cfg.FallbackOutput == out {
+		return
+	}
+	if _, err := cfg.FallbackOutput.Write(p); err == nil {
+		recordFallbackWrite()
+	}
+}
+
+// recordFallbackWrite counts a record delivered to FallbackOutput
+func recordFallbackWrite() {
+	if m := currentMetrics(); m != nil {
+		atomic.AddInt64(&m.FallbackWrites, 1)
+	}
+}
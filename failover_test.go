@@ -0,0 +1,116 @@
+package logger
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+)
+
+var errDiskFull = errors.New("disk full")
+
+// failingWriter rejects every write
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) { return 0, errDiskFull }
+
+// errorRecorder collects errors passed to Config.ErrorHandler
+type errorRecorder struct {
+	mu   sync.Mutex
+	errs []error
+}
+
+func (r *errorRecorder) handle(err error) {
+	r.mu.Lock()
+	r.errs = append(r.errs, err)
+	r.mu.Unlock()
+}
+
+func (r *errorRecorder) count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.errs)
+}
+
+func TestFallbackOutput(t *testing.T) {
+	var fallback bytes.Buffer
+	var errs errorRecorder
+	SetConfig(Config{
+		Output:         failingWriter{},
+		FallbackOutput: &fallback,
+		ErrorHandler:   errs.handle,
+		Level:          slog.LevelInfo,
+		LevelSet:       true,
+		CompactJSON:    true,
+		EnableMetrics:  true,
+		Color:          ColorAuto, // The fallback is not a terminal, so it stays plain
+	})
+	defer SetConfig(Config{Output: io.Discard, Level: LevelTrace})
+
+	LogInfo("saved", "k", "v")
+
+	if got := fallback.String(); !strings.Contains(got, `INFO saved {"k":"v"}`) || strings.Contains(got, "\x1b[") {
+		t.Errorf("unexpected fallback output %q", got)
+	}
+	if errs.count() != 1 || !errors.Is(errs.errs[0], errDiskFull) {
+		t.Errorf("ErrorHandler got %v", errs.errs)
+	}
+	m := GetMetrics()
+	if m["write_errors"] != int64(1) || m["fallback_writes"] != int64(1) {
+		t.Errorf("write_errors = %v, fallback_writes = %v", m["write_errors"], m["fallback_writes"])
+	}
+}
+
+func TestFallbackOutputCustomHandler(t *testing.T) {
+	var fallback bytes.Buffer
+	SetConfig(Config{
+		Handler:        slog.NewJSONHandler(failingWriter{}, nil),
+		FallbackOutput: &fallback,
+		Level:          slog.LevelInfo,
+		LevelSet:       true,
+		Format:         FormatLogfmt,
+	})
+	defer SetConfig(Config{Output: io.Discard, Level: LevelTrace})
+
+	LogWarn("rerouted", "n", 1)
+	if got := fallback.String(); !strings.Contains(got, "level=WARN msg=rerouted n=1") {
+		t.Errorf("unexpected fallback output %q", got)
+	}
+}
+
+func TestFallbackOutputAsyncBatch(t *testing.T) {
+	var fallback syncWriter
+	fallback.buf = &bytes.Buffer{}
+	var errs errorRecorder
+	SetConfig(Config{
+		Output:         failingWriter{},
+		FallbackOutput: &fallback,
+		ErrorHandler:   errs.handle,
+		Level:          slog.LevelInfo,
+		LevelSet:       true,
+		CompactJSON:    true,
+		AsyncMode:      true,
+		AsyncBatchSize: 2,
+	})
+	defer SetConfig(Config{Output: io.Discard, Level: LevelTrace})
+
+	LogInfo("one")
+	LogInfo("two")
+	LogInfo("three")
+
+	// Leaving async mode flushes the last partial batch
+	SetConfig(Config{Output: io.Discard, Level: LevelTrace})
+
+	got := fallback.String()
+	for _, msg := range []string{"one", "two", "three"} {
+		if !strings.Contains(got, "INFO "+msg+"\n") {
+			t.Errorf("expected %q in fallback output %q", msg, got)
+		}
+	}
+	if errs.count() == 0 {
+		t.Error("expected the batch write errors to reach ErrorHandler")
+	}
+}
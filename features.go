@@ -1,14 +1,20 @@
 package logger
 
 import (
+	"bytes"
+	"cmp"
 	"compress/gzip"
+	"context"
 	"fmt"
 	"hash/fnv"
 	"io"
-	"net/http"
+	"log/slog"
+	"maps"
 	"os"
 	"path/filepath"
-	"sort"
+	"slices"
+	"strconv"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -22,13 +28,94 @@ type logEntry struct {
 	pc        uintptr
 }
 
+// write logs the entry through the configured handler
+func (e *logEntry) write() {
+	logInternalSync(e.level, e.message, e.pc, e.keyValues...)
+}
+
+// render formats the entry into buf instead of the configured output
+func (e *logEntry) render(buf *bytes.Buffer) {
+	ctx := context.WithValue(context.Background(), renderBufferKey{}, buf)
+	logInternalSyncContext(ctx, e.level, e.message, e.pc, e.keyValues...)
+}
+
+// OverflowPolicy controls how async mode behaves when its buffer is full
+type OverflowPolicy int
+
+const (
+	// OverflowFallbackSync writes the entry synchronously on the caller's goroutine (default)
+	OverflowFallbackSync OverflowPolicy = iota
+	// OverflowBlock waits until the async worker frees space in the buffer
+	OverflowBlock
+	// OverflowDropNewest discards the entry being logged
+	OverflowDropNewest
+	// OverflowDropOldest discards the oldest queued entry to make room for the new one
+	OverflowDropOldest
+	// OverflowSpill appends the entry to a file at Config.SpillPath and replays
+	// it once the worker catches up; entries beyond SpillMaxBytes are dropped
+	OverflowSpill
+)
+
+// String returns the policy name
+func (p OverflowPolicy) String() string {
+	switch p {
+	case OverflowFallbackSync:
+		return "fallback_sync"
+	case OverflowBlock:
+		return "block"
+	case OverflowDropNewest:
+		return "drop_newest"
+	case OverflowDropOldest:
+		return "drop_oldest"
+	case OverflowSpill:
+		return "spill"
+	default:
+		return fmt.Sprintf("OverflowPolicy(%d)", int(p))
+	}
+}
+
+// Sliding-window rates are kept in rateBuckets buckets of rateBucketWidth,
+// covering the longest window, 15 minutes
+const (
+	rateBucketWidth = 10 * time.Second
+	rateBuckets     = int(15 * time.Minute / rateBucketWidth)
+	numLogLevels    = int(Audit) + 1
+)
+
+// rateWindows are the windows reported by GetMetrics and MetricsHandler
+var rateWindows = []time.Duration{time.Minute, 5 * time.Minute, 15 * time.Minute}
+
 // LogMetrics tracks logging metrics
 type LogMetrics struct {
-	mu            sync.RWMutex
-	TotalLogs     int64
-	LogsByLevel   map[LogLevel]int64
-	ErrorRate     float64
-	lastErrorTime time.Time
+	mu          sync.RWMutex
+	TotalLogs   int64
+	DroppedLogs int64 // Entries discarded by the async overflow policy
+	SpilledLogs int64 // Entries written to the spill file by OverflowSpill
+	LogsByLevel map[LogLevel]int64
+
+	// What the logger hides: entries rejected before formatting and
+	// attribute values replaced with RedactMask
+	SuppressedLogs   int64 // Below the global or module level
+	FilteredLogs     int64 // Dropped by Filters
+	SampledOutLogs   int64 // Skipped by SampleRate
+	DeduplicatedLogs int64 // Suppressed as duplicates by EnableDedup
+	RedactedValues   int64 // Masked by RedactKeys or RedactPatterns
+
+	// Output failures: records (or async batches) the handler could not
+	// write, and those delivered to FallbackOutput instead
+	WriteErrors    int64
+	FallbackWrites int64
+
+	// ErrorRate is errors per second over the last minute, as of the most
+	// recent record. Use Rate for an up-to-date value.
+	ErrorRate float64
+
+	writeLatency latencyHistogram // Handler encode+write time per record
+
+	// Per-level counts in a ring of time buckets; stamps holds the bucket
+	// number (time / rateBucketWidth) each slot currently counts
+	buckets [rateBuckets][numLogLevels]int64
+	stamps  [rateBuckets]int64
 }
 
 // NewLogMetrics creates a new metrics instance
@@ -40,44 +127,201 @@ func NewLogMetrics() *LogMetrics {
 
 // RecordLog increments log counters
 func (m *LogMetrics) RecordLog(level LogLevel) {
+	m.recordAt(level, time.Now())
+}
+
+func (m *LogMetrics) recordAt(level LogLevel, now time.Time) {
 	atomic.AddInt64(&m.TotalLogs, 1)
 
 	m.mu.Lock()
 	m.LogsByLevel[level]++
-	if level == Error {
-		now := time.Now()
-		// Calculate error rate (errors per second since first error)
-		totalErrors := m.LogsByLevel[Error]
-		if m.lastErrorTime.IsZero() {
-			m.lastErrorTime = now
-			m.ErrorRate = 0
-		} else {
-			duration := now.Sub(m.lastErrorTime).Seconds()
-			if duration > 0 {
-				m.ErrorRate = float64(totalErrors) / duration
-			}
+	if level >= Trace && level <= Audit {
+		bucket := now.UnixNano() / int64(rateBucketWidth)
+		slot := bucket % int64(rateBuckets)
+		if m.stamps[slot] != bucket {
+			m.stamps[slot] = bucket
+			m.buckets[slot] = [numLogLevels]int64{}
 		}
+		m.buckets[slot][level]++
+	}
+	if level == Error {
+		m.ErrorRate = m.rateLocked(Error, time.Minute, now) / 60
 	}
 	m.mu.Unlock()
 }
 
-// GetMetrics returns a snapshot of current metrics
+// Rate returns entries per minute at level over the trailing window, which
+// is rounded up to 10 seconds and capped at 15 minutes
+func (m *LogMetrics) Rate(level LogLevel, window time.Duration) float64 {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.rateLocked(level, window, time.Now())
+}
+
+func (m *LogMetrics) rateLocked(level LogLevel, window time.Duration, now time.Time) float64 {
+	if level < Trace || level > Audit || window <= 0 {
+		return 0
+	}
+	n := min(int64((window+rateBucketWidth-1)/rateBucketWidth), int64(rateBuckets))
+	current := now.UnixNano() / int64(rateBucketWidth)
+
+	var count int64
+	for slot := range m.stamps {
+		if b := m.stamps[slot]; b > current-n && b <= current {
+			count += m.buckets[slot][level]
+		}
+	}
+	return float64(count) / (time.Duration(n) * rateBucketWidth).Minutes()
+}
+
+// droppedCount returns the number of entries dropped by the async overflow policy
+func (m *LogMetrics) droppedCount() int64 {
+	return atomic.LoadInt64(&m.DroppedLogs)
+}
+
+// latencyBounds are the upper bounds of the write latency histogram buckets
+var latencyBounds = [...]time.Duration{
+	time.Microsecond, 5 * time.Microsecond, 10 * time.Microsecond, 50 * time.Microsecond,
+	100 * time.Microsecond, 500 * time.Microsecond, time.Millisecond, 5 * time.Millisecond,
+	10 * time.Millisecond, 50 * time.Millisecond, 100 * time.Millisecond, time.Second,
+}
+
+// latencyHistogram counts durations into latencyBounds buckets plus an
+// overflow bucket, without locks
+type latencyHistogram struct {
+	counts [len(latencyBounds) + 1]atomic.Int64
+	sum    atomic.Int64 // Nanoseconds
+}
+
+func (h *latencyHistogram) observe(d time.Duration) {
+	i, _ := slices.BinarySearch(latencyBounds[:], d)
+	h.counts[i].Add(1)
+	h.sum.Add(int64(d))
+}
+
+// HistogramBucket is one cumulative histogram bucket: Count observations
+// took at most UpperBound
+type HistogramBucket struct {
+	UpperBound time.Duration
+	Count      int64
+}
+
+// HistogramSnapshot is a point-in-time copy of a latency histogram. Buckets
+// are cumulative like Prometheus; Count includes observations above the
+// last bound.
+type HistogramSnapshot struct {
+	Buckets []HistogramBucket
+	Count   int64
+	Sum     time.Duration
+}
+
+func (h *latencyHistogram) snapshot() HistogramSnapshot {
+	snap := HistogramSnapshot{Buckets: make([]HistogramBucket, len(latencyBounds))}
+	for i, bound := range latencyBounds {
+		snap.Count += h.counts[i].Load()
+		snap.Buckets[i] = HistogramBucket{UpperBound: bound, Count: snap.Count}
+	}
+	snap.Count += h.counts[len(latencyBounds)].Load()
+	snap.Sum = time.Duration(h.sum.Load())
+	return snap
+}
+
+// RecordWriteLatency records how long the handler took to encode and write
+// one record
+func (m *LogMetrics) RecordWriteLatency(d time.Duration) {
+	m.writeLatency.observe(d)
+}
+
+// WriteLatency returns the handler encode+write latency histogram
+func (m *LogMetrics) WriteLatency() HistogramSnapshot {
+	return m.writeLatency.snapshot()
+}
+
+// skipReason is why admitLog rejected an entry
+type skipReason int
+
+const (
+	skipLevel skipReason = iota
+	skipFilter
+	skipSample
+	skipDedup
+)
+
+// recordSkipped counts an entry rejected before formatting
+func recordSkipped(cfg Config, reason skipReason) {
+	m := currentMetrics()
+	if !cfg.EnableMetrics || m == nil {
+		return
+	}
+	switch reason {
+	case skipLevel:
+		atomic.AddInt64(&m.SuppressedLogs, 1)
+	case skipFilter:
+		atomic.AddInt64(&m.FilteredLogs, 1)
+	case skipSample:
+		atomic.AddInt64(&m.SampledOutLogs, 1)
+	case skipDedup:
+		atomic.AddInt64(&m.DeduplicatedLogs, 1)
+	}
+}
+
+// recordRedacted counts a value replaced with the redact mask
+func recordRedacted() {
+	if m := currentMetrics(); m != nil {
+		atomic.AddInt64(&m.RedactedValues, 1)
+	}
+}
+
+// RecordDropped increments the dropped entries counter
+func (m *LogMetrics) RecordDropped() {
+	atomic.AddInt64(&m.DroppedLogs, 1)
+}
+
+// GetMetrics returns a snapshot of current metrics. Besides the counters it
+// reports entries per minute for each level seen over the last 1, 5 and 15
+// minutes as rate_<level>_<window> (e.g. rate_error_5m).
 func (m *LogMetrics) GetMetrics() map[string]any {
+	return m.metricsAt(time.Now())
+}
+
+func (m *LogMetrics) metricsAt(now time.Time) map[string]any {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 
 	result := map[string]any{
-		"total_logs": atomic.LoadInt64(&m.TotalLogs),
-		"error_rate": m.ErrorRate,
+		"total_logs":        atomic.LoadInt64(&m.TotalLogs),
+		"dropped_logs":      atomic.LoadInt64(&m.DroppedLogs),
+		"spilled_logs":      atomic.LoadInt64(&m.SpilledLogs),
+		"suppressed_logs":   atomic.LoadInt64(&m.SuppressedLogs),
+		"filtered_logs":     atomic.LoadInt64(&m.FilteredLogs),
+		"sampled_out_logs":  atomic.LoadInt64(&m.SampledOutLogs),
+		"deduplicated_logs": atomic.LoadInt64(&m.DeduplicatedLogs),
+		"redacted_values":   atomic.LoadInt64(&m.RedactedValues),
+		"write_errors":      atomic.LoadInt64(&m.WriteErrors),
+		"fallback_writes":   atomic.LoadInt64(&m.FallbackWrites),
+		"error_rate":        m.rateLocked(Error, time.Minute, now) / 60,
+		"write_latency":     m.writeLatency.snapshot(),
 	}
 
 	for level, count := range m.LogsByLevel {
-		result[fmt.Sprintf("logs_%s", levelToString(level))] = count
+		name := levelToString(level)
+		result[fmt.Sprintf("logs_%s", name)] = count
+		for _, window := range rateWindows {
+			result[fmt.Sprintf("rate_%s_%s", name, windowName(window))] = m.rateLocked(level, window, now)
+		}
 	}
 
 	return result
 }
 
+// windowName formats a rate window as a metric suffix ("1m", "5m", "15m")
+func windowName(window time.Duration) string {
+	if window%time.Minute == 0 {
+		return strconv.Itoa(int(window/time.Minute)) + "m"
+	}
+	return strconv.Itoa(int(window/time.Second)) + "s"
+}
+
 func levelToString(level LogLevel) string {
 	switch level {
 	case Trace:
@@ -99,6 +343,10 @@ func levelToString(level LogLevel) string {
 	}
 }
 
+// SampleOff is a Config.SampleRate value that samples out every message.
+// A plain 0 reads as "unset" (log everything) unless SampleRateSet is true.
+const SampleOff = -1.0
+
 // shouldSample determines if a log message should be logged based on sampling rate
 func shouldSample(msg string, rate float64, seed int64) bool {
 	if rate >= 1.0 {
@@ -116,73 +364,483 @@ func shouldSample(msg string, rate float64, seed int64) bool {
 	return float64(hashValue%10000) < rate*10000
 }
 
-// startAsyncLogger starts the async logging goroutine
+// startAsyncLogger starts the async logging workers, restarting them if
+// they are already running
 func startAsyncLogger(cfg Config) {
+	// Drain and stop any running workers first to avoid race conditions
+	stopAsyncLogger()
+
 	asyncMu.Lock()
 
-	// If already running, stop it first to avoid race conditions
-	if asyncRunning {
-		// Signal stop and close channels
-		asyncDone <- true
-		close(logChan)
-		asyncRunning = false
-		asyncMu.Unlock()
+	logChan = make(chan *logEntry, cfg.BufferSize)
+	asyncDone = make(chan struct{})
+	asyncAbort = make(chan struct{})
+	stopCtx, stop := context.WithCancel(context.Background())
+	asyncStop, asyncStopped = stop, stopCtx.Done()
+	asyncWg = new(sync.WaitGroup)
+	asyncRunning = true
 
-		// Wait for goroutine to finish
-		asyncWg.Wait()
+	workers := max(cfg.AsyncWorkers, 1)
 
-		asyncMu.Lock()
+	// Several workers (or a spill replay) share the output with synchronous
+	// fallback writes, so route everything through the batch writer's lock
+	// even when unbatched
+	var batch *batchWriter
+	if cfg.AsyncBatchSize > 1 || workers > 1 || cfg.AsyncOverflowPolicy == OverflowSpill {
+		interval := cfg.AsyncBatchInterval
+		if interval == 0 {
+			interval = cfg.FlushTimeout
+		}
+		batch = newBatchWriter(configOutput(cfg), max(cfg.AsyncBatchSize, 1), interval)
 	}
+	asyncBatch.Store(batch)
 
-	logChan = make(chan *logEntry, cfg.BufferSize)
-	asyncDone = make(chan bool, 1) // Buffered to prevent blocking
-	asyncRunning = true
-	asyncWg.Add(1)
+	var spill *spillQueue
+	if cfg.AsyncOverflowPolicy == OverflowSpill {
+		maxBytes := cfg.SpillMaxBytes
+		if maxBytes == 0 {
+			maxBytes = DefaultSpillMaxBytes
+		}
+		// Without a spill file overflow falls back to synchronous writes
+		var err error
+		if spill, err = openSpillQueue(cfg.SpillPath, maxBytes, batch); err != nil {
+			reportWriteError(cfg, err)
+		}
+	}
+	asyncSpill.Store(spill)
+
+	// The workers keep their own references so a restart cannot swap the
+	// channels underneath them
+	entries, done, abort := logChan, asyncDone, asyncAbort
+
+	if workers == 1 || cfg.AsyncUnordered {
+		for range workers {
+			asyncWg.Go(func() { runAsyncWorker(entries, done, abort, batch, spill, cfg.FlushTimeout) })
+		}
+	} else {
+		asyncWg.Go(func() { runOrderedAsync(entries, abort, batch, spill, workers, cfg.FlushTimeout) })
+	}
 
 	asyncMu.Unlock()
+}
 
+// runAsyncWorker logs queued entries until the queue is closed. Several
+// workers may share one queue when ordering is not required. Spilled
+// entries are replayed whenever the queue runs empty.
+func runAsyncWorker(entries <-chan *logEntry, done, abort <-chan struct{}, batch *batchWriter, spill *spillQueue, flushTimeout time.Duration) {
+	ticker := time.NewTicker(flushTimeout)
+	defer ticker.Stop()
+
+	var wake <-chan struct{}
+	if spill != nil {
+		wake = spill.wake
+	}
+
+	for {
+		select {
+		case entry, ok := <-entries:
+			if !ok {
+				// Closed by stopAsyncLogger before done was observed
+				return
+			}
+			writeEntry(entry, abort)
+			if spill != nil && len(entries) == 0 {
+				drainSpill(spill, entries)
+			} else if batch != nil {
+				// An empty queue means nothing is left to coalesce with
+				batch.flushIfDue(len(entries) == 0)
+			}
+		case <-wake:
+			drainSpill(spill, entries)
+		case <-ticker.C:
+			// Flush any pending logs
+			for len(entries) > 0 {
+				entry, ok := <-entries
+				if !ok {
+					break
+				}
+				writeEntry(entry, abort)
+			}
+			if batch != nil {
+				_ = batch.Flush()
+			}
+		case <-done:
+			// Drain remaining logs (channel is closed by stopAsyncLogger)
+			for entry := range entries {
+				writeEntry(entry, abort)
+			}
+			return
+		}
+	}
+}
+
+// writeEntry writes entry unless Shutdown has given up on the queue, in
+// which case the entry is counted as dropped
+func writeEntry(entry *logEntry, abort <-chan struct{}) {
+	select {
+	case <-abort:
+		recordDropped(*loadConfig())
+	default:
+		entry.write()
+	}
+}
+
+// asyncJob is an entry being rendered by the ordered worker pool
+type asyncJob struct {
+	entry *logEntry
+	buf   *bytes.Buffer
+	ready chan struct{}
+}
+
+// runOrderedAsync renders queued entries on several encoder goroutines and
+// writes the results in submission order through batch. Spilled entries are
+// replayed whenever nothing is queued or being rendered.
+func runOrderedAsync(entries <-chan *logEntry, abort <-chan struct{}, batch *batchWriter, spill *spillQueue, workers int, flushTimeout time.Duration) {
+	jobs := make(chan *asyncJob, workers)
+	// Bounds how far encoders may run ahead of the writer
+	order := make(chan *asyncJob, workers*2)
+
+	var encoders sync.WaitGroup
+	for range workers {
+		encoders.Go(func() {
+			for job := range jobs {
+				job.entry.render(job.buf)
+				close(job.ready)
+			}
+		})
+	}
+
+	writerDone := make(chan struct{})
 	go func() {
-		defer asyncWg.Done()
-		ticker := time.NewTicker(cfg.FlushTimeout)
+		defer close(writerDone)
+		ticker := time.NewTicker(flushTimeout)
 		defer ticker.Stop()
 
+		var wake <-chan struct{}
+		if spill != nil {
+			wake = spill.wake
+		}
+
 		for {
 			select {
-			case entry := <-logChan:
-				logInternalSync(entry.level, entry.message, entry.pc, entry.keyValues...)
-			case <-ticker.C:
-				// Flush any pending logs
-				for len(logChan) > 0 {
-					entry := <-logChan
-					logInternalSync(entry.level, entry.message, entry.pc, entry.keyValues...)
+			case job, ok := <-order:
+				if !ok {
+					return
 				}
-			case <-asyncDone:
-				// Drain remaining logs (channel is closed by stopAsyncLogger)
-				for entry := range logChan {
-					logInternalSync(entry.level, entry.message, entry.pc, entry.keyValues...)
+				<-job.ready
+				select {
+				case <-abort:
+					// Rendered, but Shutdown has given up on the queue
+					recordDropped(*loadConfig())
+					job.buf.Reset()
+				default:
 				}
-				return
+				if job.buf.Len() > 0 {
+					_, _ = batch.WriteLevel(slogLevelFromLogLevel(job.entry.level), job.buf.Bytes())
+				}
+				renderBufferPool.Put(job.buf)
+				if spill != nil && len(order) == 0 {
+					drainSpill(spill, entries)
+				} else {
+					batch.flushIfDue(len(order) == 0)
+				}
+			case <-wake:
+				if len(order) == 0 {
+					drainSpill(spill, entries)
+				}
+			case <-ticker.C:
+				_ = batch.Flush()
 			}
 		}
 	}()
+
+	for entry := range entries {
+		select {
+		case <-abort:
+			recordDropped(*loadConfig())
+			continue
+		default:
+		}
+		buf := renderBufferPool.Get().(*bytes.Buffer)
+		buf.Reset()
+		job := &asyncJob{entry: entry, buf: buf, ready: make(chan struct{})}
+		order <- job
+		jobs <- job
+	}
+
+	close(jobs)
+	encoders.Wait()
+	close(order)
+	<-writerDone
+}
+
+// renderBufferPool holds buffers that ordered async workers render into
+var renderBufferPool = sync.Pool{
+	New: func() any {
+		return new(bytes.Buffer)
+	},
+}
+
+// batchWriter coalesces records written during async mode into fewer,
+// larger writes to the underlying writer
+type batchWriter struct {
+	mu       sync.Mutex
+	out      io.Writer
+	buf      []byte
+	runs     []batchRun // Consecutive records sharing a level, when out is a LevelWriter
+	pending  int
+	oldest   time.Time
+	maxBatch int
+	interval time.Duration
+	closed   bool
+}
+
+// batchRun marks where a run of same-level records ends in batchWriter.buf
+type batchRun struct {
+	level   slog.Level
+	leveled bool
+	end     int
+}
+
+func newBatchWriter(out io.Writer, maxBatch int, interval time.Duration) *batchWriter {
+	return &batchWriter{
+		out:      out,
+		maxBatch: maxBatch,
+		interval: interval,
+	}
+}
+
+// Write buffers one record, flushing once maxBatch records are pending.
+// After close it writes straight through so late records are not lost.
+func (b *batchWriter) Write(p []byte) (int, error) {
+	return b.write(0, false, p)
+}
+
+// WriteLevel is Write for a record of the given level, keeping level
+// routing intact when the underlying writer is a LevelWriter
+func (b *batchWriter) WriteLevel(level slog.Level, p []byte) (int, error) {
+	return b.write(level, true, p)
+}
+
+func (b *batchWriter) write(level slog.Level, leveled bool, p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, ok := b.out.(LevelWriter); !ok {
+		leveled = false
+	}
+
+	if b.closed {
+		if leveled {
+			return writeLevel(b.out, level, p)
+		}
+		return b.out.Write(p)
+	}
+
+	if b.pending == 0 {
+		b.oldest = time.Now()
+	}
+	b.buf = append(b.buf, p...)
+	b.pending++
+
+	if n := len(b.runs); n > 0 && b.runs[n-1].leveled == leveled && b.runs[n-1].level == level {
+		b.runs[n-1].end = len(b.buf)
+	} else {
+		b.runs = append(b.runs, batchRun{level: level, leveled: leveled, end: len(b.buf)})
+	}
+
+	if b.pending >= b.maxBatch {
+		// flushLocked reports failures and hands the batch to FallbackOutput
+		_ = b.flushLocked()
+	}
+	return len(p), nil
+}
+
+// Flush writes all pending records to the underlying writer
+func (b *batchWriter) Flush() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.flushLocked()
 }
 
-// stopAsyncLogger stops the async logging goroutine
+// flushIfDue flushes when the caller has nothing left to add or the oldest
+// pending record has waited longer than the batch interval
+func (b *batchWriter) flushIfDue(idle bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.pending > 0 && (idle || time.Since(b.oldest) >= b.interval) {
+		_ = b.flushLocked()
+	}
+}
+
+func (b *batchWriter) flushLocked() error {
+	if b.pending == 0 {
+		return nil
+	}
+	var err error
+	start := 0
+	for _, run := range b.runs {
+		chunk := b.buf[start:run.end]
+		start = run.end
+		var werr error
+		if run.leveled {
+			_, werr = writeLevel(b.out, run.level, chunk)
+		} else {
+			_, werr = b.out.Write(chunk)
+		}
+		if werr != nil {
+			cfg := *loadConfig()
+			reportWriteError(cfg, werr)
+			writeFallback(cfg, b.out, chunk)
+		}
+		if err == nil {
+			err = werr
+		}
+	}
+	b.buf = b.buf[:0]
+	b.runs = b.runs[:0]
+	b.pending = 0
+	return err
+}
+
+// close flushes pending records and switches to write-through mode
+func (b *batchWriter) close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	_ = b.flushLocked()
+	b.closed = true
+}
+
+// enqueueAsync hands entry to the async worker, applying cfg.AsyncOverflowPolicy
+// when the buffer is full. It reports false when the caller must log the entry
+// synchronously instead (worker not running, or OverflowFallbackSync).
+func enqueueAsync(entry *logEntry, cfg Config) bool {
+	// The read lock keeps stopAsyncLogger from closing the channel mid-send
+	asyncMu.RLock()
+	defer asyncMu.RUnlock()
+
+	if !asyncRunning {
+		return false
+	}
+
+	// While records wait in the spill, newer entries queue up behind them
+	// there so they are not written out of order
+	var spill *spillQueue
+	if cfg.AsyncOverflowPolicy == OverflowSpill {
+		spill = asyncSpill.Load()
+	}
+	if spill == nil || !spill.pending() {
+		select {
+		case logChan <- entry:
+			return true
+		default:
+		}
+	}
+
+	switch cfg.AsyncOverflowPolicy {
+	case OverflowBlock:
+		// Wait for room, but give way to a stop, which needs the write lock
+		select {
+		case logChan <- entry:
+			return true
+		case <-asyncStopped:
+			return false
+		}
+	case OverflowDropNewest:
+		recordDropped(cfg)
+		return true
+	case OverflowDropOldest:
+		// The worker competes for the same slots, so retry a few times before
+		// giving up on the new entry as well
+		for range 3 {
+			select {
+			case <-logChan:
+				recordDropped(cfg)
+			default:
+			}
+			select {
+			case logChan <- entry:
+				return true
+			default:
+			}
+		}
+		recordDropped(cfg)
+		return true
+	case OverflowSpill:
+		if spill == nil {
+			return false
+		}
+		spillEntry(spill, entry, cfg)
+		return true
+	default:
+		return false
+	}
+}
+
+// recordDropped counts an entry discarded by the overflow policy
+func recordDropped(cfg Config) {
+	if m := currentMetrics(); cfg.EnableMetrics && m != nil {
+		m.RecordDropped()
+	}
+}
+
+// stopAsyncLogger stops the async logging workers after they drain the queue
 func stopAsyncLogger() {
+	_, _ = stopAsyncLoggerContext(context.Background())
+}
+
+// stopAsyncLoggerContext stops the async logging workers and waits until
+// they drain the queue or ctx ends. In the latter case the workers discard
+// what is still queued and the number of entries abandoned is returned;
+// spilled entries stay on disk for the next run.
+func stopAsyncLoggerContext(ctx context.Context) (int, error) {
+	// Senders blocked on a full queue hold the read lock; wake them first
+	asyncMu.RLock()
+	if asyncStop != nil {
+		asyncStop()
+	}
+	asyncMu.RUnlock()
+
 	asyncMu.Lock()
 
 	if !asyncRunning {
 		asyncMu.Unlock()
-		return
+		return 0, nil
 	}
 
-	asyncDone <- true
+	entries, abort, wg := logChan, asyncAbort, asyncWg
+	spill, batch := asyncSpill.Load(), asyncBatch.Load()
+	close(asyncDone)
 	close(logChan)
 	asyncRunning = false
 	asyncMu.Unlock()
 
-	// Wait for goroutine to finish
-	asyncWg.Wait()
+	drained := make(chan struct{})
+	go func() {
+		defer close(drained)
+		// Wait for workers to finish, then write out whatever is still batched
+		wg.Wait()
+		if spill != nil {
+			spill.close()
+			asyncSpill.CompareAndSwap(spill, nil)
+		}
+		if batch != nil {
+			batch.close()
+			asyncBatch.CompareAndSwap(batch, nil)
+		}
+	}()
+
+	select {
+	case <-drained:
+		return 0, nil
+	case <-ctx.Done():
+		dropped := len(entries)
+		if spill != nil {
+			spill.abandoned.Store(true)
+		}
+		close(abort)
+		return dropped, ctx.Err()
+	}
 }
 
 // RotatingWriter wraps an io.Writer with rotation capabilities
@@ -194,6 +852,7 @@ type RotatingWriter struct {
 	config    *RotationConfig
 	openTime  time.Time
 	backupNum int
+	cleanMu   sync.Mutex // Serializes backup cleanup goroutines
 }
 
 // NewRotatingWriter creates a new rotating file writer
@@ -216,22 +875,26 @@ func NewRotatingWriter(filename string, config *RotationConfig) (*RotatingWriter
 	if err := w.openFile(); err != nil {
 		return nil, err
 	}
+	// Backups left by earlier runs count toward the limits too
+	w.cleanOldBackups(w.size)
 
 	return w, nil
 }
 
+// openFile opens the log file and takes its size; w is left untouched if
+// that fails
 func (w *RotatingWriter) openFile() error {
-	info, err := os.Stat(w.filename)
-	if err == nil {
-		w.size = info.Size()
-	}
-
 	file, err := os.OpenFile(w.filename, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
 	if err != nil {
 		return err
 	}
+	var size int64
+	if info, err := file.Stat(); err == nil {
+		size = info.Size()
+	}
 
 	w.file = file
+	w.size = size
 	w.openTime = time.Now()
 	return nil
 }
@@ -266,9 +929,10 @@ func (w *RotatingWriter) rotate() error {
 	if w.file != nil {
 		_ = w.file.Close()
 	} // Create backup filename
+	now := time.Now()
 	backupName := fmt.Sprintf("%s.%s.%d",
 		w.filename,
-		time.Now().Format("20060102-150405"),
+		now.Format(backupTimeLayout),
 		w.backupNum,
 	)
 	w.backupNum++
@@ -278,57 +942,126 @@ func (w *RotatingWriter) rotate() error {
 		return err
 	}
 
-	// Compress if needed
-	if w.config.Compress {
-		go compressFile(backupName)
+	// Compress and describe the backup if needed
+	if w.config.Compress || w.config.Manifest {
+		go finishBackup(backupName, w.openTime, now, w.config)
 	}
 
-	// Clean old backups
-	go w.cleanOldBackups()
+	// Open new file, then clean old backups around it
+	if err := w.openFile(); err != nil {
+		return err
+	}
+	go w.cleanOldBackups(w.size)
+	return nil
+}
+
+// backupTimeLayout is the timestamp embedded in rotated backup names
+const backupTimeLayout = "20060102-150405"
 
-	// Open new file
-	w.size = 0
-	return w.openFile()
+// backupFile is a rotated backup, possibly present both plain and gzipped
+// while compression is in flight
+type backupFile struct {
+	paths   []string
+	stamp   time.Time // Embedded rotation timestamp (zero if unparsable)
+	seq     int
+	modTime time.Time
+	size    int64
 }
 
-func (w *RotatingWriter) cleanOldBackups() {
-	if w.config.MaxBackups <= 0 {
-		return
+// listBackups returns the writer's backups sorted oldest first. Backups are
+// ordered by the timestamp embedded in their name, then modification time,
+// so lexical quirks such as ".10" sorting before ".9" cannot reorder them.
+func (w *RotatingWriter) listBackups() []*backupFile {
+	matches, err := filepath.Glob(w.filename + ".*")
+	if err != nil {
+		return nil
 	}
 
-	pattern := w.filename + ".*"
-	matches, err := filepath.Glob(pattern)
-	if err != nil {
+	prefix := w.filename + "."
+	byName := make(map[string]*backupFile, len(matches))
+	for _, path := range matches {
+		info, err := os.Stat(path)
+		if err != nil || info.IsDir() {
+			continue
+		}
+		// A backup's gzipped form and manifest belong to it
+		base := strings.TrimSuffix(strings.TrimSuffix(path, manifestSuffix), ".gz")
+		b, ok := byName[base]
+		if !ok {
+			b = &backupFile{}
+			stamp, seq, _ := strings.Cut(strings.TrimPrefix(base, prefix), ".")
+			if t, err := time.ParseInLocation(backupTimeLayout, stamp, time.Local); err == nil {
+				b.stamp = t
+			}
+			b.seq, _ = strconv.Atoi(seq)
+			byName[base] = b
+		}
+		b.paths = append(b.paths, path)
+		b.size += info.Size()
+		if info.ModTime().After(b.modTime) {
+			b.modTime = info.ModTime()
+		}
+	}
+
+	backups := slices.Collect(maps.Values(byName))
+	slices.SortFunc(backups, func(a, b *backupFile) int {
+		if c := a.stamp.Compare(b.stamp); c != 0 {
+			return c
+		}
+		if c := a.modTime.Compare(b.modTime); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.seq, b.seq)
+	})
+	return backups
+}
+
+// cleanOldBackups removes the oldest backups beyond MaxBackups, then keeps
+// removing the oldest until backups plus the active file fit MaxTotalSize
+func (w *RotatingWriter) cleanOldBackups(activeSize int64) {
+	if w.config.MaxBackups <= 0 && w.config.MaxTotalSize <= 0 {
 		return
 	}
 
-	if len(matches) > w.config.MaxBackups {
-		// Sort by modification time (oldest first) to handle clock adjustments
-		sort.Slice(matches, func(i, j int) bool {
-			fi, erri := os.Stat(matches[i])
-			fj, errj := os.Stat(matches[j])
-			if erri != nil || errj != nil {
-				return matches[i] < matches[j]
-			}
-			return fi.ModTime().Before(fj.ModTime())
-		})
-		// Remove oldest files
-		for i := 0; i < len(matches)-w.config.MaxBackups; i++ {
-			_ = os.Remove(matches[i])
+	w.cleanMu.Lock()
+	defer w.cleanMu.Unlock()
+
+	backups := w.listBackups()
+
+	remove := 0
+	if w.config.MaxBackups > 0 && len(backups) > w.config.MaxBackups {
+		remove = len(backups) - w.config.MaxBackups
+	}
+	if w.config.MaxTotalSize > 0 {
+		total := activeSize
+		for _, b := range backups[remove:] {
+			total += b.size
+		}
+		for remove < len(backups) && total > w.config.MaxTotalSize {
+			total -= backups[remove].size
+			remove++
+		}
+	}
+
+	for _, b := range backups[:remove] {
+		for _, path := range b.paths {
+			_ = os.Remove(path)
 		}
 	}
 }
 
-func compressFile(filename string) {
+// compressFile gzips filename, removing it on success, and returns the
+// path of the backup: filename+".gz", or filename if compression failed
+func compressFile(filename string) string {
 	src, err := os.Open(filename)
 	if err != nil {
-		return
+		return filename
 	}
 	defer func() { _ = src.Close() }()
 
 	dst, err := os.Create(filename + ".gz")
 	if err != nil {
-		return
+		return filename
 	}
 
 	gw := gzip.NewWriter(dst)
@@ -336,21 +1069,36 @@ func compressFile(filename string) {
 		_ = gw.Close()
 		_ = dst.Close()
 		_ = os.Remove(filename + ".gz")
-		return
+		return filename
 	}
 
 	if err := gw.Close(); err != nil {
 		_ = dst.Close()
 		_ = os.Remove(filename + ".gz")
-		return
+		return filename
 	}
 	if err := dst.Close(); err != nil {
 		_ = os.Remove(filename + ".gz")
-		return
+		return filename
 	}
 
 	_ = src.Close()
 	_ = os.Remove(filename)
+	return filename + ".gz"
+}
+
+// debugState describes the active file and its backups for DebugSnapshot
+func (w *RotatingWriter) debugState() *DebugRotation {
+	w.mu.Lock()
+	state := &DebugRotation{
+		Filename: w.filename,
+		Size:     w.size,
+		MaxSize:  w.config.MaxSize,
+		Opened:   w.openTime,
+	}
+	w.mu.Unlock()
+	state.Backups = len(w.listBackups())
+	return state
 }
 
 // Close closes the rotating writer
@@ -364,51 +1112,39 @@ func (w *RotatingWriter) Close() error {
 	return nil
 }
 
-// GetMetrics returns the current logger metrics
-func GetMetrics() map[string]any {
-	if metrics == nil {
-		return map[string]any{}
+// Reopen closes and reopens the log file by name. Call it after an external
+// tool (e.g. logrotate) has moved or truncated the file so writes stop going
+// to the renamed inode. On failure the previous file stays in use.
+func (w *RotatingWriter) Reopen() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	old := w.file
+	if err := w.openFile(); err != nil {
+		return err
+	}
+	if old != nil {
+		_ = old.Close()
 	}
-	return metrics.GetMetrics()
+	return nil
 }
 
-// MetricsHandler returns an http.Handler that serves metrics in Prometheus exposition format.
-// Enable metrics with logger.SetConfig(logger.Config{EnableMetrics: true}).
-func MetricsHandler() http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
-
-		if metrics == nil {
-			_, _ = fmt.Fprintln(w, "# No metrics collected (EnableMetrics is false)")
-			return
-		}
-
-		m := metrics.GetMetrics()
-
-		prefix := globalConfig.Load().MetricsPrefix
-		if prefix == "" {
-			prefix = "logger"
-		}
-
-		_, _ = fmt.Fprintf(w, "# HELP %s_logs_total Total number of log entries\n", prefix)
-		_, _ = fmt.Fprintf(w, "# TYPE %s_logs_total counter\n", prefix)
-		if total, ok := m["total_logs"].(int64); ok {
-			_, _ = fmt.Fprintf(w, "%s_logs_total %d\n", prefix, total)
-		}
-
-		_, _ = fmt.Fprintf(w, "# HELP %s_logs_by_level Log entries by level\n", prefix)
-		_, _ = fmt.Fprintf(w, "# TYPE %s_logs_by_level counter\n", prefix)
-		for _, level := range []string{"trace", "debug", "info", "notice", "warn", "error", "audit"} {
-			key := "logs_" + level
-			if count, ok := m[key]; ok {
-				_, _ = fmt.Fprintf(w, "%s_logs_by_level{level=%q} %v\n", prefix, level, count)
-			}
-		}
-
-		_, _ = fmt.Fprintf(w, "# HELP %s_error_rate Errors per second\n", prefix)
-		_, _ = fmt.Fprintf(w, "# TYPE %s_error_rate gauge\n", prefix)
-		if rate, ok := m["error_rate"].(float64); ok {
-			_, _ = fmt.Fprintf(w, "%s_error_rate %f\n", prefix, rate)
+// GetMetrics returns the current logger metrics. In AsyncMode it also
+// reports the queue occupancy as async_queue_depth and async_queue_capacity.
+func GetMetrics() map[string]any {
+	m := currentMetrics()
+	if m == nil {
+		return map[string]any{}
+	}
+	result := m.GetMetrics()
+	if loadConfig().AsyncMode {
+		asyncMu.RLock()
+		result["async_queue_depth"] = len(logChan)
+		result["async_queue_capacity"] = cap(logChan)
+		if s := asyncSpill.Load(); s != nil {
+			result["async_spill_bytes"] = s.size.Load()
 		}
-	})
+		asyncMu.RUnlock()
+	}
+	return result
 }
@@ -2,10 +2,16 @@ package logger
 
 import (
 	"bytes"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -61,6 +67,18 @@ func TestLogSampling(t *testing.T) {
 			message:    "test message",
 			expectLog:  true, // With seed 42, this particular message passes
 		},
+		{
+			name:       "zero rate means unset",
+			sampleRate: 0,
+			message:    "test message",
+			expectLog:  true,
+		},
+		{
+			name:       "SampleOff never logs",
+			sampleRate: SampleOff,
+			message:    "test message",
+			expectLog:  false,
+		},
 	}
 
 	for _, tt := range tests {
@@ -88,6 +106,21 @@ func TestLogSampling(t *testing.T) {
 	}
 }
 
+func TestSampleRateValidate(t *testing.T) {
+	for _, rate := range []float64{-0.5, 1.5} {
+		cfg := defaultConfig
+		cfg.SampleRate = rate
+		if err := cfg.Validate(); err == nil {
+			t.Errorf("Expected error for SampleRate %v", rate)
+		}
+	}
+	cfg := defaultConfig
+	cfg.SampleRate = SampleOff
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Expected SampleOff to be valid, got %v", err)
+	}
+}
+
 func TestLogRotation(t *testing.T) {
 	tmpDir := t.TempDir()
 	logFile := filepath.Join(tmpDir, "test.log")
@@ -289,6 +322,168 @@ func TestMetricsConcurrency(t *testing.T) {
 	}
 }
 
+func TestMetricsRates(t *testing.T) {
+	m := NewLogMetrics()
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	// 30 errors 10 minutes ago, then 6 errors and 12 warnings in the last minute
+	for range 30 {
+		m.recordAt(Error, start.Add(-10*time.Minute))
+	}
+	for i := range 6 {
+		m.recordAt(Error, start.Add(-time.Duration(i)*5*time.Second))
+	}
+	for range 12 {
+		m.recordAt(Warn, start)
+	}
+
+	got := m.metricsAt(start)
+	want := map[string]float64{
+		"rate_error_1m":  6,
+		"rate_error_5m":  6.0 / 5,
+		"rate_error_15m": 36.0 / 15,
+		"rate_warn_1m":   12,
+		"error_rate":     6.0 / 60,
+	}
+	for key, rate := range want {
+		if got[key] != rate {
+			t.Errorf("%s = %v, want %v", key, got[key], rate)
+		}
+	}
+	if _, ok := got["rate_info_1m"]; ok {
+		t.Error("expected no rate for a level that was never logged")
+	}
+
+	// Twenty minutes later everything has left the windows, and a slot reused
+	// for a new bucket starts from zero
+	later := start.Add(20 * time.Minute)
+	if rate := m.metricsAt(later)["rate_error_15m"]; rate != 0.0 {
+		t.Errorf("expected stale buckets to be ignored, got %v", rate)
+	}
+	m.recordAt(Error, later)
+	if rate := m.metricsAt(later)["rate_error_1m"]; rate != 1.0 {
+		t.Errorf("expected 1 error per minute after slot reuse, got %v", rate)
+	}
+}
+
+func TestMetricsHiddenRecords(t *testing.T) {
+	SetConfig(Config{
+		Output:         io.Discard,
+		Level:          slog.LevelInfo,
+		LevelSet:       true,
+		EnableMetrics:  true,
+		EnableDedup:    true,
+		DedupWindow:    time.Minute,
+		Filters:        []FilterRule{{Message: "^healthz$"}},
+		RedactPatterns: []string{`^\d{16}$`},
+	})
+	defer SetConfig(Config{Output: io.Discard, Level: LevelTrace})
+	base := GetMetrics()
+
+	LogDebug("below level")
+	LogTrace("below level")
+	LogInfo("healthz")
+	LogInfo("same")
+	LogInfo("same")
+	LogInfo("secrets", "password", "hunter2", "card", "4111111111111111", Group("auth", "token", "t"))
+
+	got := GetMetrics()
+	want := map[string]int64{
+		"suppressed_logs":   2,
+		"filtered_logs":     1,
+		"deduplicated_logs": 1,
+		"redacted_values":   3,
+	}
+	for key, n := range want {
+		if delta := got[key].(int64) - base[key].(int64); delta != n {
+			t.Errorf("%s increased by %d, want %d", key, delta, n)
+		}
+	}
+
+	SetConfig(Config{
+		Output:        io.Discard,
+		Level:         slog.LevelInfo,
+		LevelSet:      true,
+		EnableMetrics: true,
+		SampleRate:    0,
+		SampleRateSet: true,
+	})
+	before := GetMetrics()["sampled_out_logs"].(int64)
+	LogInfo("sampled out")
+	if after := GetMetrics()["sampled_out_logs"].(int64); after != before+1 {
+		t.Errorf("sampled_out_logs = %d, want %d", after, before+1)
+	}
+}
+
+func TestLatencyHistogram(t *testing.T) {
+	var h latencyHistogram
+	for _, d := range []time.Duration{500 * time.Nanosecond, time.Microsecond, 3 * time.Millisecond, 2 * time.Second} {
+		h.observe(d)
+	}
+
+	snap := h.snapshot()
+	if snap.Count != 4 || snap.Sum != 2*time.Second+3*time.Millisecond+1500*time.Nanosecond {
+		t.Errorf("count = %d, sum = %v", snap.Count, snap.Sum)
+	}
+	want := map[time.Duration]int64{time.Microsecond: 2, time.Millisecond: 2, 5 * time.Millisecond: 3, time.Second: 3}
+	for _, b := range snap.Buckets {
+		if n, ok := want[b.UpperBound]; ok && b.Count != n {
+			t.Errorf("bucket le=%v has %d, want %d", b.UpperBound, b.Count, n)
+		}
+	}
+}
+
+func TestMetricsWriteLatencyAndQueue(t *testing.T) {
+	SetConfig(Config{
+		Output:        io.Discard,
+		Level:         slog.LevelInfo,
+		LevelSet:      true,
+		EnableMetrics: true,
+		AsyncMode:     true,
+		BufferSize:    64,
+	})
+	defer SetConfig(Config{Output: io.Discard, Level: LevelTrace})
+
+	for range 10 {
+		LogInfo("timed")
+	}
+
+	m := GetMetrics()
+	if capacity := m["async_queue_capacity"]; capacity != 64 {
+		t.Errorf("async_queue_capacity = %v, want 64", capacity)
+	}
+	if _, ok := m["async_queue_depth"].(int); !ok {
+		t.Errorf("expected async_queue_depth, got %v", m)
+	}
+	rec := httptest.NewRecorder()
+	MetricsHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if !strings.Contains(rec.Body.String(), "logger_async_queue_capacity 64") {
+		t.Errorf("expected the queue capacity gauge in:\n%s", rec.Body.String())
+	}
+
+	// Leaving async mode drains the queue
+	SetConfig(Config{Output: io.Discard, Level: slog.LevelInfo, LevelSet: true, EnableMetrics: true})
+
+	m = GetMetrics()
+	if latency := m["write_latency"].(HistogramSnapshot); latency.Count < 10 {
+		t.Errorf("expected at least 10 latency observations, got %d", latency.Count)
+	}
+	if _, ok := m["async_queue_depth"]; ok {
+		t.Error("expected no queue depth outside async mode")
+	}
+	rec = httptest.NewRecorder()
+	MetricsHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	for _, want := range []string{
+		`logger_write_latency_seconds_bucket{le="0.001"}`,
+		`logger_write_latency_seconds_bucket{le="+Inf"}`,
+		"logger_write_latency_seconds_count",
+	} {
+		if !strings.Contains(rec.Body.String(), want) {
+			t.Errorf("expected %s in:\n%s", want, rec.Body.String())
+		}
+	}
+}
+
 func TestRotatingWriterCompression(t *testing.T) {
 	tmpDir := t.TempDir()
 	logFile := filepath.Join(tmpDir, "compress.log")
@@ -387,3 +582,324 @@ func TestRotatingWriterDefaultConfig(t *testing.T) {
 		t.Error("Expected log file to be created")
 	}
 }
+
+// blockingWriter blocks every Write until release is closed
+type blockingWriter struct {
+	*syncWriter
+	started chan struct{}
+	release chan struct{}
+	once    sync.Once
+}
+
+func newBlockingWriter() *blockingWriter {
+	return &blockingWriter{
+		syncWriter: newSyncWriter(),
+		started:    make(chan struct{}),
+		release:    make(chan struct{}),
+	}
+}
+
+func (bw *blockingWriter) Write(p []byte) (int, error) {
+	bw.once.Do(func() { close(bw.started) })
+	<-bw.release
+	return bw.syncWriter.Write(p)
+}
+
+func TestAsyncOverflowPolicies(t *testing.T) {
+	tests := []struct {
+		name        string
+		policy      OverflowPolicy
+		wantDropped bool
+	}{
+		{"drop newest", OverflowDropNewest, true},
+		{"drop oldest", OverflowDropOldest, true},
+		{"block", OverflowBlock, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bw := newBlockingWriter()
+			SetConfig(Config{
+				Output:              bw,
+				Level:               LevelTrace,
+				AsyncMode:           true,
+				BufferSize:          2,
+				FlushTimeout:        time.Hour,
+				AsyncOverflowPolicy: tt.policy,
+				EnableMetrics:       true,
+			})
+
+			// Park the worker inside Write so the buffer cannot drain
+			LogInfo("first")
+			<-bw.started
+
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				for i := range 10 {
+					LogInfo("overflow", "n", i)
+				}
+			}()
+
+			if tt.policy == OverflowBlock {
+				select {
+				case <-done:
+					t.Fatal("Expected OverflowBlock to block while the buffer is full")
+				case <-time.After(50 * time.Millisecond):
+				}
+			}
+			close(bw.release)
+			<-done
+
+			SetConfig(Config{Output: bw, Level: LevelTrace, EnableMetrics: true})
+
+			dropped := GetMetrics()["dropped_logs"].(int64)
+			if tt.wantDropped && dropped == 0 {
+				t.Error("Expected dropped entries to be counted")
+			}
+			if !tt.wantDropped && dropped != 0 {
+				t.Errorf("Expected no dropped entries, got %d", dropped)
+			}
+			if !tt.wantDropped {
+				if n := strings.Count(bw.String(), "overflow"); n != 10 {
+					t.Errorf("Expected 10 entries written, got %d", n)
+				}
+			}
+
+			SetConfig(Config{Output: bw, Level: LevelTrace})
+		})
+	}
+}
+
+func TestAsyncOverflowPolicyValidate(t *testing.T) {
+	cfg := defaultConfig
+	cfg.AsyncOverflowPolicy = OverflowPolicy(42)
+	if err := cfg.Validate(); err == nil {
+		t.Error("Expected error for unknown AsyncOverflowPolicy")
+	}
+}
+
+// countingWriter counts Write calls
+type countingWriter struct {
+	*blockingWriter
+	writes atomic.Int64
+}
+
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	cw.writes.Add(1)
+	return cw.blockingWriter.Write(p)
+}
+
+func TestAsyncBatchedWrites(t *testing.T) {
+	cw := &countingWriter{blockingWriter: newBlockingWriter()}
+	SetConfig(Config{
+		Output:         cw,
+		Level:          LevelTrace,
+		AsyncMode:      true,
+		BufferSize:     100,
+		FlushTimeout:   time.Hour,
+		AsyncBatchSize: 50,
+	})
+
+	// Hold the worker in its first flush while the queue fills up
+	LogInfo("first")
+	<-cw.started
+	for i := range 20 {
+		LogInfo("batched", "n", i)
+	}
+	close(cw.release)
+
+	SetConfig(Config{Output: cw, Level: LevelTrace})
+
+	if n := strings.Count(cw.String(), "batched"); n != 20 {
+		t.Errorf("Expected 20 batched entries, got %d", n)
+	}
+	if w := cw.writes.Load(); w > 3 {
+		t.Errorf("Expected queued entries to be coalesced into few writes, got %d writes", w)
+	}
+}
+
+func TestBatchWriterFlushesAtMaxBatch(t *testing.T) {
+	var buf bytes.Buffer
+	b := newBatchWriter(&buf, 3, time.Hour)
+
+	_, _ = b.Write([]byte("a\n"))
+	_, _ = b.Write([]byte("b\n"))
+	if buf.Len() != 0 {
+		t.Fatalf("Expected nothing written before the batch is full, got %q", buf.String())
+	}
+	_, _ = b.Write([]byte("c\n"))
+	if buf.String() != "a\nb\nc\n" {
+		t.Errorf("Expected full batch in one write, got %q", buf.String())
+	}
+
+	b.close()
+	_, _ = b.Write([]byte("d\n"))
+	if !strings.HasSuffix(buf.String(), "d\n") {
+		t.Error("Expected write-through after close")
+	}
+}
+
+func TestAsyncWorkerPool(t *testing.T) {
+	tests := []struct {
+		name      string
+		unordered bool
+	}{
+		{"ordered", false},
+		{"unordered", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sw := newSyncWriter()
+			SetConfig(Config{
+				Output:              sw,
+				Level:               LevelTrace,
+				EnableColor:         false,
+				AsyncMode:           true,
+				BufferSize:          64,
+				AsyncWorkers:        4,
+				AsyncUnordered:      tt.unordered,
+				AsyncOverflowPolicy: OverflowBlock,
+			})
+
+			const total = 200
+			for i := range total {
+				LogInfo(fmt.Sprintf("pool-%03d", i), "payload", map[string]any{"n": i})
+			}
+
+			SetConfig(Config{Output: sw, Level: LevelTrace})
+
+			lines := strings.Split(strings.TrimSpace(sw.String()), "\n")
+			var seen []int
+			for _, line := range lines {
+				if idx := strings.Index(line, "pool-"); idx >= 0 {
+					var n int
+					_, _ = fmt.Sscanf(line[idx:], "pool-%03d", &n)
+					seen = append(seen, n)
+				}
+			}
+			if len(seen) != total {
+				t.Fatalf("Expected %d entries, got %d", total, len(seen))
+			}
+			if !tt.unordered {
+				for i, n := range seen {
+					if n != i {
+						t.Fatalf("Expected submission order, entry %d was pool-%03d", i, n)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestAsyncReconfigureOutput(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		cfg  Config
+	}{
+		{"batched", Config{AsyncBatchSize: 10}},
+		{"workers", Config{AsyncWorkers: 2}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			first, second := newSyncWriter(), newSyncWriter()
+			cfg := tt.cfg
+			cfg.Level, cfg.AsyncMode, cfg.FlushTimeout = LevelTrace, true, time.Hour
+			cfg.Output = first
+			SetConfig(cfg)
+			LogInfo("to first")
+
+			cfg.Output = second
+			SetConfig(cfg)
+			LogInfo("to second")
+			SetConfig(Config{Output: second, Level: LevelTrace})
+
+			if !strings.Contains(first.String(), "to first") || strings.Contains(first.String(), "to second") {
+				t.Errorf("Expected only the first record in the old output, got %q", first.String())
+			}
+			if !strings.Contains(second.String(), "to second") {
+				t.Errorf("Expected the second record in the new output, got %q", second.String())
+			}
+		})
+	}
+}
+
+func TestRotatingWriterCleanupOrdersByTimestamp(t *testing.T) {
+	tmpDir := t.TempDir()
+	logFile := filepath.Join(tmpDir, "order.log")
+
+	writer, err := NewRotatingWriter(logFile, &RotationConfig{MaxBackups: 2})
+	if err != nil {
+		t.Fatalf("Failed to create rotating writer: %v", err)
+	}
+	defer func() { _ = writer.Close() }()
+
+	// Lexical order (".10" < ".9", "0101" < "0102") disagrees with age, and
+	// modification times are set newest-first to prove they are not trusted
+	backups := []string{
+		logFile + ".20240103-000000.9",  // newest
+		logFile + ".20240103-000000.10", // newest, later sequence
+		logFile + ".20240102-000000.1",
+		logFile + ".20240101-000000.2.gz", // oldest
+	}
+	now := time.Now()
+	for i, name := range backups {
+		if err := os.WriteFile(name, []byte("x"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		mtime := now.Add(-time.Duration(i) * time.Hour)
+		_ = os.Chtimes(name, mtime, mtime)
+	}
+
+	writer.cleanOldBackups(0)
+
+	for i, name := range backups {
+		_, err := os.Stat(name)
+		kept := err == nil
+		if wantKept := i < 2; kept != wantKept {
+			t.Errorf("%s: kept=%v, want %v", filepath.Base(name), kept, wantKept)
+		}
+	}
+}
+
+func TestRotatingWriterMaxTotalSize(t *testing.T) {
+	// 4 backups of 100 bytes and a 50-byte active file: 250 keeps exactly
+	// the 2 newest backups, one byte less only the newest
+	for _, tc := range []struct {
+		budget int64
+		kept   []string
+	}{
+		{250, []string{"20240103", "20240104"}},
+		{249, []string{"20240104"}},
+		{450, []string{"20240101", "20240102", "20240103", "20240104"}},
+	} {
+		t.Run(fmt.Sprint(tc.budget), func(t *testing.T) {
+			logFile := filepath.Join(t.TempDir(), "budget.log")
+			for day := 1; day <= 4; day++ {
+				name := fmt.Sprintf("%s.2024010%d-000000.0", logFile, day)
+				if err := os.WriteFile(name, bytes.Repeat([]byte("x"), 100), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := os.WriteFile(logFile, bytes.Repeat([]byte("x"), 50), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			writer, err := NewRotatingWriter(logFile, &RotationConfig{MaxTotalSize: tc.budget})
+			if err != nil {
+				t.Fatalf("Failed to create rotating writer: %v", err)
+			}
+			defer func() { _ = writer.Close() }()
+
+			matches, _ := filepath.Glob(logFile + ".*")
+			if len(matches) != len(tc.kept) {
+				t.Fatalf("Expected %d backups within the byte budget, got %v", len(tc.kept), matches)
+			}
+			for i, m := range matches {
+				if !strings.Contains(m, tc.kept[i]) {
+					t.Errorf("Expected only the newest backups to remain, found %s", filepath.Base(m))
+				}
+			}
+		})
+	}
+}
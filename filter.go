@@ -0,0 +1,148 @@
+package logger
+
+import (
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strings"
+	"sync/atomic"
+)
+
+// FilterAction is what a matching FilterRule does with a record
+type FilterAction int
+
+const (
+	// FilterDrop discards matching records (default)
+	FilterDrop FilterAction = iota
+	// FilterKeep writes matching records, shielding them from later rules
+	FilterKeep
+)
+
+// String returns the action name
+func (a FilterAction) String() string {
+	switch a {
+	case FilterDrop:
+		return "drop"
+	case FilterKeep:
+		return "keep"
+	default:
+		return "unknown"
+	}
+}
+
+// FilterRule matches records by message, module, attribute and level. Every
+// condition that is set must hold. Rules in Config.Filters are checked in
+// order and the first match decides; records no rule matches are written.
+//
+//	Filters: []logger.FilterRule{
+//		{Key: "__path", Value: `^/healthz$`},                 // Drop health checks
+//		{Module: "cache", Below: logger.LevelWarn},           // Only Warn and up from "cache"
+//		{Message: `^retrying`, Key: "attempt", Value: `^1$`}, // Drop first retries
+//	}
+type FilterRule struct {
+	Action  FilterAction
+	Message string       // Regexp the message must match
+	Module  string       // Module (see Named) the record must come from; covers dotted children
+	Key     string       // Attribute the record must have
+	Value   string       // Regexp the Key attribute's value must match (requires Key)
+	Below   slog.Leveler // Only records below this level match (nil = any level)
+}
+
+// compiledFilter is a FilterRule with its regexps compiled
+type compiledFilter struct {
+	rule    FilterRule
+	message *regexp.Regexp
+	value   *regexp.Regexp
+}
+
+// activeFilters holds the compiled Config.Filters, rebuilt by initLogger
+var activeFilters atomic.Pointer[[]compiledFilter]
+
+// compileFilters compiles rules, reporting the first invalid one
+func compileFilters(rules []FilterRule) ([]compiledFilter, error) {
+	compiled := make([]compiledFilter, 0, len(rules))
+	for i, rule := range rules {
+		f := compiledFilter{rule: rule}
+		if rule.Action < FilterDrop || rule.Action > FilterKeep {
+			return nil, fmt.Errorf("filter %d: invalid Action %d", i, rule.Action)
+		}
+		if rule.Value != "" && rule.Key == "" {
+			return nil, fmt.Errorf("filter %d: Value requires Key", i)
+		}
+		var err error
+		if rule.Message != "" {
+			if f.message, err = regexp.Compile(rule.Message); err != nil {
+				return nil, fmt.Errorf("filter %d: invalid Message pattern: %w", i, err)
+			}
+		}
+		if rule.Value != "" {
+			if f.value, err = regexp.Compile(rule.Value); err != nil {
+				return nil, fmt.Errorf("filter %d: invalid Value pattern: %w", i, err)
+			}
+		}
+		compiled = append(compiled, f)
+	}
+	return compiled, nil
+}
+
+// setFilters installs the compiled filters for cfg
+func setFilters(cfg Config) {
+	compiled, err := compileFilters(cfg.Filters)
+	if err != nil || len(compiled) == 0 {
+		// Validate rejects invalid rules before they get here
+		activeFilters.Store(nil)
+		return
+	}
+	activeFilters.Store(&compiled)
+}
+
+// filterAllows reports whether the active filters let the record through
+func filterAllows(module string, level LogLevel, message string, keyValues []any) bool {
+	p := activeFilters.Load()
+	if p == nil {
+		return true
+	}
+	for i := range *p {
+		f := &(*p)[i]
+		if f.matches(module, level, message, keyValues) {
+			return f.rule.Action == FilterKeep
+		}
+	}
+	return true
+}
+
+// matches reports whether every condition of the rule holds
+func (f *compiledFilter) matches(module string, level LogLevel, message string, keyValues []any) bool {
+	if f.rule.Below != nil && slogLevelFromLogLevel(level) >= f.rule.Below.Level() {
+		return false
+	}
+	if f.rule.Module != "" && module != f.rule.Module && !strings.HasPrefix(module, f.rule.Module+".") {
+		return false
+	}
+	if f.message != nil && !f.message.MatchString(message) {
+		return false
+	}
+	if f.rule.Key == "" {
+		return true
+	}
+	for i := 0; i < len(keyValues); i += pairLen(keyValues, i) {
+		if a, ok := keyValues[i].(slog.Attr); ok {
+			if a.Key != f.rule.Key {
+				continue
+			}
+			return f.value == nil || f.value.MatchString(a.Value.String())
+		}
+		if i+1 >= len(keyValues) || attrKey(keyValues[i]) != f.rule.Key {
+			continue
+		}
+		if f.value == nil {
+			return true
+		}
+		value, ok := keyValues[i+1].(string)
+		if !ok {
+			value = fmt.Sprint(keyValues[i+1])
+		}
+		return f.value.MatchString(value)
+	}
+	return false
+}
@@ -0,0 +1,61 @@
+package logger
+
+import (
+	"bytes"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestFilters(t *testing.T) {
+	var buf bytes.Buffer
+	SetConfig(Config{
+		Output:      &buf,
+		Level:       slog.LevelInfo,
+		LevelSet:    true,
+		CompactJSON: true,
+		Filters: []FilterRule{
+			{Action: FilterKeep, Key: "__path", Value: `^/healthz$`, Message: `\[500\]`},
+			{Key: "__path", Value: `^/healthz$`},
+			{Module: "cache", Below: LevelWarn},
+			{Message: `^retrying`, Key: "attempt", Value: `^1$`},
+		},
+	})
+	defer SetConfig(Config{Output: io.Discard, Level: LevelTrace})
+
+	LogInfo("GET /healthz [200]", "__path", "/healthz")
+	LogError("GET /healthz [500]", "__path", "/healthz")
+	Named("cache.lru").LogInfo("cache hit")
+	Named("cache").LogWarn("cache full")
+	Named("db").LogInfo("db info")
+	LogInfo("retrying", "attempt", 1)
+	LogInfo("retrying", "attempt", 2)
+
+	out := buf.String()
+	for _, want := range []string{"GET /healthz [500]", "cache full", "db info", `"attempt":2`} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in output:\n%s", want, out)
+		}
+	}
+	for _, unwanted := range []string{"GET /healthz [200]", "cache hit", `"attempt":1`} {
+		if strings.Contains(out, unwanted) {
+			t.Errorf("did not expect %q in output:\n%s", unwanted, out)
+		}
+	}
+}
+
+func TestFiltersValidate(t *testing.T) {
+	for _, rules := range [][]FilterRule{
+		{{Value: "x"}},
+		{{Message: "("}},
+		{{Key: "k", Value: "["}},
+		{{Action: FilterAction(5)}},
+	} {
+		cfg := defaultConfig
+		cfg.Filters = rules
+		if err := cfg.Validate(); err == nil {
+			t.Errorf("expected validation error for %+v", rules)
+		}
+	}
+}
@@ -3,13 +3,15 @@ package logger
 import (
 	"fmt"
 	"net/url"
+	"strconv"
 	"strings"
 )
 
 type color int
 
 const (
-	Blue color = iota
+	NoColor color = iota // Keeps the terminal's text color
+	Blue
 	Cyan
 	Green
 	Purple
@@ -33,6 +35,20 @@ const (
 	brightCyan = BrightCyan
 )
 
+// colorCodes holds the ANSI foreground sequence of each color
+var colorCodes = [...]string{
+	NoColor:    "",
+	Blue:       "\033[34m",
+	Cyan:       "\033[36m",
+	Green:      "\033[32m",
+	Purple:     "\033[35m",
+	Red:        "\033[31m",
+	Yellow:     "\033[33m",
+	Gray:       "\033[90m",
+	Magenta:    "\033[95m", // Bright magenta
+	BrightCyan: "\033[96m", // Bright cyan
+}
+
 // FormatString applies ANSI color codes to the given text
 func FormatString(text string, c color, bold bool) string {
 	return formatString(text, c, bold)
@@ -40,83 +56,76 @@ func FormatString(text string, c color, bold bool) string {
 
 // formatString applies ANSI color codes to the given text (internal)
 func formatString(text string, c color, bold bool) string {
-	var colorCode string
-	if bold {
-		colorCode = "\033[1m"
-	}
-
-	colorReset := "\033[0m"
-
-	switch c {
-	case blue:
-		colorCode += "\033[34m"
-	case cyan:
-		colorCode += "\033[36m"
-	case green:
-		colorCode += "\033[032m"
-	case purple:
-		colorCode += "\033[35m"
-	case red:
-		colorCode += "\033[31m"
-	case yellow:
-		colorCode += "\033[33m"
-	case gray:
-		colorCode += "\033[90m"
-	case magenta:
-		colorCode += "\033[95m" // Bright magenta
-	case brightCyan:
-		colorCode += "\033[96m" // Bright cyan
-	default:
-		colorCode += colorReset
+	if c < NoColor || int(c) >= len(colorCodes) {
+		c = NoColor
 	}
-
-	return fmt.Sprintf("%s%s%s", colorCode, text, colorReset)
+	return string(Style{Color: c, Bold: bold}.appendStyled(nil, text))
 }
 
-// getFullPath constructs the full path including query parameters
+// getFullPath constructs the full path including query parameters, with
+// the values of RedactQueryParams masked
 func getFullPath(u *url.URL) string {
 	if u.RawQuery == "" {
 		return u.Path
 	}
-	return fmt.Sprintf("%s?%s", u.Path, u.RawQuery)
+	cfg := loadConfig()
+	return fmt.Sprintf("%s?%s", u.Path, redactQuery(u.RawQuery, cfg.RedactQueryParams, cfg.RedactMask))
 }
 
-// formatStatusCode returns the status code as a string with appropriate color formatting
-func formatStatusCode(code int) (string, LogLevel) {
-	var (
-		statusCode string
-		logLevel   = Info
-	)
+// redactQuery masks the values of the params in a raw query, keeping the
+// order and encoding of the other parameters
+func redactQuery(rawQuery string, params []string, mask string) string {
+	if len(params) == 0 {
+		return rawQuery
+	}
+	var b strings.Builder
+	for i, part := range strings.Split(rawQuery, "&") {
+		if i > 0 {
+			b.WriteByte('&')
+		}
+		rawName, _, hasValue := strings.Cut(part, "=")
+		name, err := url.QueryUnescape(rawName)
+		if err != nil {
+			name = rawName
+		}
+		if hasValue && isSensitiveKey(name, params) {
+			recordRedacted()
+			b.WriteString(rawName)
+			b.WriteByte('=')
+			b.WriteString(mask)
+			continue
+		}
+		b.WriteString(part)
+	}
+	return b.String()
+}
 
-	switch code / 100 {
-	case 2:
-		statusCode = formatString(fmt.Sprintf("%d", code), green, false)
-	case 3:
-		statusCode = formatString(fmt.Sprintf("%d", code), blue, false)
-	case 4, 5:
-		statusCode = formatString(fmt.Sprintf("%d", code), red, false)
+// formatStatusCode returns the status code styled by the configured theme
+// and the level to log it at
+func formatStatusCode(code int) (string, LogLevel) {
+	logLevel := Info
+	if code >= 400 && code < 600 {
 		logLevel = Error
-	default:
-		statusCode = fmt.Sprintf("%d", code)
 	}
-
-	return statusCode, logLevel
+	return configTheme(*loadConfig()).statusStyle(code).render(strconv.Itoa(code)), logLevel
 }
 
+// isSensitiveKey reports whether key matches one of the redactKeys
+// patterns (see matchKey)
 func isSensitiveKey(key string, redactKeys []string) bool {
 	for _, k := range redactKeys {
-		if strings.EqualFold(k, key) {
+		if matchKey(k, key) {
 			return true
 		}
 	}
 	return false
 }
 
-func redactValueIfNeeded(key string, value any, cfg Config) any {
-	if isSensitiveKey(key, cfg.RedactKeys) {
-		return cfg.RedactMask
-	}
-	return value
+// redactValueIfNeeded applies Redactor, RedactRules and RedactKeys to the
+// value of key; false means the attribute is removed
+func redactValueIfNeeded(key string, value any, cfg Config) (any, bool) {
+	out, keep, _ := redactField(key, value, &cfg)
+	return out, keep
 }
 
 // FormatStatusCode returns the formatted status code and the appropriate log level (exported for middleware)
@@ -124,7 +133,8 @@ func FormatStatusCode(statusCode int) (string, LogLevel) {
 	return formatStatusCode(statusCode)
 }
 
-// GetFullPath builds the full URL path with query parameters (exported for middleware)
+// GetFullPath builds the full URL path with query parameters, masking the
+// values of RedactQueryParams (exported for middleware)
 func GetFullPath(u *url.URL) string {
 	return getFullPath(u)
 }
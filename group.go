@@ -0,0 +1,86 @@
+package logger
+
+import "log/slog"
+
+// Group nests related attributes under one key, so they are written as a
+// JSON object instead of flattened to the top level:
+//
+//	logger.LogInfo("query done", logger.Group("db", "query", q, "rows", n))
+//	// {"db":{"query":"SELECT ...","rows":3}}
+//
+// keyValues take the same form as the logging functions and may contain
+// slog.Attr values, including nested groups. slog.Group attrs are accepted
+// the same way.
+func Group(name string, keyValues ...any) slog.Attr {
+	attrs := make([]slog.Attr, 0, len(keyValues)/2)
+	for i := 0; i < len(keyValues); i += pairLen(keyValues, i) {
+		if a, ok := keyValues[i].(slog.Attr); ok {
+			attrs = append(attrs, a)
+			continue
+		}
+		key := attrKey(keyValues[i])
+		var value any = "MISSING_VALUE"
+		if i+1 < len(keyValues) {
+			value = keyValues[i+1]
+		}
+		switch v := value.(type) {
+		case LazyValue:
+			// Stays unevaluated until the record is written
+			attrs = append(attrs, slog.Any(key, v))
+		case func() any:
+			attrs = append(attrs, slog.Any(key, LazyValue(v)))
+		default:
+			attrs = append(attrs, convertToSlogAttr(key, value, loadConfig().StrictTypes))
+		}
+	}
+	return slog.Attr{Key: name, Value: slog.GroupValue(attrs...)}
+}
+
+// pairLen returns how many keyValues entries the attribute at i spans: a
+// slog.Attr stands on its own, anything else is a key followed by its value
+func pairLen(keyValues []any, i int) int {
+	if _, ok := keyValues[i].(slog.Attr); ok {
+		return 1
+	}
+	return 2
+}
+
+// redactAttr applies key redaction to a and, for groups and composite
+// values, to every member, resolving slog.LogValuer values only when they
+// are not redacted. A removed attribute is returned as the zero Attr.
+func redactAttr(a slog.Attr, cfg Config) slog.Attr {
+	var value any = a.Value
+	if cfg.Redactor != nil {
+		// The Redactor sees Go values, not slog.Values
+		a.Value = a.Value.Resolve()
+		value = a.Value.Any()
+	}
+	if a.Value.Kind() != slog.KindGroup {
+		if v, keep, redacted := redactField(a.Key, value, &cfg); redacted {
+			if !keep {
+				return slog.Attr{}
+			}
+			return slog.Any(a.Key, v)
+		}
+	} else if s, ok := redactRule(a.Key, &cfg); ok {
+		// A whole group behind a redacted key
+		v, keep := redactValue(s, a.Value, &cfg)
+		if !keep {
+			return slog.Attr{}
+		}
+		return slog.Any(a.Key, v)
+	}
+	a.Value = a.Value.Resolve()
+	if a.Value.Kind() != slog.KindGroup {
+		return redactComposite(a, &cfg)
+	}
+	group := a.Value.Group()
+	redacted := make([]slog.Attr, 0, len(group))
+	for _, ga := range group {
+		if ga = redactAttr(ga, cfg); !ga.Equal(slog.Attr{}) {
+			redacted = append(redacted, ga)
+		}
+	}
+	a.Value = slog.GroupValue(redacted...)
+	return a
+}
@@ -0,0 +1,56 @@
+package logger
+
+import (
+	"bytes"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestGroup(t *testing.T) {
+	var buf bytes.Buffer
+	SetConfig(Config{
+		Output:      &buf,
+		Level:       slog.LevelInfo,
+		LevelSet:    true,
+		CompactJSON: true,
+		RedactKeys:  []string{"password"},
+		Filters:     []FilterRule{{Key: "db", Value: `DROP`}},
+	})
+	defer SetConfig(Config{Output: io.Discard, Level: LevelTrace})
+
+	evaluated := false
+	LogInfo("query done",
+		Group("db", "query", "SELECT 1", "rows", 3, Group("pool", "idle", 2)),
+		"user", "alice",
+		slog.Group("auth", slog.String("password", "hunter2"), slog.Any("token", Lazy(func() any { return "t0k" }))),
+		slog.Group("", slog.Int("inlined", 1)),
+		Group("empty"),
+		"trailing",
+	)
+	LogInfo("secret", Group("req", "password", Lazy(func() any { evaluated = true; return "x" })))
+	LogInfo("dropped", Group("db", "query", "DROP TABLE users"))
+
+	out := buf.String()
+	for _, want := range []string{
+		`"db":{"pool":{"idle":2},"query":"SELECT 1","rows":3}`,
+		`"auth":{"password":"***","token":"t0k"}`,
+		`"inlined":1`,
+		`"trailing":"MISSING_VALUE"`,
+		`"user":"alice"`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %s in:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "empty") {
+		t.Errorf("expected the empty group to be omitted:\n%s", out)
+	}
+	if evaluated {
+		t.Error("expected a redacted lazy value inside a group not to be evaluated")
+	}
+	if strings.Contains(out, "dropped") {
+		t.Errorf("expected the filter to match a group value:\n%s", out)
+	}
+}
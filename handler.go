@@ -1,26 +1,41 @@
 package logger
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
-	"fmt"
 	"io"
-	"log"
 	"log/slog"
+	"math"
 	"path/filepath"
 	"regexp"
 	"runtime"
-	"time"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
 )
 
 // prettyHandler is a custom slog.Handler that formats log records in a human-readable way
 type prettyHandler struct {
 	slog.Handler
-	logger         *log.Logger
+	mu             *sync.Mutex // Serializes writes to out
+	out            io.Writer
 	config         Config
-	redactPatterns []*regexp.Regexp
+	values         valueRedactor // RedactPatterns and DetectPII
+	theme          *Theme
+	levelLabels    map[slog.Level]string // Styled level labels
+	styleKeyValues bool                  // Style key=value attributes (console, or ColorizeJSON)
 }
 
+// renderBufferKey carries a *bytes.Buffer that Handle writes the formatted
+// line into instead of the output, used by the ordered async worker pool
+type renderBufferKey struct{}
+
+// rawMessageKey marks a record whose message the package built itself with
+// line breaks and colors, such as a Dump tree, so Handle keeps it as is
+type rawMessageKey struct{}
+
 // prettyHandlerOptions holds configuration options for the prettyHandler
 type prettyHandlerOptions struct {
 	SlogOpts slog.HandlerOptions
@@ -37,163 +52,335 @@ const (
 	LevelAudit  = slog.Level(10) // Higher than Error for security audit logs
 )
 
-// Handle formats and outputs the log record
-func (handler *prettyHandler) Handle(ctx context.Context, record slog.Record) error {
-	var recordLevel string
+// LevelDefault is a Config.Level or SetLevel value selecting the default
+// level (LevelTrace). Unlike the zero value, which is slog.LevelInfo when
+// LevelSet is true, it always means the default.
+const LevelDefault = slog.Level(math.MinInt32)
+
+// Precomputed level labels so Handle does not format them per record; the
+// styled ones are built per handler from its Theme
+var plainLevelLabels = map[slog.Level]string{
+	LevelTrace:  "TRACE",
+	LevelDebug:  "DEBUG",
+	LevelInfo:   "INFO",
+	LevelNotice: "NOTICE",
+	LevelWarn:   "WARN",
+	LevelError:  "ERROR",
+	LevelAudit:  "AUDIT",
+}
 
-	// Use config.EnableColor to conditionally apply colors
+// LevelString returns the upper-case name of level (TRACE, DEBUG, INFO,
+// NOTICE, WARN, ERROR, AUDIT), falling back to slog's representation
+func LevelString(level slog.Level) string {
+	if label, ok := plainLevelLabels[level]; ok {
+		return label
+	}
+	return level.String()
+}
+
+// levelLabel returns the (optionally colorized) label for level
+func (handler *prettyHandler) levelLabel(level slog.Level) string {
 	if handler.config.EnableColor {
-		switch record.Level {
-		case LevelDebug:
-			recordLevel = formatString("DEBUG", purple, false)
-		case LevelInfo:
-			recordLevel = formatString("INFO", blue, false)
-		case LevelNotice:
-			recordLevel = formatString("NOTICE", green, false)
-		case LevelTrace:
-			recordLevel = formatString("TRACE", gray, false)
-		case LevelWarn:
-			recordLevel = formatString("WARN", yellow, false)
-		case LevelError:
-			recordLevel = formatString("ERROR", red, false)
-		case LevelAudit:
-			recordLevel = formatString("AUDIT", brightCyan, false)
-		default:
-			recordLevel = formatString(record.Level.String(), gray, false)
-		}
-	} else {
-		switch record.Level {
-		case LevelDebug:
-			recordLevel = "DEBUG"
-		case LevelInfo:
-			recordLevel = "INFO"
-		case LevelNotice:
-			recordLevel = "NOTICE"
-		case LevelTrace:
-			recordLevel = "TRACE"
-		case LevelWarn:
-			recordLevel = "WARN"
-		case LevelError:
-			recordLevel = "ERROR"
-		case LevelAudit:
-			recordLevel = "AUDIT"
-		default:
-			recordLevel = record.Level.String()
+		if label, ok := handler.levelLabels[level]; ok {
+			return label
 		}
+		return handler.theme.UnknownLevel.render(level.String())
 	}
+	return LevelString(level)
+}
+
+// handleState is the per-record scratch space reused across Handle calls
+type handleState struct {
+	buf    []byte
+	attrs  []slog.Attr
+	indent bytes.Buffer
+}
+
+// maxPooledBuffer keeps one oversized record from pinning memory in the pool
+const maxPooledBuffer = 64 << 10
+
+var handleStatePool = sync.Pool{
+	New: func() any {
+		return &handleState{
+			buf:   make([]byte, 0, 1024),
+			attrs: make([]slog.Attr, 0, 16),
+		}
+	},
+}
 
-	// Caller attribution
-	var caller string
-	if handler.config.EnableCaller && record.PC != 0 {
-		fs := runtime.CallersFrames([]uintptr{record.PC})
-		f, _ := fs.Next()
-		caller = fmt.Sprintf("%s:%d", filepath.Base(f.File), f.Line)
-		if handler.config.EnableColor {
-			caller = formatString(caller, gray, false)
+// Handle formats and outputs the log record
+func (handler *prettyHandler) Handle(ctx context.Context, record slog.Record) error {
+	state := handleStatePool.Get().(*handleState)
+	defer func() {
+		if cap(state.buf) <= maxPooledBuffer && state.indent.Cap() <= maxPooledBuffer {
+			state.buf = state.buf[:0]
+			clear(state.attrs)
+			state.attrs = state.attrs[:0]
+			handleStatePool.Put(state)
+		}
+	}()
+
+	if handler.config.Format == FormatPretty || handler.config.Format == FormatConsole {
+		// The other formats quote the message; FormatV1 writes it as is
+		if !handler.config.AllowControlChars && handler.config.FormatVersion.resolve() >= FormatV2 &&
+			ctx.Value(rawMessageKey{}) == nil {
+			record.Message = safeText(record.Message)
 		}
 	}
 
-	recordAttrs := record.NumAttrs()
+	var buf []byte
+	var err error
+	switch handler.config.Format {
+	case FormatJSON:
+		buf, err = handler.appendJSONLine(state.buf[:0], state, record)
+	case FormatLogfmt:
+		buf, err = handler.appendLogfmtLine(state.buf[:0], state, record)
+	case FormatConsole:
+		buf, err = handler.appendConsoleLine(state.buf[:0], state, record)
+	default:
+		buf, err = handler.appendPrettyLine(state.buf[:0], state, record)
+	}
+	if err != nil {
+		state.buf = buf
+		return err
+	}
+	buf = append(buf, '\n')
+	state.buf = buf
 
-	fields := make(map[string]any, recordAttrs)
-	record.Attrs(func(a slog.Attr) bool {
-		if a.Key == "duration" {
-			if duration, ok := a.Value.Any().(time.Duration); ok {
-				fields[a.Key] = fmt.Sprintf("%.9fs", duration.Seconds())
+	if out, ok := ctx.Value(renderBufferKey{}).(*bytes.Buffer); ok {
+		_, err = out.Write(buf)
+		return err
+	}
+	handler.mu.Lock()
+	defer handler.mu.Unlock()
+	_, err = writeLevel(handler.out, record.Level, buf)
+	return err
+}
+
+// appendPrettyLine appends the fields of Config.Layout, by default
+// "time LEVEL [file:line] message {attrs}"
+func (handler *prettyHandler) appendPrettyLine(buf []byte, state *handleState, record slog.Record) ([]byte, error) {
+	layout := handler.config.Layout
+	start := len(buf)
+	for _, field := range layout.fields() {
+		sep := len(buf) > start
+		switch field {
+		case FieldTime:
+			if sep {
+				buf = append(buf, ' ')
+			}
+			if handler.config.EnableColor && !handler.theme.Timestamp.isZero() {
+				buf = handler.theme.Timestamp.appendStyled(buf, record.Time.Format(handler.config.TimeFormat))
 			} else {
-				fields[a.Key] = a.Value.Any()
+				buf = record.Time.AppendFormat(buf, handler.config.TimeFormat)
+			}
+		case FieldLevel:
+			if sep {
+				buf = append(buf, ' ')
+			}
+			buf = append(buf, handler.levelLabel(record.Level)...)
+		case FieldSource:
+			// Caller attribution
+			if !handler.config.EnableCaller || record.PC == 0 {
+				continue
+			}
+			if sep {
+				buf = append(buf, ' ')
+			}
+			buf = append(buf, '[')
+			if handler.config.EnableColor {
+				buf = handler.theme.Source.appendStyled(buf, string(appendSource(nil, record.PC)))
+			} else {
+				buf = appendSource(buf, record.PC)
+			}
+			buf = append(buf, ']')
+		case FieldMessage:
+			if record.Message == "" {
+				continue
+			}
+			if sep {
+				buf = append(buf, ' ')
+			}
+			if handler.config.EnableColor {
+				buf = handler.theme.Message.appendStyled(buf, record.Message)
+			} else {
+				buf = append(buf, record.Message...)
+			}
+		case FieldAttrs:
+			if record.NumAttrs() == 0 || layout.Attrs == AttrsHidden {
+				continue
 			}
-		} else {
-			val := a.Value.Any()
-			// Apply regex redaction to string values
-			if s, ok := val.(string); ok {
-				for _, re := range handler.redactPatterns {
-					if re.MatchString(s) {
-						val = handler.config.RedactMask
-						break
-					}
+			var err error
+			if layout.Attrs == AttrsKeyValue {
+				// Each pair starts with its own space
+				pairs := len(buf)
+				if buf, err = handler.appendLogfmtAttrs(buf, "", sortedRecordAttrs(state, record)); err != nil {
+					return buf, err
 				}
+				if !sep && len(buf) > pairs {
+					buf = append(buf[:pairs], buf[pairs+1:]...)
+				}
+				continue
 			}
-			fields[a.Key] = val
-		}
-		return true
-	})
-
-	var jsonStr string
-	if recordAttrs > 0 {
-		if handler.config.CompactJSON {
-			jsonData, err := json.Marshal(fields)
-			if err != nil {
-				return err
+			if sep {
+				buf = append(buf, ' ')
 			}
-			jsonStr = string(jsonData)
-		} else {
-			jsonData, err := json.MarshalIndent(fields, "", "  ")
-			if err != nil {
-				return err
+			if buf, err = handler.appendAttrs(buf, state, record); err != nil {
+				return buf, err
 			}
-			jsonStr = string(jsonData)
-		}
-		if handler.config.EnableColor && handler.config.ColorizeJSON {
-			jsonStr = colorizeJSONOutput(jsonStr)
 		}
 	}
+	return buf, nil
+}
 
-	// Use config.TimeFormat
-	timeStr := record.Time.Format(handler.config.TimeFormat)
+// appendSource appends the "file.go:line" of the frame at pc
+func appendSource(buf []byte, pc uintptr) []byte {
+	f, _ := runtime.CallersFrames([]uintptr{pc}).Next()
+	buf = append(buf, filepath.Base(f.File)...)
+	buf = append(buf, ':')
+	return strconv.AppendInt(buf, int64(f.Line), 10)
+}
 
-	// Build output parts
-	parts := []any{timeStr, recordLevel}
-	if caller != "" {
-		parts = append(parts, "["+caller+"]")
+// appendAttrs appends the record's attributes as a JSON object with keys in
+// sorted order; when a key repeats, the last value wins.
+func (handler *prettyHandler) appendAttrs(buf []byte, state *handleState, record slog.Record) ([]byte, error) {
+	attrs := sortedRecordAttrs(state, record)
+
+	start := len(buf)
+	buf = append(buf, '{')
+	buf, err := handler.appendMembers(buf, attrs)
+	if err != nil {
+		return buf, err
 	}
-	if record.Message == "" {
-		if recordAttrs > 0 {
-			parts = append(parts, jsonStr)
-		}
-	} else {
-		msg := record.Message
-		if handler.config.EnableColor {
-			msg = formatString(record.Message, cyan, false)
-		}
-		parts = append(parts, msg)
-		if recordAttrs > 0 {
-			parts = append(parts, jsonStr)
+	buf = append(buf, '}')
+
+	if handler.config.Layout.indent(record.NumAttrs(), handler.config.CompactJSON) {
+		state.indent.Reset()
+		if err := json.Indent(&state.indent, buf[start:], "", "  "); err != nil {
+			return buf, err
 		}
+		buf = append(buf[:start], state.indent.Bytes()...)
+	}
+	if handler.config.EnableColor && handler.config.ColorizeJSON {
+		// Key and value styling is opt-in, so it may allocate
+		buf = handler.theme.appendColorizedJSON(buf[:start], slices.Clone(buf[start:]))
 	}
+	return buf, nil
+}
 
-	handler.logger.Println(parts...)
+// sortedRecordAttrs collects the record's attributes into state, sorted by key
+func sortedRecordAttrs(state *handleState, record slog.Record) []slog.Attr {
+	attrs := state.attrs[:0]
+	record.Attrs(func(a slog.Attr) bool {
+		attrs = append(attrs, a)
+		return true
+	})
+	state.attrs = attrs
+	slices.SortStableFunc(attrs, func(a, b slog.Attr) int {
+		return strings.Compare(a.Key, b.Key)
+	})
+	return attrs
+}
 
-	return nil
+// shadowed reports whether attrs[i] is left out of the output: a later
+// attribute has the same key, or it is an empty group
+func shadowed(attrs []slog.Attr, i int) bool {
+	return i+1 < len(attrs) && attrs[i+1].Key == attrs[i].Key || isEmptyGroup(attrs[i])
 }
 
-var jsonKeyColorRe = regexp.MustCompile(`("(?:[^"\\]|\\.)*")\s*:`)
+// appendMembers appends sorted attrs as comma-separated JSON object members
+func (handler *prettyHandler) appendMembers(buf []byte, attrs []slog.Attr) ([]byte, error) {
+	first := true
+	for i, a := range attrs {
+		if shadowed(attrs, i) {
+			continue
+		}
+		if !first {
+			buf = append(buf, ',')
+		}
+		first = false
+		buf = appendJSONString(buf, a.Key)
+		buf = append(buf, ':')
 
-func colorizeJSONOutput(jsonStr string) string {
-	return jsonKeyColorRe.ReplaceAllStringFunc(jsonStr, func(match string) string {
-		// Find last quote before the colon
-		for i := len(match) - 1; i >= 0; i-- {
-			if match[i] == '"' {
-				key := match[:i+1]
-				rest := match[i+1:]
-				return formatString(key, blue, false) + rest
-			}
+		var err error
+		if buf, err = handler.appendAttrValue(buf, a); err != nil {
+			return buf, err
+		}
+	}
+	return buf, nil
+}
+
+// appendAttrValue appends a single attribute value, applying the duration
+// formatting and regex redaction rules
+func (handler *prettyHandler) appendAttrValue(buf []byte, a slog.Attr) ([]byte, error) {
+	v := a.Value.Resolve()
+	switch v.Kind() {
+	case slog.KindGroup:
+		return handler.appendGroup(buf, v.Group())
+	case slog.KindDuration:
+		if a.Key == "duration" {
+			buf = append(buf, '"')
+			buf = strconv.AppendFloat(buf, v.Duration().Seconds(), 'f', 9, 64)
+			return append(buf, 's', '"'), nil
 		}
-		return match
+	case slog.KindString:
+		// Apply regex redaction and PII scrubbing to string values
+		return appendJSONString(buf, handler.values.redact(v.String())), nil
+	}
+	return appendJSONValue(buf, v)
+}
+
+// appendGroup appends group members as a nested JSON object, sorted by key
+// like the top level
+func (handler *prettyHandler) appendGroup(buf []byte, group []slog.Attr) ([]byte, error) {
+	attrs := sortedAttrs(group)
+	buf = append(buf, '{')
+	buf, err := handler.appendMembers(buf, attrs)
+	if err != nil {
+		return buf, err
+	}
+	return append(buf, '}'), nil
+}
+
+// sortedAttrs returns a copy of group sorted by key
+func sortedAttrs(group []slog.Attr) []slog.Attr {
+	attrs := slices.Clone(group)
+	slices.SortStableFunc(attrs, func(a, b slog.Attr) int {
+		return strings.Compare(a.Key, b.Key)
 	})
+	return attrs
+}
+
+// isEmptyGroup reports whether a is a group without members, which slog
+// handlers omit
+func isEmptyGroup(a slog.Attr) bool {
+	return a.Value.Kind() == slog.KindGroup && len(a.Value.Group()) == 0
 }
 
 // newPrettyHandler creates a new instance of prettyHandler with the given output and options
 func newPrettyHandler(out io.Writer, opts prettyHandlerOptions) *prettyHandler {
 	h := &prettyHandler{
 		Handler: slog.NewJSONHandler(out, &opts.SlogOpts),
-		logger:  log.New(out, "", 0),
+		mu:      &sync.Mutex{},
+		out:     out,
 		config:  opts.Config,
 	}
-	for _, pattern := range opts.Config.RedactPatterns {
+	h.values = newValueRedactor(opts.Config)
+	h.theme = configTheme(opts.Config)
+	h.levelLabels = h.theme.levelLabels()
+	h.styleKeyValues = opts.Config.EnableColor &&
+		(opts.Config.Format == FormatConsole || opts.Config.Format == FormatPretty && opts.Config.ColorizeJSON)
+	return h
+}
+
+// compileRedactPatterns compiles Config.RedactPatterns, skipping invalid
+// ones (Validate reports them)
+func compileRedactPatterns(patterns []string) []*regexp.Regexp {
+	var compiled []*regexp.Regexp
+	for _, pattern := range patterns {
 		if re, err := regexp.Compile(pattern); err == nil {
-			h.redactPatterns = append(h.redactPatterns, re)
+			compiled = append(compiled, re)
 		}
 	}
-	return h
+	return compiled
 }
@@ -8,18 +8,19 @@ import (
 func HealthCheck() error {
 	var errs []error
 
-	cfg := *globalConfig.Load()
+	cfg := *loadConfig()
 
 	if cfg.Output == nil {
 		errs = append(errs, fmt.Errorf("health: output writer is nil"))
 	}
 
-	if cfg.AsyncMode && asyncRunning {
-		asyncMu.Lock()
+	if cfg.AsyncMode {
+		asyncMu.RLock()
 		chanLen := len(logChan)
 		chanCap := cap(logChan)
-		asyncMu.Unlock()
-		if chanCap > 0 {
+		running := asyncRunning
+		asyncMu.RUnlock()
+		if running && chanCap > 0 {
 			usage := float64(chanLen) / float64(chanCap)
 			if usage > 0.9 {
 				errs = append(errs, fmt.Errorf("health: async buffer %.0f%% full (%d/%d)", usage*100, chanLen, chanCap))
@@ -27,8 +28,8 @@ func HealthCheck() error {
 		}
 	}
 
-	if auditLogger != nil {
-		stats := auditLogger.GetStats()
+	if al := loadState().audit; al != nil {
+		stats := al.GetStats()
 		if stats.Closed {
 			errs = append(errs, fmt.Errorf("health: audit logger is closed"))
 		}
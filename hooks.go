@@ -0,0 +1,87 @@
+package logger
+
+import (
+	"log/slog"
+	"slices"
+	"sync"
+	"sync/atomic"
+)
+
+// Hook inspects a record before it is written. It returns the attributes to
+// write, which it may modify, extend or replace, and false to drop the
+// record. Hooks run after key redaction, in the order they were added, on
+// the goroutine that writes the record (an async worker in AsyncMode). attrs
+// is only valid during the call.
+type Hook func(level LogLevel, msg string, attrs []slog.Attr) ([]slog.Attr, bool)
+
+// hookEntry identifies a hook so it can be removed
+type hookEntry struct {
+	id uint64
+	fn Hook
+}
+
+var (
+	hooksMu    sync.Mutex // Serializes AddHook and remove
+	hooks      atomic.Pointer[[]hookEntry]
+	nextHookID uint64
+)
+
+// AddHook registers h for every record and returns a function that removes it
+//
+//	remove := logger.AddHook(func(level logger.LogLevel, msg string, attrs []slog.Attr) ([]slog.Attr, bool) {
+//		if msg == "cache miss" {
+//			return nil, false // Drop
+//		}
+//		return append(attrs, slog.String("deployment", "blue")), true
+//	})
+//	defer remove()
+func AddHook(h Hook) (remove func()) {
+	hooksMu.Lock()
+	defer hooksMu.Unlock()
+
+	nextHookID++
+	id := nextHookID
+	var current []hookEntry
+	if p := hooks.Load(); p != nil {
+		current = *p
+	}
+	updated := append(slices.Clip(current), hookEntry{id: id, fn: h})
+	hooks.Store(&updated)
+
+	var once sync.Once
+	return func() {
+		once.Do(func() { removeHook(id) })
+	}
+}
+
+// removeHook unregisters the hook with the given id
+func removeHook(id uint64) {
+	hooksMu.Lock()
+	defer hooksMu.Unlock()
+
+	p := hooks.Load()
+	if p == nil {
+		return
+	}
+	updated := slices.DeleteFunc(slices.Clone(*p), func(e hookEntry) bool { return e.id == id })
+	if len(updated) == 0 {
+		hooks.Store(nil)
+		return
+	}
+	hooks.Store(&updated)
+}
+
+// runHooks passes attrs through every registered hook, stopping at the first veto
+func runHooks(level LogLevel, msg string, attrs []slog.Attr) ([]slog.Attr, bool) {
+	p := hooks.Load()
+	if p == nil {
+		return attrs, true
+	}
+	for _, h := range *p {
+		var keep bool
+		if attrs, keep = h.fn(level, msg, attrs); !keep {
+			return nil, false
+		}
+	}
+	return attrs, true
+}
@@ -0,0 +1,56 @@
+package logger
+
+import (
+	"bytes"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestAddHook(t *testing.T) {
+	var buf bytes.Buffer
+	SetConfig(Config{
+		Output:      &buf,
+		Level:       slog.LevelInfo,
+		LevelSet:    true,
+		CompactJSON: true,
+	})
+	defer SetConfig(Config{Output: io.Discard, Level: LevelTrace})
+
+	var seen []string
+	removeEnrich := AddHook(func(level LogLevel, msg string, attrs []slog.Attr) ([]slog.Attr, bool) {
+		seen = append(seen, msg)
+		for _, a := range attrs {
+			if a.Key == "password" && a.Value.String() != "***" {
+				t.Errorf("hook saw unredacted password %q", a.Value.String())
+			}
+		}
+		return append(attrs, slog.String("deployment", "blue")), true
+	})
+	removeVeto := AddHook(func(level LogLevel, msg string, attrs []slog.Attr) ([]slog.Attr, bool) {
+		return attrs, msg != "cache miss"
+	})
+
+	LogInfo("request served", "password", "hunter2")
+	LogInfo("cache miss")
+	removeVeto()
+	removeVeto() // Removing twice is a no-op
+	LogInfo("cache miss")
+	removeEnrich()
+	LogInfo("no hooks")
+
+	out := buf.String()
+	if !strings.Contains(out, `"deployment":"blue"`) {
+		t.Errorf("expected enriched record:\n%s", out)
+	}
+	if strings.Count(out, "cache miss") != 1 {
+		t.Errorf("expected the vetoed record to be dropped once:\n%s", out)
+	}
+	if strings.Contains(out[strings.Index(out, "no hooks"):], "deployment") {
+		t.Errorf("expected removed hook to stop running:\n%s", out)
+	}
+	if len(seen) != 3 {
+		t.Errorf("expected the first hook to see 3 records, got %v", seen)
+	}
+}
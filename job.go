@@ -0,0 +1,114 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// JobOption configures a job wrapped with WrapJob
+type JobOption func(*job)
+
+// WithJobTimeout cancels the context of each run after d
+func WithJobTimeout(d time.Duration) JobOption {
+	return func(j *job) {
+		j.timeout = d
+	}
+}
+
+// WithJobContext sets the parent context of each run (default:
+// context.Background()), so cancelling it stops the job
+func WithJobContext(ctx context.Context) JobOption {
+	return func(j *job) {
+		j.parent = ctx
+	}
+}
+
+// WithJobSkipOverlap skips a run, logging a warning, while the previous one
+// is still going instead of running both
+func WithJobSkipOverlap() JobOption {
+	return func(j *job) {
+		j.skipOverlap = true
+	}
+}
+
+// job is a scheduled task wrapped with WrapJob
+type job struct {
+	name        string
+	fn          func(ctx context.Context) error
+	timeout     time.Duration
+	parent      context.Context
+	skipOverlap bool
+
+	runs    atomic.Int64
+	mu      sync.Mutex
+	running int       // Runs in progress
+	since   time.Time // Start of the first of the runs in progress
+}
+
+// WrapJob wraps a scheduled background task so each run logs its start at
+// Debug and its end with "duration" at Info, or at Error with the error or
+// recovered panic and stack. Runs are numbered in "job_run"; a run that
+// starts while another is in progress is logged at Warn with
+// "overlap": true. fn's context carries a logger with "job" and "job_run"
+// for FromContext.
+//
+//	c.AddFunc("@hourly", logger.WrapJob("cleanup-sessions", cleanup, logger.WithJobSkipOverlap()))
+func WrapJob(name string, fn func(ctx context.Context) error, opts ...JobOption) func() {
+	j := &job{name: name, fn: fn, parent: context.Background()}
+	for _, opt := range opts {
+		opt(j)
+	}
+	return j.run
+}
+
+// run executes one run of the job
+func (j *job) run() {
+	run := j.runs.Add(1)
+	l := DefaultLogger().With("job", j.name, "job_run", run)
+
+	j.mu.Lock()
+	if j.running > 0 {
+		running := time.Since(j.since)
+		if j.skipOverlap {
+			j.mu.Unlock()
+			l.LogWarn(fmt.Sprintf("Job %s skipped, previous run still in progress", j.name), "overlap", true, "running_for", running)
+			return
+		}
+		l.LogWarn(fmt.Sprintf("Job %s overlaps a run still in progress", j.name), "overlap", true, "running_for", running)
+	} else {
+		j.since = time.Now()
+	}
+	j.running++
+	j.mu.Unlock()
+
+	ctx := NewContext(j.parent, l)
+	if j.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, j.timeout)
+		defer cancel()
+	}
+
+	start := time.Now()
+	l.LogDebug(fmt.Sprintf("Job %s started", j.name))
+	defer func() {
+		duration := time.Since(start)
+		j.mu.Lock()
+		j.running--
+		j.mu.Unlock()
+
+		if r := recover(); r != nil {
+			l.LogError(fmt.Sprintf("Job %s panicked", j.name),
+				"duration", duration, "panic", fmt.Sprint(r), "panic_type", fmt.Sprintf("%T", r), "stack", string(debug.Stack()))
+		}
+	}()
+
+	if err := j.fn(ctx); err != nil {
+		l.LogError(fmt.Sprintf("Job %s failed", j.name), "duration", time.Since(start), "__error", err)
+		return
+	}
+	l.LogInfo(fmt.Sprintf("Job %s finished", j.name), "duration", time.Since(start))
+}
@@ -0,0 +1,95 @@
+package logger
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWrapJob(t *testing.T) {
+	sw := newSyncWriter()
+	SetConfig(Config{Output: sw, Level: LevelTrace, CompactJSON: true, Format: FormatJSON})
+	defer SetConfig(Config{Output: io.Discard, Level: LevelTrace})
+
+	calls := 0
+	run := WrapJob("cleanup", func(ctx context.Context) error {
+		calls++
+		FromContext(ctx).LogInfo("deleting sessions")
+		switch calls {
+		case 2:
+			return errors.New("db unavailable")
+		case 3:
+			panic("nil map")
+		}
+		return nil
+	})
+	run()
+	run()
+	run() // Recovered
+
+	out := sw.String()
+	for _, want := range []string{
+		`"msg":"Job cleanup started"`,
+		`"msg":"Job cleanup finished"`,
+		`"msg":"Job cleanup failed"`,
+		`"msg":"Job cleanup panicked"`,
+		`"panic":"nil map"`,
+		`"job_run":3`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected %s in:\n%s", want, out)
+		}
+	}
+	for line := range strings.Lines(out) {
+		if strings.Contains(line, "deleting sessions") && !strings.Contains(line, `"job":"cleanup"`) {
+			t.Errorf("Expected the job's records to carry its name: %s", line)
+		}
+	}
+}
+
+func TestWrapJobOverlap(t *testing.T) {
+	for _, skip := range []bool{false, true} {
+		sw := newSyncWriter()
+		SetConfig(Config{Output: sw, Level: LevelTrace, CompactJSON: true, Format: FormatJSON})
+
+		var opts []JobOption
+		if skip {
+			opts = append(opts, WithJobSkipOverlap())
+		}
+		release := make(chan struct{})
+		started := make(chan struct{}, 2)
+		run := WrapJob("sync", func(ctx context.Context) error {
+			started <- struct{}{}
+			<-release
+			return nil
+		}, opts...)
+
+		done := make(chan struct{})
+		go func() {
+			run()
+			close(done)
+		}()
+		<-started
+		if skip {
+			run() // Returns at once
+		} else {
+			go run()
+			<-started
+		}
+		close(release)
+		<-done
+		time.Sleep(5 * time.Millisecond) // Let the overlapping run finish
+
+		out := sw.String()
+		if !strings.Contains(out, `"overlap":true`) {
+			t.Errorf("skip=%v: expected the overlap to be logged:\n%s", skip, out)
+		}
+		if got := strings.Contains(out, "skipped"); got != skip {
+			t.Errorf("skip=%v: unexpected skipped record:\n%s", skip, out)
+		}
+	}
+	SetConfig(Config{Output: io.Discard, Level: LevelTrace})
+}
@@ -0,0 +1,115 @@
+package logger
+
+import "fmt"
+
+// LayoutField is one part of a pretty-format line
+type LayoutField int
+
+const (
+	FieldTime    LayoutField = iota // Timestamp in TimeFormat
+	FieldLevel                      // Level label
+	FieldSource                     // [file:line], with EnableCaller
+	FieldMessage                    // Log message
+	FieldAttrs                      // Attributes, rendered per Layout.Attrs
+)
+
+// String returns the field name
+func (f LayoutField) String() string {
+	switch f {
+	case FieldTime:
+		return "time"
+	case FieldLevel:
+		return "level"
+	case FieldSource:
+		return "source"
+	case FieldMessage:
+		return "message"
+	case FieldAttrs:
+		return "attrs"
+	default:
+		return "unknown"
+	}
+}
+
+// AttrStyle selects how the pretty format renders attributes
+type AttrStyle int
+
+const (
+	// AttrsJSON writes a JSON object, indented unless CompactJSON (default)
+	AttrsJSON AttrStyle = iota
+	// AttrsKeyValue writes logfmt-style key=value pairs, groups flattened
+	// with dots
+	AttrsKeyValue
+	// AttrsHidden leaves attributes out of the line
+	AttrsHidden
+)
+
+// String returns the style name
+func (s AttrStyle) String() string {
+	switch s {
+	case AttrsJSON:
+		return "json"
+	case AttrsKeyValue:
+		return "key_value"
+	case AttrsHidden:
+		return "hidden"
+	default:
+		return "unknown"
+	}
+}
+
+// Layout customizes the pretty format line. The zero Layout is the
+// default "time level [source] message {attrs}".
+type Layout struct {
+	// Fields lists the line parts in order; omitted parts are not written
+	// (default: time, level, source, message, attrs)
+	Fields []LayoutField
+
+	// Attrs selects the attribute rendering (default: AttrsJSON)
+	Attrs AttrStyle
+
+	// IndentMinAttrs indents the JSON only for records with at least this
+	// many attributes, keeping short records on one line (0: CompactJSON
+	// decides for every record)
+	IndentMinAttrs int
+}
+
+// defaultLayoutFields is the order used when Layout.Fields is empty
+var defaultLayoutFields = []LayoutField{FieldTime, FieldLevel, FieldSource, FieldMessage, FieldAttrs}
+
+// fields returns the configured order or the default
+func (l Layout) fields() []LayoutField {
+	if len(l.Fields) == 0 {
+		return defaultLayoutFields
+	}
+	return l.Fields
+}
+
+// indent reports whether a record with n attributes gets indented JSON
+func (l Layout) indent(n int, compact bool) bool {
+	if l.IndentMinAttrs > 0 {
+		return n >= l.IndentMinAttrs
+	}
+	return !compact
+}
+
+// validate checks field names, duplicates and ranges
+func (l Layout) validate() error {
+	seen := make(map[LayoutField]bool, len(l.Fields))
+	for _, f := range l.Fields {
+		if f < FieldTime || f > FieldAttrs {
+			return fmt.Errorf("invalid Layout field %d", f)
+		}
+		if seen[f] {
+			return fmt.Errorf("duplicate Layout field %s", f)
+		}
+		seen[f] = true
+	}
+	if l.Attrs < AttrsJSON || l.Attrs > AttrsHidden {
+		return fmt.Errorf("invalid Layout.Attrs %d", l.Attrs)
+	}
+	if l.IndentMinAttrs < 0 {
+		return fmt.Errorf("invalid Layout.IndentMinAttrs %d", l.IndentMinAttrs)
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package logger
+
+import (
+	"bytes"
+	"io"
+	"log/slog"
+	"testing"
+)
+
+func TestLayout(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		layout Layout
+		want   string
+	}{
+		{"default", Layout{}, "T INFO hello {\"k\":\"v w\",\"n\":3}\n"},
+		{"reordered key/value", Layout{Fields: []LayoutField{FieldLevel, FieldMessage, FieldAttrs, FieldTime}, Attrs: AttrsKeyValue},
+			"INFO hello k=\"v w\" n=3 T\n"},
+		{"attrs first", Layout{Fields: []LayoutField{FieldAttrs, FieldMessage}, Attrs: AttrsKeyValue}, "k=\"v w\" n=3 hello\n"},
+		{"hidden attrs", Layout{Attrs: AttrsHidden}, "T INFO hello\n"},
+		{"no time", Layout{Fields: []LayoutField{FieldLevel, FieldMessage, FieldAttrs}}, "INFO hello {\"k\":\"v w\",\"n\":3}\n"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			SetConfig(Config{Output: &buf, Level: slog.LevelInfo, LevelSet: true, CompactJSON: true, TimeFormat: "T", Layout: tc.layout})
+			defer SetConfig(Config{Output: io.Discard, Level: LevelTrace})
+
+			LogInfo("hello", "n", 3, "k", "v w")
+			if got := buf.String(); got != tc.want {
+				t.Errorf("got %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestLayoutIndentMinAttrs(t *testing.T) {
+	var buf bytes.Buffer
+	SetConfig(Config{Output: &buf, Level: slog.LevelInfo, LevelSet: true, TimeFormat: "T", Layout: Layout{IndentMinAttrs: 3}})
+	defer SetConfig(Config{Output: io.Discard, Level: LevelTrace})
+
+	LogInfo("short", "a", 1, "b", 2)
+	LogInfo("long", "a", 1, "b", 2, "c", 3)
+	want := "T INFO short {\"a\":1,\"b\":2}\n" +
+		"T INFO long {\n  \"a\": 1,\n  \"b\": 2,\n  \"c\": 3\n}\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestLayoutValidate(t *testing.T) {
+	for _, l := range []Layout{
+		{Fields: []LayoutField{FieldTime, FieldTime}},
+		{Fields: []LayoutField{FieldAttrs + 1}},
+		{Attrs: AttrsHidden + 1},
+		{IndentMinAttrs: -1},
+	} {
+		cfg := defaultConfig
+		cfg.Layout = l
+		if err := cfg.Validate(); err == nil {
+			t.Errorf("expected an error for %+v", l)
+		}
+	}
+}
@@ -0,0 +1,205 @@
+package logger
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"maps"
+	"net/http"
+	"strings"
+)
+
+// Named returns a logger for a subsystem. Its records carry a "logger"
+// field and use Config.ModuleLevels[name] instead of Config.Level when set.
+// Use dots for hierarchy: Named("db.pool") falls back to the "db" level.
+func Named(name string) Logger {
+	return &childLogger{name: name, fields: []any{"logger", name}}
+}
+
+// SetLevel changes the global level at runtime. Unlike SetConfig it only
+// swaps the level, leaving async workers, sinks and the handler in place.
+// LevelDefault restores the default level.
+func SetLevel(level slog.Level) {
+	if level == LevelDefault {
+		level = defaultConfig.Level
+	}
+	updateConfig(func(cfg *Config) {
+		cfg.Level = level
+		cfg.LevelSet = true
+	})
+}
+
+// GetLevel returns the global level
+func GetLevel() slog.Level {
+	return loadConfig().Level
+}
+
+// SetModuleLevel overrides the level of the named module at runtime
+func SetModuleLevel(name string, level slog.Level) {
+	updateConfig(func(cfg *Config) {
+		cfg.ModuleLevels = maps.Clone(cfg.ModuleLevels)
+		if cfg.ModuleLevels == nil {
+			cfg.ModuleLevels = make(map[string]slog.Level)
+		}
+		cfg.ModuleLevels[name] = level
+	})
+}
+
+// ResetModuleLevel removes the override for the named module so it falls
+// back to its parent module or Config.Level
+func ResetModuleLevel(name string) {
+	updateConfig(func(cfg *Config) {
+		cfg.ModuleLevels = maps.Clone(cfg.ModuleLevels)
+		delete(cfg.ModuleLevels, name)
+	})
+}
+
+// ModuleLevel returns the level in effect for the named module
+func ModuleLevel(name string) slog.Level {
+	return moduleLevel(*loadConfig(), name)
+}
+
+// handlerLevel is the built-in handler's threshold. It is a slog.Leveler
+// so level changes take effect without rebuilding the handler.
+var handlerLevel slog.LevelVar
+
+// updateConfig applies a level change to a copy of the current config and
+// publishes it
+func updateConfig(fn func(*Config)) {
+	configWriteMu.Lock()
+
+	// Levels are read through handlerLevel, so the handler is kept
+	st := *loadState()
+	old := st.config
+	fn(&st.config)
+	current.Store(&st)
+
+	handlerLevel.Set(minLevel(st.config))
+	slog.SetLogLoggerLevel(st.config.Level)
+	configWriteMu.Unlock()
+
+	if old.AuditConfigChanges || st.config.AuditConfigChanges {
+		auditConfigChange(old, st.config, diffConfig(old, st.config))
+	}
+}
+
+// moduleLevel resolves the level for module, walking up dotted parents
+func moduleLevel(cfg Config, module string) slog.Level {
+	if module == "" || len(cfg.ModuleLevels) == 0 {
+		return cfg.Level
+	}
+	for {
+		if level, ok := cfg.ModuleLevels[module]; ok {
+			return level
+		}
+		i := strings.LastIndexByte(module, '.')
+		if i < 0 {
+			return cfg.Level
+		}
+		module = module[:i]
+	}
+}
+
+// minLevel returns the most verbose level any logger may use under cfg
+func minLevel(cfg Config) slog.Level {
+	level := cfg.Level
+	for _, l := range cfg.ModuleLevels {
+		level = min(level, l)
+	}
+	return level
+}
+
+// ParseLevel parses a level name (trace, debug, info, notice, warn, error,
+// audit; case-insensitive)
+func ParseLevel(s string) (slog.Level, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "trace":
+		return LevelTrace, nil
+	case "debug":
+		return slog.LevelDebug, nil
+	case "info":
+		return slog.LevelInfo, nil
+	case "notice":
+		return LevelNotice, nil
+	case "warn", "warning":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	case "audit":
+		return LevelAudit, nil
+	default:
+		return 0, fmt.Errorf("logger: unknown level %q", s)
+	}
+}
+
+// levelState is the JSON document served by LevelHandler
+type levelState struct {
+	Level   string            `json:"level"`
+	Modules map[string]string `json:"modules,omitempty"`
+}
+
+// levelRequest is the body LevelHandler accepts on PUT
+type levelRequest struct {
+	Level  string `json:"level"`
+	Module string `json:"module,omitempty"`
+}
+
+// LevelHandler returns an http.Handler to inspect and change levels at
+// runtime. Mount it on an internal/admin port only.
+//
+//	GET                                      -> {"level":"info","modules":{"db":"debug"}}
+//	PUT {"level":"debug"}                    -> sets the global level
+//	PUT {"level":"debug","module":"db"}      -> sets a module level
+//	DELETE ?module=db                        -> removes a module override
+func LevelHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+		case http.MethodPut, http.MethodPost:
+			var req levelRequest
+			if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<10)).Decode(&req); err != nil {
+				writeLevelError(w, http.StatusBadRequest, fmt.Errorf("logger: invalid request body: %w", err))
+				return
+			}
+			level, err := ParseLevel(req.Level)
+			if err != nil {
+				writeLevelError(w, http.StatusBadRequest, err)
+				return
+			}
+			if req.Module != "" {
+				SetModuleLevel(req.Module, level)
+			} else {
+				SetLevel(level)
+			}
+		case http.MethodDelete:
+			module := r.URL.Query().Get("module")
+			if module == "" {
+				writeLevelError(w, http.StatusBadRequest, fmt.Errorf("logger: module query parameter is required"))
+				return
+			}
+			ResetModuleLevel(module)
+		default:
+			w.Header().Set("Allow", "GET, PUT, POST, DELETE")
+			writeLevelError(w, http.StatusMethodNotAllowed, fmt.Errorf("logger: method %s not allowed", r.Method))
+			return
+		}
+
+		cfg := *loadConfig()
+		state := levelState{Level: strings.ToLower(LevelString(cfg.Level))}
+		if len(cfg.ModuleLevels) > 0 {
+			state.Modules = make(map[string]string, len(cfg.ModuleLevels))
+			for name, level := range cfg.ModuleLevels {
+				state.Modules[name] = strings.ToLower(LevelString(level))
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(state)
+	})
+}
+
+// writeLevelError writes err as a JSON error response
+func writeLevelError(w http.ResponseWriter, status int, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+}
@@ -0,0 +1,299 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestNamedLoggerModuleLevels(t *testing.T) {
+	var buf bytes.Buffer
+	SetConfig(Config{
+		Output:       &buf,
+		Level:        slog.LevelInfo,
+		LevelSet:     true,
+		CompactJSON:  true,
+		EnableCaller: true,
+		ModuleLevels: map[string]slog.Level{"db": slog.LevelDebug},
+	})
+	defer SetConfig(Config{Output: io.Discard, Level: LevelTrace})
+
+	Named("db").LogDebug("db query")
+	Named("db.pool").LogDebug("pool checkout")
+	Named("http").LogDebug("http hidden")
+	LogDebug("root hidden")
+	Named("http").LogInfo("http visible")
+
+	out := buf.String()
+	for _, want := range []string{"db query", "pool checkout", "http visible", `"logger":"db.pool"`, "level_test.go:"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in output:\n%s", want, out)
+		}
+	}
+	for _, unwanted := range []string{"http hidden", "root hidden"} {
+		if strings.Contains(out, unwanted) {
+			t.Errorf("did not expect %q in output", unwanted)
+		}
+	}
+
+	buf.Reset()
+	SetModuleLevel("http", slog.LevelDebug)
+	Named("http").With("route", "/users").LogDebug("http now visible")
+	if !strings.Contains(buf.String(), "http now visible") {
+		t.Error("expected SetModuleLevel to take effect immediately")
+	}
+	if ModuleLevel("http") != slog.LevelDebug || ModuleLevel("other") != slog.LevelInfo {
+		t.Errorf("unexpected effective levels %v / %v", ModuleLevel("http"), ModuleLevel("other"))
+	}
+
+	ResetModuleLevel("http")
+	buf.Reset()
+	Named("http").LogDebug("http hidden again")
+	if buf.Len() != 0 {
+		t.Errorf("expected ResetModuleLevel to restore the default, got %q", buf.String())
+	}
+}
+
+func TestModuleLevelsFromEnv(t *testing.T) {
+	t.Setenv("LOG_LEVELS", "db=debug, http = warn,broken")
+	cfg := ConfigFromEnv()
+	if cfg.ModuleLevels["db"] != slog.LevelDebug || cfg.ModuleLevels["http"] != slog.LevelWarn {
+		t.Errorf("unexpected module levels %v", cfg.ModuleLevels)
+	}
+	if _, ok := cfg.ModuleLevels["broken"]; ok {
+		t.Error("expected entries without a level to be ignored")
+	}
+}
+
+func TestSetLevel(t *testing.T) {
+	var buf bytes.Buffer
+	SetConfig(Config{Output: &buf, Level: slog.LevelInfo, LevelSet: true, CompactJSON: true})
+	defer SetConfig(Config{Output: io.Discard, Level: LevelTrace})
+
+	LogDebug("hidden")
+	SetLevel(slog.LevelDebug)
+	LogDebug("shown")
+	if GetLevel() != slog.LevelDebug {
+		t.Errorf("GetLevel() = %v", GetLevel())
+	}
+	SetLevel(slog.LevelError)
+	LogWarn("hidden too")
+
+	out := buf.String()
+	if strings.Contains(out, "hidden") || !strings.Contains(out, "shown") {
+		t.Errorf("unexpected output %q", out)
+	}
+}
+
+func TestLoggerWithLevel(t *testing.T) {
+	var buf bytes.Buffer
+	extra := newSyncWriter()
+	SetConfig(Config{
+		Output:             &buf,
+		Level:              slog.LevelInfo,
+		LevelSet:           true,
+		CompactJSON:        true,
+		AdditionalHandlers: []slog.Handler{slog.NewJSONHandler(extra, nil)},
+	})
+	defer SetConfig(Config{Output: io.Discard, Level: LevelTrace})
+
+	verbose := LoggerWithLevel(Named("db").With("session", "s1"), LevelTrace)
+	verbose.LogTrace("verbose trace")
+	verbose.With("step", 2).LogDebug("verbose debug")
+	Named("db").LogDebug("db hidden")
+	LogDebug("root hidden")
+	LoggerWithLevel(DefaultLogger(), slog.LevelError).LogWarn("quiet hidden")
+
+	out := buf.String()
+	for _, want := range []string{"verbose trace", "verbose debug", `"logger":"db"`, `"session":"s1"`, `"step":2`} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected %q in output:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "hidden") {
+		t.Errorf("Expected other loggers to keep their level, got:\n%s", out)
+	}
+	if GetLevel() != slog.LevelInfo {
+		t.Errorf("Expected the global level to be unchanged, got %v", GetLevel())
+	}
+	if strings.Contains(extra.String(), "verbose") {
+		t.Errorf("Expected additional handlers to keep their own level, got %q", extra.String())
+	}
+}
+
+// plainLogger implements Logger without WithLevel, like implementations
+// outside this package
+type plainLogger struct{ Logger }
+
+// Test that loggers without WithLevel are passed through unchanged
+func TestLoggerWithLevelPlainLogger(t *testing.T) {
+	plain := plainLogger{DefaultLogger()}
+	if got := LoggerWithLevel(plain, LevelTrace); got != Logger(plain) {
+		t.Errorf("Expected the logger to be returned unchanged, got %#v", got)
+	}
+	ctx := NewLevelContext(NewContext(context.Background(), plain), LevelTrace)
+	if got := FromContext(ctx); got != Logger(plain) {
+		t.Errorf("Expected the stored logger to be kept, got %#v", got)
+	}
+}
+
+func TestNewLevelContext(t *testing.T) {
+	var buf bytes.Buffer
+	SetConfig(Config{Output: &buf, Level: slog.LevelInfo, LevelSet: true, CompactJSON: true})
+	defer SetConfig(Config{Output: io.Discard, Level: LevelTrace})
+
+	ctx := NewContext(context.Background(), With("request_id", "r1"))
+	ctx = NewLevelContext(ctx, LevelTrace)
+	LogTraceWithContext(ctx, "request trace")
+	FromContext(ctx).LogDebug("request debug")
+	LogTraceWithContext(context.Background(), "other hidden")
+
+	out := buf.String()
+	if !strings.Contains(out, "request trace") || !strings.Contains(out, "request debug") {
+		t.Errorf("Expected the context's records at Trace, got:\n%s", out)
+	}
+	if strings.Count(out, `"request_id":"r1"`) != 2 {
+		t.Errorf("Expected the stored logger's fields to be kept, got:\n%s", out)
+	}
+	if strings.Contains(out, "hidden") {
+		t.Errorf("Expected other contexts to keep the global level, got:\n%s", out)
+	}
+}
+
+func TestWithTempConfig(t *testing.T) {
+	var buf bytes.Buffer
+	SetConfig(Config{Output: &buf, Level: slog.LevelInfo, LevelSet: true, CompactJSON: true})
+	defer SetConfig(Config{Output: io.Discard, Level: LevelTrace})
+
+	cfg := GetConfig()
+	cfg.Level = LevelDebug
+	err := WithTempConfig(cfg, func() {
+		LogDebug("inside")
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	LogDebug("outside")
+
+	func() {
+		defer func() { _ = recover() }()
+		_ = WithTempConfig(cfg, func() { panic("boom") })
+	}()
+	if GetLevel() != slog.LevelInfo {
+		t.Errorf("Expected the level to be restored after a panic, got %v", GetLevel())
+	}
+
+	out := buf.String()
+	if !strings.Contains(out, "inside") || strings.Contains(out, "outside") {
+		t.Errorf("Unexpected output %q", out)
+	}
+
+	called := false
+	cfg.Format = OutputFormat(42)
+	if err := WithTempConfig(cfg, func() { called = true }); err == nil || called {
+		t.Errorf("Expected an invalid config to be rejected without calling fn, got %v", err)
+	}
+}
+
+func TestLevelZeroValues(t *testing.T) {
+	defer SetConfig(Config{Output: io.Discard, Level: LevelTrace})
+
+	// A zero Level without LevelSet means the default
+	SetConfig(Config{Output: io.Discard})
+	if GetLevel() != LevelTrace {
+		t.Errorf("Expected the default level for a zero Config, got %v", GetLevel())
+	}
+
+	// Configs derived from the defaults keep an explicit slog.LevelInfo
+	cfg := ConfigFromEnv()
+	cfg.Output = io.Discard
+	cfg.Level = slog.LevelInfo
+	SetConfig(cfg)
+	if GetLevel() != slog.LevelInfo {
+		t.Errorf("Expected slog.LevelInfo to be kept, got %v", GetLevel())
+	}
+
+	// GetConfig round-trips
+	SetConfig(GetConfig())
+	if GetLevel() != slog.LevelInfo {
+		t.Errorf("Expected slog.LevelInfo after SetConfig(GetConfig()), got %v", GetLevel())
+	}
+
+	cfg.Level = LevelDefault
+	SetConfig(cfg)
+	if GetLevel() != LevelTrace {
+		t.Errorf("Expected LevelDefault to select the default level, got %v", GetLevel())
+	}
+
+	SetLevel(LevelError)
+	SetLevel(LevelDefault)
+	if GetLevel() != LevelTrace {
+		t.Errorf("Expected SetLevel(LevelDefault) to restore the default, got %v", GetLevel())
+	}
+}
+
+func TestLevelHandler(t *testing.T) {
+	SetConfig(Config{Output: io.Discard, Level: slog.LevelInfo, LevelSet: true})
+	defer SetConfig(Config{Output: io.Discard, Level: LevelTrace})
+
+	h := LevelHandler()
+	do := func(method, target, body string) (int, map[string]any) {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(method, target, strings.NewReader(body)))
+		var resp map[string]any
+		_ = json.Unmarshal(rec.Body.Bytes(), &resp)
+		return rec.Code, resp
+	}
+
+	if code, resp := do(http.MethodGet, "/", ""); code != http.StatusOK || resp["level"] != "info" {
+		t.Errorf("GET = %d %v", code, resp)
+	}
+	if code, resp := do(http.MethodPut, "/", `{"level":"debug"}`); code != http.StatusOK || resp["level"] != "debug" {
+		t.Errorf("PUT = %d %v", code, resp)
+	}
+	if GetLevel() != slog.LevelDebug {
+		t.Error("expected PUT to change the global level")
+	}
+
+	_, resp := do(http.MethodPut, "/", `{"level":"trace","module":"db"}`)
+	if modules, _ := resp["modules"].(map[string]any); modules["db"] != "trace" {
+		t.Errorf("module PUT = %v", resp)
+	}
+	if ModuleLevel("db") != LevelTrace {
+		t.Error("expected PUT to set the module level")
+	}
+	if _, resp := do(http.MethodDelete, "/?module=db", ""); resp["modules"] != nil {
+		t.Errorf("DELETE = %v", resp)
+	}
+
+	for _, tc := range []struct {
+		method, target, body string
+		want                 int
+	}{
+		{http.MethodPut, "/", `{"level":"loud"}`, http.StatusBadRequest},
+		{http.MethodPut, "/", `not json`, http.StatusBadRequest},
+		{http.MethodDelete, "/", "", http.StatusBadRequest},
+		{http.MethodPatch, "/", "", http.StatusMethodNotAllowed},
+	} {
+		if code, _ := do(tc.method, tc.target, tc.body); code != tc.want {
+			t.Errorf("%s %s %q = %d, want %d", tc.method, tc.target, tc.body, code, tc.want)
+		}
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	for in, want := range map[string]slog.Level{"TRACE": LevelTrace, " warning ": slog.LevelWarn, "audit": LevelAudit} {
+		if got, err := ParseLevel(in); err != nil || got != want {
+			t.Errorf("ParseLevel(%q) = %v, %v", in, got, err)
+		}
+	}
+	if _, err := ParseLevel("verbose"); err == nil {
+		t.Error("expected an unknown level to fail")
+	}
+}
@@ -0,0 +1,87 @@
+package logger
+
+import (
+	"encoding/json"
+	"log/slog"
+	"slices"
+)
+
+// TruncatedKey marks a record that MaxAttrs or MaxValueBytes cut short
+const TruncatedKey = "truncated"
+
+// limitRecord applies MaxAttrs and MaxValueBytes to a record's message and
+// attributes, adding TruncatedKey when anything was dropped or cut
+func limitRecord(message string, attrs []slog.Attr, cfg Config) (string, []slog.Attr) {
+	l := attrLimiter{left: -1, maxBytes: cfg.MaxValueBytes}
+	if cfg.MaxAttrs > 0 {
+		l.left = cfg.MaxAttrs
+	}
+	if l.maxBytes > 0 && len(message) > l.maxBytes {
+		message = truncateUTF8(message, l.maxBytes)
+		l.truncated = true
+	}
+	attrs = l.limit(attrs)
+	if l.truncated {
+		attrs = append(attrs, slog.Bool(TruncatedKey, true))
+	}
+	return message, attrs
+}
+
+// attrLimiter drops the attributes past MaxAttrs and truncates values
+// longer than MaxValueBytes
+type attrLimiter struct {
+	left      int // Attributes still allowed, -1 for no limit
+	maxBytes  int // 0 for no limit
+	truncated bool
+}
+
+// limit returns the kept attrs, reusing the slice. Group members count
+// toward MaxAttrs like top-level attributes.
+func (l *attrLimiter) limit(attrs []slog.Attr) []slog.Attr {
+	n := 0
+	for _, a := range attrs {
+		if l.left == 0 {
+			l.truncated = true
+			break
+		}
+		a.Value = a.Value.Resolve()
+		if a.Value.Kind() == slog.KindGroup {
+			// The members may be shared with a Group or a child logger
+			a.Value = slog.GroupValue(l.limit(slices.Clone(a.Value.Group()))...)
+		} else {
+			if l.left > 0 {
+				l.left--
+			}
+			a = l.value(a)
+		}
+		attrs[n] = a
+		n++
+	}
+	return attrs[:n]
+}
+
+// value truncates a string, or the JSON encoding of a struct, map or
+// slice, to maxBytes
+func (l *attrLimiter) value(a slog.Attr) slog.Attr {
+	if l.maxBytes <= 0 {
+		return a
+	}
+	var s string
+	switch a.Value.Kind() {
+	case slog.KindString:
+		s = a.Value.String()
+	case slog.KindAny:
+		data, err := json.Marshal(a.Value.Any())
+		if err != nil {
+			return a
+		}
+		s = string(data)
+	default:
+		return a
+	}
+	if len(s) <= l.maxBytes {
+		return a
+	}
+	l.truncated = true
+	return slog.String(a.Key, truncateUTF8(s, l.maxBytes))
+}
@@ -0,0 +1,43 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestRecordLimits(t *testing.T) {
+	defer SetConfig(Config{Output: io.Discard, Level: LevelTrace})
+
+	var buf bytes.Buffer
+	SetConfig(NewConfig(WithOutput(&buf), WithLevel(LevelTrace), WithJSON(), WithLimits(3, 8)))
+
+	LogInfo("a message that is too long", "note", "héllo world", "ids", []int{1, 2, 3, 4, 5},
+		Group("g", "a", 1, "b", 2), "dropped", true)
+
+	var rec map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &rec); err != nil {
+		t.Fatalf("invalid JSON %q: %v", buf.String(), err)
+	}
+	if rec["msg"] != "a messag…" || rec["note"] != "héllo w…" || rec["ids"] != "[1,2,3,4…" {
+		t.Errorf("expected the message and values cut at 8 bytes: %s", buf.String())
+	}
+	if g, _ := rec["g"].(map[string]any); len(g) != 1 || rec["dropped"] != nil {
+		t.Errorf("expected group members counted toward MaxAttrs: %s", buf.String())
+	}
+	if rec[TruncatedKey] != true {
+		t.Errorf("expected the truncated marker: %s", buf.String())
+	}
+
+	buf.Reset()
+	LogInfo("short", "n", 12345678901)
+	if strings.Contains(buf.String(), TruncatedKey) {
+		t.Errorf("expected no marker on a record within the limits: %s", buf.String())
+	}
+
+	if err := SetConfigE(Config{Output: io.Discard, MaxValueBytes: -1}); err == nil {
+		t.Error("expected an error for a negative limit")
+	}
+}
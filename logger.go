@@ -1,18 +1,21 @@
 package logger
 
 import (
-	"bytes"
 	"context"
 	"errors"
 	"fmt"
-	"io"
 	"log/slog"
 	"net/http"
+	"slices"
 	"time"
 
 	"github.com/jozefvalachovic/logger/v4/audit"
 )
 
+// LogLevel is the severity accepted by Log and the Logger interface. It is
+// the only level type in the package; its values increase with severity
+// in the same order as the slog levels they map to (LevelTrace ..
+// LevelAudit), so comparisons like level >= Warn are safe.
 type LogLevel int
 
 const (
@@ -51,6 +54,17 @@ func FromContext(ctx context.Context) Logger {
 	return DefaultLogger()
 }
 
+// NewLevelContext returns a copy of ctx whose logger uses level instead of
+// the configured one, so the LogXxxWithContext functions and FromContext
+// log at that verbosity for this context only. Fields of a logger already
+// stored with NewContext are kept.
+//
+//	ctx = logger.NewLevelContext(ctx, logger.LevelTrace)
+//	logger.LogTraceWithContext(ctx, "cache miss", "key", key) // Written even at Info
+func NewLevelContext(ctx context.Context, level slog.Level) context.Context {
+	return NewContext(ctx, LoggerWithLevel(FromContext(ctx), level))
+}
+
 // Logger interface for dependency injection
 type Logger interface {
 	Log(level LogLevel, message string, keyValues ...any)
@@ -74,11 +88,29 @@ type Logger interface {
 	LogErrorWithStack(err error, msg string, keyValues ...any)
 }
 
+// LevelLogger is a Logger that can be copied with its own level. The
+// loggers of this package implement it; implementations of Logger
+// elsewhere need not.
+type LevelLogger interface {
+	Logger
+	WithLevel(level slog.Level) Logger
+}
+
+// LoggerWithLevel returns a copy of l that uses level instead of its
+// module or global level. Loggers that do not implement LevelLogger are
+// returned unchanged.
+func LoggerWithLevel(l Logger, level slog.Level) Logger {
+	if ll, ok := l.(LevelLogger); ok {
+		return ll.WithLevel(level)
+	}
+	return l
+}
+
 // defaultLoggerImpl implements the Logger interface
 type defaultLoggerImpl struct{}
 
-// Ensure defaultLoggerImpl implements Logger
-var _ Logger = (*defaultLoggerImpl)(nil)
+// Ensure defaultLoggerImpl implements LevelLogger
+var _ LevelLogger = (*defaultLoggerImpl)(nil)
 
 // DefaultLogger returns a Logger instance using the global configuration
 func DefaultLogger() Logger {
@@ -127,27 +159,27 @@ func (l *defaultLoggerImpl) LogInfoWithContext(ctx context.Context, message stri
 }
 
 func (l *defaultLoggerImpl) LogWithContext(ctx context.Context, level LogLevel, message string, keyValues ...any) {
-	FromContext(ctx).Log(level, message, keyValues...)
+	FromContext(ctx).Log(level, message, correlate(ctx, keyValues)...)
 }
 
 func (l *defaultLoggerImpl) LogDebugWithContext(ctx context.Context, message string, keyValues ...any) {
-	FromContext(ctx).LogDebug(message, keyValues...)
+	FromContext(ctx).LogDebug(message, correlate(ctx, keyValues)...)
 }
 
 func (l *defaultLoggerImpl) LogTraceWithContext(ctx context.Context, message string, keyValues ...any) {
-	FromContext(ctx).LogTrace(message, keyValues...)
+	FromContext(ctx).LogTrace(message, correlate(ctx, keyValues)...)
 }
 
 func (l *defaultLoggerImpl) LogNoticeWithContext(ctx context.Context, message string, keyValues ...any) {
-	FromContext(ctx).LogNotice(message, keyValues...)
+	FromContext(ctx).LogNotice(message, correlate(ctx, keyValues)...)
 }
 
 func (l *defaultLoggerImpl) LogWarnWithContext(ctx context.Context, message string, keyValues ...any) {
-	FromContext(ctx).LogWarn(message, keyValues...)
+	FromContext(ctx).LogWarn(message, correlate(ctx, keyValues)...)
 }
 
 func (l *defaultLoggerImpl) LogErrorWithContext(ctx context.Context, message string, keyValues ...any) {
-	FromContext(ctx).LogError(message, keyValues...)
+	FromContext(ctx).LogError(message, correlate(ctx, keyValues)...)
 }
 
 func (l *defaultLoggerImpl) LogHttpRequest(r *http.Request) {
@@ -158,16 +190,25 @@ func (l *defaultLoggerImpl) With(keyValues ...any) Logger {
 	return &childLogger{fields: keyValues}
 }
 
+// WithLevel returns a logger that uses level instead of Config.Level. The
+// global configuration is left alone, so other loggers keep their level.
+func (l *defaultLoggerImpl) WithLevel(level slog.Level) Logger {
+	return &childLogger{level: &level}
+}
+
 func (l *defaultLoggerImpl) LogErrorWithStack(err error, msg string, keyValues ...any) {
 	logErrorWithStackInternal(err, msg, keyValues...)
 }
 
 // childLogger is a logger with pre-set fields prepended to every log call.
 type childLogger struct {
+	name   string      // Module name for loggers created with Named
+	level  *slog.Level // Set by WithLevel; overrides the module and global level
 	fields []any
+	buffer *RecordBuffer // Set by NewBufferContext; holds records until flushed
 }
 
-var _ Logger = (*childLogger)(nil)
+var _ LevelLogger = (*childLogger)(nil)
 
 func mergeKV(base []any, extra ...any) []any {
 	merged := make([]any, 0, len(base)+len(extra))
@@ -177,35 +218,35 @@ func mergeKV(base []any, extra ...any) []any {
 }
 
 func (l *childLogger) Log(level LogLevel, message string, keyValues ...any) {
-	logInternal(level, message, mergeKV(l.fields, keyValues...)...)
+	logModule(l, 3, level, message, mergeKV(l.fields, keyValues...)...)
 }
 
 func (l *childLogger) LogDebug(message string, keyValues ...any) {
-	logInternal(Debug, message, mergeKV(l.fields, keyValues...)...)
+	logModule(l, 3, Debug, message, mergeKV(l.fields, keyValues...)...)
 }
 
 func (l *childLogger) LogInfo(message string, keyValues ...any) {
-	logInternal(Info, message, mergeKV(l.fields, keyValues...)...)
+	logModule(l, 3, Info, message, mergeKV(l.fields, keyValues...)...)
 }
 
 func (l *childLogger) LogNotice(message string, keyValues ...any) {
-	logInternal(Notice, message, mergeKV(l.fields, keyValues...)...)
+	logModule(l, 3, Notice, message, mergeKV(l.fields, keyValues...)...)
 }
 
 func (l *childLogger) LogTrace(message string, keyValues ...any) {
-	logInternal(Trace, message, mergeKV(l.fields, keyValues...)...)
+	logModule(l, 3, Trace, message, mergeKV(l.fields, keyValues...)...)
 }
 
 func (l *childLogger) LogWarn(message string, keyValues ...any) {
-	logInternal(Warn, message, mergeKV(l.fields, keyValues...)...)
+	logModule(l, 3, Warn, message, mergeKV(l.fields, keyValues...)...)
 }
 
 func (l *childLogger) LogError(message string, keyValues ...any) {
-	logInternal(Error, message, mergeKV(l.fields, keyValues...)...)
+	logModule(l, 3, Error, message, mergeKV(l.fields, keyValues...)...)
 }
 
 func (l *childLogger) LogAudit(keyValues ...any) {
-	logInternal(Audit, "", mergeKV(l.fields, keyValues...)...)
+	logModule(l, 3, Audit, "", mergeKV(l.fields, keyValues...)...)
 }
 
 func (l *childLogger) LogAuditEvent(ctx context.Context, event audit.AuditEvent) error {
@@ -217,27 +258,27 @@ func (l *childLogger) LogInfoWithContext(ctx context.Context, message string, ke
 }
 
 func (l *childLogger) LogWithContext(ctx context.Context, level LogLevel, message string, keyValues ...any) {
-	FromContext(ctx).Log(level, message, mergeKV(l.fields, keyValues...)...)
+	FromContext(ctx).Log(level, message, correlate(ctx, mergeKV(l.fields, keyValues...))...)
 }
 
 func (l *childLogger) LogDebugWithContext(ctx context.Context, message string, keyValues ...any) {
-	FromContext(ctx).LogDebug(message, mergeKV(l.fields, keyValues...)...)
+	FromContext(ctx).LogDebug(message, correlate(ctx, mergeKV(l.fields, keyValues...))...)
 }
 
 func (l *childLogger) LogTraceWithContext(ctx context.Context, message string, keyValues ...any) {
-	FromContext(ctx).LogTrace(message, mergeKV(l.fields, keyValues...)...)
+	FromContext(ctx).LogTrace(message, correlate(ctx, mergeKV(l.fields, keyValues...))...)
 }
 
 func (l *childLogger) LogNoticeWithContext(ctx context.Context, message string, keyValues ...any) {
-	FromContext(ctx).LogNotice(message, mergeKV(l.fields, keyValues...)...)
+	FromContext(ctx).LogNotice(message, correlate(ctx, mergeKV(l.fields, keyValues...))...)
 }
 
 func (l *childLogger) LogWarnWithContext(ctx context.Context, message string, keyValues ...any) {
-	FromContext(ctx).LogWarn(message, mergeKV(l.fields, keyValues...)...)
+	FromContext(ctx).LogWarn(message, correlate(ctx, mergeKV(l.fields, keyValues...))...)
 }
 
 func (l *childLogger) LogErrorWithContext(ctx context.Context, message string, keyValues ...any) {
-	FromContext(ctx).LogError(message, mergeKV(l.fields, keyValues...)...)
+	FromContext(ctx).LogError(message, correlate(ctx, mergeKV(l.fields, keyValues...))...)
 }
 
 func (l *childLogger) LogHttpRequest(r *http.Request) {
@@ -245,7 +286,13 @@ func (l *childLogger) LogHttpRequest(r *http.Request) {
 }
 
 func (l *childLogger) With(keyValues ...any) Logger {
-	return &childLogger{fields: mergeKV(l.fields, keyValues...)}
+	return &childLogger{name: l.name, level: l.level, fields: mergeKV(l.fields, keyValues...), buffer: l.buffer}
+}
+
+// WithLevel returns a copy of the logger that uses level instead of its
+// module or global level
+func (l *childLogger) WithLevel(level slog.Level) Logger {
+	return &childLogger{name: l.name, level: &level, fields: l.fields, buffer: l.buffer}
 }
 
 func (l *childLogger) LogErrorWithStack(err error, msg string, keyValues ...any) {
@@ -262,6 +309,27 @@ func LogErrorWithStack(err error, msg string, keyValues ...any) {
 	logErrorWithStackInternal(err, msg, keyValues...)
 }
 
+// LogErrorIf logs msg at Error with err as "__error" when err is not nil,
+// and reports whether it did:
+//
+//	logger.LogErrorIf(f.Close(), "Failed to close export", "path", path)
+func LogErrorIf(err error, msg string, keyValues ...any) bool {
+	if err == nil {
+		return false
+	}
+	logInternal(Error, msg, append(slices.Clip(keyValues), "__error", err)...)
+	return true
+}
+
+// LogIf logs msg at level when cond is true
+//
+//	logger.LogIf(retries > 3, logger.Warn, "Upstream is flaky", "retries", retries)
+func LogIf(cond bool, level LogLevel, msg string, keyValues ...any) {
+	if cond {
+		logInternal(level, msg, keyValues...)
+	}
+}
+
 func logErrorWithStackInternal(err error, msg string, keyValues ...any) {
 	kv := []any{
 		"error", err.Error(),
@@ -281,7 +349,7 @@ func logErrorWithStackInternal(err error, msg string, keyValues ...any) {
 
 // IfTrace calls fn only if the current log level would output Trace messages.
 func IfTrace(fn func()) {
-	level := globalConfig.Load().Level
+	level := loadConfig().Level
 	if level <= LevelTrace {
 		fn()
 	}
@@ -289,7 +357,7 @@ func IfTrace(fn func()) {
 
 // IfDebug calls fn only if the current log level would output Debug messages.
 func IfDebug(fn func()) {
-	level := globalConfig.Load().Level
+	level := loadConfig().Level
 	if level <= slog.LevelDebug {
 		fn()
 	}
@@ -297,7 +365,7 @@ func IfDebug(fn func()) {
 
 // IfInfo calls fn only if the current log level would output Info messages.
 func IfInfo(fn func()) {
-	level := globalConfig.Load().Level
+	level := loadConfig().Level
 	if level <= slog.LevelInfo {
 		fn()
 	}
@@ -305,7 +373,7 @@ func IfInfo(fn func()) {
 
 // IfWarn calls fn only if the current log level would output Warn messages.
 func IfWarn(fn func()) {
-	level := globalConfig.Load().Level
+	level := loadConfig().Level
 	if level <= slog.LevelWarn {
 		fn()
 	}
@@ -313,7 +381,7 @@ func IfWarn(fn func()) {
 
 // IfError calls fn only if the current log level would output Error messages.
 func IfError(fn func()) {
-	level := globalConfig.Load().Level
+	level := loadConfig().Level
 	if level <= slog.LevelError {
 		fn()
 	}
@@ -321,14 +389,25 @@ func IfError(fn func()) {
 
 // SetConfig configures the logger with custom settings.
 // This will reinitialize the logger with the new configuration.
+// An invalid configuration is logged and ignored; use SetConfigE to get
+// the error instead.
 func SetConfig(cfg Config) {
+	if err := SetConfigE(cfg); err != nil {
+		LogError("Invalid configuration", "__error", err)
+	}
+}
+
+// SetConfigE is SetConfig returning validation errors to the caller. On
+// error the current configuration is left unchanged.
+func SetConfigE(cfg Config) error {
 	// Use provided value or fallback to defaultConfig for each field
 	if cfg.Output == nil {
 		cfg.Output = defaultConfig.Output
 	}
-	if cfg.Level == 0 && !cfg.LevelSet {
+	if cfg.Level == LevelDefault || cfg.Level == 0 && !cfg.LevelSet {
 		cfg.Level = defaultConfig.Level
 	}
+	cfg.LevelSet = true
 	if cfg.TimeFormat == "" {
 		cfg.TimeFormat = defaultConfig.TimeFormat
 	}
@@ -344,10 +423,16 @@ func SetConfig(cfg Config) {
 	if cfg.RedactPaths == nil {
 		cfg.RedactPaths = defaultConfig.RedactPaths
 	}
-	// SampleRate: use SampleRateSet to distinguish "not specified" from "explicitly set to 0"
-	if !cfg.SampleRateSet && cfg.SampleRate == 0 {
+	if cfg.RedactQueryParams == nil {
+		cfg.RedactQueryParams = defaultConfig.RedactQueryParams
+	}
+	// SampleRate: SampleOff or SampleRateSet distinguish "explicitly 0" from "not specified"
+	if cfg.SampleRate == SampleOff {
+		cfg.SampleRate = 0
+	} else if !cfg.SampleRateSet && cfg.SampleRate == 0 {
 		cfg.SampleRate = defaultConfig.SampleRate
 	}
+	cfg.SampleRateSet = true
 	if cfg.BufferSize == 0 {
 		cfg.BufferSize = defaultConfig.BufferSize
 	}
@@ -360,73 +445,147 @@ func SetConfig(cfg Config) {
 
 	// Validate the configuration after filling defaults
 	if err := cfg.Validate(); err != nil {
-		LogError("Invalid configuration", "__error", err)
-		return
+		return err
+	}
+
+	old, changed := applyConfig(cfg)
+	if cfg.LogConfigChanges && len(changed) > 0 {
+		LogDebug("Logger configuration changed", "fields", changed)
 	}
+	auditConfigChange(old, cfg, changed)
+	return nil
+}
 
-	// Handle async mode changes
+// applyConfig publishes a validated cfg, starting and stopping async
+// workers, metrics, dedup and the audit logger as needed, and returns the
+// previous configuration and the names of the fields that changed
+func applyConfig(cfg Config) (Config, []string) {
 	configWriteMu.Lock()
-	oldCfg := globalConfig.Load()
-	oldAsync := oldCfg.AsyncMode
-	oldAuditConfig := oldCfg.Audit
+	defer configWriteMu.Unlock()
+
+	old := loadState()
+	next := runtimeState{metrics: old.metrics, dedup: old.dedup, adaptive: old.adaptive, burst: old.burst, tail: old.tail, audit: old.audit}
 
-	if cfg.AsyncMode && !oldAsync {
-		// Starting async mode
+	// Handle async mode changes. A running pipeline is drained and rebuilt
+	// when its output or its settings change, as its workers, batch writer
+	// and spill file keep the ones they were started with.
+	if cfg.AsyncMode && (!old.config.AsyncMode || asyncSettingsChanged(old.config, cfg)) {
 		startAsyncLogger(cfg)
-	} else if !cfg.AsyncMode && oldAsync {
+	} else if !cfg.AsyncMode && old.config.AsyncMode {
 		// Stopping async mode
 		stopAsyncLogger()
 	}
 
-	// Handle metrics changes
-	if cfg.EnableMetrics && metrics == nil {
-		metrics = NewLogMetrics()
-	} else if !cfg.EnableMetrics && metrics != nil {
-		metrics = nil
+	// Handle metrics changes; counters survive while metrics stay enabled
+	if cfg.EnableMetrics && next.metrics == nil {
+		next.metrics = NewLogMetrics()
+	} else if !cfg.EnableMetrics {
+		next.metrics = nil
 	}
 
-	// Handle dedup changes
-	if cfg.EnableDedup && dedupMgr == nil {
+	// Handle dedup changes. The old manager is stopped once the new state
+	// is published, as log calls may still be using it.
+	var oldDedup *dedupManager
+	if cfg.EnableDedup && next.dedup == nil {
 		window := cfg.DedupWindow
 		if window == 0 {
 			window = 5 * time.Second
 		}
-		startDedup(window)
-	} else if !cfg.EnableDedup && dedupMgr != nil {
-		stopDedup()
+		next.dedup = newDedupManager(window)
+	} else if !cfg.EnableDedup && next.dedup != nil {
+		oldDedup, next.dedup = next.dedup, nil
+	}
+
+	// Handle adaptive sampling changes; the current rate survives while the
+	// budget is unchanged
+	if cfg.SampleBudget == 0 {
+		next.adaptive = nil
+	} else if next.adaptive == nil || cfg.SampleBudget != old.config.SampleBudget {
+		next.adaptive = newAdaptiveSampler(cfg.SampleBudget)
+	}
+
+	// Handle burst sampling changes; the old sampler is stopped with the
+	// old dedup manager
+	var oldBurst *burstSampler
+	interval := cfg.SampleInterval
+	if interval == 0 {
+		interval = time.Second
+	}
+	if b := next.burst; b != nil && (b.first != cfg.SampleFirst || b.thereafter != cfg.SampleThereafter || b.interval != interval) {
+		oldBurst, next.burst = b, nil
+	}
+	if cfg.SampleFirst > 0 && next.burst == nil {
+		next.burst = newBurstSampler(cfg.SampleFirst, cfg.SampleThereafter, interval)
+	}
+
+	// Handle tail buffer changes; the kept records survive while the size
+	// is unchanged
+	if cfg.TailSize == 0 {
+		next.tail = nil
+	} else if next.tail == nil || cfg.TailSize != old.config.TailSize {
+		next.tail = newTailBuffer(cfg.TailSize)
+	}
+	if next.tail != nil {
+		next.tail.setValues(newValueRedactor(cfg))
 	}
 
 	// Handle enterprise audit logger changes
-	if cfg.Audit != nil && oldAuditConfig == nil {
+	if cfg.Audit != nil && old.config.Audit == nil {
 		// Initialize enterprise audit logger
 		if al, err := audit.New(*cfg.Audit); err != nil {
 			LogError("Failed to initialize enterprise audit logger", "__error", err)
 		} else {
-			auditLogger = al
+			next.audit = al
 		}
-	} else if cfg.Audit == nil && auditLogger != nil {
+	} else if cfg.Audit == nil && next.audit != nil {
 		// Close existing audit logger
-		_ = auditLogger.Close()
-		auditLogger = nil
-	} else if cfg.Audit != nil && auditLogger != nil {
+		_ = next.audit.Close()
+		next.audit = nil
+	} else if cfg.Audit != nil && next.audit != nil {
 		// Reconfigure: close old and create new
-		_ = auditLogger.Close()
+		_ = next.audit.Close()
 		if al, err := audit.New(*cfg.Audit); err != nil {
 			LogError("Failed to reinitialize enterprise audit logger", "__error", err)
-			auditLogger = nil
+			next.audit = nil
 		} else {
-			auditLogger = al
+			next.audit = al
 		}
 	}
 
-	globalConfig.Store(&cfg)
-	configWriteMu.Unlock()
-	initLogger()
+	publishState(cfg, next)
+	if oldDedup != nil {
+		oldDedup.Stop()
+	}
+	if oldBurst != nil {
+		oldBurst.Flush()
+		oldBurst.Stop()
+	}
+	return old.config, diffConfig(old.config, cfg)
 }
 
 // GetConfig returns the current logger configuration.
 func GetConfig() Config {
-	return *globalConfig.Load()
+	return *loadConfig()
+}
+
+// WithTempConfig applies cfg, runs fn and restores the previous
+// configuration, even if fn panics. It is global: every goroutine logs with
+// cfg while fn runs, and a SetConfig made meanwhile is undone on return.
+// Use LoggerWithLevel to raise the verbosity of one code path only.
+//
+//	cfg := logger.GetConfig()
+//	cfg.Level = logger.LevelTrace
+//	err := logger.WithTempConfig(cfg, func() { reproduceIssue() })
+//
+// An invalid cfg is returned without calling fn.
+func WithTempConfig(cfg Config, fn func()) error {
+	old := GetConfig()
+	if err := SetConfigE(cfg); err != nil {
+		return err
+	}
+	defer SetConfig(old)
+	fn()
+	return nil
 }
 
 // Basic Log function
@@ -478,11 +637,25 @@ func LogAudit(keyValues ...any) {
 // LogAuditEvent logs a structured audit event using the enterprise audit logger
 // If enterprise audit is not configured, falls back to legacy LogAudit behavior
 func LogAuditEvent(ctx context.Context, event audit.AuditEvent) error {
-	cfg := *globalConfig.Load()
+	st := loadState()
+	cfg := st.config
+
+	if event.CorrelationID == "" {
+		event.CorrelationID = CorrelationID(ctx)
+	}
 
 	// If enterprise audit is configured, use it
-	if cfg.Audit != nil && auditLogger != nil {
-		return auditLogger.Log(ctx, event)
+	if cfg.Audit != nil && st.audit != nil {
+		return st.audit.Log(ctx, event)
+	}
+
+	if len(cfg.AuditRequiredFields) > 0 {
+		if err := event.Validate(); err != nil {
+			return err
+		}
+		if err := event.Require(cfg.AuditRequiredFields...); err != nil {
+			return err
+		}
 	}
 
 	// Fallback to legacy behavior: convert event to key-value pairs
@@ -510,6 +683,14 @@ func LogAuditEvent(ctx context.Context, event audit.AuditEvent) error {
 		keyValues = append(keyValues, "reason", event.Reason)
 	}
 
+	if event.CorrelationID != "" {
+		keyValues = append(keyValues, CorrelationIDKey, event.CorrelationID)
+	}
+
+	if !event.Timestamp.IsZero() {
+		keyValues = append(keyValues, "event_time", event.Timestamp)
+	}
+
 	// Add metadata
 	for k, v := range event.Metadata {
 		keyValues = append(keyValues, k, v)
@@ -522,10 +703,8 @@ func LogAuditEvent(ctx context.Context, event audit.AuditEvent) error {
 // LogAuditEventSync logs a structured audit event synchronously (guaranteed delivery)
 // Only available when enterprise audit is configured
 func LogAuditEventSync(ctx context.Context, event audit.AuditEvent) error {
-	cfg := *globalConfig.Load()
-
-	if cfg.Audit != nil && auditLogger != nil {
-		return auditLogger.LogSync(ctx, event)
+	if st := loadState(); st.config.Audit != nil && st.audit != nil {
+		return st.audit.LogSync(ctx, event)
 	}
 
 	// Fallback to regular async logging
@@ -535,7 +714,7 @@ func LogAuditEventSync(ctx context.Context, event audit.AuditEvent) error {
 // GetAuditLogger returns the enterprise audit logger instance
 // Returns nil if enterprise audit is not configured
 func GetAuditLogger() *audit.Logger {
-	return auditLogger
+	return loadState().audit
 }
 
 // TraceIDKey is the typed context key for trace ID extraction.
@@ -562,47 +741,50 @@ func LogInfoWithContext(ctx context.Context, message string, keyValues ...any) {
 	if traceID != nil {
 		keyValues = append(keyValues, "trace_id", traceID)
 	}
-	logInternal(Info, message, keyValues...)
+	logInternal(Info, message, correlate(ctx, keyValues)...)
 }
 
 // LogWithContext retrieves the Logger from ctx (see NewContext / FromContext) and logs at the given level.
 func LogWithContext(ctx context.Context, level LogLevel, message string, keyValues ...any) {
-	FromContext(ctx).Log(level, message, keyValues...)
+	FromContext(ctx).Log(level, message, correlate(ctx, keyValues)...)
 }
 
 // LogDebugWithContext retrieves the Logger from ctx and logs at Debug level.
 func LogDebugWithContext(ctx context.Context, message string, keyValues ...any) {
-	FromContext(ctx).LogDebug(message, keyValues...)
+	FromContext(ctx).LogDebug(message, correlate(ctx, keyValues)...)
 }
 
 // LogTraceWithContext retrieves the Logger from ctx and logs at Trace level.
 func LogTraceWithContext(ctx context.Context, message string, keyValues ...any) {
-	FromContext(ctx).LogTrace(message, keyValues...)
+	FromContext(ctx).LogTrace(message, correlate(ctx, keyValues)...)
 }
 
 // LogNoticeWithContext retrieves the Logger from ctx and logs at Notice level.
 func LogNoticeWithContext(ctx context.Context, message string, keyValues ...any) {
-	FromContext(ctx).LogNotice(message, keyValues...)
+	FromContext(ctx).LogNotice(message, correlate(ctx, keyValues)...)
 }
 
 // LogWarnWithContext retrieves the Logger from ctx and logs at Warn level.
 func LogWarnWithContext(ctx context.Context, message string, keyValues ...any) {
-	FromContext(ctx).LogWarn(message, keyValues...)
+	FromContext(ctx).LogWarn(message, correlate(ctx, keyValues)...)
 }
 
 // LogErrorWithContext retrieves the Logger from ctx and logs at Error level.
 func LogErrorWithContext(ctx context.Context, message string, keyValues ...any) {
-	FromContext(ctx).LogError(message, keyValues...)
+	FromContext(ctx).LogError(message, correlate(ctx, keyValues)...)
 }
 
-// LogHttpRequest logs details of an HTTP request
+// LogHttpRequest logs details of an HTTP request whose Response holds the
+// status. It shares path, query and body handling with
+// middleware.LogHTTPMiddleware, the package's only HTTP middleware, which
+// should be preferred for servers.
 func LogHttpRequest(r *http.Request) {
 	logHttpRequestInternal(r)
 }
 
 // logHttpRequestInternal is the internal implementation for logging HTTP requests
 func logHttpRequestInternal(r *http.Request) {
-	cfg := *globalConfig.Load()
+	cfg := *loadConfig()
 
 	// Check if path should be redacted
 	fullPath := getFullPath(r.URL)
@@ -626,18 +808,17 @@ func logHttpRequestInternal(r *http.Request) {
 		"__user_agent", r.UserAgent(),
 	}
 
-	// Read and log the body if present
-	if r.Body != nil {
-		bodyBytes, err := io.ReadAll(io.LimitReader(r.Body, cfg.MaxBodySize))
-		if err != nil {
-			logInternal(Error, "Failed to read HTTP request body", "__error", err)
-			return
-		}
-		r.Body = io.NopCloser(bytes.NewBuffer(bodyBytes))
-		if len(bodyBytes) > 0 {
-			bodyKeyValues := bodyToKeyValues("body", bodyBytes)
-			keyValues = append(keyValues, bodyKeyValues...)
+	// Log up to MaxBodySize of a text body, leaving all of it to be read
+	body, truncated, err := ReadBody(r, cfg.MaxBodySize)
+	if err != nil {
+		logInternal(Error, "Failed to read HTTP request body", "__error", err)
+		return
+	}
+	if contentType := r.Header.Get("Content-Type"); len(body) > 0 && LoggableBody(contentType, body) {
+		if truncated {
+			body = append(body[:len(body):len(body)], "..."...)
 		}
+		keyValues = append(keyValues, bodyToKeyValues("body", contentType, body)...)
 	}
 
 	// Log with key details in the message
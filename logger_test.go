@@ -3,14 +3,19 @@ package logger
 import (
 	"bytes"
 	"context"
+	"errors"
+	"fmt"
 	"io"
 	"log/slog"
+	"net"
 	"net/http"
+	"net/netip"
 	"net/url"
 	"os"
 	"strings"
 	"sync"
 	"testing"
+	"time"
 )
 
 // Benchmark
@@ -448,3 +453,333 @@ func TestContextLoggerConcurrent(t *testing.T) {
 		t.Error("No output from concurrent context logging")
 	}
 }
+
+func BenchmarkLogInfoScalarAttrs(b *testing.B) {
+	SetConfig(Config{
+		Output:      io.Discard,
+		Level:       LevelInfo,
+		EnableColor: false,
+		TimeFormat:  "15:04:05",
+	})
+	b.ReportAllocs()
+
+	for b.Loop() {
+		LogInfo("Benchmark test", "user", "alice", "attempt", 3, "ratio", 0.5, "ok", true)
+	}
+}
+
+func TestLazyValues(t *testing.T) {
+	buf := &bytes.Buffer{}
+	SetConfig(Config{
+		Output:      buf,
+		Level:       slog.LevelInfo,
+		LevelSet:    true,
+		EnableColor: false,
+		CompactJSON: true,
+		TimeFormat:  "15:04:05",
+	})
+
+	calls := 0
+	expensive := func() any {
+		calls++
+		return map[string]any{"rows": 42}
+	}
+
+	LogDebug("suppressed", "dump", Lazy(expensive))
+	if calls != 0 {
+		t.Fatalf("Expected lazy value to be skipped below the level, got %d calls", calls)
+	}
+
+	LogInfo("written", "dump", Lazy(expensive), "plain", expensive)
+	if calls != 2 {
+		t.Errorf("Expected both lazy values to be evaluated once, got %d calls", calls)
+	}
+	if !strings.Contains(buf.String(), `"dump":{"rows":42}`) {
+		t.Errorf("Expected evaluated value in output, got: %s", buf.String())
+	}
+
+	LogInfo("redacted", "password", Lazy(func() any {
+		t.Error("Lazy value behind a redacted key must not be evaluated")
+		return "secret"
+	}))
+}
+
+// Test that async mode and record buffers evaluate lazy values behind
+// redacted keys exactly like the synchronous path
+func TestLazyValuesRedactedAsync(t *testing.T) {
+	out := newSyncWriter()
+	SetConfig(Config{
+		Output:      out,
+		Level:       slog.LevelInfo,
+		LevelSet:    true,
+		CompactJSON: true,
+		AsyncMode:   true,
+		RedactRules: map[string]RedactStrategy{"card": RedactPartial},
+	})
+	defer SetConfig(Config{Output: io.Discard, Level: LevelTrace})
+
+	secret := Lazy(func() any {
+		t.Error("Lazy value behind a redacted key must not be evaluated")
+		return "secret"
+	})
+	card := Lazy(func() any { return "4111111111111111" })
+	LogInfo("async", "password", secret, "card", card)
+	ctx, held := NewBufferContext(context.Background(), 1)
+	LogInfoWithContext(ctx, "buffered", "password", secret, "card", card)
+	held.Flush()
+	stopAsyncLogger()
+
+	if got := strings.Count(out.String(), `"card":"************1111"`); got != 2 {
+		t.Errorf("Expected the partially redacted card twice, got %d in:\n%s", got, out.String())
+	}
+}
+
+func TestLogLevelOrderMatchesSlog(t *testing.T) {
+	levels := []LogLevel{Trace, Debug, Info, Notice, Warn, Error, Audit}
+	for i := 1; i < len(levels); i++ {
+		prev, cur := levels[i-1], levels[i]
+		if cur <= prev {
+			t.Errorf("LogLevel %d is not above %d", cur, prev)
+		}
+		if slogLevelFromLogLevel(cur) <= slogLevelFromLogLevel(prev) {
+			t.Errorf("slog level for %s is not above %s", levelToString(cur), levelToString(prev))
+		}
+	}
+}
+
+// secret self-redacts through slog.LogValuer
+type secret string
+
+func (secret) LogValue() slog.Value { return slog.StringValue("[secret]") }
+
+// account logs as a struct through slog.LogValuer
+type account struct{ id int }
+
+func (a account) LogValue() slog.Value {
+	return slog.AnyValue(map[string]any{"id": a.id})
+}
+
+// rgb renders through fmt.Stringer instead of reflection
+type rgb struct{ R, G, B uint8 }
+
+func (c rgb) String() string { return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B) }
+
+func TestValueInterfaces(t *testing.T) {
+	buf := &bytes.Buffer{}
+	SetConfig(Config{
+		Output:      buf,
+		Level:       slog.LevelInfo,
+		LevelSet:    true,
+		CompactJSON: true,
+		TimeFormat:  "15:04:05",
+	})
+	defer SetConfig(Config{Output: io.Discard, Level: LevelTrace})
+
+	var nilColor *rgb
+	LogInfo("values",
+		"ssn", secret("hunter2"),
+		"account", account{id: 7},
+		"ip", net.ParseIP("10.0.0.1"),
+		"color", rgb{255, 0, 16},
+		"nil", nilColor,
+		"err", errors.New("boom"),
+	)
+
+	out := buf.String()
+	for _, want := range []string{
+		`"ssn":"[secret]"`, `"account":{"id":7}`, `"ip":"10.0.0.1"`, `"color":"#ff0010"`,
+		`"nil":"\u003cnil\u003e"`, `"err":"boom"`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected %s in output, got: %s", want, out)
+		}
+	}
+	if strings.Contains(out, "hunter2") {
+		t.Errorf("Expected the LogValuer to hide the secret, got: %s", out)
+	}
+}
+
+// node is a self-referential type for the conversion limit tests
+type node struct {
+	Name     string  `json:"name"`
+	Next     *node   `json:"next,omitempty"`
+	Children []*node `json:"children,omitempty"`
+}
+
+// base is embedded to check encoding/json style inlining
+type base struct {
+	ID int `json:"id"`
+}
+
+type embedding struct {
+	base
+	Label  string `json:"label"`
+	Hidden string `json:"-"`
+	Empty  string `json:"empty,omitempty"`
+}
+
+func TestConversionLimits(t *testing.T) {
+	buf := &bytes.Buffer{}
+	SetConfig(Config{
+		Output:      buf,
+		Level:       slog.LevelInfo,
+		LevelSet:    true,
+		CompactJSON: true,
+		TimeFormat:  "15:04:05",
+	})
+	defer SetConfig(Config{Output: io.Discard, Level: LevelTrace})
+
+	loop := &node{Name: "a"}
+	loop.Next = &node{Name: "b", Next: loop}
+	shared := &node{Name: "leaf"}
+	deep := &node{Name: "0"}
+	for i, n := 1, deep; i <= maxConvertDepth+2; i, n = i+1, n.Next {
+		n.Next = &node{Name: fmt.Sprint(i)}
+	}
+	selfMap := map[string]any{}
+	selfMap["self"] = selfMap
+	big := make([]int, maxConvertElements+5)
+	bigMap := make(map[int]bool, maxConvertElements+5)
+	for i := range maxConvertElements + 5 {
+		bigMap[i] = true
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		LogInfo("limits",
+			"loop", loop,
+			"shared", &node{Name: "root", Children: []*node{shared, shared}},
+			"deep", deep,
+			"self_map", selfMap,
+			"big", big,
+			"big_map", bigMap,
+			"embedded", embedding{base: base{ID: 7}, Label: "x", Hidden: "h"},
+		)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Logging a self-referential value did not finish")
+	}
+
+	out := buf.String()
+	for _, want := range []string{
+		`"loop":{"name":"a","next":{"name":"b","next":"[cycle]"}}`,
+		`"shared":{"children":[{"name":"leaf"},{"name":"leaf"}],"name":"root"}`,
+		`"self_map":{"self":"[cycle]"}`,
+		`"[max depth]"`,
+		`"…5 more"`,
+		`"…":"5 more"`,
+		`"embedded":{"id":7,"label":"x"}`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected %s in output, got: %s", want, out)
+		}
+	}
+}
+
+// point is a struct map key without a text form
+type point struct{ X, Y int }
+
+func TestMapKeys(t *testing.T) {
+	buf := &bytes.Buffer{}
+	SetConfig(Config{
+		Output:      buf,
+		Level:       slog.LevelInfo,
+		LevelSet:    true,
+		CompactJSON: true,
+		TimeFormat:  "15:04:05",
+	})
+	defer SetConfig(Config{Output: io.Discard, Level: LevelTrace})
+
+	one, two := 1, 2
+	var nilKey *int
+	LogInfo("maps",
+		"ints", map[int]string{1: "a", -2: "b"},
+		"structs", map[point]int{{1, 2}: 3},
+		"pointers", map[*int]string{&one: "one", &two: "two", nilKey: "none"},
+		"addrs", map[netip.Addr]bool{netip.MustParseAddr("10.0.0.1"): true},
+		"formats", map[DurationFormat]int{DurationMillis: 1},
+	)
+
+	out := buf.String()
+	for _, want := range []string{
+		`"ints":{"-2":"b","1":"a"}`,
+		`"structs":{"{1 2}":3}`,
+		`"pointers":{"1":"one","2":"two","\u003cnil\u003e":"none"}`,
+		`"addrs":{"10.0.0.1":true}`,
+		`"formats":{"millis":1}`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected %s in output, got: %s", want, out)
+		}
+	}
+}
+
+// amount is a struct with its own JSON encoding
+type amount struct{ cents int64 }
+
+func (a amount) MarshalJSON() ([]byte, error) {
+	return fmt.Appendf(nil, `{"eur":"%d.%02d"}`, a.cents/100, a.cents%100), nil
+}
+
+func TestStrictTypes(t *testing.T) {
+	buf := &bytes.Buffer{}
+	SetConfig(NewConfig(WithOutput(buf), WithLevel(LevelTrace), WithJSON(), WithStrictTypes()))
+	defer SetConfig(Config{Output: io.Discard, Level: LevelTrace})
+
+	LogInfo("strict",
+		"user", struct{ Password string }{"hunter2"},
+		"ptr", &node{Name: "a"},
+		"tags", []string{"a"},
+		"price", amount{150},
+		"addr", netip.MustParseAddr("10.0.0.1"),
+		"n", 3,
+		Group("g", "m", map[string]int{"x": 1}),
+	)
+
+	out := buf.String()
+	for _, want := range []string{
+		`"user":"[rejected struct { Password string }]"`,
+		`"ptr":"[rejected *logger.node]"`,
+		`"tags":"[rejected []string]"`,
+		`"price":{"eur":"1.50"}`,
+		`"addr":"10.0.0.1"`,
+		`"n":3`,
+		`"g":{"m":"[rejected map[string]int]"}`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected %s in output, got: %s", want, out)
+		}
+	}
+}
+
+func TestConditionalLogging(t *testing.T) {
+	var buf bytes.Buffer
+	SetConfig(Config{Output: &buf, Level: LevelTrace, CompactJSON: true, Format: FormatJSON, EnableCaller: true})
+	defer SetConfig(Config{Output: io.Discard, Level: LevelTrace})
+
+	if LogErrorIf(nil, "never") {
+		t.Error("Expected LogErrorIf to report false for a nil error")
+	}
+	LogIf(false, Warn, "never")
+	if buf.Len() != 0 {
+		t.Fatalf("Expected no output, got %q", buf.String())
+	}
+
+	if !LogErrorIf(errors.New("disk full"), "Failed to close export", "path", "/tmp/x") {
+		t.Error("Expected LogErrorIf to report true for an error")
+	}
+	LogIf(true, Warn, "Upstream is flaky", "retries", 4)
+
+	out := buf.String()
+	for _, want := range []string{`"msg":"Failed to close export"`, `"path":"/tmp/x"`, `disk full`, `"level":"WARN"`, `"retries":4`} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected %s in output, got: %s", want, out)
+		}
+	}
+	if strings.Count(out, "logger_test.go") != 2 {
+		t.Errorf("Expected both records attributed to the caller, got: %s", out)
+	}
+}
@@ -0,0 +1,83 @@
+package logger
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"time"
+)
+
+// LogIDKey is the attribute that carries the record ID
+const LogIDKey = "log_id"
+
+// LogIDFormat selects the unique ID stamped on every record
+type LogIDFormat int
+
+const (
+	// LogIDNone adds no ID (default)
+	LogIDNone LogIDFormat = iota
+	// LogIDUUIDv7 adds an RFC 9562 UUIDv7, e.g. 01890a5d-ac96-774b-bcce-b302099a8057
+	LogIDUUIDv7
+	// LogIDULID adds a ULID, e.g. 01H455VB4PEX5VSKNK084SN02Q
+	LogIDULID
+)
+
+// String returns the format name
+func (f LogIDFormat) String() string {
+	switch f {
+	case LogIDNone:
+		return "none"
+	case LogIDUUIDv7:
+		return "uuidv7"
+	case LogIDULID:
+		return "ulid"
+	default:
+		return "unknown"
+	}
+}
+
+// newLogID returns a new ID in format f. Both formats start with the
+// millisecond timestamp t, so IDs sort roughly by time.
+func newLogID(f LogIDFormat, t time.Time) string {
+	var b [16]byte
+	_, _ = rand.Read(b[6:]) // Never fails since Go 1.24
+	ms := uint64(t.UnixMilli())
+	binary.BigEndian.PutUint16(b[0:2], uint16(ms>>32))
+	binary.BigEndian.PutUint32(b[2:6], uint32(ms))
+
+	if f == LogIDULID {
+		return encodeULID(b)
+	}
+
+	b[6] = b[6]&0x0f | 0x70 // Version 7
+	b[8] = b[8]&0x3f | 0x80 // RFC 9562 variant
+	var s [36]byte
+	hex.Encode(s[0:8], b[0:4])
+	s[8] = '-'
+	hex.Encode(s[9:13], b[4:6])
+	s[13] = '-'
+	hex.Encode(s[14:18], b[6:8])
+	s[18] = '-'
+	hex.Encode(s[19:23], b[8:10])
+	s[23] = '-'
+	hex.Encode(s[24:], b[10:])
+	return string(s[:])
+}
+
+// crockford is the ULID base32 alphabet
+const crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// encodeULID encodes 128 bits as 26 Crockford base32 characters, most
+// significant bits first
+func encodeULID(b [16]byte) string {
+	hi := binary.BigEndian.Uint64(b[0:8])
+	lo := binary.BigEndian.Uint64(b[8:16])
+	var s [26]byte
+	// The first character holds the top 3 bits, the rest 5 bits each
+	for i := 25; i >= 0; i-- {
+		s[i] = crockford[lo&0x1f]
+		lo = lo>>5 | hi<<59
+		hi >>= 5
+	}
+	return string(s[:])
+}
@@ -0,0 +1,76 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewLogID(t *testing.T) {
+	at := time.UnixMilli(1700000000123)
+
+	id := newLogID(LogIDUUIDv7, at)
+	if !regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-7[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`).MatchString(id) {
+		t.Errorf("invalid UUIDv7 %q", id)
+	}
+	if ms, _ := strconv.ParseUint(strings.ReplaceAll(id[:13], "-", ""), 16, 64); ms != 1700000000123 {
+		t.Errorf("UUIDv7 timestamp = %d", ms)
+	}
+
+	ulid := newLogID(LogIDULID, at)
+	if !regexp.MustCompile(`^[0-7][0-9A-HJKMNP-TV-Z]{25}$`).MatchString(ulid) {
+		t.Errorf("invalid ULID %q", ulid)
+	}
+	var ms uint64
+	for _, c := range ulid[:10] {
+		ms = ms<<5 | uint64(strings.IndexRune(crockford, c))
+	}
+	if ms != 1700000000123 {
+		t.Errorf("ULID timestamp = %d", ms)
+	}
+
+	if later := newLogID(LogIDULID, at.Add(time.Millisecond)); later <= ulid {
+		t.Errorf("expected %q to sort after %q", later, ulid)
+	}
+	if newLogID(LogIDUUIDv7, at) == id {
+		t.Error("expected distinct IDs within one millisecond")
+	}
+}
+
+func TestLogIDStamped(t *testing.T) {
+	var buf bytes.Buffer
+	SetConfig(Config{Output: &buf, Level: slog.LevelInfo, LevelSet: true, Format: FormatJSON, LogID: LogIDULID})
+	defer SetConfig(Config{Output: io.Discard, Level: LevelTrace})
+
+	LogInfo("first")
+	LogInfo("second", "k", "v")
+
+	seen := map[string]bool{}
+	for line := range strings.Lines(buf.String()) {
+		var entry map[string]any
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatal(err)
+		}
+		id, _ := entry[LogIDKey].(string)
+		if len(id) != 26 || seen[id] {
+			t.Errorf("unexpected log_id %q in %s", id, line)
+		}
+		seen[id] = true
+	}
+	if len(seen) != 2 {
+		t.Errorf("expected 2 IDs, got %v", seen)
+	}
+}
+
+func BenchmarkNewLogID(b *testing.B) {
+	now := time.Now()
+	for b.Loop() {
+		_ = newLogID(LogIDUUIDv7, now)
+	}
+}
@@ -0,0 +1,215 @@
+// Package logtest records log entries in memory so tests can assert on
+// structured fields instead of matching formatted output.
+//
+//	func TestCheckout(t *testing.T) {
+//		rec := logtest.Capture(t)
+//		checkout(cart)
+//		if !rec.Contains("order placed", "items", 3) {
+//			t.Errorf("missing order log, got %v", rec.Entries())
+//		}
+//	}
+package logtest
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jozefvalachovic/logger/v4"
+)
+
+// Entry is one recorded log record
+type Entry struct {
+	Time    time.Time
+	Level   slog.Level
+	Message string
+	Attrs   map[string]any // Resolved values; group keys are joined with dots
+}
+
+// Attr returns the value recorded under key
+func (e Entry) Attr(key string) (any, bool) {
+	v, ok := e.Attrs[key]
+	return v, ok
+}
+
+// Recorder is a slog.Handler that keeps every record it handles. It is safe
+// for concurrent use; WithAttrs and WithGroup clones record into the same
+// entry list.
+type Recorder struct {
+	core   *recorderCore
+	attrs  []slog.Attr
+	prefix string
+}
+
+// recorderCore is the state shared by a Recorder and its clones
+type recorderCore struct {
+	level   slog.Leveler
+	mu      sync.Mutex
+	entries []Entry
+}
+
+// NewRecorder creates a recorder that keeps records at or above level
+// (default: every level)
+func NewRecorder(level slog.Leveler) *Recorder {
+	if level == nil {
+		level = logger.LevelTrace
+	}
+	return &Recorder{core: &recorderCore{level: level}}
+}
+
+// Capture routes the logger through a new Recorder until the test ends.
+// The logger's own level still applies; lower it with logger.SetLevel to
+// capture debug entries.
+func Capture(t testing.TB) *Recorder {
+	t.Helper()
+	rec := NewRecorder(nil)
+	logger.UseHandler(rec)
+	t.Cleanup(func() { logger.UseHandler(nil) })
+	return rec
+}
+
+// Enabled reports whether level meets the recorder's minimum
+func (r *Recorder) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= r.core.level.Level()
+}
+
+// Handle stores the record
+func (r *Recorder) Handle(_ context.Context, rec slog.Record) error {
+	e := Entry{
+		Time:    rec.Time,
+		Level:   rec.Level,
+		Message: rec.Message,
+		Attrs:   make(map[string]any, len(r.attrs)+rec.NumAttrs()),
+	}
+	for _, a := range r.attrs {
+		flattenAttr(e.Attrs, "", a)
+	}
+	rec.Attrs(func(a slog.Attr) bool {
+		flattenAttr(e.Attrs, r.prefix, a)
+		return true
+	})
+
+	r.core.mu.Lock()
+	r.core.entries = append(r.core.entries, e)
+	r.core.mu.Unlock()
+	return nil
+}
+
+// WithAttrs returns a recorder that adds attrs to every entry
+func (r *Recorder) WithAttrs(attrs []slog.Attr) slog.Handler {
+	clone := *r
+	clone.attrs = slices.Clip(r.attrs)
+	for _, a := range attrs {
+		a.Key = r.prefix + a.Key
+		clone.attrs = append(clone.attrs, a)
+	}
+	return &clone
+}
+
+// WithGroup returns a recorder that qualifies subsequent attribute keys with name
+func (r *Recorder) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return r
+	}
+	clone := *r
+	clone.prefix = r.prefix + name + "."
+	return &clone
+}
+
+// Entries returns a copy of the recorded entries, oldest first
+func (r *Recorder) Entries() []Entry {
+	r.core.mu.Lock()
+	defer r.core.mu.Unlock()
+	return slices.Clone(r.core.entries)
+}
+
+// LastEntry returns the most recent entry
+func (r *Recorder) LastEntry() (Entry, bool) {
+	r.core.mu.Lock()
+	defer r.core.mu.Unlock()
+	if len(r.core.entries) == 0 {
+		return Entry{}, false
+	}
+	return r.core.entries[len(r.core.entries)-1], true
+}
+
+// Len returns the number of recorded entries
+func (r *Recorder) Len() int {
+	r.core.mu.Lock()
+	defer r.core.mu.Unlock()
+	return len(r.core.entries)
+}
+
+// CountByLevel returns the number of entries recorded at exactly level
+func (r *Recorder) CountByLevel(level slog.Level) int {
+	r.core.mu.Lock()
+	defer r.core.mu.Unlock()
+	n := 0
+	for _, e := range r.core.entries {
+		if e.Level == level {
+			n++
+		}
+	}
+	return n
+}
+
+// Contains reports whether an entry has message msg and every key/value
+// pair in keyValues. Values are compared as slog values, so 3 matches a
+// recorded int64(3).
+func (r *Recorder) Contains(msg string, keyValues ...any) bool {
+	return len(r.Find(msg, keyValues...)) > 0
+}
+
+// Find returns the entries with message msg and every key/value pair in
+// keyValues. An empty msg matches any message.
+func (r *Recorder) Find(msg string, keyValues ...any) []Entry {
+	r.core.mu.Lock()
+	defer r.core.mu.Unlock()
+	var found []Entry
+	for _, e := range r.core.entries {
+		if (msg == "" || e.Message == msg) && e.matches(keyValues) {
+			found = append(found, e)
+		}
+	}
+	return found
+}
+
+// Reset discards the recorded entries
+func (r *Recorder) Reset() {
+	r.core.mu.Lock()
+	r.core.entries = nil
+	r.core.mu.Unlock()
+}
+
+// matches reports whether e has every key/value pair in keyValues
+func (e Entry) matches(keyValues []any) bool {
+	for i := 0; i+1 < len(keyValues); i += 2 {
+		key, _ := keyValues[i].(string)
+		got, ok := e.Attrs[key]
+		if !ok || !slog.AnyValue(got).Equal(slog.AnyValue(keyValues[i+1])) {
+			return false
+		}
+	}
+	return true
+}
+
+// flattenAttr stores a resolved attribute in fields, joining group keys with dots
+func flattenAttr(fields map[string]any, prefix string, a slog.Attr) {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return
+	}
+	if a.Value.Kind() == slog.KindGroup {
+		if a.Key != "" {
+			prefix += a.Key + "."
+		}
+		for _, ga := range a.Value.Group() {
+			flattenAttr(fields, prefix, ga)
+		}
+		return
+	}
+	fields[prefix+a.Key] = a.Value.Any()
+}
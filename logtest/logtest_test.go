@@ -0,0 +1,85 @@
+package logtest
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jozefvalachovic/logger/v4"
+)
+
+func TestCapture(t *testing.T) {
+	rec := Capture(t)
+
+	logger.LogInfo("order placed", "items", 3, "user", "ana")
+	logger.LogError("payment failed", "error", "declined")
+	logger.Named("billing").LogWarn("retrying", "attempt", 2)
+
+	if n := rec.Len(); n != 3 {
+		t.Fatalf("Len = %d, want 3: %v", n, rec.Entries())
+	}
+	if !rec.Contains("order placed", "items", 3, "user", "ana") {
+		t.Errorf("expected the order entry in %v", rec.Entries())
+	}
+	if rec.Contains("order placed", "items", 4) {
+		t.Error("unexpected match for items=4")
+	}
+	if !rec.Contains("retrying", "logger", "billing") {
+		t.Errorf("expected the named logger field in %v", rec.Entries())
+	}
+	if got := rec.CountByLevel(slog.LevelError); got != 1 {
+		t.Errorf("CountByLevel(Error) = %d, want 1", got)
+	}
+
+	last, ok := rec.LastEntry()
+	if !ok || last.Message != "retrying" || last.Level != slog.LevelWarn {
+		t.Errorf("LastEntry = %+v, %v", last, ok)
+	}
+	if v, _ := last.Attr("attempt"); v != int64(2) {
+		t.Errorf("attempt = %#v, want int64(2)", v)
+	}
+
+	rec.Reset()
+	if _, ok := rec.LastEntry(); ok {
+		t.Error("expected no entries after Reset")
+	}
+}
+
+func TestRecorderGroupsAndClones(t *testing.T) {
+	rec := NewRecorder(slog.LevelInfo)
+	log := slog.New(rec).With("service", "api").WithGroup("req")
+
+	log.Debug("hidden")
+	log.Info("handled", "status", 200, slog.Group("user", "id", 7))
+
+	entries := rec.Entries()
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 entry, got %v", entries)
+	}
+	for key, want := range map[string]any{"service": "api", "req.status": int64(200), "req.user.id": int64(7)} {
+		if got := entries[0].Attrs[key]; got != want {
+			t.Errorf("%s = %#v, want %#v", key, got, want)
+		}
+	}
+	if found := rec.Find("", "service", "api"); len(found) != 1 {
+		t.Errorf("Find with any message = %v", found)
+	}
+}
+
+func TestRecorderConcurrent(t *testing.T) {
+	rec := NewRecorder(nil)
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Go(func() {
+			for range 50 {
+				_ = rec.Handle(context.Background(), slog.NewRecord(time.Time{}, slog.LevelInfo, "x", 0))
+			}
+		})
+	}
+	wg.Wait()
+	if n := rec.CountByLevel(slog.LevelInfo); n != 400 {
+		t.Errorf("CountByLevel = %d, want 400", n)
+	}
+}
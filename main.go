@@ -12,11 +12,12 @@ import (
 	"fmt"
 	"io"
 	"log/slog"
+	"maps"
 	"os"
 	"regexp"
 	"runtime"
 	"runtime/debug"
-	"strings"
+	"slices"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -27,18 +28,51 @@ import (
 type Config struct {
 	Output      io.Writer
 	Level       slog.Level
-	LevelSet    bool // Explicitly marks Level as set (allows setting Level to 0/slog.LevelDebug)
-	EnableColor bool
+	LevelSet    bool      // Explicitly marks Level as set (allows setting Level to 0/slog.LevelInfo)
+	EnableColor bool      // Used when Color is ColorDefault
+	Color       ColorMode // Auto, Always or Never; ColorDefault follows EnableColor
 	TimeFormat  string
 	RedactKeys  []string
 	RedactMask  string
 	MaxBodySize int64    // Maximum size for HTTP body logging in bytes (default: 1MB)
 	RedactPaths []string // URL paths to completely redact from logs
 
+	// RedactQueryParams are query parameters whose values are masked in
+	// logged paths, matched case-insensitively (default: token, api_key,
+	// signature, ...)
+	RedactQueryParams []string
+
+	// RedactRules selects how the value of each key is redacted, e.g.
+	// {"card_number": RedactPartial, "email": RedactHash}. Keys are matched
+	// case-insensitively and need not be in RedactKeys, whose keys use
+	// RedactReplace.
+	RedactRules   map[string]RedactStrategy
+	RedactHashKey []byte // HMAC key for RedactHash, so low-entropy values can't be brute-forced
+
+	// Redactor is called with every attribute, including group members,
+	// before RedactRules and RedactKeys. Returning true replaces the value
+	// with the one returned; false leaves it to the built-in rules. It must
+	// not log through this package.
+	Redactor func(key string, value any) (any, bool)
+
+	// SplitStdStreams sends Warn and above to os.Stderr and everything else
+	// to Output (12-factor convention). For other per-level routing set
+	// Output to a LevelRouter.
+	SplitStdStreams bool
+
 	// Sampling configuration
-	SampleRate    float64 // 0.0 to 1.0, where 0.1 = log 10% of messages (default: 1.0 = all)
-	SampleRateSet bool    // Explicitly marks SampleRate as set (allows setting to 0.0)
-	SampleSeed    int64   // Seed for deterministic sampling
+	SampleRate    float64    // 0.0 to 1.0, where 0.1 = log 10% of messages (default: 1.0 = all; SampleOff = none)
+	SampleRateSet bool       // Explicitly marks SampleRate as set (allows setting to 0.0)
+	SampleSeed    int64      // Seed for deterministic sampling
+	SampleMode    SampleMode // How records are picked (default: SampleByMessage)
+	SampleKey     string     // Attribute SampleByKey hashes, e.g. "request_id"
+	SampleBudget  int        // Records per second above which Info and lower are sampled down (0 = off)
+
+	// Burst sampling: log the first SampleFirst identical (level, message)
+	// records per SampleInterval, then every SampleThereafter-th
+	SampleFirst      int           // 0 = off
+	SampleThereafter int           // 0 = drop the rest of the interval
+	SampleInterval   time.Duration // Default: 1s
 
 	// Rotation configuration
 	Rotation *RotationConfig
@@ -48,6 +82,24 @@ type Config struct {
 	BufferSize   int           // Channel buffer size for async mode (default: 1000)
 	FlushTimeout time.Duration // How often to flush in async mode (default: 1s)
 
+	// AsyncOverflowPolicy controls what happens when the async buffer is full
+	// (default: OverflowFallbackSync)
+	AsyncOverflowPolicy OverflowPolicy
+
+	// Disk spill for OverflowSpill. Records left over by a previous run are
+	// replayed on start; the file is removed when async mode stops.
+	SpillPath     string // Spill file, required with OverflowSpill; must not be a symbolic link
+	SpillMaxBytes int64  // Spill size limit; entries beyond it are dropped (default: 64 MiB)
+
+	// Batched async writes: coalesce queued entries into a single write to Output
+	AsyncBatchSize     int           // Max entries per write (0 or 1 = one write per entry)
+	AsyncBatchInterval time.Duration // Max time an entry waits in a batch (default: FlushTimeout)
+
+	// Async worker pool: encode entries on several goroutines (default: 1).
+	// Entries are written in submission order unless AsyncUnordered is set.
+	AsyncWorkers   int
+	AsyncUnordered bool
+
 	// Metrics configuration
 	EnableMetrics bool
 	MetricsPrefix string // Prefix for metric names (default: "logger")
@@ -55,21 +107,123 @@ type Config struct {
 	// Caller attribution: includes source file:line in log output
 	EnableCaller bool
 
+	// LogID stamps each record with a unique "log_id" (UUIDv7 or ULID) so
+	// entries can be referenced in tickets and deduplicated downstream
+	LogID LogIDFormat
+
 	// Regex-based value redaction patterns (applied to all string values)
 	RedactPatterns []string
 
+	// DetectPII scrubs the personal data of the selected kinds (emails,
+	// phone numbers, IPs, IBANs, card numbers, JWTs) from string values and
+	// bodies, leaving the rest of the string intact (0 = off)
+	DetectPII PIIDetector
+
+	// StrictTypes writes structs, maps, slices and arrays as "[rejected T]"
+	// instead of expanding them by reflection, for deployments that must
+	// not log arbitrary structs. Scalars and types with their own
+	// representation (errors, slog.LogValuer, fmt.Stringer,
+	// encoding.TextMarshaler, json.Marshaler) are unaffected, as are
+	// slog.Attr values built by the caller.
+	StrictTypes bool
+
 	// Output format options
-	CompactJSON  bool // Single-line JSON instead of indented
-	ColorizeJSON bool // Colorize JSON keys (requires EnableColor)
+	Format        OutputFormat  // Line encoding: pretty (default), JSON, logfmt or console
+	FormatVersion FormatVersion // Pins the line layout (default: latest); see FormatVersion
+	CompactJSON   bool          // Single-line JSON instead of indented (pretty format)
+	ColorizeJSON  bool          // Style JSON keys and values with Theme (requires colors, pretty format)
+	Theme         *Theme        // Colors of the pretty format and status codes (default: DarkTheme)
+	Layout        Layout        // Field order and attribute style of the pretty format
+
+	// ConsoleDimKeys lists attributes FormatConsole writes faint, e.g.
+	// request_id, so per-request identifiers recede behind the message
+	ConsoleDimKeys []string
+
+	// Value formats for time.Duration, time.Time and []byte attributes,
+	// applied before any handler sees the record
+	DurationFormat  DurationFormat  // Default: nanoseconds, "duration" key in seconds
+	TimeValueFormat TimeValueFormat // Default: RFC 3339
+	BytesFormat     BytesFormat     // Default: base64
 
 	// Deduplication: suppress repeated identical messages within a window
 	EnableDedup bool
 	DedupWindow time.Duration // Default: 5s
 
+	// ModuleLevels overrides Level for loggers created with Named, e.g.
+	// {"db": slog.LevelDebug}. Dotted names inherit from their parent, so
+	// "db" also covers "db.pool". Adjust at runtime with SetModuleLevel.
+	ModuleLevels map[string]slog.Level
+
+	// Filters drop or keep records by message, module, attribute and level;
+	// see FilterRule
+	Filters []FilterRule
+
+	// FieldProcessors rename, truncate, coerce or remove attributes before
+	// encoding, e.g. []FieldProcessor{RenameKey("msg", "message"), TruncateValues(1024)}
+	FieldProcessors []FieldProcessor
+
+	// MaxAttrs caps the attributes of a record, group members included,
+	// and MaxValueBytes the message and each value, a struct, map or slice
+	// by its JSON encoding. The rest is dropped or cut with "…" and the
+	// record gets "truncated": true, so one call cannot emit megabytes
+	// (0 = unlimited).
+	MaxAttrs      int
+	MaxValueBytes int
+
+	// TailSize keeps the last TailSize records in memory for Tail and
+	// TailHandler, e.g. for an admin page showing recent logs (0 = off)
+	TailSize int
+
+	// AllowControlChars writes messages as given in the pretty and console
+	// formats. From FormatV2 on (the default), their line breaks, ANSI
+	// escape sequences and other control characters are otherwise escaped
+	// (\n, \x1b), so input logged in a message cannot forge log lines;
+	// values are always quoted.
+	AllowControlChars bool
+
+	// Handler replaces the built-in formatter; see UseHandler
+	Handler slog.Handler
+
+	// LogConfigChanges logs a Debug record listing the fields each
+	// SetConfig call changed
+	LogConfigChanges bool
+
+	// AuditConfigChanges logs an Audit record with the old and new value of
+	// every field SetConfig, SetLevel or SetModuleLevel changes. Writers,
+	// handlers and functions are shown by type, key material masked.
+	// Turning it off is recorded too.
+	AuditConfigChanges bool
+
+	// ErrorHandler receives errors from writing records, which are
+	// otherwise dropped; it must not log through this package
+	ErrorHandler func(err error)
+
+	// FallbackOutput receives records the primary output (Output or
+	// Handler) failed to write, e.g. os.Stderr
+	FallbackOutput io.Writer
+
 	// AdditionalHandlers allows sending log output to multiple destinations
-	// using slog.NewMultiHandler (Go 1.26+). The prettyHandler is always included.
+	// using slog.NewMultiHandler (Go 1.26+). The primary handler (built-in or
+	// Handler) is always included. RedactPatterns and DetectPII apply to
+	// them like to Handler.
 	AdditionalHandlers []slog.Handler
 
+	// AuditOutput receives Audit-level records instead of Output, e.g. an
+	// append-only RotatingWriter with its own rotation and retention, or a
+	// NetWriter to a SIEM. Audit records are then written synchronously.
+	AuditOutput io.Writer
+	AuditFormat AuditFormat // Encoding for AuditOutput (default: AuditFormatText)
+
+	// AuditSigning signs each Audit record with an Ed25519 or HMAC key
+	// (nil = unsigned)
+	AuditSigning *AuditSigning
+
+	// AuditRequiredFields makes LogAuditEvent reject events that fail
+	// AuditEvent.Validate or miss one of these fields (see AuditEvent.Require)
+	// when no enterprise Audit logger is configured; that logger uses
+	// audit.Config.RequiredFields instead.
+	AuditRequiredFields []string
+
 	// Enterprise Audit configuration (nil = use legacy LogAudit behavior)
 	Audit *audit.Config
 }
@@ -80,6 +234,16 @@ type RotationConfig struct {
 	MaxAge     time.Duration // Max age before rotation (default: 7 days)
 	MaxBackups int           // Number of old files to keep (default: 3)
 	Compress   bool          // Compress rotated files (default: false)
+
+	// MaxTotalSize caps the bytes used by backups plus the active file,
+	// checked when the writer opens and after each rotation; the oldest
+	// backups are removed first (0 = no limit)
+	MaxTotalSize int64
+
+	// Manifest writes a BackupManifest next to each backup once it is
+	// complete (compressed, with Compress), so integrity can be checked
+	// with VerifyBackup and shippers can pick up finished files only
+	Manifest bool
 }
 
 // Validate checks if the Config has valid settings
@@ -93,14 +257,112 @@ func (c *Config) Validate() error {
 	if c.RedactMask == "" {
 		return fmt.Errorf("RedactMask cannot be empty")
 	}
+	if c.SampleRate != SampleOff && (c.SampleRate < 0 || c.SampleRate > 1) {
+		return fmt.Errorf("invalid SampleRate %v", c.SampleRate)
+	}
+	if c.SampleMode < SampleByMessage || c.SampleMode > SampleByKey {
+		return fmt.Errorf("invalid SampleMode %d", c.SampleMode)
+	}
+	if c.SampleMode == SampleByKey && c.SampleKey == "" {
+		return fmt.Errorf("SampleByKey requires SampleKey")
+	}
+	for key, s := range c.RedactRules {
+		if s < RedactReplace || s > RedactRemove {
+			return fmt.Errorf("invalid RedactRules strategy %d for %q", s, key)
+		}
+	}
+	if c.TailSize < 0 {
+		return fmt.Errorf("TailSize cannot be negative")
+	}
+	if c.MaxAttrs < 0 || c.MaxValueBytes < 0 {
+		return fmt.Errorf("MaxAttrs and MaxValueBytes cannot be negative")
+	}
+	if c.SampleBudget < 0 {
+		return fmt.Errorf("SampleBudget cannot be negative")
+	}
+	if c.SampleFirst < 0 || c.SampleThereafter < 0 || c.SampleInterval < 0 {
+		return fmt.Errorf("SampleFirst, SampleThereafter and SampleInterval cannot be negative")
+	}
 	if c.MaxBodySize < 0 {
 		return fmt.Errorf("MaxBodySize cannot be negative")
 	}
+	if c.AsyncBatchSize < 0 {
+		return fmt.Errorf("AsyncBatchSize cannot be negative")
+	}
+	if c.AsyncWorkers < 0 {
+		return fmt.Errorf("AsyncWorkers cannot be negative")
+	}
+	if c.AsyncBatchInterval < 0 {
+		return fmt.Errorf("AsyncBatchInterval cannot be negative")
+	}
+	if c.SpillMaxBytes < 0 {
+		return fmt.Errorf("SpillMaxBytes cannot be negative")
+	}
+	if c.AuditFormat < AuditFormatText || c.AuditFormat > AuditFormatJSON {
+		return fmt.Errorf("invalid AuditFormat %d", c.AuditFormat)
+	}
+	if c.Color < ColorDefault || c.Color > ColorNever {
+		return fmt.Errorf("invalid Color %d", c.Color)
+	}
+	if c.LogID < LogIDNone || c.LogID > LogIDULID {
+		return fmt.Errorf("invalid LogID %d", c.LogID)
+	}
+	if c.Format < FormatPretty || c.Format > FormatConsole {
+		return fmt.Errorf("invalid Format %d", c.Format)
+	}
+	if err := c.Layout.validate(); err != nil {
+		return err
+	}
+	if c.FormatVersion < FormatVersionLatest || c.FormatVersion > CurrentFormatVersion {
+		return fmt.Errorf("invalid FormatVersion %d", c.FormatVersion)
+	}
+	if c.DurationFormat < DurationDefault || c.DurationFormat > DurationString {
+		return fmt.Errorf("invalid DurationFormat %d", c.DurationFormat)
+	}
+	if c.TimeValueFormat < TimeRFC3339 || c.TimeValueFormat > TimeUnixMillis {
+		return fmt.Errorf("invalid TimeValueFormat %d", c.TimeValueFormat)
+	}
+	if c.BytesFormat < BytesBase64 || c.BytesFormat > BytesLength {
+		return fmt.Errorf("invalid BytesFormat %d", c.BytesFormat)
+	}
+	if c.AsyncOverflowPolicy < OverflowFallbackSync || c.AsyncOverflowPolicy > OverflowSpill {
+		return fmt.Errorf("invalid AsyncOverflowPolicy %d", c.AsyncOverflowPolicy)
+	}
+	if c.AsyncOverflowPolicy == OverflowSpill && c.SpillPath == "" {
+		// A stable path is what lets the next run replay what was left
+		return fmt.Errorf("SpillPath is required with OverflowSpill")
+	}
 	for _, p := range c.RedactPatterns {
 		if _, err := regexp.Compile(p); err != nil {
 			return fmt.Errorf("invalid redact pattern %q: %w", p, err)
 		}
 	}
+	if c.DetectPII&^PIIAll != 0 {
+		return fmt.Errorf("invalid DetectPII %d", c.DetectPII)
+	}
+	if err := validatePatterns("RedactKeys", c.RedactKeys); err != nil {
+		return err
+	}
+	if err := validatePatterns("RedactPaths", c.RedactPaths); err != nil {
+		return err
+	}
+	if err := validatePatterns("RedactQueryParams", c.RedactQueryParams); err != nil {
+		return err
+	}
+	if err := validatePatterns("RedactRules", slices.Collect(maps.Keys(c.RedactRules))); err != nil {
+		return err
+	}
+	if _, err := compileFilters(c.Filters); err != nil {
+		return err
+	}
+	if c.AuditSigning != nil {
+		if err := c.AuditSigning.validate(); err != nil {
+			return err
+		}
+	}
+	if err := audit.ValidateRequiredFields(c.AuditRequiredFields); err != nil {
+		return err
+	}
 	if c.Audit != nil {
 		if err := c.Audit.Validate(); err != nil {
 			return fmt.Errorf("audit config: %w", err)
@@ -109,39 +371,41 @@ func (c *Config) Validate() error {
 	return nil
 }
 
-// Global logger instance and configuration
+// Global logger configuration; the active config, handler and collectors
+// are published together as a runtimeState (see state.go)
 var (
-	defaultLogger *slog.Logger
-	globalConfig  atomic.Pointer[Config]
-
 	// configWriteMu serialises SetConfig calls so that read-modify writes
 	// (e.g. comparing old vs new async/audit config) are safe.
 	configWriteMu sync.Mutex
 
 	// Async logging
 	logChan      chan *logEntry
-	asyncDone    chan bool
+	asyncDone    chan struct{}
+	asyncAbort   chan struct{}      // Closed when Shutdown gives up on the queue
+	asyncStop    context.CancelFunc // Wakes OverflowBlock senders so a stop can take asyncMu
+	asyncStopped <-chan struct{}    // Closed by asyncStop
 	asyncRunning bool
-	asyncMu      sync.Mutex
-	asyncWg      sync.WaitGroup // Tracks if async goroutine is running
-
-	// Metrics
-	metrics *LogMetrics
-
-	// Enterprise audit logger (nil when using legacy behavior)
-	auditLogger *audit.Logger
+	asyncMu      sync.RWMutex    // Write-locked to start/stop, read-locked to enqueue
+	asyncWg      *sync.WaitGroup // Tracks the running async workers
+	asyncBatch   atomic.Pointer[batchWriter]
+	asyncSpill   atomic.Pointer[spillQueue]
 
 	defaultConfig = Config{
-		Output:        os.Stdout,
-		Level:         LevelTrace,
-		EnableColor:   true,
-		CompactJSON:   true, // Single-line JSON by default for production log aggregators
-		TimeFormat:    "2006-01-02 15:04:05",
-		RedactKeys:    []string{"password", "secret", "token", "authorization", "bearer", "api_key", "api-key"},
-		RedactMask:    "***",
-		MaxBodySize:   1 << 20, // 1MB default
-		RedactPaths:   []string{},
+		Output:      os.Stdout,
+		Level:       LevelTrace,
+		LevelSet:    true, // Configs derived from the defaults keep an explicit slog.LevelInfo
+		EnableColor: true,
+		Color:       ColorAuto, // Plain output when piped to a file
+		CompactJSON: true,      // Single-line JSON by default for production log aggregators
+		TimeFormat:  "2006-01-02 15:04:05",
+		RedactKeys:  []string{"password", "secret", "token", "authorization", "bearer", "api_key", "api-key"},
+		RedactMask:  "***",
+		MaxBodySize: 1 << 20, // 1MB default
+		RedactPaths: []string{},
+		RedactQueryParams: []string{"token", "access_token", "refresh_token", "id_token", "api_key", "apikey",
+			"signature", "sig", "password", "secret", "client_secret", "x-amz-signature", "x-amz-credential"},
 		SampleRate:    1.0, // Log everything by default
+		SampleRateSet: true,
 		SampleSeed:    0,
 		Rotation:      nil, // No rotation by default
 		AsyncMode:     false,
@@ -157,86 +421,168 @@ var (
 func init() {
 	cfg := defaultConfig
 	applyEnvOverrides(&cfg)
-	globalConfig.Store(&cfg)
-	initLogger()
+	publishState(cfg, runtimeState{})
 }
 
-// initLogger initializes the default logger based on the current configuration
-func initLogger() {
-	cfg := *globalConfig.Load()
-
+// newHandler builds the handler chain for cfg
+func newHandler(cfg Config) slog.Handler {
 	opts := prettyHandlerOptions{
 		SlogOpts: slog.HandlerOptions{
-			Level:     cfg.Level,
+			// Module overrides may be more verbose than Level; logModule
+			// applies the per-module threshold before records get here
+			Level:     &handlerLevel,
 			AddSource: cfg.EnableCaller,
 		},
 		Config: cfg,
 	}
 
-	var handler slog.Handler = newPrettyHandler(cfg.Output, opts)
+	out := configOutput(cfg)
+	opts.Config.EnableColor = colorEnabled(cfg, out)
+	if b := asyncBatch.Load(); b != nil && cfg.AsyncMode {
+		out = b
+	}
+
+	var handler slog.Handler
+	if cfg.Handler != nil {
+		handler = newRedactHandler(cfg.Handler, cfg)
+	} else {
+		handler = newPrettyHandler(out, opts)
+	}
+	if cfg.FallbackOutput != nil {
+		handler = &failoverHandler{primary: handler, fallback: newFallbackHandler(cfg, opts)}
+	}
+	if cfg.AuditOutput != nil || cfg.AuditSigning != nil {
+		handler = &auditSplitHandler{main: handler, audit: newAuditHandler(cfg, out)}
+	}
 	if len(cfg.AdditionalHandlers) > 0 {
 		allHandlers := make([]slog.Handler, 0, len(cfg.AdditionalHandlers)+1)
-		allHandlers = append(allHandlers, handler)
-		allHandlers = append(allHandlers, cfg.AdditionalHandlers...)
+		allHandlers = append(allHandlers, admittedHandler{handler})
+		for _, h := range cfg.AdditionalHandlers {
+			allHandlers = append(allHandlers, newRedactHandler(h, cfg))
+		}
 		handler = slog.NewMultiHandler(allHandlers...)
 	}
-	defaultLogger = slog.New(handler)
+	return handler
+}
 
-	// Sync the stdlib log package level with our configured level
-	// so log.Print/log.Printf respect the same threshold (Go 1.26+).
-	slog.SetLogLoggerLevel(cfg.Level)
+// admittedHandler reports every level as enabled. Records reaching the
+// built-in handler have already passed admitLog, which may have used a
+// level more verbose than handlerLevel (LoggerWithLevel), so the
+// MultiHandler must not filter them again.
+type admittedHandler struct {
+	slog.Handler
+}
+
+func (admittedHandler) Enabled(context.Context, slog.Level) bool {
+	return true
 }
 
 // logInternal is an internal function to log messages with key-value pairs
 func logInternal(level LogLevel, message string, keyValues ...any) {
+	logModule(nil, 4, level, message, keyValues...)
+}
+
+// logModule logs on behalf of the child logger l, or the root logger when
+// l is nil: its module level, WithLevel override and buffer apply. skip is
+// passed to runtime.Callers to find the call site.
+func logModule(l *childLogger, skip int, level LogLevel, message string, keyValues ...any) {
 	// Lazy evaluation: skip expensive operations if log level doesn't match
-	cfg := *globalConfig.Load()
+	cfg := *loadConfig()
 
-	if cfg.Level > slogLevelFromLogLevel(level) {
-		return // Early return - don't process if we won't log anyway
+	var module string
+	var buffer *RecordBuffer
+	if l != nil {
+		module, buffer = l.name, l.buffer
+	}
+	threshold := moduleLevel(cfg, module)
+	if l != nil && l.level != nil {
+		threshold = *l.level
+	}
+	if !admitLogAt(cfg, threshold, module, level, message, keyValues) {
+		return
 	}
 
-	// Apply sampling
-	if cfg.SampleRate < 1.0 && !shouldSample(message, cfg.SampleRate, cfg.SampleSeed) {
+	// Capture caller PC for source attribution
+	var pc uintptr
+	if cfg.EnableCaller {
+		var pcs [1]uintptr
+		runtime.Callers(skip, pcs[:])
+		pc = pcs[0]
+	}
+
+	if buffer != nil && buffer.add(level, message, pc, keyValues, &cfg) {
 		return
 	}
+	dispatchLog(cfg, level, message, pc, keyValues)
+}
+
+// admitLog applies the level, filter, sampling and deduplication checks and
+// counts the entry in metrics when it passes
+func admitLog(cfg Config, module string, level LogLevel, message string, keyValues []any) bool {
+	return admitLogAt(cfg, moduleLevel(cfg, module), module, level, message, keyValues)
+}
+
+// admitLogAt is admitLog with the level threshold already resolved
+func admitLogAt(cfg Config, threshold slog.Level, module string, level LogLevel, message string, keyValues []any) bool {
+	if threshold > slogLevelFromLogLevel(level) {
+		recordSkipped(cfg, skipLevel)
+		return false // Early return - don't process if we won't log anyway
+	}
+
+	// Apply filter rules
+	if !filterAllows(module, level, message, keyValues) {
+		recordSkipped(cfg, skipFilter)
+		return false
+	}
+
+	// Apply sampling
+	if cfg.SampleRate < 1.0 && !sampleAdmits(cfg, message, keyValues) {
+		recordSkipped(cfg, skipSample)
+		return false
+	}
+	if a := loadState().adaptive; a != nil && !a.admit(level, time.Now()) {
+		recordSkipped(cfg, skipSample)
+		return false
+	}
+	if b := loadState().burst; b != nil && !b.admit(level, message, time.Now()) {
+		recordSkipped(cfg, skipSample)
+		return false
+	}
 
 	// Apply deduplication
-	if cfg.EnableDedup && dedupMgr != nil {
-		if !dedupMgr.ShouldLog(level, message) {
-			return
+	if d := loadState().dedup; cfg.EnableDedup && d != nil {
+		if !d.ShouldLog(level, message) {
+			recordSkipped(cfg, skipDedup)
+			return false
 		}
 	}
 
 	// Track metrics
-	if cfg.EnableMetrics && metrics != nil {
-		metrics.RecordLog(level)
-	}
-
-	// Capture caller PC for source attribution
-	var pc uintptr
-	if cfg.EnableCaller {
-		var pcs [1]uintptr
-		runtime.Callers(3, pcs[:])
-		pc = pcs[0]
+	if m := currentMetrics(); cfg.EnableMetrics && m != nil {
+		m.RecordLog(level)
 	}
+	return true
+}
 
-	// Use async logging if enabled
-	if cfg.AsyncMode && asyncRunning {
+// dispatchLog writes an admitted entry, asynchronously when enabled
+func dispatchLog(cfg Config, level LogLevel, message string, pc uintptr, keyValues []any) {
+	// Use async logging if enabled. Audit records with their own output or a
+	// signature are written synchronously so an overflow policy can never
+	// drop them.
+	if cfg.AsyncMode && (level != Audit || cfg.AuditOutput == nil && cfg.AuditSigning == nil) {
+		// Copy keyValues so the caller's variadic slice stays on its stack
+		// when logging synchronously, and evaluate lazy values here so they
+		// never run on a worker goroutine
 		entry := &logEntry{
 			level:     level,
 			message:   message,
-			keyValues: keyValues,
+			keyValues: resolveLazyValues(slices.Clone(keyValues), &cfg),
 			pc:        pc,
 		}
-		select {
-		case logChan <- entry:
-			// Successfully queued
-		default:
-			// Channel full, fall back to sync logging
-			logInternalSync(level, message, pc, keyValues...)
+		if enqueueAsync(entry, cfg) {
+			return
 		}
-		return
+		// Worker not running or buffer full with OverflowFallbackSync
 	}
 
 	// Synchronous logging
@@ -245,29 +591,119 @@ func logInternal(level LogLevel, message string, keyValues ...any) {
 
 // logInternalSync performs synchronous logging (used by both sync and async paths)
 func logInternalSync(level LogLevel, message string, pc uintptr, keyValues ...any) {
-	cfg := *globalConfig.Load()
+	logInternalSyncContext(context.Background(), level, message, pc, keyValues...)
+}
 
-	if len(keyValues)%2 != 0 {
-		// Handle odd number of arguments
-		keyValues = append(keyValues, "MISSING_VALUE")
-	}
+// logInternalSyncContext is logInternalSync with a context passed to the handler
+func logInternalSyncContext(ctx context.Context, level LogLevel, message string, pc uintptr, keyValues ...any) {
+	// The handler and metrics must come from the same state as cfg
+	st := loadState()
+	cfg := st.config
+
+	// Small records keep their attrs on the stack
+	var attrBuf [8]slog.Attr
+	attrs := attrBuf[:0]
+	for i := 0; i < len(keyValues); i += pairLen(keyValues, i) {
+		if a, ok := keyValues[i].(slog.Attr); ok {
+			if a = redactAttr(a, cfg); a.Equal(slog.Attr{}) {
+				continue // RedactRemove
+			}
+			a = formatAttrValue(a, cfg)
+			if a.Key == "" && a.Value.Kind() == slog.KindGroup {
+				// Inline an unnamed group, as slog does
+				attrs = append(attrs, a.Value.Group()...)
+			} else {
+				attrs = append(attrs, a)
+			}
+			continue
+		}
 
-	attrs := make([]slog.Attr, 0, len(keyValues)/2)
-	for i := 0; i < len(keyValues); i += 2 {
+		key := attrKey(keyValues[i])
+		var value any = "MISSING_VALUE" // Odd number of arguments
 		if i+1 < len(keyValues) {
-			key := fmt.Sprintf("%v", keyValues[i])
-			value := keyValues[i+1]
-			value = redactValueIfNeeded(key, value, cfg)
+			value = keyValues[i+1]
+		}
+		// Redact first so a lazy value behind a sensitive key is never evaluated
+		value, keep := redactValueIfNeeded(key, value, cfg)
+		if !keep {
+			continue
+		}
+		value = resolveLazy(value)
+
+		// Use the new convertToSlogAttr function for all types
+		attr := redactComposite(convertToSlogAttr(key, value, cfg.StrictTypes), &cfg)
+		attrs = append(attrs, formatAttrValue(attr, cfg))
+	}
+
+	if cfg.MaxAttrs > 0 || cfg.MaxValueBytes > 0 {
+		message, attrs = limitRecord(message, attrs, cfg)
+	}
+
+	now := time.Now()
+	if t, ok := ctx.Value(recordTimeKey{}).(time.Time); ok {
+		now = t // Written late by RecordBuffer.Flush
+	}
+	if cfg.LogID != LogIDNone {
+		attrs = append(attrs, slog.String(LogIDKey, newLogID(cfg.LogID, now)))
+	}
 
-			// Use the new convertToSlogAttr function for all types
-			attrs = append(attrs, convertToSlogAttr(key, value))
+	if hooks.Load() != nil {
+		// Hooks get a heap copy so attrBuf can stay on the stack
+		var keep bool
+		if attrs, keep = runHooks(level, message, slices.Clone(attrs)); !keep {
+			return
 		}
 	}
 
+	if len(cfg.FieldProcessors) > 0 {
+		attrs = processAttrs(cfg.FieldProcessors, attrs)
+	}
+
 	slogLevel := slogLevelFromLogLevel(level)
-	record := slog.NewRecord(time.Now(), slogLevel, message, pc)
+	record := slog.NewRecord(now, slogLevel, message, pc)
 	record.AddAttrs(attrs...)
-	_ = defaultLogger.Handler().Handle(context.Background(), record)
+	if st.tail != nil {
+		st.tail.add(record)
+	}
+	var err error
+	if m := st.metrics; m != nil {
+		start := time.Now()
+		err = st.handler.Handle(ctx, record)
+		m.RecordWriteLatency(time.Since(start))
+	} else {
+		err = st.handler.Handle(ctx, record)
+	}
+	if err != nil {
+		reportWriteError(cfg, err)
+	}
+}
+
+// attrKey converts a key argument to a string without formatting plain strings
+func attrKey(k any) string {
+	if s, ok := k.(string); ok {
+		return s
+	}
+	return fmt.Sprintf("%v", k)
+}
+
+// logLevelFromSlog maps a slog level onto the nearest LogLevel at or below it
+func logLevelFromSlog(level slog.Level) LogLevel {
+	switch {
+	case level >= LevelAudit:
+		return Audit
+	case level >= slog.LevelError:
+		return Error
+	case level >= slog.LevelWarn:
+		return Warn
+	case level >= LevelNotice:
+		return Notice
+	case level >= slog.LevelInfo:
+		return Info
+	case level >= slog.LevelDebug:
+		return Debug
+	default:
+		return Trace
+	}
 }
 
 // slogLevelFromLogLevel converts LogLevel to slog.Level
@@ -305,7 +741,7 @@ func ShouldRedactPath(path string, cfg Config) bool {
 // shouldRedactPath checks if a path should be completely redacted (internal)
 func shouldRedactPath(path string, cfg Config) bool {
 	for _, redactPath := range cfg.RedactPaths {
-		if strings.Contains(path, redactPath) {
+		if matchPath(redactPath, path) {
 			return true
 		}
 	}
@@ -0,0 +1,124 @@
+package logger
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// manifestSuffix is appended to a backup's name, without ".gz", to name
+// its manifest: app.log.20260102-150405.0.manifest.json
+const manifestSuffix = ".manifest.json"
+
+// BackupManifest describes a rotated backup. With RotationConfig.Manifest
+// it is written next to the backup once the backup is complete, so its
+// presence marks a file as ready to ship.
+type BackupManifest struct {
+	File   string    `json:"file"`   // Base name of the backup, ending in .gz when compressed
+	Size   int64     `json:"size"`   // Bytes of File
+	SHA256 string    `json:"sha256"` // Hex SHA-256 of File
+	From   time.Time `json:"from"`   // When the writer opened the file
+	To     time.Time `json:"to"`     // When the file was rotated
+}
+
+// finishBackup compresses a backup renamed by rotate and writes its
+// manifest, as configured
+func finishBackup(path string, from, to time.Time, config *RotationConfig) {
+	if config.Compress {
+		path = compressFile(path)
+	}
+	if config.Manifest {
+		_ = writeManifest(path, from, to)
+	}
+}
+
+// writeManifest writes the manifest of the backup at path. It is written
+// to a temporary file and renamed, so a manifest is never seen half done.
+func writeManifest(path string, from, to time.Time) error {
+	size, sum, err := hashFile(path)
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(BackupManifest{
+		File:   filepath.Base(path),
+		Size:   size,
+		SHA256: sum,
+		From:   from,
+		To:     to,
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+	// The temporary name must not look like a backup to listBackups
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".manifest-*.tmp")
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(append(data, '\n'))
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Chmod(tmp.Name(), 0644)
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), strings.TrimSuffix(path, ".gz")+manifestSuffix)
+	}
+	if err != nil {
+		_ = os.Remove(tmp.Name())
+	}
+	return err
+}
+
+// hashFile returns the size and hex SHA-256 of the file at path
+func hashFile(path string) (int64, string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, "", err
+	}
+	defer func() { _ = f.Close() }()
+
+	h := sha256.New()
+	n, err := io.Copy(h, f)
+	if err != nil {
+		return 0, "", err
+	}
+	return n, hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// VerifyBackup checks the backup described by the manifest at
+// manifestPath, which must sit in the same directory, against the
+// manifest's size and SHA-256:
+//
+//	manifests, _ := filepath.Glob("/var/log/app.log.*.manifest.json")
+//	for _, m := range manifests {
+//		if err := logger.VerifyBackup(m); err != nil {
+//			log.Printf("corrupt backup: %v", err)
+//		}
+//	}
+func VerifyBackup(manifestPath string) error {
+	data, err := os.ReadFile(manifestPath)
+	if err != nil {
+		return err
+	}
+	var m BackupManifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return fmt.Errorf("invalid manifest %s: %w", manifestPath, err)
+	}
+	path := filepath.Join(filepath.Dir(manifestPath), filepath.Base(m.File))
+	size, sum, err := hashFile(path)
+	if err != nil {
+		return err
+	}
+	if size != m.Size || sum != m.SHA256 {
+		return fmt.Errorf("backup %s does not match its manifest: %d bytes, sha256 %s; want %d bytes, sha256 %s",
+			path, size, sum, m.Size, m.SHA256)
+	}
+	return nil
+}
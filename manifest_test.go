@@ -0,0 +1,56 @@
+package logger
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRotatingWriterManifest(t *testing.T) {
+	for _, compress := range []bool{false, true} {
+		logFile := filepath.Join(t.TempDir(), "app.log")
+		writer, err := NewRotatingWriter(logFile, &RotationConfig{MaxSize: 50, MaxBackups: 1, Compress: compress, Manifest: true})
+		if err != nil {
+			t.Fatalf("Failed to create rotating writer: %v", err)
+		}
+		_, _ = writer.Write([]byte(strings.Repeat("A", 40)))
+		_, _ = writer.Write([]byte(strings.Repeat("B", 40))) // Rotates
+		_ = writer.Close()
+
+		var manifests []string
+		for deadline := time.Now().Add(2 * time.Second); len(manifests) == 0 && time.Now().Before(deadline); {
+			time.Sleep(10 * time.Millisecond)
+			manifests, _ = filepath.Glob(logFile + ".*" + manifestSuffix)
+		}
+		if len(manifests) != 1 {
+			t.Fatalf("Expected one manifest (compress=%v), got %v", compress, manifests)
+		}
+
+		data, _ := os.ReadFile(manifests[0])
+		var m BackupManifest
+		if err := json.Unmarshal(data, &m); err != nil {
+			t.Fatalf("invalid manifest %s: %v", data, err)
+		}
+		if strings.HasSuffix(m.File, ".gz") != compress || m.Size == 0 || len(m.SHA256) != 64 || m.To.Before(m.From) {
+			t.Errorf("Unexpected manifest (compress=%v): %+v", compress, m)
+		}
+		if err := VerifyBackup(manifests[0]); err != nil {
+			t.Errorf("Expected the backup to verify: %v", err)
+		}
+		if backups := writer.listBackups(); len(backups) != 1 {
+			t.Errorf("Expected the manifest to belong to its backup, got %d backups", len(backups))
+		}
+
+		// Tamper with the backup
+		backup := filepath.Join(filepath.Dir(logFile), m.File)
+		if err := os.WriteFile(backup, []byte("tampered"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := VerifyBackup(manifests[0]); err == nil {
+			t.Error("Expected a modified backup to fail verification")
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package logger
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// Patterns in RedactKeys, RedactPaths, RedactQueryParams and RedactRules:
+//
+//	password        exact key, ignoring case (a substring of the path for RedactPaths)
+//	*_token         glob: * matches any run of characters, including none
+//	re:^x-api-\d+$  regular expression, matched anywhere unless anchored
+//
+// Keys are matched ignoring case, paths with case.
+const regexPatternPrefix = "re:"
+
+// patternRegexps caches the compiled "re:" patterns by expression
+var patternRegexps sync.Map // map[string]*regexp.Regexp
+
+// matchKey reports whether key matches a RedactKeys pattern
+func matchKey(pattern, key string) bool {
+	if expr, ok := strings.CutPrefix(pattern, regexPatternPrefix); ok {
+		re := patternRegexp("(?i)" + expr)
+		return re != nil && re.MatchString(key)
+	}
+	if strings.IndexByte(pattern, '*') >= 0 {
+		return globMatch(pattern, key, true)
+	}
+	return strings.EqualFold(pattern, key)
+}
+
+// matchPath reports whether path matches a RedactPaths pattern. Plain
+// patterns match anywhere in the path, as they always have.
+func matchPath(pattern, path string) bool {
+	if expr, ok := strings.CutPrefix(pattern, regexPatternPrefix); ok {
+		re := patternRegexp(expr)
+		return re != nil && re.MatchString(path)
+	}
+	if strings.IndexByte(pattern, '*') >= 0 {
+		return globMatch(pattern, path, false)
+	}
+	return strings.Contains(path, pattern)
+}
+
+// patternRegexp returns the compiled expression, nil if it is invalid
+// (Validate reports those)
+func patternRegexp(expr string) *regexp.Regexp {
+	if re, ok := patternRegexps.Load(expr); ok {
+		return re.(*regexp.Regexp)
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil
+	}
+	patternRegexps.Store(expr, re)
+	return re
+}
+
+// validatePatterns reports the first invalid "re:" pattern of a field
+func validatePatterns(field string, patterns []string) error {
+	for _, p := range patterns {
+		if expr, ok := strings.CutPrefix(p, regexPatternPrefix); ok {
+			if _, err := regexp.Compile(expr); err != nil {
+				return fmt.Errorf("invalid %s pattern %q: %w", field, p, err)
+			}
+		}
+	}
+	return nil
+}
+
+// globMatch reports whether s matches pattern in full, where * matches any
+// run of bytes. fold ignores ASCII case.
+func globMatch(pattern, s string, fold bool) bool {
+	p, i := 0, 0
+	star, mark := -1, 0
+	for i < len(s) {
+		switch {
+		case p < len(pattern) && pattern[p] == '*':
+			star, mark = p, i
+			p++
+		case p < len(pattern) && sameByte(pattern[p], s[i], fold):
+			p++
+			i++
+		case star >= 0:
+			// Let the last * absorb one more byte
+			p = star + 1
+			mark++
+			i = mark
+		default:
+			return false
+		}
+	}
+	for p < len(pattern) && pattern[p] == '*' {
+		p++
+	}
+	return p == len(pattern)
+}
+
+// sameByte compares two bytes, ignoring ASCII case when fold is set
+func sameByte(a, b byte, fold bool) bool {
+	if a == b {
+		return true
+	}
+	if !fold {
+		return false
+	}
+	if 'A' <= a && a <= 'Z' {
+		a += 'a' - 'A'
+	}
+	if 'A' <= b && b <= 'Z' {
+		b += 'a' - 'A'
+	}
+	return a == b
+}
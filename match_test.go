@@ -0,0 +1,76 @@
+package logger
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestMatchPatterns(t *testing.T) {
+	keys := []struct {
+		pattern, key string
+		want         bool
+	}{
+		{"password", "Password", true},
+		{"password", "db_password", false},
+		{"*_token", "refresh_token", true},
+		{"*_token", "REFRESH_TOKEN", true},
+		{"*_token", "token", false},
+		{"x-api-*", "X-API-Key", true},
+		{"*secret*", "client_secret_v2", true},
+		{"a*b*c", "aXbYc", true},
+		{"a*b*c", "aXbYcZ", false},
+		{"re:^x-.*-key$", "X-Vendor-Key", true},
+		{"re:^x-.*-key$", "x-vendor-keys", false},
+	}
+	for _, tt := range keys {
+		if got := matchKey(tt.pattern, tt.key); got != tt.want {
+			t.Errorf("matchKey(%q, %q) = %v, want %v", tt.pattern, tt.key, got, tt.want)
+		}
+	}
+
+	paths := []struct {
+		pattern, path string
+		want          bool
+	}{
+		{"/admin", "/api/admin/users", true}, // Plain patterns match anywhere
+		{"/admin/*", "/admin/users?id=1", true},
+		{"/admin/*", "/api/admin/users", false},
+		{"/Admin/*", "/admin/users", false}, // Paths keep their case
+		{"*/internal/*", "/v1/internal/metrics", true},
+		{`re:^/users/\d+/ssn$`, "/users/42/ssn", true},
+	}
+	for _, tt := range paths {
+		if got := matchPath(tt.pattern, tt.path); got != tt.want {
+			t.Errorf("matchPath(%q, %q) = %v, want %v", tt.pattern, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestRedactKeyPatterns(t *testing.T) {
+	defer SetConfig(Config{Output: io.Discard, Level: LevelTrace})
+
+	var buf bytes.Buffer
+	SetConfig(Config{Output: &buf, Level: LevelTrace, Format: FormatJSON, CompactJSON: true,
+		RedactKeys: []string{"*_token", "x-api-*"},
+		RedactRules: map[string]RedactStrategy{
+			"*_id":      RedactHash,
+			"stripe_id": RedactPartial, // The exact key wins over the pattern
+		}})
+	LogInfo("call", "refresh_token", "r1", "X-Api-Key", "k1", "user_id", "u1", "stripe_id", "cus_1234567890", "name", "ok")
+
+	out := buf.String()
+	for _, secret := range []string{"r1", "k1", "u1", "cus_"} {
+		if strings.Contains(out, `"`+secret) {
+			t.Errorf("expected %q redacted: %s", secret, out)
+		}
+	}
+	if !strings.Contains(out, `"user_id":"sha256:`) || !strings.Contains(out, `"stripe_id":"**********7890"`) {
+		t.Errorf("expected rule strategies by pattern and exact key: %s", out)
+	}
+
+	if err := SetConfigE(Config{Output: io.Discard, RedactPaths: []string{"re:("}}); err == nil {
+		t.Error("expected an error for an invalid regular expression")
+	}
+}
@@ -0,0 +1,178 @@
+package logger
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Content types served by MetricsHandler
+const (
+	prometheusContentType  = "text/plain; version=0.0.4; charset=utf-8"
+	openMetricsContentType = "application/openmetrics-text; version=1.0.0; charset=utf-8"
+)
+
+// metricFamily is one exposed metric with its samples
+type metricFamily struct {
+	name    string // Without the prefix, and without "_total" for counters
+	help    string
+	typ     string // "counter", "gauge" or "histogram"
+	total   bool   // Counter sample is named <name>_total in the Prometheus format
+	samples []metricSample
+}
+
+// metricSample is one sample line of a family
+type metricSample struct {
+	suffix string // "_total", "_bucket", "_sum", "_count" or ""
+	labels string // Rendered label set without braces, e.g. level="info"
+	value  string
+}
+
+// logLevelNames lists the level labels in severity order
+var logLevelNames = []string{"trace", "debug", "info", "notice", "warn", "error", "audit"}
+
+// MetricsHandler returns an http.Handler that serves metrics in the
+// Prometheus text format, or in OpenMetrics 1.0 when the scraper asks for
+// application/openmetrics-text. Only the standard library is used.
+// Enable metrics with logger.SetConfig(logger.Config{EnableMetrics: true}).
+func MetricsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		openMetrics := strings.Contains(r.Header.Get("Accept"), "application/openmetrics-text")
+		if openMetrics {
+			w.Header().Set("Content-Type", openMetricsContentType)
+		} else {
+			w.Header().Set("Content-Type", prometheusContentType)
+		}
+
+		if currentMetrics() == nil {
+			if openMetrics {
+				_, _ = io.WriteString(w, "# EOF\n")
+				return
+			}
+			_, _ = fmt.Fprintln(w, "# No metrics collected (EnableMetrics is false)")
+			return
+		}
+
+		prefix := loadConfig().MetricsPrefix
+		if prefix == "" {
+			prefix = "logger"
+		}
+		writeMetricFamilies(w, prefix, metricFamilies(GetMetrics()), openMetrics)
+	})
+}
+
+// metricFamilies converts a GetMetrics snapshot into exposed families
+func metricFamilies(m map[string]any) []metricFamily {
+	counter := func(name, help string, total bool, key string) metricFamily {
+		f := metricFamily{name: name, help: help, typ: "counter", total: total}
+		if v, ok := m[key].(int64); ok {
+			f.samples = []metricSample{{suffix: "_total", value: strconv.FormatInt(v, 10)}}
+		}
+		return f
+	}
+
+	families := []metricFamily{
+		counter("logs", "Total number of log entries", true, "total_logs"),
+	}
+
+	byLevel := metricFamily{name: "logs_by_level", help: "Log entries by level", typ: "counter"}
+	for _, level := range logLevelNames {
+		if v, ok := m["logs_"+level].(int64); ok {
+			byLevel.samples = append(byLevel.samples, metricSample{suffix: "_total", labels: fmt.Sprintf("level=%q", level), value: strconv.FormatInt(v, 10)})
+		}
+	}
+	families = append(families,
+		byLevel,
+		counter("dropped_logs", "Entries discarded by the async overflow policy", true, "dropped_logs"),
+		counter("spilled_logs", "Entries written to the async spill file", true, "spilled_logs"),
+		counter("suppressed_logs", "Entries below the global or module level", true, "suppressed_logs"),
+		counter("filtered_logs", "Entries dropped by filter rules", true, "filtered_logs"),
+		counter("sampled_out_logs", "Entries skipped by sampling", true, "sampled_out_logs"),
+		counter("deduplicated_logs", "Entries suppressed as duplicates", true, "deduplicated_logs"),
+		counter("redacted_values", "Attribute values replaced with the redact mask", true, "redacted_values"),
+		counter("write_errors", "Records or async batches the output failed to write", true, "write_errors"),
+		counter("fallback_writes", "Records or async batches written to FallbackOutput", true, "fallback_writes"),
+	)
+
+	errorRate := metricFamily{name: "error_rate", help: "Errors per second over the last minute", typ: "gauge"}
+	if v, ok := m["error_rate"].(float64); ok {
+		errorRate.samples = []metricSample{{value: formatMetricFloat(v)}}
+	}
+	families = append(families, errorRate)
+
+	if depth, ok := m["async_queue_depth"].(int); ok {
+		families = append(families,
+			metricFamily{name: "async_queue_depth", help: "Entries waiting in the async queue", typ: "gauge",
+				samples: []metricSample{{value: strconv.Itoa(depth)}}},
+			metricFamily{name: "async_queue_capacity", help: "Size of the async queue", typ: "gauge",
+				samples: []metricSample{{value: fmt.Sprint(m["async_queue_capacity"])}}},
+		)
+	}
+	if spill, ok := m["async_spill_bytes"].(int64); ok {
+		families = append(families, metricFamily{name: "async_spill_bytes", help: "Bytes waiting in the async spill file", typ: "gauge",
+			samples: []metricSample{{value: strconv.FormatInt(spill, 10)}}})
+	}
+
+	if latency, ok := m["write_latency"].(HistogramSnapshot); ok {
+		f := metricFamily{name: "write_latency_seconds", help: "Time the handler took to encode and write a record", typ: "histogram"}
+		for _, b := range latency.Buckets {
+			f.samples = append(f.samples, metricSample{suffix: "_bucket", labels: fmt.Sprintf("le=%q", formatMetricFloat(b.UpperBound.Seconds())), value: strconv.FormatInt(b.Count, 10)})
+		}
+		f.samples = append(f.samples,
+			metricSample{suffix: "_bucket", labels: `le="+Inf"`, value: strconv.FormatInt(latency.Count, 10)},
+			metricSample{suffix: "_sum", value: formatMetricFloat(latency.Sum.Seconds())},
+			metricSample{suffix: "_count", value: strconv.FormatInt(latency.Count, 10)},
+		)
+		families = append(families, f)
+	}
+
+	rates := metricFamily{name: "log_rate", help: "Log entries per minute by level over a trailing window", typ: "gauge"}
+	for _, level := range logLevelNames {
+		for _, window := range rateWindows {
+			name := windowName(window)
+			if v, ok := m["rate_"+level+"_"+name].(float64); ok {
+				rates.samples = append(rates.samples, metricSample{labels: fmt.Sprintf("level=%q,window=%q", level, name), value: formatMetricFloat(v)})
+			}
+		}
+	}
+	return append(families, rates)
+}
+
+// writeMetricFamilies renders families in the Prometheus text format or,
+// when openMetrics is set, in OpenMetrics: counter families are declared
+// without the "_total" suffix their samples carry, and the output ends
+// with "# EOF"
+func writeMetricFamilies(w io.Writer, prefix string, families []metricFamily, openMetrics bool) {
+	var b strings.Builder
+	for _, f := range families {
+		name := prefix + "_" + f.name
+		declared := name
+		if !openMetrics && f.typ == "counter" && f.total {
+			declared += "_total"
+		}
+		fmt.Fprintf(&b, "# HELP %s %s\n", declared, f.help)
+		fmt.Fprintf(&b, "# TYPE %s %s\n", declared, f.typ)
+		for _, s := range f.samples {
+			sampleName := name + s.suffix
+			if !openMetrics && f.typ == "counter" {
+				sampleName = declared
+			}
+			b.WriteString(sampleName)
+			if s.labels != "" {
+				b.WriteString("{" + s.labels + "}")
+			}
+			b.WriteString(" " + s.value + "\n")
+		}
+	}
+	if openMetrics {
+		b.WriteString("# EOF\n")
+	}
+	_, _ = io.WriteString(w, b.String())
+}
+
+// formatMetricFloat formats v in the shortest form that round-trips
+func formatMetricFloat(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
@@ -0,0 +1,84 @@
+package logger
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func scrapeMetrics(t *testing.T, accept string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	rec := httptest.NewRecorder()
+	MetricsHandler().ServeHTTP(rec, req)
+	return rec
+}
+
+func TestMetricsHandlerFormats(t *testing.T) {
+	SetConfig(Config{Output: io.Discard, Level: slog.LevelInfo, LevelSet: true, EnableMetrics: true, MetricsPrefix: "app"})
+	defer SetConfig(Config{Output: io.Discard, Level: LevelTrace})
+
+	LogInfo("one")
+	LogError("two")
+
+	t.Run("prometheus", func(t *testing.T) {
+		rec := scrapeMetrics(t, "")
+		if ct := rec.Header().Get("Content-Type"); ct != prometheusContentType {
+			t.Errorf("Content-Type = %q", ct)
+		}
+		body := rec.Body.String()
+		for _, want := range []string{
+			"# TYPE app_logs_total counter\napp_logs_total 2\n",
+			"# TYPE app_logs_by_level counter\n",
+			`app_logs_by_level{level="error"} 1`,
+			"# TYPE app_redacted_values_total counter\n",
+			"# TYPE app_error_rate gauge\n",
+		} {
+			if !strings.Contains(body, want) {
+				t.Errorf("expected %q in:\n%s", want, body)
+			}
+		}
+		if strings.Contains(body, "# EOF") {
+			t.Error("unexpected # EOF in the Prometheus format")
+		}
+	})
+
+	t.Run("openmetrics", func(t *testing.T) {
+		rec := scrapeMetrics(t, "application/openmetrics-text;version=1.0.0,text/plain;q=0.5")
+		if ct := rec.Header().Get("Content-Type"); ct != openMetricsContentType {
+			t.Errorf("Content-Type = %q", ct)
+		}
+		body := rec.Body.String()
+		for _, want := range []string{
+			"# TYPE app_logs counter\napp_logs_total 2\n",
+			"# TYPE app_logs_by_level counter\n",
+			`app_logs_by_level_total{level="error"} 1`,
+			"# TYPE app_redacted_values counter\n",
+			"# TYPE app_write_latency_seconds histogram\n",
+		} {
+			if !strings.Contains(body, want) {
+				t.Errorf("expected %q in:\n%s", want, body)
+			}
+		}
+		if !strings.HasSuffix(body, "# EOF\n") {
+			t.Errorf("expected the body to end with # EOF:\n%s", body)
+		}
+	})
+}
+
+func TestMetricsHandlerDisabled(t *testing.T) {
+	SetConfig(Config{Output: io.Discard, Level: LevelTrace})
+
+	if body := scrapeMetrics(t, "").Body.String(); !strings.HasPrefix(body, "# No metrics collected") {
+		t.Errorf("unexpected Prometheus body %q", body)
+	}
+	if body := scrapeMetrics(t, "application/openmetrics-text").Body.String(); body != "# EOF\n" {
+		t.Errorf("unexpected OpenMetrics body %q", body)
+	}
+}
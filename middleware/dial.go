@@ -0,0 +1,113 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http/httptrace"
+	"sync"
+	"time"
+
+	"github.com/jozefvalachovic/logger/v4"
+)
+
+// DialFunc is the signature of net.Dialer.DialContext and
+// http.Transport.DialContext
+type DialFunc func(ctx context.Context, network, address string) (net.Conn, error)
+
+// LogDialer returns a DialFunc that dials through d (a zero net.Dialer if
+// nil) and logs each outbound connection with its target, the address it
+// reached, and how long DNS resolution and connecting took: at Debug when
+// it succeeds, at Warn with the failing phase when it does not. Records go
+// through logger.FromContext, so they carry the fields of the request
+// that caused the dial.
+//
+// The dial itself is left to d.DialContext, so its timeouts, Happy Eyeballs
+// racing and FallbackDelay apply unchanged; the phases are timed through
+// the httptrace hooks the net package reports to. dial.attempts counts the
+// addresses tried when there was more than one.
+//
+//	transport := &http.Transport{DialContext: middleware.LogDialer(&net.Dialer{Timeout: 5 * time.Second})}
+func LogDialer(d *net.Dialer) DialFunc {
+	if d == nil {
+		d = &net.Dialer{}
+	}
+	return func(ctx context.Context, network, address string) (net.Conn, error) {
+		start := time.Now()
+		t := &dialTrace{}
+		conn, err := d.DialContext(httptrace.WithClientTrace(ctx, t.clientTrace()), network, address)
+		duration := time.Since(start)
+
+		kv := []any{"dial.network", network, "dial.addr", address}
+		phase := "connect"
+		t.mu.Lock()
+		// IP literals and Unix sockets skip DNS
+		if !t.dnsStart.IsZero() {
+			dnsDone := t.dnsDone
+			if dnsDone.IsZero() {
+				dnsDone = start.Add(duration)
+				phase = "dns"
+			}
+			kv = append(kv, "dial.dns", dnsDone.Sub(t.dnsStart).String())
+			if t.dnsErr != nil {
+				phase = "dns"
+			}
+		}
+		if !t.connectStart.IsZero() {
+			kv = append(kv, "dial.connect", start.Add(duration).Sub(t.connectStart).String())
+		} else if t.dnsStart.IsZero() && err == nil {
+			// The trace hooks only fire for IP networks
+			kv = append(kv, "dial.connect", duration.String())
+		}
+		if t.attempts > 1 {
+			kv = append(kv, "dial.attempts", t.attempts)
+		}
+		t.mu.Unlock()
+
+		log := logger.FromContext(ctx)
+		kv = append(kv, "duration", duration.String())
+		if err != nil {
+			kv = append(kv, "__error", err, "dial.phase", phase)
+			log.LogWarn(fmt.Sprintf("DIAL %s failed %s", address, duration), kv...)
+			return nil, err
+		}
+		kv = append(kv, "dial.remote", conn.RemoteAddr().String())
+		log.LogDebug(fmt.Sprintf("DIAL %s %s", address, duration), kv...)
+		return conn, nil
+	}
+}
+
+// dialTrace collects the phases of one dial. Happy Eyeballs connects from
+// several goroutines, which may still report after the dial has returned.
+type dialTrace struct {
+	mu           sync.Mutex
+	dnsStart     time.Time
+	dnsDone      time.Time
+	dnsErr       error
+	connectStart time.Time
+	attempts     int
+}
+
+func (t *dialTrace) clientTrace() *httptrace.ClientTrace {
+	return &httptrace.ClientTrace{
+		DNSStart: func(httptrace.DNSStartInfo) {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			t.dnsStart = time.Now()
+		},
+		DNSDone: func(info httptrace.DNSDoneInfo) {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			t.dnsDone = time.Now()
+			t.dnsErr = info.Err
+		},
+		ConnectStart: func(network, addr string) {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			if t.connectStart.IsZero() {
+				t.connectStart = time.Now()
+			}
+			t.attempts++
+		},
+	}
+}
@@ -0,0 +1,422 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jozefvalachovic/logger/v4"
+)
+
+// maxGraphQLErrors is how many resolver errors a record lists
+const maxGraphQLErrors = 10
+
+// GraphQLOptions configures GraphQL operation logging.
+type GraphQLOptions struct {
+	// LogVariables logs the operation's variables, with the logger's
+	// RedactKeys applied at any depth and RedactVariables masked
+	LogVariables bool
+	// RedactVariables are variable names whose values are always masked,
+	// e.g. "input" for a mutation taking a whole form
+	RedactVariables []string
+	// SkipOperations are operation names passed through without logging,
+	// e.g. IntrospectionQuery
+	SkipOperations []string
+}
+
+// GraphQLOption is a functional option for GraphQL logging.
+type GraphQLOption func(*GraphQLOptions)
+
+// WithGraphQLVariables logs operation variables, redacted.
+func WithGraphQLVariables(enabled bool) GraphQLOption {
+	return func(o *GraphQLOptions) { o.LogVariables = enabled }
+}
+
+// WithGraphQLRedactVariables masks the values of the named variables.
+func WithGraphQLRedactVariables(names ...string) GraphQLOption {
+	return func(o *GraphQLOptions) { o.RedactVariables = names }
+}
+
+// WithGraphQLSkipOperations skips logging for the named operations.
+func WithGraphQLSkipOperations(names ...string) GraphQLOption {
+	return func(o *GraphQLOptions) { o.SkipOperations = names }
+}
+
+// GraphQLOperation describes a GraphQL operation for logging.
+type GraphQLOperation struct {
+	// Name is the operation name, "" for an anonymous operation
+	Name string
+	// Type is query, mutation or subscription
+	Type string
+	// Variables are the operation's variables
+	Variables map[string]any
+}
+
+// graphQLRequest is the body of a GraphQL-over-HTTP request
+type graphQLRequest struct {
+	Query         string         `json:"query"`
+	OperationName string         `json:"operationName"`
+	Variables     map[string]any `json:"variables"`
+}
+
+// graphQLResponse holds the errors of a GraphQL-over-HTTP response
+type graphQLResponse struct {
+	Errors []struct {
+		Message string `json:"message"`
+		Path    []any  `json:"path"`
+	} `json:"errors"`
+}
+
+// LogGraphQLMiddleware logs each GraphQL operation served by next with its
+// type, name, duration and resolver errors, instead of one generic
+// "POST /graphql" line per request. It reads GET and POST requests,
+// including batches, and the errors of JSON responses:
+//
+//	mux.Handle("/graphql", middleware.LogGraphQLMiddleware(srv, middleware.WithGraphQLVariables(true)))
+//	// GraphQL mutation CreateOrder [200] 12.5ms  graphql.errors=["items.0.sku: not found"]
+//
+// Operations are logged at Info, at Warn with resolver errors and at Error
+// when the status is 5xx. The handler's context carries a logger with the
+// operation name and type, for logger.FromContext.
+func LogGraphQLMiddleware(next http.Handler, opts ...GraphQLOption) http.Handler {
+	options := &GraphQLOptions{}
+	for _, o := range opts {
+		o(options)
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ops := readGraphQLRequest(r)
+		if len(ops) == 0 || slices.ContainsFunc(ops, func(op GraphQLOperation) bool {
+			return slices.Contains(options.SkipOperations, op.Name)
+		}) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if len(ops) == 1 {
+			r = r.WithContext(logger.NewContext(r.Context(), logger.FromContext(r.Context()).With(
+				"graphql.operation", ops[0].Name, "graphql.type", ops[0].Type)))
+		}
+		gw := &graphQLWriter{ResponseWriter: w, status: http.StatusOK, limit: logger.GetConfig().MaxBodySize}
+		start := time.Now()
+		next.ServeHTTP(gw, r)
+		duration := time.Since(start)
+
+		errs := gw.errors(len(ops))
+		for i, op := range ops {
+			logGraphQLOperation(op, gw.status, errs[i], duration, options)
+		}
+	})
+}
+
+// LogGraphQL logs one operation run by handler, which returns the
+// operation's errors. It provides the logging of LogGraphQLMiddleware to
+// servers with their own operation hooks, without importing them; with
+// gqlgen:
+//
+//	srv.AroundResponses(func(ctx context.Context, next graphql.ResponseHandler) *graphql.Response {
+//	    oc := graphql.GetOperationContext(ctx)
+//	    op := middleware.GraphQLOperation{Name: oc.OperationName, Type: string(oc.Operation.Operation), Variables: oc.Variables}
+//	    var resp *graphql.Response
+//	    middleware.LogGraphQL(ctx, op, func(ctx context.Context) (errs []error) {
+//	        resp = next(ctx)
+//	        for _, err := range resp.Errors {
+//	            errs = append(errs, err)
+//	        }
+//	        return errs
+//	    })
+//	    return resp
+//	})
+func LogGraphQL(ctx context.Context, op GraphQLOperation, handler func(ctx context.Context) []error, opts ...GraphQLOption) {
+	options := &GraphQLOptions{}
+	for _, o := range opts {
+		o(options)
+	}
+
+	if slices.Contains(options.SkipOperations, op.Name) {
+		handler(ctx)
+		return
+	}
+
+	ctx = logger.NewContext(ctx, logger.FromContext(ctx).With("graphql.operation", op.Name, "graphql.type", op.Type))
+	start := time.Now()
+	errs := handler(ctx)
+	duration := time.Since(start)
+
+	messages := make([]string, len(errs))
+	for i, err := range errs {
+		messages[i] = err.Error()
+	}
+	logGraphQLOperation(op, 0, messages, duration, options)
+}
+
+// logGraphQLOperation writes the record of one operation; status is 0
+// outside HTTP
+func logGraphQLOperation(op GraphQLOperation, status int, errs []string, duration time.Duration, options *GraphQLOptions) {
+	opType, name := op.Type, op.Name
+	if opType == "" {
+		opType = "operation"
+	}
+	if name == "" {
+		name = "anonymous"
+	}
+	msg := fmt.Sprintf("GraphQL %s %s %s", opType, name, duration)
+	if status > 0 {
+		msg = fmt.Sprintf("GraphQL %s %s [%d] %s", opType, name, status, duration)
+	}
+
+	kv := []any{
+		"graphql.operation", op.Name,
+		"graphql.type", op.Type,
+		"graphql.duration", duration.String(),
+	}
+	if status > 0 {
+		kv = append(kv, "graphql.status", status)
+	}
+	if options.LogVariables && len(op.Variables) > 0 {
+		kv = append(kv, "graphql.variables", redactVariables(op.Variables, options.RedactVariables))
+	}
+	if len(errs) > 0 {
+		kv = append(kv, "graphql.error_count", len(errs), "graphql.errors", errs[:min(len(errs), maxGraphQLErrors)])
+	}
+
+	switch {
+	case status >= 500:
+		logger.LogError(msg, kv...)
+	case len(errs) > 0:
+		logger.LogWarn(msg, kv...)
+	default:
+		logger.LogInfo(msg, kv...)
+	}
+}
+
+// redactVariables returns vars with the named variables masked. The
+// logger's RedactKeys are applied to the rest when the record is written.
+func redactVariables(vars map[string]any, names []string) map[string]any {
+	if len(names) == 0 {
+		return vars
+	}
+	mask := logger.GetConfig().RedactMask
+	out := make(map[string]any, len(vars))
+	for k, v := range vars {
+		if slices.ContainsFunc(names, func(n string) bool { return strings.EqualFold(n, k) }) {
+			v = mask
+		}
+		out[k] = v
+	}
+	return out
+}
+
+// readGraphQLRequest returns the operations of r, nil when it is not a
+// GraphQL request, leaving the body for the handler to read
+func readGraphQLRequest(r *http.Request) []GraphQLOperation {
+	var reqs []graphQLRequest
+	switch r.Method {
+	case http.MethodGet:
+		q := r.URL.Query()
+		req := graphQLRequest{Query: q.Get("query"), OperationName: q.Get("operationName")}
+		if v := q.Get("variables"); v != "" {
+			_ = json.Unmarshal([]byte(v), &req.Variables)
+		}
+		reqs = []graphQLRequest{req}
+	case http.MethodPost:
+		if r.Body == nil {
+			return nil
+		}
+		body, truncated, err := logger.ReadBody(r, logger.GetConfig().MaxBodySize)
+		if err != nil || truncated {
+			return nil
+		}
+		body = bytes.TrimSpace(body)
+		if len(body) > 0 && body[0] == '[' {
+			err = json.Unmarshal(body, &reqs)
+		} else {
+			reqs = make([]graphQLRequest, 1)
+			err = json.Unmarshal(body, &reqs[0])
+		}
+		if err != nil {
+			return nil
+		}
+	default:
+		return nil
+	}
+
+	ops := make([]GraphQLOperation, 0, len(reqs))
+	for _, req := range reqs {
+		if req.Query == "" {
+			return nil // Persisted queries and other non-standard requests
+		}
+		opType, name := parseGraphQLOperation(req.Query, req.OperationName)
+		ops = append(ops, GraphQLOperation{Name: name, Type: opType, Variables: req.Variables})
+	}
+	return ops
+}
+
+// parseGraphQLOperation returns the type and name of the operation of
+// document that operationName selects, or of its first operation. The
+// shorthand "{ ... }" is an anonymous query.
+func parseGraphQLOperation(document, operationName string) (opType, name string) {
+	depth := 0
+	found := false
+	for i := 0; i < len(document); {
+		c := document[i]
+		switch {
+		case c == '#':
+			for i < len(document) && document[i] != '\n' {
+				i++
+			}
+		case c == '"':
+			i = skipGraphQLString(document, i)
+		case c == '{' || c == '(' || c == '[':
+			if depth == 0 && c == '{' && !found {
+				// Shorthand query
+				found = true
+				if operationName == "" {
+					return "query", ""
+				}
+			}
+			depth++
+			i++
+		case c == '}' || c == ')' || c == ']':
+			depth--
+			i++
+			if depth == 0 && c == '}' {
+				found = false // End of a definition
+			}
+		case depth == 0 && isGraphQLNameStart(c):
+			word := graphQLName(document, i)
+			i += len(word)
+			switch word {
+			case "query", "mutation", "subscription":
+				for i < len(document) && strings.IndexByte(" \t\r\n,", document[i]) >= 0 {
+					i++
+				}
+				n := ""
+				if i < len(document) && isGraphQLNameStart(document[i]) {
+					n = graphQLName(document, i)
+					i += len(n)
+				}
+				found = true
+				if operationName == "" || n == operationName {
+					return word, n
+				}
+			case "fragment":
+				found = true
+			}
+		default:
+			i++
+		}
+	}
+	return "", operationName
+}
+
+// skipGraphQLString returns the index after the string or block string
+// starting at i
+func skipGraphQLString(s string, i int) int {
+	if strings.HasPrefix(s[i:], `"""`) {
+		if end := strings.Index(s[i+3:], `"""`); end >= 0 {
+			return i + 3 + end + 3
+		}
+		return len(s)
+	}
+	for i++; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '"':
+			return i + 1
+		}
+	}
+	return len(s)
+}
+
+func isGraphQLNameStart(c byte) bool {
+	return c == '_' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'
+}
+
+// graphQLName returns the name starting at i
+func graphQLName(s string, i int) string {
+	j := i
+	for j < len(s) && (isGraphQLNameStart(s[j]) || '0' <= s[j] && s[j] <= '9') {
+		j++
+	}
+	return s[i:j]
+}
+
+// graphQLWriter records the status of a GraphQL response and keeps up to
+// limit bytes of its body to read the errors from
+type graphQLWriter struct {
+	http.ResponseWriter
+	status  int
+	body    bytes.Buffer
+	limit   int64
+	over    bool
+	written bool
+}
+
+func (w *graphQLWriter) WriteHeader(code int) {
+	if !w.written {
+		w.status = code
+		w.written = true
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *graphQLWriter) Write(b []byte) (int, error) {
+	w.written = true
+	if !w.over {
+		if int64(w.body.Len()+len(b)) > w.limit {
+			w.over = true
+			w.body.Reset()
+		} else {
+			w.body.Write(b)
+		}
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Unwrap lets http.ResponseController reach Flush and Hijack, which
+// subscriptions over SSE and WebSockets use
+func (w *graphQLWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// errors returns the error messages of each of n operations, prefixed
+// with their path; none when the body is not a JSON response
+func (w *graphQLWriter) errors(n int) [][]string {
+	errs := make([][]string, n)
+	if w.over {
+		return errs
+	}
+	body := bytes.TrimSpace(w.body.Bytes())
+	var resps []graphQLResponse
+	if n > 1 {
+		if json.Unmarshal(body, &resps) != nil {
+			return errs
+		}
+	} else {
+		resps = make([]graphQLResponse, 1)
+		if json.Unmarshal(body, &resps[0]) != nil {
+			return errs
+		}
+	}
+	for i, resp := range resps[:min(len(resps), n)] {
+		for _, e := range resp.Errors {
+			msg := e.Message
+			if len(e.Path) > 0 {
+				path := make([]string, len(e.Path))
+				for j, p := range e.Path {
+					path[j] = fmt.Sprint(p)
+				}
+				msg = strings.Join(path, ".") + ": " + msg
+			}
+			errs[i] = append(errs[i], msg)
+		}
+	}
+	return errs
+}
@@ -0,0 +1,144 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// grpcWebTailSize is how many trailing response bytes are kept to find the
+// trailer frame of a gRPC-Web response
+const grpcWebTailSize = 4096
+
+// grpcCodes are the gRPC status codes by number, with the HTTP status
+// their outcome corresponds to, which picks the level of the access record
+var grpcCodes = []struct {
+	name   string
+	status int
+}{
+	{"OK", http.StatusOK},
+	{"Canceled", 499},
+	{"Unknown", http.StatusInternalServerError},
+	{"InvalidArgument", http.StatusBadRequest},
+	{"DeadlineExceeded", http.StatusGatewayTimeout},
+	{"NotFound", http.StatusNotFound},
+	{"AlreadyExists", http.StatusConflict},
+	{"PermissionDenied", http.StatusForbidden},
+	{"ResourceExhausted", http.StatusTooManyRequests},
+	{"FailedPrecondition", http.StatusBadRequest},
+	{"Aborted", http.StatusConflict},
+	{"OutOfRange", http.StatusBadRequest},
+	{"Unimplemented", http.StatusNotImplemented},
+	{"Internal", http.StatusInternalServerError},
+	{"Unavailable", http.StatusServiceUnavailable},
+	{"DataLoss", http.StatusInternalServerError},
+	{"Unauthenticated", http.StatusUnauthorized},
+}
+
+// grpcKind reports whether a request is gRPC or gRPC-Web by its Content-Type
+func grpcKind(r *http.Request) (isGRPC, isWeb bool) {
+	ct := r.Header.Get("Content-Type")
+	if !strings.HasPrefix(ct, "application/grpc") {
+		return false, false
+	}
+	return true, strings.HasPrefix(ct, "application/grpc-web")
+}
+
+// grpcStatus returns the grpc-status and grpc-message of a response: from
+// its headers for trailers-only responses, its trailers, or the trailer
+// frame at the end of a gRPC-Web body, whose last bytes are tail
+func grpcStatus(h http.Header, tail []byte) (code int, message string, ok bool) {
+	for _, key := range []string{"Grpc-Status", http.TrailerPrefix + "Grpc-Status"} {
+		if v := h.Get(key); v != "" {
+			if code, err := strconv.Atoi(v); err == nil {
+				message = h.Get("Grpc-Message")
+				if message == "" {
+					message = h.Get(http.TrailerPrefix + "Grpc-Message")
+				}
+				return code, message, true
+			}
+		}
+	}
+	if trailers, ok := grpcWebTrailers(tail); ok {
+		if code, err := strconv.Atoi(trailers["grpc-status"]); err == nil {
+			return code, trailers["grpc-message"], true
+		}
+	}
+	return 0, "", false
+}
+
+// grpcWebTrailers parses the trailer frame ending tail: a 0x80 flag byte, a
+// 4-byte length and "key: value\r\n" lines. For application/grpc-web-text
+// the frame is base64, starting with "gAAAA".
+func grpcWebTrailers(tail []byte) (map[string]string, bool) {
+	if i := bytes.LastIndex(tail, []byte("gAAAA")); i >= 0 {
+		if frame, err := base64.StdEncoding.DecodeString(string(tail[i:])); err == nil {
+			if trailers, ok := trailerFrame(frame); ok {
+				return trailers, true
+			}
+		}
+	}
+	return trailerFrame(tail)
+}
+
+// trailerFrame parses the binary trailer frame ending tail
+func trailerFrame(tail []byte) (map[string]string, bool) {
+	for i := len(tail) - 5; i >= 0; i-- {
+		if tail[i]&0x80 == 0 || int(binary.BigEndian.Uint32(tail[i+1:i+5])) != len(tail)-i-5 {
+			continue
+		}
+		trailers := make(map[string]string)
+		for line := range strings.SplitSeq(string(tail[i+5:]), "\r\n") {
+			if k, v, ok := strings.Cut(line, ":"); ok {
+				trailers[strings.ToLower(strings.TrimSpace(k))] = strings.TrimSpace(v)
+			}
+		}
+		return trailers, true
+	}
+	return nil, false
+}
+
+// keepTail keeps the last grpcWebTailSize bytes written for grpcStatus
+func (w *wrappedWriter) keepTail(b []byte) {
+	if len(b) >= grpcWebTailSize {
+		w.tail = append(w.tail[:0], b[len(b)-grpcWebTailSize:]...)
+		return
+	}
+	if over := len(w.tail) + len(b) - grpcWebTailSize; over > 0 {
+		w.tail = append(w.tail[:0], w.tail[over:]...)
+	}
+	w.tail = append(w.tail, b...)
+}
+
+// grpcFields returns the gRPC outcome of a response for the access record,
+// the name of its code and the HTTP status it corresponds to, which only
+// replaces a successful status. Without a grpc-status, as when the stream
+// broke, status is kept.
+func grpcFields(h http.Header, tail []byte, status int) (fields []any, name string, _ int) {
+	code, message, ok := grpcStatus(h, tail)
+	if !ok {
+		return nil, "", status
+	}
+	name = strconv.Itoa(code)
+	if code >= 0 && code < len(grpcCodes) {
+		name = grpcCodes[code].name
+		if status < 400 {
+			status = grpcCodes[code].status
+		}
+	} else if status < 400 {
+		status = http.StatusInternalServerError
+	}
+	fields = []any{"grpc.status", name, "grpc.code", code}
+	if message != "" {
+		// grpc-message is percent-encoded
+		if m, err := url.PathUnescape(message); err == nil {
+			message = m
+		}
+		fields = append(fields, "grpc.message", message)
+	}
+	return fields, name, status
+}
@@ -1,15 +1,23 @@
 package middleware
 
 import (
+	"bufio"
 	"bytes"
 	"context"
 	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
 	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"net"
 	"net/http"
 	"slices"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/jozefvalachovic/logger/v4"
@@ -26,77 +34,318 @@ const (
 	RequestStartKey contextKey = "request_start"
 )
 
-// wrappedWriter is used to capture the status code and response body of HTTP responses
+// ErrWriteAfterReturn is returned by writes to the ResponseWriter of
+// LogHTTPMiddleware once the handler has returned, such as from a goroutine
+// it left running
+var ErrWriteAfterReturn = errors.New("middleware: write after the handler returned")
+
+// wrappedWriter is used to capture the status code and response body of HTTP
+// responses. Handlers may keep it after returning, so it is never reused,
+// and once finish is called, writes are refused and its fields stay as they
+// were logged.
 type wrappedWriter struct {
 	http.ResponseWriter
-	statusCode      int
+	mu              sync.Mutex // Guards the fields from goroutines the handler started
+	done            bool       // The handler returned
+	statusCode      int        // The status sent; 200 until WriteHeader, like net/http
+	wroteHeader     bool       // A final status went out, so later WriteHeader calls are ignored
 	responseBody    *bytes.Buffer
 	captureBody     bool
 	maxCaptureBytes int64 // Maximum bytes to capture for response body
 	capturedBytes   int64
+	bytesWritten    int64 // Response body bytes written, reported as bytes_out
+	streaming       bool  // Set on the first Flush or a text/event-stream response
+	hijacked        bool
+	onStreamStart   func() // Called once when the response turns out to be a stream
+	grpcWeb         bool   // Keep the tail, which holds the gRPC-Web trailer frame
+	tail            []byte
 }
 
-// WriteHeader captures the status code for logging
+// WriteHeader captures the status code for logging. As in net/http, only
+// the first final status counts: one after a Write or an earlier
+// WriteHeader is superfluous, and 1xx informational responses are not final.
 func (w *wrappedWriter) WriteHeader(statusCode int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.done {
+		return
+	}
+	w.detectEventStream()
 	w.ResponseWriter.WriteHeader(statusCode)
+	if w.wroteHeader || isInformational(statusCode) {
+		return
+	}
 	w.statusCode = statusCode
+	w.wroteHeader = true
 }
 
 // Write captures the response body if enabled, up to maxCaptureBytes
 func (w *wrappedWriter) Write(b []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.done {
+		return 0, ErrWriteAfterReturn
+	}
+	if w.bytesWritten == 0 {
+		w.detectEventStream()
+	}
+	w.wroteHeader = true // The first Write sends an implicit 200
 	if w.captureBody && w.responseBody != nil && w.capturedBytes < w.maxCaptureBytes {
 		remaining := w.maxCaptureBytes - w.capturedBytes
 		toCapture := min(int64(len(b)), remaining)
 		w.responseBody.Write(b[:toCapture])
 		w.capturedBytes += toCapture
 	}
-	return w.ResponseWriter.Write(b)
+	n, err := w.ResponseWriter.Write(b)
+	w.bytesWritten += int64(n)
+	if w.grpcWeb {
+		w.keepTail(b[:n])
+	}
+	return n, err
 }
 
 // Flush ensures that the underlying ResponseWriter's Flush method is called if it exists
 func (w *wrappedWriter) Flush() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.done {
+		return
+	}
+	w.markStreaming()
 	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
 		flusher.Flush()
+		w.wroteHeader = true // Flushing sends the headers with the status so far
 	}
 }
 
-// Optional: ensure at compile time that wrappedWriter implements http.Flusher
-var _ http.Flusher = (*wrappedWriter)(nil)
+// hijack lets WebSocket upgrades and other protocol switches take over the connection
+func (w *wrappedWriter) hijack() (net.Conn, *bufio.ReadWriter, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.done {
+		return nil, nil, ErrWriteAfterReturn
+	}
+	conn, rw, err := w.ResponseWriter.(http.Hijacker).Hijack()
+	if err == nil {
+		w.hijacked = true
+	}
+	return conn, rw, err
+}
 
-// Pools for memory optimization
-var (
-	wrappedWriterPool = sync.Pool{
-		New: func() any {
-			return new(wrappedWriter{statusCode: http.StatusOK})
-		},
+// push starts an HTTP/2 server push
+func (w *wrappedWriter) push(target string, opts *http.PushOptions) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.done {
+		return ErrWriteAfterReturn
 	}
+	return w.ResponseWriter.(http.Pusher).Push(target, opts)
+}
 
-	bufferPool = sync.Pool{
-		New: func() any {
-			return new(bytes.Buffer)
-		},
+// closeNotify passes through the deprecated http.CloseNotifier, which some
+// older handlers still assert
+func (w *wrappedWriter) closeNotify() <-chan bool {
+	return w.ResponseWriter.(http.CloseNotifier).CloseNotify()
+}
+
+// ReadFrom keeps sendfile and splice working for io.Copy and http.ServeContent
+func (w *wrappedWriter) ReadFrom(r io.Reader) (int64, error) {
+	rf, ok := w.ResponseWriter.(io.ReaderFrom)
+	if !ok || w.captureBody || w.grpcWeb {
+		// writerOnly hides this method so io.Copy does not recurse
+		return io.Copy(writerOnly{w}, r)
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.done {
+		return 0, ErrWriteAfterReturn
+	}
+	if w.bytesWritten == 0 {
+		w.detectEventStream()
+	}
+	w.wroteHeader = true
+	n, err := rf.ReadFrom(r)
+	w.bytesWritten += n
+	return n, err
+}
+
+// Unwrap returns the underlying ResponseWriter for http.ResponseController
+func (w *wrappedWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// finish refuses further writes once the handler has returned, after which
+// the fields can be read without the lock
+func (w *wrappedWriter) finish() {
+	w.mu.Lock()
+	w.done = true
+	w.mu.Unlock()
+}
+
+// isInformational reports whether statusCode is a 1xx response that is
+// followed by the final one, such as 103 Early Hints; 101 Switching
+// Protocols is final
+func isInformational(statusCode int) bool {
+	return statusCode >= 100 && statusCode < 200 && statusCode != http.StatusSwitchingProtocols
+}
+
+// detectEventStream marks Server-Sent Events responses as streams before
+// anything is written
+func (w *wrappedWriter) detectEventStream() {
+	if strings.HasPrefix(w.Header().Get("Content-Type"), "text/event-stream") {
+		w.markStreaming()
+	}
+}
+
+// markStreaming records that the response is a stream, once
+func (w *wrappedWriter) markStreaming() {
+	if w.streaming {
+		return
 	}
+	w.streaming = true
+	if w.onStreamStart != nil {
+		w.onStreamStart()
+	}
+}
+
+// writerOnly exposes only the Write method of an io.Writer
+type writerOnly struct {
+	io.Writer
+}
+
+// Ensure at compile time that wrappedWriter keeps the optional ResponseWriter interfaces
+var (
+	_ http.Flusher  = (*wrappedWriter)(nil)
+	_ io.ReaderFrom = (*wrappedWriter)(nil)
+)
+
+// hijacker, pusher and closeNotifier add one optional interface each to a
+// wrappedWriter, so exposeWriter can offer exactly the ones the underlying
+// ResponseWriter has
+type (
+	hijacker      struct{ w *wrappedWriter }
+	pusher        struct{ w *wrappedWriter }
+	closeNotifier struct{ w *wrappedWriter }
 )
 
-// shouldLogBody checks if the content type is appropriate for logging
-func shouldLogBody(contentType string) bool {
-	contentType = strings.ToLower(contentType)
-	// Log only text-based content types
-	allowedTypes := []string{
-		"application/json",
-		"application/xml",
-		"text/",
-		"application/x-www-form-urlencoded",
+func (h hijacker) Hijack() (net.Conn, *bufio.ReadWriter, error)   { return h.w.hijack() }
+func (p pusher) Push(target string, opts *http.PushOptions) error { return p.w.push(target, opts) }
+func (c closeNotifier) CloseNotify() <-chan bool                  { return c.w.closeNotify() }
+
+// exposeWriter returns w as the handler should see it. Flush and ReadFrom
+// always work, so they are always there; Hijack, Push and CloseNotify are
+// only there when the underlying ResponseWriter has them, so handlers that
+// type-assert them, such as WebSocket libraries, still see what the server
+// supports.
+func exposeWriter(w *wrappedWriter) http.ResponseWriter {
+	_, canHijack := w.ResponseWriter.(http.Hijacker)
+	_, canPush := w.ResponseWriter.(http.Pusher)
+	_, canNotify := w.ResponseWriter.(http.CloseNotifier)
+	h, p, c := hijacker{w}, pusher{w}, closeNotifier{w}
+
+	switch {
+	case canHijack && canPush && canNotify:
+		return struct {
+			*wrappedWriter
+			hijacker
+			pusher
+			closeNotifier
+		}{w, h, p, c}
+	case canHijack && canPush:
+		return struct {
+			*wrappedWriter
+			hijacker
+			pusher
+		}{w, h, p}
+	case canHijack && canNotify:
+		return struct {
+			*wrappedWriter
+			hijacker
+			closeNotifier
+		}{w, h, c}
+	case canPush && canNotify:
+		return struct {
+			*wrappedWriter
+			pusher
+			closeNotifier
+		}{w, p, c}
+	case canHijack:
+		return struct {
+			*wrappedWriter
+			hijacker
+		}{w, h}
+	case canPush:
+		return struct {
+			*wrappedWriter
+			pusher
+		}{w, p}
+	case canNotify:
+		return struct {
+			*wrappedWriter
+			closeNotifier
+		}{w, c}
 	}
+	return w
+}
 
-	for _, allowed := range allowedTypes {
-		if strings.Contains(contentType, allowed) {
-			return true
-		}
+// countingBody counts the request body bytes the handler reads, reported as bytes_in
+type countingBody struct {
+	io.ReadCloser
+	n atomic.Int64 // Goroutines the handler started may still be reading
+}
+
+func (b *countingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.n.Add(int64(n))
+	return n, err
+}
+
+// cancelWatch notes when a request's context ends while its handler still
+// runs, which tells a client that went away or a deadline that passed apart
+// from a handler that was merely slow
+type cancelWatch struct {
+	ctx     context.Context
+	start   time.Time
+	elapsed atomic.Int64 // Nanoseconds from start to the cancellation, 0 if none
+	stop    func() bool
+}
+
+// watchCancel starts watching ctx for a request that started at start
+func watchCancel(ctx context.Context, start time.Time) *cancelWatch {
+	c := &cancelWatch{ctx: ctx, start: start}
+	c.stop = context.AfterFunc(ctx, func() {
+		c.elapsed.Store(max(int64(time.Since(c.start)), 1))
+	})
+	return c
+}
+
+// fields stops watching and returns cancelled, cancelled_after and
+// cancel_cause if the context ended before the handler returned
+func (c *cancelWatch) fields() []any {
+	if c.stop() {
+		return nil
+	}
+	elapsed := time.Duration(c.elapsed.Load())
+	if elapsed == 0 {
+		// The callback was started but has not stored the time yet
+		elapsed = time.Since(c.start)
+	}
+	return []any{
+		"cancelled", true,
+		"cancelled_after", elapsed.String(),
+		"cancel_cause", context.Cause(c.ctx).Error(),
 	}
-	return false
 }
 
+// Pools for memory optimization
+var (
+	bufferPool = sync.Pool{
+		New: func() any {
+			return new(bytes.Buffer)
+		},
+	}
+)
+
 // generateRequestID generates a random request ID
 func generateRequestID() string {
 	b := make([]byte, 16)
@@ -115,6 +364,29 @@ func GetRequestID(ctx context.Context) string {
 	return ""
 }
 
+// BearerClaims returns the claims of the JWT in the Authorization header of
+// r without verifying its signature, for a FieldExtractor that logs the
+// caller. Never use them to authorize anything.
+func BearerClaims(r *http.Request) (map[string]any, bool) {
+	auth := r.Header.Get("Authorization")
+	if len(auth) < 7 || !strings.EqualFold(auth[:7], "Bearer ") {
+		return nil, false
+	}
+	parts := strings.Split(auth[7:], ".")
+	if len(parts) != 3 {
+		return nil, false
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return nil, false
+	}
+	var claims map[string]any
+	if json.Unmarshal(payload, &claims) != nil {
+		return nil, false
+	}
+	return claims, true
+}
+
 // GetRequestStart extracts the request start time from context
 func GetRequestStart(ctx context.Context) time.Time {
 	if t, ok := ctx.Value(RequestStartKey).(time.Time); ok {
@@ -138,6 +410,16 @@ func shouldSkipPath(path string, options *HTTPMiddlewareOptions) bool {
 	return false
 }
 
+// isDebugRequest reports whether r carries the configured debug header and
+// token. The token is compared in constant time.
+func isDebugRequest(r *http.Request, options *HTTPMiddlewareOptions) bool {
+	if options.DebugHeader == "" || options.DebugToken == "" {
+		return false
+	}
+	value := r.Header.Get(options.DebugHeader)
+	return subtle.ConstantTimeCompare([]byte(value), []byte(options.DebugToken)) == 1
+}
+
 // getLogLevelForStatus determines the log level based on status code and options
 func getLogLevelForStatus(statusCode int, options *HTTPMiddlewareOptions) logger.LogLevel {
 	// Check for exact status code match first
@@ -256,7 +538,7 @@ func logErrorDetails(r *http.Request, wrapped *wrappedWriter, options *HTTPMiddl
 	bodyBytes []byte, bodyErr error, truncated bool, fullPath, requestID string, cfg logger.Config) {
 
 	contentType := r.Header.Get("Content-Type")
-	shouldLog := shouldLogBody(contentType)
+	shouldLog := logger.LoggableBody(contentType, bodyBytes)
 
 	if !options.LogBodyOnErrors && !options.LogResponseBody {
 		return
@@ -289,28 +571,24 @@ func logErrorDetails(r *http.Request, wrapped *wrappedWriter, options *HTTPMiddl
 			logger.LogError("Failed to read HTTP request body for error logging", "__error", bodyErr)
 		} else if len(bodyBytes) > 0 {
 			if truncated {
-				bodyStr := string(bodyBytes) + "..."
-				keyValues = append(keyValues, "request_body", bodyStr)
-			} else {
-				bodyKeyValues := logger.BodyToKeyValues("request_body", bodyBytes)
-				keyValues = append(keyValues, bodyKeyValues...)
+				// Not valid JSON or form data any more: logged as a string
+				bodyBytes = append(bodyBytes[:len(bodyBytes):len(bodyBytes)], "..."...)
 			}
+			keyValues = append(keyValues, logger.BodyToKeyValuesWithType("request_body", contentType, bodyBytes)...)
 		}
 	}
 
 	// Log response body
 	if options.LogResponseBody && wrapped.responseBody != nil && wrapped.responseBody.Len() > 0 {
 		respContentType := wrapped.Header().Get("Content-Type")
-		if shouldLogBody(respContentType) {
-			// Use Peek to preview response body without consuming the buffer
-			peekSize := min(int64(wrapped.responseBody.Len()), cfg.MaxBodySize)
-			respBody, _ := wrapped.responseBody.Peek(int(peekSize))
+		// Use Peek to preview response body without consuming the buffer
+		peekSize := min(int64(wrapped.responseBody.Len()), cfg.MaxBodySize)
+		respBody, _ := wrapped.responseBody.Peek(int(peekSize))
+		if logger.LoggableBody(respContentType, respBody) {
 			if int64(wrapped.responseBody.Len()) > cfg.MaxBodySize {
-				keyValues = append(keyValues, "response_body", string(respBody)+"...")
-			} else {
-				respKeyValues := logger.BodyToKeyValues("response_body", respBody)
-				keyValues = append(keyValues, respKeyValues...)
+				respBody = append(respBody[:len(respBody):len(respBody)], "..."...)
 			}
+			keyValues = append(keyValues, logger.BodyToKeyValuesWithType("response_body", respContentType, respBody)...)
 		}
 	}
 
@@ -5,21 +5,44 @@ import (
 	"bytes"
 	"context"
 	"fmt"
-	"io"
 	"math/rand/v2"
 	"net/http"
 	"runtime/debug"
+	"strconv"
 	"time"
 
 	"github.com/jozefvalachovic/logger/v4"
 )
 
+// LogHTTPMiddleware logs each request to next with an access record
 func LogHTTPMiddleware(next http.Handler, opts ...HTTPMiddlewareOption) http.Handler {
+	return New(opts...).Handler(next)
+}
+
+// HTTPMiddleware is the HTTP logging middleware with its options applied,
+// for wrapping several handlers or chaining with other middleware
+type HTTPMiddleware struct {
+	options *HTTPMiddlewareOptions
+}
+
+// New returns the HTTP logging middleware configured by opts
+func New(opts ...HTTPMiddlewareOption) *HTTPMiddleware {
 	options := DefaultHTTPMiddlewareOptions()
 	for _, opt := range opts {
 		opt(options)
 	}
-	return logHTTPMiddlewareWithOptions(next, options)
+	return &HTTPMiddleware{options: options}
+}
+
+// Handler wraps next; its signature fits chi's Use and alice's New
+func (m *HTTPMiddleware) Handler(next http.Handler) http.Handler {
+	return logHTTPMiddlewareWithOptions(next, m.options)
+}
+
+// Middleware returns the HTTP logging middleware as a
+// func(http.Handler) http.Handler, for routers and chains that take that form
+func Middleware(opts ...HTTPMiddlewareOption) func(http.Handler) http.Handler {
+	return New(opts...).Handler
 }
 
 // logHTTPMiddlewareWithOptions is the internal implementation with resolved options
@@ -30,10 +53,7 @@ func logHTTPMiddlewareWithOptions(next http.Handler, options *HTTPMiddlewareOpti
 		start := time.Now()
 
 		// Check if path should be skipped
-		fullPath := r.URL.Path
-		if r.URL.RawQuery != "" {
-			fullPath = r.URL.Path + "?" + r.URL.RawQuery
-		}
+		fullPath := logger.GetFullPath(r.URL)
 
 		if shouldSkipPath(fullPath, options) {
 			next.ServeHTTP(w, r)
@@ -58,33 +78,70 @@ func logHTTPMiddlewareWithOptions(next http.Handler, options *HTTPMiddlewareOpti
 			r = r.WithContext(ctx)
 		}
 
+		// Raise the verbosity of this request only
+		log := logger.DefaultLogger()
+		if isDebugRequest(r, options) {
+			log = logger.LoggerWithLevel(log, options.DebugLevel)
+			r = r.WithContext(logger.NewLevelContext(r.Context(), options.DebugLevel))
+		}
+
+		// Carry the propagation headers to handlers and downstream services
+		var propagated []any
+		if len(options.PropagateHeaders) > 0 {
+			var ctx context.Context
+			ctx, propagated = propagate(r, requestID, options)
+			r = r.WithContext(ctx)
+		}
+
+		// Extract domain fields before the handler can consume the request
+		var extracted []any
+		for _, extract := range options.FieldExtractors {
+			extracted = append(extracted, extract(r)...)
+		}
+
+		// Hold the records handlers log until the status is known
+		var held *logger.RecordBuffer
+		if options.BufferRecords {
+			var ctx context.Context
+			ctx, held = logger.NewBufferContext(r.Context(), 0)
+			r = r.WithContext(ctx)
+		}
+
 		// Call start callback
 		if options.OnRequestStart != nil {
 			options.OnRequestStart(r)
 		}
 
-		// Read and buffer the body for potential logging
+		// Read up to MaxBodySize of a text body for potential logging; the
+		// handler still reads all of it
 		var bodyBytes []byte
 		var bodyErr error
 		truncated := false
 		shouldCapture := options.LogBodyOnErrors || (options.BodySampleRate > 0 && rand.Float64() < options.BodySampleRate)
-		if r.Body != nil && shouldCapture {
-			bodyBytes, bodyErr = io.ReadAll(io.LimitReader(r.Body, cfg.MaxBodySize+1))
-			_ = r.Body.Close()
-			if int64(len(bodyBytes)) > cfg.MaxBodySize {
-				truncated = true
-				bodyBytes = bodyBytes[:cfg.MaxBodySize]
-			}
-			r.Body = io.NopCloser(bytes.NewBuffer(bodyBytes))
+		if ct := r.Header.Get("Content-Type"); shouldCapture && (ct == "" || logger.LoggableBody(ct, nil)) {
+			bodyBytes, truncated, bodyErr = logger.ReadBody(r, cfg.MaxBodySize)
 		}
 
-		// Get a wrapped writer from pool
-		wrapped := wrappedWriterPool.Get().(*wrappedWriter)
-		wrapped.ResponseWriter = w
-		wrapped.statusCode = http.StatusOK
-		wrapped.captureBody = options.LogResponseBody
-		wrapped.maxCaptureBytes = cfg.MaxBodySize
-		wrapped.capturedBytes = 0
+		// Count the request body bytes the handler consumes
+		var body *countingBody
+		if r.Body != nil && r.Body != http.NoBody {
+			body = &countingBody{ReadCloser: r.Body}
+			r.Body = body
+		}
+
+		isGRPC, isGRPCWeb := grpcKind(r)
+		wrapped := &wrappedWriter{
+			ResponseWriter:  w,
+			statusCode:      http.StatusOK,
+			grpcWeb:         isGRPCWeb,
+			captureBody:     options.LogResponseBody,
+			maxCaptureBytes: cfg.MaxBodySize,
+		}
+		if options.LogStreamStart {
+			wrapped.onStreamStart = func() {
+				logStreamStart(r, fullPath, requestID, options, cfg)
+			}
+		}
 		if options.LogResponseBody {
 			wrapped.responseBody = bufferPool.Get().(*bytes.Buffer)
 			wrapped.responseBody.Reset()
@@ -93,6 +150,7 @@ func logHTTPMiddlewareWithOptions(next http.Handler, options *HTTPMiddlewareOpti
 		// Panic recovery
 		defer func() {
 			if rec := recover(); rec != nil {
+				wrapped.finish()
 				wrapped.statusCode = http.StatusInternalServerError
 				stack := debug.Stack()
 
@@ -117,19 +175,35 @@ func logHTTPMiddlewareWithOptions(next http.Handler, options *HTTPMiddlewareOpti
 				if requestID != "" {
 					keyValues = append(keyValues, "request_id", requestID)
 				}
+				keyValues = append(keyValues, propagated...)
 				// Add custom fields
 				for k, v := range options.CustomFields {
 					keyValues = append(keyValues, k, v)
 				}
+				keyValues = append(keyValues, extracted...)
 
+				if held != nil {
+					releaseRecords(held, wrapped.statusCode, options)
+				}
 				logger.LogError(fmt.Sprintf("PANIC %s %s [%d]", r.Method, panicLogPath, wrapped.statusCode), keyValues...)
 
-				// Try to write error response if not already written
-				http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+				switch {
+				case options.PanicHandler != nil:
+					options.PanicHandler(w, r, rec)
+				case !options.RepanicOnPanic:
+					// Try to write error response if not already written
+					http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+				}
+				if options.RepanicOnPanic {
+					panic(rec)
+				}
 			}
 		}()
 
-		next.ServeHTTP(wrapped, r)
+		cancel := watchCancel(r.Context(), start)
+		next.ServeHTTP(exposeWriter(wrapped), r)
+		wrapped.finish()
+		cancelled := cancel.fields()
 
 		duration := time.Since(start)
 
@@ -151,8 +225,18 @@ func logHTTPMiddlewareWithOptions(next http.Handler, options *HTTPMiddlewareOpti
 			emitAuditEvent(r, wrapped.statusCode, duration, requestID, fullPath)
 		}
 
+		// gRPC reports its outcome in grpc-status; a failed call is still a 200
+		status, statusText := wrapped.statusCode, strconv.Itoa(wrapped.statusCode)
+		var grpcKV []any
+		if isGRPC {
+			var code string
+			if grpcKV, code, status = grpcFields(wrapped.Header(), wrapped.tail, status); code != "" {
+				statusText += " " + code
+			}
+		}
+
 		// Determine log level based on status code
-		logLevel := getLogLevelForStatus(wrapped.statusCode, options)
+		logLevel := getLogLevelForStatus(status, options)
 
 		// Check if path should be redacted
 		logPath := fullPath
@@ -166,40 +250,101 @@ func logHTTPMiddlewareWithOptions(next http.Handler, options *HTTPMiddlewareOpti
 			"__path", logPath,
 			"__status", wrapped.statusCode,
 			"__duration", duration.String(),
+			"protocol", r.Proto,
+		}
+		if ua := r.UserAgent(); ua != "" {
+			keyValues = append(keyValues, "__user_agent", ua)
+		}
+		keyValues = append(keyValues, grpcKV...)
+
+		bytesIn := int64(0)
+		if body != nil {
+			bytesIn = body.n.Load()
+		}
+		keyValues = append(keyValues, "bytes_in", bytesIn, "bytes_out", wrapped.bytesWritten)
+		if wrapped.streaming {
+			keyValues = append(keyValues, "streaming", true)
+		}
+		if wrapped.hijacked {
+			keyValues = append(keyValues, "hijacked", true)
+		}
+		keyValues = append(keyValues, cancelled...)
+		if options.LogTLS && r.TLS != nil {
+			keyValues = append(keyValues, tlsFields(r.TLS)...)
 		}
 
 		if requestID != "" {
 			keyValues = append(keyValues, "request_id", requestID)
 		}
+		keyValues = append(keyValues, propagated...)
 
 		// Add custom fields
 		for k, v := range options.CustomFields {
 			keyValues = append(keyValues, k, v)
 		}
+		keyValues = append(keyValues, extracted...)
 
 		// Log at the appropriate level with key details in the message
-		logMsg := fmt.Sprintf("%s %s [%d] %s", r.Method, logPath, wrapped.statusCode, duration)
+		logMsg := fmt.Sprintf("%s %s [%s] %s", r.Method, logPath, statusText, duration)
 
-		switch logLevel {
-		case logger.Error:
-			logErrorDetails(r, wrapped, options, bodyBytes, bodyErr, truncated, fullPath, requestID, cfg)
-			logger.LogError(logMsg, keyValues...)
-		case logger.Warn:
+		// Write or drop the held records; grouped ones go into the request record
+		if held != nil && (!options.BufferGrouped || wrapped.statusCode < options.BufferMinStatus) {
+			releaseRecords(held, wrapped.statusCode, options)
+		}
+		if logLevel == logger.Error || logLevel == logger.Warn {
 			logErrorDetails(r, wrapped, options, bodyBytes, bodyErr, truncated, fullPath, requestID, cfg)
-			logger.LogWarn(logMsg, keyValues...)
-		case logger.Debug:
-			logger.LogDebug(logMsg, keyValues...)
+		}
+
+		switch {
+		case held != nil && held.FlushGrouped(logLevel, logMsg, keyValues...):
+			// Written with the held records
+		case logLevel == logger.Error:
+			log.LogError(logMsg, keyValues...)
+		case logLevel == logger.Warn:
+			log.LogWarn(logMsg, keyValues...)
+		case logLevel == logger.Debug:
+			log.LogDebug(logMsg, keyValues...)
 		default:
-			logger.LogInfo(logMsg, keyValues...)
+			log.LogInfo(logMsg, keyValues...)
 		}
 
-		// Return pooled objects
+		// Return the capture buffer, which writes after finish no longer touch
 		if wrapped.responseBody != nil {
 			wrapped.responseBody.Reset()
 			bufferPool.Put(wrapped.responseBody)
 			wrapped.responseBody = nil
 		}
-		wrapped.captureBody = false
-		wrappedWriterPool.Put(wrapped)
 	})
 }
+
+// releaseRecords writes the records held for a request by
+// WithBufferedRecords, or drops them when its status is below BufferMinStatus
+func releaseRecords(held *logger.RecordBuffer, status int, options *HTTPMiddlewareOptions) {
+	if status < options.BufferMinStatus {
+		held.Discard()
+	} else {
+		held.Flush()
+	}
+}
+
+// logStreamStart logs the start of a streaming response
+func logStreamStart(r *http.Request, fullPath, requestID string, options *HTTPMiddlewareOptions, cfg logger.Config) {
+	logPath := fullPath
+	if logger.ShouldRedactPath(fullPath, cfg) {
+		logPath = cfg.RedactMask
+	}
+
+	keyValues := []any{
+		"__method", r.Method,
+		"__path", logPath,
+		"streaming", true,
+	}
+	if requestID != "" {
+		keyValues = append(keyValues, "request_id", requestID)
+	}
+	for k, v := range options.CustomFields {
+		keyValues = append(keyValues, k, v)
+	}
+
+	logger.LogInfo(fmt.Sprintf("STREAM %s %s started", r.Method, logPath), keyValues...)
+}
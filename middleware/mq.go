@@ -0,0 +1,158 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/jozefvalachovic/logger/v4"
+)
+
+// ErrMessagePanic is returned by a wrapped message handler that panicked, so
+// the consumer can nack or dead-letter the message.
+var ErrMessagePanic = errors.New("message handler panicked")
+
+// Message describes a queue message for logging. Describe functions build
+// it from the client's own message type.
+type Message struct {
+	// ID is the message ID (Kafka: topic/partition/offset, SQS: MessageId)
+	ID string
+	// Topic is the topic, queue or subject
+	Topic string
+	// Size is the payload size in bytes
+	Size int
+	// Attempt is the broker's delivery attempt, 1 for the first (0 if unknown)
+	Attempt int
+}
+
+// MQOptions configures message queue logging.
+type MQOptions struct {
+	// SkipTopics are passed through without logging
+	SkipTopics []string
+	// Retries re-runs a failed handler in-process up to this many times
+	Retries int
+	// RetryBackoff is the delay between retries, doubled after each one
+	RetryBackoff time.Duration
+	// RepanicOnPanic re-raises a recovered panic after logging it instead of
+	// returning ErrMessagePanic
+	RepanicOnPanic bool
+}
+
+// MQOption is a functional option for message queue logging.
+type MQOption func(*MQOptions)
+
+// WithMQSkipTopics skips logging for the specified topics or queues.
+func WithMQSkipTopics(topics ...string) MQOption {
+	return func(o *MQOptions) { o.SkipTopics = topics }
+}
+
+// WithMQRetries retries a failed handler up to n times, waiting backoff
+// before the first retry and doubling it after each.
+func WithMQRetries(n int, backoff time.Duration) MQOption {
+	return func(o *MQOptions) {
+		o.Retries = n
+		o.RetryBackoff = backoff
+	}
+}
+
+// WithMQRepanic re-raises handler panics after logging them.
+func WithMQRepanic(enabled bool) MQOption {
+	return func(o *MQOptions) { o.RepanicOnPanic = enabled }
+}
+
+// LogMessageConsumer wraps a message handler to log each processed message
+// with its ID, topic, size, delivery attempt, duration and retries.
+// Panics are recovered and logged with their stack, and the handler then
+// returns ErrMessagePanic. The handler's context carries a logger with the
+// message ID and topic, for logger.FromContext.
+//
+// The wrapper works with any client; describe extracts the fields:
+//
+//	handle := middleware.LogMessageConsumer(processOrder, func(m *sqs.Message) middleware.Message {
+//	    return middleware.Message{ID: *m.MessageId, Topic: "orders", Size: len(*m.Body)}
+//	}, middleware.WithMQRetries(2, 100*time.Millisecond))
+//	err := handle(ctx, msg)
+func LogMessageConsumer[M any](next func(ctx context.Context, msg M) error, describe func(M) Message, opts ...MQOption) func(ctx context.Context, msg M) error {
+	return logMessages("consume", next, describe, opts)
+}
+
+// LogMessageProducer wraps a publish function to log each sent message
+// like LogMessageConsumer.
+//
+//	publish := middleware.LogMessageProducer(func(ctx context.Context, m *nats.Msg) error {
+//	    return nc.PublishMsg(m)
+//	}, func(m *nats.Msg) middleware.Message {
+//	    return middleware.Message{Topic: m.Subject, Size: len(m.Data)}
+//	})
+func LogMessageProducer[M any](next func(ctx context.Context, msg M) error, describe func(M) Message, opts ...MQOption) func(ctx context.Context, msg M) error {
+	return logMessages("publish", next, describe, opts)
+}
+
+// logMessages wraps next for the consume or publish direction
+func logMessages[M any](direction string, next func(context.Context, M) error, describe func(M) Message, opts []MQOption) func(context.Context, M) error {
+	options := &MQOptions{}
+	for _, o := range opts {
+		o(options)
+	}
+
+	return func(ctx context.Context, msg M) (err error) {
+		m := describe(msg)
+		if slices.Contains(options.SkipTopics, m.Topic) {
+			return next(ctx, msg)
+		}
+
+		kv := []any{"mq.topic", m.Topic}
+		if m.ID != "" {
+			kv = append(kv, "mq.message_id", m.ID)
+		}
+		ctx = logger.NewContext(ctx, logger.FromContext(ctx).With(kv...))
+		kv = append(kv, "mq.size", m.Size)
+		if m.Attempt > 0 {
+			kv = append(kv, "mq.attempt", m.Attempt)
+		}
+
+		start := time.Now()
+		logger.LogDebug(fmt.Sprintf("MQ %s %s started", direction, m.Topic), kv...)
+
+		retries := 0
+		defer func() {
+			duration := time.Since(start)
+			kv = append(kv, "mq.duration", duration.String())
+			if retries > 0 {
+				kv = append(kv, "mq.retries", retries)
+			}
+
+			if rec := recover(); rec != nil {
+				kv = append(kv, "panic", rec, "stack", logger.GetStackTrace())
+				logger.LogError(fmt.Sprintf("MQ %s %s panicked %s", direction, m.Topic, duration), kv...)
+				if options.RepanicOnPanic {
+					panic(rec)
+				}
+				err = fmt.Errorf("%w: %v", ErrMessagePanic, rec)
+				return
+			}
+
+			if err != nil {
+				kv = append(kv, "mq.error", err.Error())
+				logger.LogError(fmt.Sprintf("MQ %s %s failed %s", direction, m.Topic, duration), kv...)
+			} else {
+				logger.LogInfo(fmt.Sprintf("MQ %s %s completed %s", direction, m.Topic, duration), kv...)
+			}
+		}()
+
+		backoff := options.RetryBackoff
+		for err = next(ctx, msg); err != nil && retries < options.Retries; err = next(ctx, msg) {
+			logger.LogWarn(fmt.Sprintf("MQ %s %s retrying", direction, m.Topic), slices.Concat(kv, []any{"mq.error", err.Error(), "mq.retry", retries + 1})...)
+			select {
+			case <-ctx.Done():
+				return err
+			case <-time.After(backoff):
+			}
+			backoff *= 2
+			retries++
+		}
+		return err
+	}
+}
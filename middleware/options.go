@@ -1,6 +1,7 @@
 package middleware
 
 import (
+	"log/slog"
 	"net/http"
 	"time"
 
@@ -29,6 +30,9 @@ type HTTPMiddlewareOptions struct {
 	LogLevelByStatus map[int]logger.LogLevel
 	// CustomFields adds custom fields to every log entry
 	CustomFields map[string]any
+	// FieldExtractors add fields computed from each request to its access
+	// record
+	FieldExtractors []FieldExtractor
 	// EnableMetrics enables request metrics collection
 	EnableMetrics bool
 	// MetricsCollector is an optional custom metrics collector
@@ -40,8 +44,49 @@ type HTTPMiddlewareOptions struct {
 	// BodySampleRate samples request bodies for a percentage of all requests (0.0-1.0)
 	// When > 0, bodies are captured and logged even for successful requests.
 	BodySampleRate float64
+	// PanicHandler writes the response after a recovered panic has been
+	// logged (default: 500 Internal Server Error)
+	PanicHandler func(w http.ResponseWriter, r *http.Request, err any)
+	// RepanicOnPanic re-raises a recovered panic after logging it (and after
+	// PanicHandler, if set) so outer recovery middleware such as OTel or
+	// Sentry still sees it. No default 500 response is written.
+	RepanicOnPanic bool
+	// LogStreamStart logs a "stream started" record as soon as a response
+	// turns out to be a stream (first Flush or text/event-stream), in
+	// addition to the summary record when it ends
+	LogStreamStart bool
+	// DebugHeader names a request header (e.g. X-Debug-Log) that turns on
+	// DebugLevel logging for that request when its value is DebugToken.
+	// Disabled unless both are set.
+	DebugHeader string
+	// DebugToken is the secret DebugHeader must carry
+	DebugToken string
+	// DebugLevel is the level used for debug requests (default: Trace)
+	DebugLevel slog.Level
+	// PropagateHeaders are request headers (e.g. X-Tenant-ID) logged with
+	// the request and its handlers' records and kept in the context for
+	// PropagationTransport to send downstream
+	PropagateHeaders []string
+	// BufferRecords holds the records handlers log through
+	// logger.FromContext(r.Context()) and writes them together when the
+	// request ends, before its request record
+	BufferRecords bool
+	// BufferMinStatus drops the held records of requests answered with a
+	// lower status, e.g. 400 to see handler logs of failed requests only
+	BufferMinStatus int
+	// BufferGrouped writes the held records inside the request record, under
+	// "records", instead of as lines of their own
+	BufferGrouped bool
+	// LogTLS adds the negotiated TLS version, cipher, SNI name and client
+	// certificate to the request records of TLS connections
+	LogTLS bool
 }
 
+// FieldExtractor returns key-value pairs to add to the access record of r,
+// such as the tenant from the subdomain. The logger's redaction applies to
+// them like to any other field.
+type FieldExtractor func(r *http.Request) []any
+
 // HTTPMiddlewareOption is a functional option for configuring middleware
 type HTTPMiddlewareOption func(*HTTPMiddlewareOptions)
 
@@ -58,6 +103,7 @@ func DefaultHTTPMiddlewareOptions() *HTTPMiddlewareOptions {
 		SkipPathPrefixes: nil,
 		LogLevelByStatus: nil,
 		CustomFields:     nil,
+		DebugLevel:       logger.LevelTrace,
 	}
 }
 
@@ -141,6 +187,19 @@ func WithCustomFields(fields map[string]any) HTTPMiddlewareOption {
 	}
 }
 
+// WithFieldExtractors adds fields computed from each request to its access
+// record:
+//
+//	middleware.WithFieldExtractors(func(r *http.Request) []any {
+//		tenant, _, _ := strings.Cut(r.Host, ".")
+//		return []any{"tenant", tenant}
+//	})
+func WithFieldExtractors(extractors ...FieldExtractor) HTTPMiddlewareOption {
+	return func(o *HTTPMiddlewareOptions) {
+		o.FieldExtractors = append(o.FieldExtractors, extractors...)
+	}
+}
+
 // WithMetrics enables metrics collection
 func WithMetrics(enabled bool) HTTPMiddlewareOption {
 	return func(o *HTTPMiddlewareOptions) {
@@ -179,3 +238,77 @@ func WithBodySampleRate(rate float64) HTTPMiddlewareOption {
 		o.BodySampleRate = rate
 	}
 }
+
+// WithPanicHandler sets the function that writes the response after a panic
+func WithPanicHandler(fn func(w http.ResponseWriter, r *http.Request, err any)) HTTPMiddlewareOption {
+	return func(o *HTTPMiddlewareOptions) {
+		o.PanicHandler = fn
+	}
+}
+
+// WithRepanic re-raises recovered panics after logging them
+func WithRepanic(enabled bool) HTTPMiddlewareOption {
+	return func(o *HTTPMiddlewareOptions) {
+		o.RepanicOnPanic = enabled
+	}
+}
+
+// WithStreamStart logs a record when a streaming response starts
+func WithStreamStart(enabled bool) HTTPMiddlewareOption {
+	return func(o *HTTPMiddlewareOptions) {
+		o.LogStreamStart = enabled
+	}
+}
+
+// WithDebugHeader logs requests whose header carries token at DebugLevel,
+// including everything handlers log through logger.FromContext(r.Context())
+func WithDebugHeader(header, token string) HTTPMiddlewareOption {
+	return func(o *HTTPMiddlewareOptions) {
+		o.DebugHeader = header
+		o.DebugToken = token
+	}
+}
+
+// WithPropagation logs the given request headers, DefaultPropagationHeaders
+// if none, and keeps them for PropagationTransport
+func WithPropagation(headers ...string) HTTPMiddlewareOption {
+	return func(o *HTTPMiddlewareOptions) {
+		if len(headers) == 0 {
+			headers = DefaultPropagationHeaders
+		}
+		o.PropagateHeaders = headers
+	}
+}
+
+// WithBufferedRecords holds the records logged during a request and writes
+// them when it ends with a status of at least minStatus (0 for all)
+func WithBufferedRecords(minStatus int) HTTPMiddlewareOption {
+	return func(o *HTTPMiddlewareOptions) {
+		o.BufferRecords = true
+		o.BufferMinStatus = minStatus
+	}
+}
+
+// WithGroupedRecords writes the records held by WithBufferedRecords as part
+// of the request record
+func WithGroupedRecords(enabled bool) HTTPMiddlewareOption {
+	return func(o *HTTPMiddlewareOptions) {
+		o.BufferGrouped = enabled
+	}
+}
+
+// WithDebugLevel sets the level used for requests enabled by WithDebugHeader
+func WithDebugLevel(level slog.Level) HTTPMiddlewareOption {
+	return func(o *HTTPMiddlewareOptions) {
+		o.DebugLevel = level
+	}
+}
+
+// WithTLSDetails adds the TLS version, cipher, SNI name and client
+// certificate subject, issuer and expiry to the request records of TLS
+// connections
+func WithTLSDetails(enabled bool) HTTPMiddlewareOption {
+	return func(o *HTTPMiddlewareOptions) {
+		o.LogTLS = enabled
+	}
+}
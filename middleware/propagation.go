@@ -0,0 +1,139 @@
+package middleware
+
+import (
+	"context"
+	"maps"
+	"net/http"
+	"strings"
+
+	"github.com/jozefvalachovic/logger/v4"
+)
+
+// CorrelationIDHeader carries the correlation ID of logger.WithCorrelation
+// between services
+const CorrelationIDHeader = "X-Correlation-ID"
+
+// DefaultPropagationHeaders are the headers WithPropagation and
+// PropagationTransport carry when given none
+var DefaultPropagationHeaders = []string{"X-Request-ID", CorrelationIDHeader, "X-Tenant-ID"}
+
+// baggageCtxKey is the context key of the propagated headers
+type baggageCtxKey struct{}
+
+// WithBaggage returns ctx carrying header with value, for
+// PropagationTransport to send downstream
+func WithBaggage(ctx context.Context, header, value string) context.Context {
+	baggage := maps.Clone(Baggage(ctx))
+	if baggage == nil {
+		baggage = make(map[string]string, 1)
+	}
+	baggage[http.CanonicalHeaderKey(header)] = value
+	return context.WithValue(ctx, baggageCtxKey{}, baggage)
+}
+
+// Baggage returns the propagated headers of ctx by canonical name. The map
+// must not be modified.
+func Baggage(ctx context.Context) map[string]string {
+	baggage, _ := ctx.Value(baggageCtxKey{}).(map[string]string)
+	return baggage
+}
+
+// PropagationField returns the log field of a propagated header: its
+// name without "X-", in lower case with underscores, e.g. X-Tenant-ID is
+// logged as "tenant_id"
+func PropagationField(header string) string {
+	if len(header) > 2 && strings.EqualFold(header[:2], "x-") {
+		header = header[2:]
+	}
+	return strings.ReplaceAll(strings.ToLower(header), "-", "_")
+}
+
+// propagate stores the propagation headers of r in its context, with the
+// request ID the middleware settled on, and returns the context and the
+// fields to log. CorrelationIDHeader becomes the context's correlation ID,
+// which LogXxxWithContext add themselves; the others are added to the
+// context's logger.
+func propagate(r *http.Request, requestID string, options *HTTPMiddlewareOptions) (context.Context, []any) {
+	ctx := r.Context()
+	var fields, loggerFields []any
+	for _, header := range options.PropagateHeaders {
+		header = http.CanonicalHeaderKey(header)
+		value := r.Header.Get(header)
+		isRequestID := requestID != "" && header == http.CanonicalHeaderKey(options.RequestIDHeader)
+		if isRequestID {
+			value = requestID // Generated IDs are propagated too
+		}
+		if value == "" {
+			continue
+		}
+		ctx = WithBaggage(ctx, header, value)
+		switch {
+		case isRequestID:
+			// Already logged as request_id
+		case header == http.CanonicalHeaderKey(CorrelationIDHeader):
+			ctx = logger.WithCorrelationID(ctx, value)
+			fields = append(fields, logger.CorrelationIDKey, value)
+		default:
+			fields = append(fields, PropagationField(header), value)
+			loggerFields = append(loggerFields, PropagationField(header), value)
+		}
+	}
+	if len(loggerFields) > 0 {
+		ctx = logger.NewContext(ctx, logger.FromContext(ctx).With(loggerFields...))
+	}
+	return ctx, fields
+}
+
+// PropagationTransport returns an http.RoundTripper that sends the
+// propagated headers of each request's context through next
+// (http.DefaultTransport if nil): those received by WithPropagation or set
+// with WithBaggage, and the correlation ID of logger.WithCorrelation as
+// CorrelationIDHeader. Headers the request sets itself are kept. Without
+// headers, DefaultPropagationHeaders are sent.
+//
+//	client := &http.Client{Transport: middleware.PropagationTransport(nil)}
+//	req, _ := http.NewRequestWithContext(r.Context(), "GET", billingURL, nil)
+//	resp, err := client.Do(req) // Carries X-Request-ID, X-Correlation-ID, X-Tenant-ID
+func PropagationTransport(next http.RoundTripper, headers ...string) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	if len(headers) == 0 {
+		headers = DefaultPropagationHeaders
+	}
+	canonical := make([]string, len(headers))
+	for i, h := range headers {
+		canonical[i] = http.CanonicalHeaderKey(h)
+	}
+	return &propagationTransport{next: next, headers: canonical}
+}
+
+// propagationTransport adds the propagated headers to the requests of next
+type propagationTransport struct {
+	next    http.RoundTripper
+	headers []string // Canonical
+}
+
+func (t *propagationTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	baggage := Baggage(ctx)
+	out := req
+	for _, header := range t.headers {
+		if req.Header.Get(header) != "" {
+			continue
+		}
+		value := baggage[header]
+		if value == "" && header == http.CanonicalHeaderKey(CorrelationIDHeader) {
+			value = logger.CorrelationID(ctx)
+		}
+		if value == "" {
+			continue
+		}
+		// A RoundTripper must not modify the caller's request
+		if out == req {
+			out = req.Clone(ctx)
+		}
+		out.Header.Set(header, value)
+	}
+	return t.next.RoundTrip(out)
+}
@@ -1,8 +1,12 @@
 package middleware
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"net"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/jozefvalachovic/logger/v4"
@@ -39,8 +43,9 @@ func LogTCPMiddleware(next func(conn net.Conn)) func(conn net.Conn) {
 				return
 			}
 
-			// Handle connection close errors
-			if err := conn.Close(); err != nil {
+			// Handle connection close errors; one the handler or ServeTCP's
+			// shutdown already closed is not an error
+			if err := conn.Close(); err != nil && !errors.Is(err, net.ErrClosed) {
 				logger.LogError(fmt.Sprintf("TCP Connection close error %s %s", remoteAddr, duration),
 					"__error", err,
 					"remote", remoteAddr,
@@ -55,3 +60,129 @@ func LogTCPMiddleware(next func(conn net.Conn)) func(conn net.Conn) {
 		next(conn)
 	}
 }
+
+// TCPStats tracks the connections of a ServeTCP listener; Active is a gauge.
+type TCPStats struct {
+	Active       atomic.Int64
+	Accepted     atomic.Int64
+	AcceptErrors atomic.Int64
+}
+
+// TCPOptions configures ServeTCP.
+type TCPOptions struct {
+	// Stats, if set, is updated as connections come and go
+	Stats *TCPStats
+	// ShutdownTimeout bounds how long ServeTCP waits for open connections
+	// after ctx is canceled before closing them (0 waits for all of them)
+	ShutdownTimeout time.Duration
+}
+
+// TCPOption is a functional option for ServeTCP.
+type TCPOption func(*TCPOptions)
+
+// WithTCPStats updates stats with the listener's connection counts.
+func WithTCPStats(stats *TCPStats) TCPOption {
+	return func(o *TCPOptions) { o.Stats = stats }
+}
+
+// WithTCPShutdownTimeout closes connections still open d after shutdown begins.
+func WithTCPShutdownTimeout(d time.Duration) TCPOption {
+	return func(o *TCPOptions) { o.ShutdownTimeout = d }
+}
+
+// ServeTCP accepts connections on ln and serves each with handler, wrapped
+// in LogTCPMiddleware, on its own goroutine. Temporary accept errors are
+// logged and retried with backoff. When ctx is canceled, ln is closed and
+// ServeTCP returns nil once the open connections have ended; any other
+// accept error is logged and returned after the same wait.
+//
+//	ln, err := net.Listen("tcp", ":9000")
+//	...
+//	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+//	defer stop()
+//	err = middleware.ServeTCP(ctx, ln, handle, middleware.WithTCPShutdownTimeout(10*time.Second))
+func ServeTCP(ctx context.Context, ln net.Listener, handler func(conn net.Conn), opts ...TCPOption) error {
+	options := &TCPOptions{}
+	for _, opt := range opts {
+		opt(options)
+	}
+	stats := options.Stats
+	if stats == nil {
+		stats = &TCPStats{}
+	}
+
+	addr := ln.Addr().String()
+	logger.LogInfo(fmt.Sprintf("TCP Listening %s", addr), "addr", addr)
+	stop := context.AfterFunc(ctx, func() { _ = ln.Close() })
+	defer stop()
+
+	serve := LogTCPMiddleware(handler)
+	var (
+		wg    sync.WaitGroup
+		mu    sync.Mutex
+		conns = make(map[net.Conn]struct{})
+	)
+	var backoff time.Duration
+	var acceptErr error
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				break
+			}
+			stats.AcceptErrors.Add(1)
+			if te, ok := err.(interface{ Temporary() bool }); ok && te.Temporary() {
+				// Out of file descriptors and the like; wait, as net/http does
+				backoff = min(max(backoff*2, 5*time.Millisecond), time.Second)
+				logger.LogWarn(fmt.Sprintf("TCP Accept error %s, retrying in %s", addr, backoff),
+					"__error", err, "addr", addr, "retry_in", backoff.String())
+				time.Sleep(backoff)
+				continue
+			}
+			logger.LogError(fmt.Sprintf("TCP Accept error %s", addr), "__error", err, "addr", addr)
+			acceptErr = err
+			break
+		}
+		backoff = 0
+		stats.Accepted.Add(1)
+		stats.Active.Add(1)
+
+		mu.Lock()
+		conns[conn] = struct{}{}
+		mu.Unlock()
+		wg.Go(func() {
+			defer func() {
+				mu.Lock()
+				delete(conns, conn)
+				mu.Unlock()
+				stats.Active.Add(-1)
+			}()
+			serve(conn)
+		})
+	}
+	_ = ln.Close()
+
+	active := stats.Active.Load()
+	logger.LogInfo(fmt.Sprintf("TCP Shutting down %s", addr), "addr", addr, "active", active)
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	if options.ShutdownTimeout > 0 {
+		select {
+		case <-done:
+		case <-time.After(options.ShutdownTimeout):
+			mu.Lock()
+			logger.LogWarn(fmt.Sprintf("TCP Closing %d open connections %s", len(conns), addr), "addr", addr, "active", len(conns))
+			for conn := range conns {
+				_ = conn.Close()
+			}
+			mu.Unlock()
+		}
+	}
+	<-done
+
+	logger.LogInfo(fmt.Sprintf("TCP Stopped %s", addr), "addr", addr, "accepted", stats.Accepted.Load())
+	return acceptErr
+}
@@ -0,0 +1,139 @@
+package middleware
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"log"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/jozefvalachovic/logger/v4"
+)
+
+// tlsHandshakeErrorPrefix starts the lines net/http writes to
+// Server.ErrorLog when a TLS handshake fails
+const tlsHandshakeErrorPrefix = "http: TLS handshake error from "
+
+// DefaultTLSHandshakeTimeout bounds the handshakes of LogTLSHandshake
+// unless WithTLSHandshakeTimeout sets another limit
+const DefaultTLSHandshakeTimeout = 10 * time.Second
+
+// TLSOptions configures LogTLSHandshake.
+type TLSOptions struct {
+	// HandshakeTimeout bounds each handshake, so a client that connects and
+	// sends nothing cannot hold the connection (default:
+	// DefaultTLSHandshakeTimeout)
+	HandshakeTimeout time.Duration
+}
+
+// TLSOption is a functional option for LogTLSHandshake.
+type TLSOption func(*TLSOptions)
+
+// WithTLSHandshakeTimeout fails handshakes that take longer than d.
+func WithTLSHandshakeTimeout(d time.Duration) TLSOption {
+	return func(o *TLSOptions) { o.HandshakeTimeout = d }
+}
+
+// tlsFields describes a completed TLS handshake: the negotiated version,
+// cipher suite and protocol, the SNI name, and the subject, issuer and
+// expiry of the client certificate, if any. RedactKeys and RedactRules
+// apply to them like to any other field, e.g. to hash
+// tls.client_subject.
+func tlsFields(cs *tls.ConnectionState) []any {
+	kv := []any{
+		"tls.version", tls.VersionName(cs.Version),
+		"tls.cipher", tls.CipherSuiteName(cs.CipherSuite),
+	}
+	if cs.ServerName != "" {
+		kv = append(kv, "tls.sni", cs.ServerName)
+	}
+	if cs.NegotiatedProtocol != "" {
+		kv = append(kv, "tls.alpn", cs.NegotiatedProtocol)
+	}
+	if cs.DidResume {
+		kv = append(kv, "tls.resumed", true)
+	}
+	if len(cs.PeerCertificates) > 0 {
+		cert := cs.PeerCertificates[0]
+		kv = append(kv,
+			"tls.client_subject", cert.Subject.String(),
+			"tls.client_issuer", cert.Issuer.String(),
+			"tls.client_expiry", cert.NotAfter.UTC().Format(time.RFC3339),
+		)
+	}
+	return kv
+}
+
+// LogTLSHandshake completes the handshake of TLS connections before
+// handing them to next, logging its outcome: the negotiated version,
+// cipher, SNI name and client certificate at Debug, or the error at Warn,
+// after which the connection is closed. Handshakes are bounded by
+// DefaultTLSHandshakeTimeout or WithTLSHandshakeTimeout. Other connections
+// are passed through. It fits inside LogTCPMiddleware or ServeTCP:
+//
+//	ln = tls.NewListener(ln, tlsConfig)
+//	err = middleware.ServeTCP(ctx, ln, middleware.LogTLSHandshake(handle))
+func LogTLSHandshake(next func(conn net.Conn), opts ...TLSOption) func(conn net.Conn) {
+	options := &TLSOptions{HandshakeTimeout: DefaultTLSHandshakeTimeout}
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	return func(conn net.Conn) {
+		tlsConn, ok := conn.(*tls.Conn)
+		if !ok {
+			next(conn)
+			return
+		}
+
+		remoteAddr := conn.RemoteAddr().String()
+		start := time.Now()
+		ctx, cancel := context.WithTimeout(context.Background(), options.HandshakeTimeout)
+		err := tlsConn.HandshakeContext(ctx)
+		cancel()
+		if err != nil {
+			kv := []any{"__error", err, "remote", remoteAddr}
+			if sni := tlsConn.ConnectionState().ServerName; sni != "" {
+				kv = append(kv, "tls.sni", sni)
+			}
+			logger.LogWarn(fmt.Sprintf("TLS Handshake failed %s", remoteAddr), kv...)
+			_ = conn.Close()
+			return
+		}
+
+		cs := tlsConn.ConnectionState()
+		kv := append([]any{"remote", remoteAddr, "duration", time.Since(start).String()}, tlsFields(&cs)...)
+		logger.LogDebug(fmt.Sprintf("TLS Handshake %s %s", remoteAddr, tls.VersionName(cs.Version)), kv...)
+		next(conn)
+	}
+}
+
+// HTTPServerErrorLog returns a logger for http.Server.ErrorLog that logs
+// failed TLS handshakes at Warn, with the remote address and error as
+// fields, and the server's other errors at Error. Successful handshakes
+// are described in each access record by WithTLSDetails.
+//
+//	srv := &http.Server{Handler: handler, ErrorLog: middleware.HTTPServerErrorLog()}
+func HTTPServerErrorLog() *log.Logger {
+	return log.New(serverErrorWriter{}, "", 0)
+}
+
+// serverErrorWriter turns the lines net/http logs into records
+type serverErrorWriter struct{}
+
+func (serverErrorWriter) Write(p []byte) (int, error) {
+	msg := strings.TrimSpace(string(p))
+	if rest, ok := strings.CutPrefix(msg, tlsHandshakeErrorPrefix); ok {
+		// The remote address is host:port, and an IPv6 host is bracketed
+		remoteAddr, reason := rest, ""
+		if i := strings.Index(rest, ": "); i >= 0 {
+			remoteAddr, reason = rest[:i], rest[i+2:]
+		}
+		logger.LogWarn(fmt.Sprintf("TLS Handshake failed %s", remoteAddr), "__error", reason, "remote", remoteAddr)
+		return len(p), nil
+	}
+	logger.LogError(msg)
+	return len(p), nil
+}
@@ -1,7 +1,9 @@
 package middleware
 
 import (
+	"bufio"
 	"fmt"
+	"net"
 	"net/http"
 	"strings"
 	"sync/atomic"
@@ -92,6 +94,20 @@ func (w *wsWriter) Flush() {
 	}
 }
 
+// Hijack hands the connection to the WebSocket library doing the upgrade
+func (w *wsWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("middleware: %T does not support hijacking", w.ResponseWriter)
+	}
+	return hijacker.Hijack()
+}
+
+// Unwrap returns the underlying ResponseWriter for http.ResponseController
+func (w *wsWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
 func isWebSocketUpgrade(r *http.Request) bool {
 	for _, v := range r.Header.Values("Connection") {
 		if strings.EqualFold(v, "upgrade") {
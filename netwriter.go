@@ -0,0 +1,261 @@
+package logger
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrNetWriterClosed is returned by NetWriter.Write after Close
+var ErrNetWriterClosed = errors.New("logger: net writer is closed")
+
+// NetWriterConfig configures a NetWriter
+type NetWriterConfig struct {
+	TLSConfig    *tls.Config   // Enables TLS over TCP (implied by network "tls")
+	BufferSize   int           // Max queued records before new ones are dropped (default: 1000)
+	DialTimeout  time.Duration // Timeout for each connection attempt (default: 5s)
+	WriteTimeout time.Duration // Deadline for each write (default: 5s)
+	MinBackoff   time.Duration // First reconnect delay (default: 100ms)
+	MaxBackoff   time.Duration // Reconnect delay cap (default: 30s)
+	CloseTimeout time.Duration // How long Close waits to flush the queue (default: 5s)
+}
+
+// NetWriterStats is a snapshot of NetWriter counters
+type NetWriterStats struct {
+	Written    int64 // Records delivered to the remote end
+	Dropped    int64 // Records discarded because the queue was full or Close timed out
+	Reconnects int64 // Connections re-established after a failure
+	Queued     int   // Records waiting to be sent
+}
+
+// NetWriter streams log records to a remote collector (Logstash, Vector,
+// Fluent Bit, ...) over TCP, UDP or TLS. Writes never block: records are
+// queued and sent by a background goroutine that reconnects with
+// exponential backoff. Use it as Config.Output or behind an extra handler.
+type NetWriter struct {
+	network string
+	addr    string
+	config  NetWriterConfig
+	dialer  net.Dialer
+
+	mu     sync.RWMutex // Guards closed against concurrent Write/Close
+	closed bool
+	queue  chan []byte
+	stop   chan struct{}
+	done   chan struct{}
+
+	written    atomic.Int64
+	dropped    atomic.Int64
+	reconnects atomic.Int64
+}
+
+// NewNetWriter creates a writer that sends each record to addr. network is
+// "tcp", "tcp4", "tcp6", "udp", "udp4", "udp6" or "tls" (TCP with TLS).
+// The connection is established in the background, so an unreachable
+// collector does not fail construction.
+func NewNetWriter(network, addr string, config *NetWriterConfig) (*NetWriter, error) {
+	cfg := NetWriterConfig{}
+	if config != nil {
+		cfg = *config
+	}
+
+	switch network {
+	case "tls":
+		network = "tcp"
+		if cfg.TLSConfig == nil {
+			cfg.TLSConfig = &tls.Config{}
+		}
+	case "tcp", "tcp4", "tcp6":
+	case "udp", "udp4", "udp6":
+		if cfg.TLSConfig != nil {
+			return nil, fmt.Errorf("logger: TLS is not supported over %s", network)
+		}
+	default:
+		return nil, fmt.Errorf("logger: unsupported network %q", network)
+	}
+	if addr == "" {
+		return nil, fmt.Errorf("logger: net writer address cannot be empty")
+	}
+
+	if cfg.BufferSize <= 0 {
+		cfg.BufferSize = 1000
+	}
+	if cfg.DialTimeout <= 0 {
+		cfg.DialTimeout = 5 * time.Second
+	}
+	if cfg.WriteTimeout <= 0 {
+		cfg.WriteTimeout = 5 * time.Second
+	}
+	if cfg.MinBackoff <= 0 {
+		cfg.MinBackoff = 100 * time.Millisecond
+	}
+	if cfg.MaxBackoff <= 0 {
+		cfg.MaxBackoff = 30 * time.Second
+	}
+	if cfg.CloseTimeout <= 0 {
+		cfg.CloseTimeout = 5 * time.Second
+	}
+
+	w := &NetWriter{
+		network: network,
+		addr:    addr,
+		config:  cfg,
+		dialer:  net.Dialer{Timeout: cfg.DialTimeout},
+		queue:   make(chan []byte, cfg.BufferSize),
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	go w.run()
+	return w, nil
+}
+
+// Write queues a copy of p for delivery. When the queue is full the record
+// is dropped and counted rather than blocking the caller.
+func (w *NetWriter) Write(p []byte) (int, error) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	if w.closed {
+		return 0, ErrNetWriterClosed
+	}
+
+	select {
+	case w.queue <- append([]byte(nil), p...):
+	default:
+		w.dropped.Add(1)
+	}
+	return len(p), nil
+}
+
+// Stats returns the current delivery counters
+func (w *NetWriter) Stats() NetWriterStats {
+	return NetWriterStats{
+		Written:    w.written.Load(),
+		Dropped:    w.dropped.Load(),
+		Reconnects: w.reconnects.Load(),
+		Queued:     len(w.queue),
+	}
+}
+
+// Close stops accepting records and waits up to CloseTimeout for the queue
+// to drain. Records still queued after that are counted as dropped.
+func (w *NetWriter) Close() error {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return nil
+	}
+	w.closed = true
+	close(w.queue)
+	w.mu.Unlock()
+
+	timer := time.NewTimer(w.config.CloseTimeout)
+	defer timer.Stop()
+
+	select {
+	case <-w.done:
+		return nil
+	case <-timer.C:
+		close(w.stop)
+		<-w.done
+		return fmt.Errorf("logger: net writer close timed out, %d records dropped", w.dropped.Load())
+	}
+}
+
+// run owns the connection and delivers queued records in order
+func (w *NetWriter) run() {
+	defer close(w.done)
+
+	var conn net.Conn
+	defer func() {
+		if conn != nil {
+			_ = conn.Close()
+		}
+	}()
+
+	backoff := w.config.MinBackoff
+	connected := false
+
+	for msg := range w.queue {
+		for {
+			select {
+			case <-w.stop:
+				w.dropped.Add(1 + int64(w.drain()))
+				return
+			default:
+			}
+
+			if conn == nil {
+				c, err := w.dial()
+				if err != nil {
+					if !w.sleep(backoff) {
+						w.dropped.Add(1 + int64(w.drain()))
+						return
+					}
+					backoff = min(backoff*2, w.config.MaxBackoff)
+					continue
+				}
+				conn = c
+				if connected {
+					w.reconnects.Add(1)
+				}
+				connected = true
+			}
+
+			_ = conn.SetWriteDeadline(time.Now().Add(w.config.WriteTimeout))
+			if _, err := conn.Write(msg); err != nil {
+				// Back off here too so a peer that accepts and then resets
+				// cannot turn this into a busy loop
+				_ = conn.Close()
+				conn = nil
+				if !w.sleep(backoff) {
+					w.dropped.Add(1 + int64(w.drain()))
+					return
+				}
+				backoff = min(backoff*2, w.config.MaxBackoff)
+				continue
+			}
+			w.written.Add(1)
+			backoff = w.config.MinBackoff
+			break
+		}
+	}
+}
+
+// dial opens a connection, wrapping it in TLS when configured
+func (w *NetWriter) dial() (net.Conn, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), w.config.DialTimeout)
+	defer cancel()
+
+	if w.config.TLSConfig != nil {
+		d := tls.Dialer{NetDialer: &w.dialer, Config: w.config.TLSConfig}
+		return d.DialContext(ctx, w.network, w.addr)
+	}
+	return w.dialer.DialContext(ctx, w.network, w.addr)
+}
+
+// sleep waits for d, reporting false if Close gave up in the meantime
+func (w *NetWriter) sleep(d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-w.stop:
+		return false
+	}
+}
+
+// drain discards everything still queued and returns how many records it dropped
+func (w *NetWriter) drain() int {
+	n := 0
+	for range w.queue {
+		n++
+	}
+	return n
+}
@@ -0,0 +1,112 @@
+package logger
+
+import (
+	"bufio"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNetWriterTCPDeliveryAndReconnect(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = ln.Close() }()
+
+	lines := make(chan string, 10)
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			// Read one line per connection, then drop it to force a reconnect
+			line, _ := bufio.NewReader(conn).ReadString('\n')
+			lines <- strings.TrimSpace(line)
+			_ = conn.Close()
+		}
+	}()
+
+	w, err := NewNetWriter("tcp", ln.Addr().String(), &NetWriterConfig{
+		MinBackoff: 10 * time.Millisecond,
+		MaxBackoff: 50 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = w.Close() }()
+
+	_, _ = w.Write([]byte("first\n"))
+	if got := receive(t, lines); got != "first" {
+		t.Fatalf("Expected first line, got %q", got)
+	}
+
+	// The server closed the connection; keep writing until the writer notices
+	// and reconnects
+	deadline := time.Now().Add(5 * time.Second)
+	for w.Stats().Reconnects == 0 && time.Now().Before(deadline) {
+		_, _ = w.Write([]byte("second\n"))
+		time.Sleep(20 * time.Millisecond)
+	}
+	if got := receive(t, lines); got != "second" {
+		t.Errorf("Expected second line after reconnect, got %q", got)
+	}
+	if w.Stats().Reconnects == 0 {
+		t.Error("Expected reconnect to be counted")
+	}
+}
+
+func TestNetWriterDropsWhenQueueFull(t *testing.T) {
+	// Reserve a port and close it so dials fail
+	ln, _ := net.Listen("tcp", "127.0.0.1:0")
+	addr := ln.Addr().String()
+	_ = ln.Close()
+
+	w, err := NewNetWriter("tcp", addr, &NetWriterConfig{
+		BufferSize:   2,
+		MinBackoff:   time.Hour,
+		CloseTimeout: 50 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for range 10 {
+		_, _ = w.Write([]byte("x\n"))
+	}
+	if w.Stats().Dropped == 0 {
+		t.Error("Expected records to be dropped when the queue is full")
+	}
+
+	if err := w.Close(); err == nil {
+		t.Error("Expected Close to report undelivered records")
+	}
+	if _, err := w.Write([]byte("late\n")); err != ErrNetWriterClosed {
+		t.Errorf("Expected ErrNetWriterClosed after Close, got %v", err)
+	}
+	if s := w.Stats(); s.Written+s.Dropped != 10 {
+		t.Errorf("Expected every record to be written or dropped, got %+v", s)
+	}
+}
+
+func TestNewNetWriterValidation(t *testing.T) {
+	if _, err := NewNetWriter("unix", "/tmp/sock", nil); err == nil {
+		t.Error("Expected error for unsupported network")
+	}
+	if _, err := NewNetWriter("tcp", "", nil); err == nil {
+		t.Error("Expected error for empty address")
+	}
+}
+
+func receive(t *testing.T, ch <-chan string) string {
+	t.Helper()
+	select {
+	case s := <-ch:
+		return s
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for line")
+		return ""
+	}
+}
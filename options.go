@@ -0,0 +1,289 @@
+package logger
+
+import (
+	"io"
+	"log/slog"
+	"maps"
+	"slices"
+	"time"
+)
+
+// Option configures the logger; see New
+type Option func(*Config)
+
+// New configures the global logger from the defaults plus opts and returns
+// it. Options start from the defaults rather than a zero Config, so
+// WithLevel(slog.LevelInfo) and WithSampleRate(0) mean exactly that:
+//
+//	log := logger.New(
+//		logger.WithLevel(slog.LevelInfo),
+//		logger.WithJSON(),
+//		logger.WithRedactKeys("session_id"),
+//	)
+func New(opts ...Option) Logger {
+	SetConfig(NewConfig(opts...))
+	return DefaultLogger()
+}
+
+// NewConfig returns the default configuration with opts applied, for
+// callers that want to adjust it further before SetConfig
+func NewConfig(opts ...Option) Config {
+	cfg := defaultConfig
+	cfg.RedactKeys = slices.Clone(defaultConfig.RedactKeys)
+	cfg.RedactQueryParams = slices.Clone(defaultConfig.RedactQueryParams)
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return cfg
+}
+
+// WithOutput sets the writer records are written to
+func WithOutput(w io.Writer) Option {
+	return func(c *Config) {
+		c.Output = w
+	}
+}
+
+// WithLevel sets the minimum level, including slog.LevelInfo
+func WithLevel(level slog.Level) Option {
+	return func(c *Config) {
+		c.Level = level
+		c.LevelSet = true
+	}
+}
+
+// WithModuleLevel overrides the level of the named module; see Named
+func WithModuleLevel(name string, level slog.Level) Option {
+	return func(c *Config) {
+		c.ModuleLevels = maps.Clone(c.ModuleLevels)
+		if c.ModuleLevels == nil {
+			c.ModuleLevels = make(map[string]slog.Level)
+		}
+		c.ModuleLevels[name] = level
+	}
+}
+
+// WithFormat sets the line encoding
+func WithFormat(format OutputFormat) Option {
+	return func(c *Config) {
+		c.Format = format
+	}
+}
+
+// WithJSON writes one JSON object per line
+func WithJSON() Option {
+	return WithFormat(FormatJSON)
+}
+
+// WithLogfmt writes logfmt key=value lines
+func WithLogfmt() Option {
+	return WithFormat(FormatLogfmt)
+}
+
+// WithConsole writes column-aligned lines for terminals
+func WithConsole() Option {
+	return WithFormat(FormatConsole)
+}
+
+// WithColor sets when ANSI colors are written
+func WithColor(mode ColorMode) Option {
+	return func(c *Config) {
+		c.Color = mode
+	}
+}
+
+// WithTimeFormat sets the timestamp layout
+func WithTimeFormat(layout string) Option {
+	return func(c *Config) {
+		c.TimeFormat = layout
+	}
+}
+
+// WithCaller adds the source file:line to every record
+func WithCaller() Option {
+	return func(c *Config) {
+		c.EnableCaller = true
+	}
+}
+
+// WithRedactKeys masks the values of keys in addition to the default
+// RedactKeys (password, secret, token, ...)
+func WithRedactKeys(keys ...string) Option {
+	return func(c *Config) {
+		c.RedactKeys = append(slices.Clip(c.RedactKeys), keys...)
+	}
+}
+
+// WithRedactQueryParams masks the values of query parameters in logged
+// paths in addition to the default RedactQueryParams (token, api_key, ...)
+func WithRedactQueryParams(params ...string) Option {
+	return func(c *Config) {
+		c.RedactQueryParams = append(slices.Clip(c.RedactQueryParams), params...)
+	}
+}
+
+// WithRedactRule redacts the value of key with strategy s, e.g.
+// WithRedactRule("card_number", RedactPartial)
+func WithRedactRule(key string, s RedactStrategy) Option {
+	return func(c *Config) {
+		c.RedactRules = maps.Clone(c.RedactRules)
+		if c.RedactRules == nil {
+			c.RedactRules = make(map[string]RedactStrategy)
+		}
+		c.RedactRules[key] = s
+	}
+}
+
+// WithRedactor sets the callback that redacts values before RedactRules
+// and RedactKeys; see Config.Redactor
+func WithRedactor(fn func(key string, value any) (any, bool)) Option {
+	return func(c *Config) {
+		c.Redactor = fn
+	}
+}
+
+// WithRedactPatterns masks string values matching any of the regular
+// expressions
+func WithRedactPatterns(patterns ...string) Option {
+	return func(c *Config) {
+		c.RedactPatterns = append(slices.Clip(c.RedactPatterns), patterns...)
+	}
+}
+
+// WithDetectPII scrubs the selected kinds of personal data from string
+// values and bodies, e.g. WithDetectPII(PIIEmail|PIIPhone) or PIIAll
+func WithDetectPII(d PIIDetector) Option {
+	return func(c *Config) {
+		c.DetectPII = d
+	}
+}
+
+// WithStrictTypes writes structs, maps, slices and arrays as a marker
+// naming their type instead of expanding them
+func WithStrictTypes() Option {
+	return func(c *Config) {
+		c.StrictTypes = true
+	}
+}
+
+// WithLimits caps the attributes of a record and the bytes of each value;
+// see Config.MaxAttrs (0 = unlimited)
+func WithLimits(maxAttrs, maxValueBytes int) Option {
+	return func(c *Config) {
+		c.MaxAttrs = maxAttrs
+		c.MaxValueBytes = maxValueBytes
+	}
+}
+
+// WithSampleRate logs the given fraction of messages, from 0 (none) to 1 (all)
+func WithSampleRate(rate float64) Option {
+	return func(c *Config) {
+		c.SampleRate = rate
+		c.SampleRateSet = true
+	}
+}
+
+// WithSampleMode sets how SampleRate picks the records it keeps
+func WithSampleMode(mode SampleMode) Option {
+	return func(c *Config) {
+		c.SampleMode = mode
+	}
+}
+
+// WithSampleKey samples by the value of the key attribute (SampleByKey), so
+// the records sharing it, e.g. one request's, are kept or dropped together
+func WithSampleKey(key string) Option {
+	return func(c *Config) {
+		c.SampleMode = SampleByKey
+		c.SampleKey = key
+	}
+}
+
+// WithSampleBudget samples Info and lower down while more than perSecond
+// records are logged per second, and back up once the volume drops
+func WithSampleBudget(perSecond int) Option {
+	return func(c *Config) {
+		c.SampleBudget = perSecond
+	}
+}
+
+// WithBurstSampling logs the first records of each (level, message) per
+// interval, then every thereafter-th (0 drops the rest). A zero interval
+// keeps the default of 1s.
+func WithBurstSampling(first, thereafter int, interval time.Duration) Option {
+	return func(c *Config) {
+		c.SampleFirst = first
+		c.SampleThereafter = thereafter
+		c.SampleInterval = interval
+	}
+}
+
+// WithAsync enables async mode with a queue of bufferSize entries (0 keeps
+// the default)
+func WithAsync(bufferSize int) Option {
+	return func(c *Config) {
+		c.AsyncMode = true
+		if bufferSize > 0 {
+			c.BufferSize = bufferSize
+		}
+	}
+}
+
+// WithMetrics enables metrics collection
+func WithMetrics() Option {
+	return func(c *Config) {
+		c.EnableMetrics = true
+	}
+}
+
+// WithDedup suppresses repeated messages within window (0 keeps the default)
+func WithDedup(window time.Duration) Option {
+	return func(c *Config) {
+		c.EnableDedup = true
+		if window > 0 {
+			c.DedupWindow = window
+		}
+	}
+}
+
+// WithTail keeps the last size records in memory for Tail and TailHandler
+func WithTail(size int) Option {
+	return func(c *Config) {
+		c.TailSize = size
+	}
+}
+
+// WithLogID stamps every record with a unique log_id
+func WithLogID(format LogIDFormat) Option {
+	return func(c *Config) {
+		c.LogID = format
+	}
+}
+
+// WithHandler replaces the built-in formatter; see UseHandler
+func WithHandler(h slog.Handler) Option {
+	return func(c *Config) {
+		c.Handler = h
+	}
+}
+
+// WithAdditionalHandlers also sends every record to handlers
+func WithAdditionalHandlers(handlers ...slog.Handler) Option {
+	return func(c *Config) {
+		c.AdditionalHandlers = append(slices.Clip(c.AdditionalHandlers), handlers...)
+	}
+}
+
+// WithErrorHandler receives errors from writing records
+func WithErrorHandler(fn func(err error)) Option {
+	return func(c *Config) {
+		c.ErrorHandler = fn
+	}
+}
+
+// WithFallbackOutput receives records the primary output failed to write
+func WithFallbackOutput(w io.Writer) Option {
+	return func(c *Config) {
+		c.FallbackOutput = w
+	}
+}
@@ -0,0 +1,65 @@
+package logger
+
+import (
+	"bytes"
+	"io"
+	"log/slog"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestNewWithOptions(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(
+		WithOutput(&buf),
+		WithLevel(slog.LevelInfo),
+		WithJSON(),
+		WithRedactKeys("session_id"),
+	)
+	defer SetConfig(Config{Output: io.Discard, Level: LevelTrace})
+
+	l.LogDebug("hidden")
+	l.LogInfo("shown", "session_id", "abc", "password", "hunter2")
+
+	out := buf.String()
+	if strings.Contains(out, "hidden") {
+		t.Errorf("Expected Debug to be suppressed at Info level, got %q", out)
+	}
+	if !strings.HasPrefix(out, `{"time":`) || !strings.Contains(out, `"msg":"shown"`) {
+		t.Errorf("Expected a JSON line, got %q", out)
+	}
+	if strings.Contains(out, "abc") || strings.Contains(out, "hunter2") {
+		t.Errorf("Expected session_id and the default password key to be redacted, got %q", out)
+	}
+}
+
+func TestNewWithSampleRateZero(t *testing.T) {
+	var buf bytes.Buffer
+	New(WithOutput(&buf), WithSampleRate(0))
+	defer SetConfig(Config{Output: io.Discard, Level: LevelTrace})
+
+	for range 10 {
+		LogInfo("sampled")
+	}
+	if buf.Len() != 0 {
+		t.Errorf("Expected nothing logged at sample rate 0, got %q", buf.String())
+	}
+}
+
+func TestNewConfigKeepsDefaults(t *testing.T) {
+	cfg := NewConfig(WithRedactKeys("pin"), WithModuleLevel("db", LevelDebug))
+
+	if cfg.TimeFormat != defaultConfig.TimeFormat || cfg.BufferSize != defaultConfig.BufferSize {
+		t.Error("Expected unset fields to keep their defaults")
+	}
+	if !slices.Contains(cfg.RedactKeys, "pin") || !slices.Contains(cfg.RedactKeys, "password") {
+		t.Errorf("Expected pin added to the default RedactKeys, got %v", cfg.RedactKeys)
+	}
+	if slices.Contains(defaultConfig.RedactKeys, "pin") {
+		t.Error("Expected defaultConfig to be left unchanged")
+	}
+	if cfg.ModuleLevels["db"] != LevelDebug {
+		t.Errorf("Expected db module level Debug, got %v", cfg.ModuleLevels["db"])
+	}
+}
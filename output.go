@@ -0,0 +1,89 @@
+package logger
+
+import (
+	"io"
+	"log/slog"
+	"os"
+	"slices"
+)
+
+// LevelWriter is an io.Writer that can route records by level. When the
+// configured output implements it, each record is written with WriteLevel.
+type LevelWriter interface {
+	io.Writer
+	WriteLevel(level slog.Level, p []byte) (int, error)
+}
+
+// levelRoute sends records at or above level to out
+type levelRoute struct {
+	level slog.Level
+	out   io.Writer
+}
+
+// LevelRouter is a LevelWriter that sends each record to the writer
+// registered for the highest threshold at or below the record's level,
+// or to the default writer when no threshold matches.
+type LevelRouter struct {
+	def    io.Writer
+	routes []levelRoute // Sorted by level, highest first
+}
+
+// NewLevelRouter creates a router. Each key of routes is a minimum level:
+//
+//	logger.NewLevelRouter(os.Stdout, map[slog.Level]io.Writer{
+//		logger.LevelWarn:  os.Stderr,
+//		logger.LevelAudit: auditFile,
+//	})
+//
+// sends Warn and Error to stderr, Audit to auditFile and the rest to stdout.
+func NewLevelRouter(def io.Writer, routes map[slog.Level]io.Writer) *LevelRouter {
+	r := &LevelRouter{def: def}
+	for level, out := range routes {
+		r.routes = append(r.routes, levelRoute{level: level, out: out})
+	}
+	slices.SortFunc(r.routes, func(a, b levelRoute) int {
+		return int(b.level) - int(a.level)
+	})
+	return r
+}
+
+// Write sends p to the default writer
+func (r *LevelRouter) Write(p []byte) (int, error) {
+	return r.def.Write(p)
+}
+
+// WriteLevel sends p to the writer routed for level
+func (r *LevelRouter) WriteLevel(level slog.Level, p []byte) (int, error) {
+	return r.writerFor(level).Write(p)
+}
+
+func (r *LevelRouter) writerFor(level slog.Level) io.Writer {
+	for _, route := range r.routes {
+		if level >= route.level {
+			return route.out
+		}
+	}
+	return r.def
+}
+
+// StdStreams returns a router that follows the 12-factor convention: Warn
+// and above go to os.Stderr, everything else to os.Stdout
+func StdStreams() *LevelRouter {
+	return NewLevelRouter(os.Stdout, map[slog.Level]io.Writer{LevelWarn: os.Stderr})
+}
+
+// writeLevel writes p to out, routing by level when out supports it
+func writeLevel(out io.Writer, level slog.Level, p []byte) (int, error) {
+	if lw, ok := out.(LevelWriter); ok {
+		return lw.WriteLevel(level, p)
+	}
+	return out.Write(p)
+}
+
+// configOutput returns the writer records are written to for cfg
+func configOutput(cfg Config) io.Writer {
+	if cfg.SplitStdStreams {
+		return NewLevelRouter(cfg.Output, map[slog.Level]io.Writer{LevelWarn: os.Stderr})
+	}
+	return cfg.Output
+}
@@ -0,0 +1,98 @@
+package logger
+
+import (
+	"bytes"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLevelRouter(t *testing.T) {
+	var out, warn, audit bytes.Buffer
+	r := NewLevelRouter(&out, map[slog.Level]io.Writer{
+		LevelWarn:  &warn,
+		LevelAudit: &audit,
+	})
+
+	for _, level := range []slog.Level{LevelTrace, LevelInfo, LevelNotice, LevelWarn, LevelError, LevelAudit} {
+		_, _ = r.WriteLevel(level, []byte(LevelString(level)+"\n"))
+	}
+	_, _ = r.Write([]byte("plain\n"))
+
+	if got := out.String(); got != "TRACE\nINFO\nNOTICE\nplain\n" {
+		t.Errorf("default writer got %q", got)
+	}
+	if got := warn.String(); got != "WARN\nERROR\n" {
+		t.Errorf("warn writer got %q", got)
+	}
+	if got := audit.String(); got != "AUDIT\n" {
+		t.Errorf("audit writer got %q", got)
+	}
+}
+
+func TestSplitStdStreams(t *testing.T) {
+	var out bytes.Buffer
+	router, ok := configOutput(Config{Output: &out, SplitStdStreams: true}).(*LevelRouter)
+	if !ok {
+		t.Fatal("expected SplitStdStreams to wrap Output in a LevelRouter")
+	}
+	if router.writerFor(LevelInfo) != &out || router.writerFor(LevelWarn) != os.Stderr {
+		t.Error("expected Info to go to Output and Warn to os.Stderr")
+	}
+	if configOutput(Config{Output: &out}) != &out {
+		t.Error("expected Output to be used as-is without SplitStdStreams")
+	}
+}
+
+func TestLevelRoutingThroughLogger(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		cfg  Config
+	}{
+		{"sync", Config{}},
+		{"async batched", Config{AsyncMode: true, AsyncBatchSize: 10, FlushTimeout: time.Hour}},
+		{"async ordered pool", Config{AsyncMode: true, AsyncWorkers: 3, FlushTimeout: time.Hour}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var out, errOut bytes.Buffer
+			cfg := tc.cfg
+			cfg.Output = NewLevelRouter(&out, map[slog.Level]io.Writer{LevelWarn: &errOut})
+			cfg.Level = LevelTrace
+			SetConfig(cfg)
+
+			for range 5 {
+				LogInfo("to-stdout")
+				LogError("to-stderr")
+			}
+			SetConfig(Config{Output: io.Discard, Level: LevelTrace})
+
+			if n := strings.Count(out.String(), "to-stdout"); n != 5 || strings.Contains(out.String(), "to-stderr") {
+				t.Errorf("default writer got %q", out.String())
+			}
+			if n := strings.Count(errOut.String(), "to-stderr"); n != 5 || strings.Contains(errOut.String(), "to-stdout") {
+				t.Errorf("warn writer got %q", errOut.String())
+			}
+		})
+	}
+}
+
+func TestBatchWriterKeepsLevelRuns(t *testing.T) {
+	var out, errOut bytes.Buffer
+	b := newBatchWriter(NewLevelRouter(&out, map[slog.Level]io.Writer{LevelWarn: &errOut}), 10, time.Hour)
+
+	_, _ = b.WriteLevel(LevelInfo, []byte("a\n"))
+	_, _ = b.WriteLevel(LevelInfo, []byte("b\n"))
+	_, _ = b.WriteLevel(LevelError, []byte("c\n"))
+	_, _ = b.Write([]byte("d\n"))
+	if len(b.runs) != 3 {
+		t.Errorf("expected 3 runs, got %d", len(b.runs))
+	}
+	_ = b.Flush()
+
+	if out.String() != "a\nb\nd\n" || errOut.String() != "c\n" {
+		t.Errorf("out=%q err=%q", out.String(), errOut.String())
+	}
+}
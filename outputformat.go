@@ -0,0 +1,225 @@
+package logger
+
+import (
+	"log/slog"
+	"strconv"
+	"unicode/utf8"
+)
+
+// OutputFormat selects the line encoding of the built-in handler
+type OutputFormat int
+
+const (
+	// FormatPretty writes "time LEVEL [file:line] message {attrs}" with the
+	// attributes as a JSON object (default)
+	FormatPretty OutputFormat = iota
+	// FormatJSON writes one JSON object per line: time, level, source and
+	// msg, then the attributes sorted by key
+	FormatJSON
+	// FormatLogfmt writes space-separated key=value pairs in the same order
+	// as FormatJSON, with group keys joined by dots
+	FormatLogfmt
+	// FormatConsole is a column-aligned format for local development:
+	// time of day, a three-letter level column, the padded message and
+	// key=value attributes
+	FormatConsole
+)
+
+// String returns the format name
+func (f OutputFormat) String() string {
+	switch f {
+	case FormatPretty:
+		return "pretty"
+	case FormatJSON:
+		return "json"
+	case FormatLogfmt:
+		return "logfmt"
+	case FormatConsole:
+		return "console"
+	default:
+		return "unknown"
+	}
+}
+
+// FormatVersion pins the line layout of every OutputFormat. Within a
+// version, field names, field order, quoting and escaping never change;
+// a release that changes any of them adds a new version and keeps the old
+// one selectable, so parsers can pin the layout they were written for.
+type FormatVersion int
+
+const (
+	// FormatVersionLatest follows the newest layout (default)
+	FormatVersionLatest FormatVersion = iota
+	// FormatV1 is the layout of v4.2
+	FormatV1
+	// FormatV2 escapes line breaks, ANSI escape sequences and other control
+	// characters in the unquoted message of the pretty and console formats
+	FormatV2
+)
+
+// CurrentFormatVersion is the layout FormatVersionLatest resolves to
+const CurrentFormatVersion = FormatV2
+
+// resolve returns the version v stands for, CurrentFormatVersion for
+// FormatVersionLatest
+func (v FormatVersion) resolve() FormatVersion {
+	if v == FormatVersionLatest {
+		return CurrentFormatVersion
+	}
+	return v
+}
+
+// String returns the version name
+func (v FormatVersion) String() string {
+	switch v {
+	case FormatVersionLatest:
+		return "latest"
+	case FormatV1:
+		return "v1"
+	case FormatV2:
+		return "v2"
+	default:
+		return "unknown"
+	}
+}
+
+// appendJSONLine appends the record as a single-line JSON object
+func (handler *prettyHandler) appendJSONLine(buf []byte, state *handleState, record slog.Record) ([]byte, error) {
+	buf = append(buf, `{"time":`...)
+	buf = appendJSONString(buf, record.Time.Format(handler.config.TimeFormat))
+	buf = append(buf, `,"level":`...)
+	buf = appendJSONString(buf, LevelString(record.Level))
+	if handler.config.EnableCaller && record.PC != 0 {
+		buf = append(buf, `,"source":"`...)
+		buf = appendSource(buf, record.PC)
+		buf = append(buf, '"')
+	}
+	buf = append(buf, `,"msg":`...)
+	buf = appendJSONString(buf, record.Message)
+
+	attrs := sortedRecordAttrs(state, record)
+	mark := len(buf)
+	buf = append(buf, ',')
+	buf, err := handler.appendMembers(buf, attrs)
+	if err != nil {
+		return buf, err
+	}
+	if len(buf) == mark+1 {
+		buf = buf[:mark] // No attributes were written
+	}
+	return append(buf, '}'), nil
+}
+
+// appendLogfmtLine appends the record as key=value pairs
+func (handler *prettyHandler) appendLogfmtLine(buf []byte, state *handleState, record slog.Record) ([]byte, error) {
+	buf = append(buf, "time="...)
+	buf = appendLogfmtString(buf, record.Time.Format(handler.config.TimeFormat))
+	buf = append(buf, " level="...)
+	buf = append(buf, LevelString(record.Level)...)
+	if handler.config.EnableCaller && record.PC != 0 {
+		buf = append(buf, " source="...)
+		buf = appendLogfmtString(buf, string(appendSource(nil, record.PC)))
+	}
+	buf = append(buf, " msg="...)
+	buf = appendLogfmtString(buf, record.Message)
+
+	return handler.appendLogfmtAttrs(buf, "", sortedRecordAttrs(state, record))
+}
+
+// appendLogfmtAttrs appends sorted attrs as " key=value" pairs, flattening
+// groups into dotted keys
+func (handler *prettyHandler) appendLogfmtAttrs(buf []byte, prefix string, attrs []slog.Attr) ([]byte, error) {
+	for i, a := range attrs {
+		if shadowed(attrs, i) {
+			continue
+		}
+		v := a.Value.Resolve()
+		if v.Kind() == slog.KindGroup {
+			groupPrefix := prefix
+			if a.Key != "" {
+				groupPrefix += a.Key + "."
+			}
+			var err error
+			if buf, err = handler.appendLogfmtAttrs(buf, groupPrefix, sortedAttrs(v.Group())); err != nil {
+				return buf, err
+			}
+			continue
+		}
+
+		buf = append(buf, ' ')
+		keyStart := len(buf)
+		buf = appendLogfmtKey(buf, prefix+a.Key)
+		buf = append(buf, '=')
+		valueStart := len(buf)
+
+		var err error
+		if buf, err = handler.appendLogfmtValue(buf, a.Key, v); err != nil {
+			return buf, err
+		}
+		if handler.styleKeyValues {
+			buf = handler.stylePair(buf, keyStart, valueStart, prefix+a.Key)
+		}
+	}
+	return buf, nil
+}
+
+// appendLogfmtValue appends a resolved non-group value, quoted as needed
+func (handler *prettyHandler) appendLogfmtValue(buf []byte, key string, v slog.Value) ([]byte, error) {
+	if v.Kind() == slog.KindString {
+		// appendAttrValue applies the value redaction; undo its JSON quoting
+		return appendLogfmtString(buf, handler.values.redact(v.String())), nil
+	}
+
+	start := len(buf)
+	buf, err := handler.appendAttrValue(buf, slog.Attr{Key: key, Value: v})
+	if err != nil {
+		return buf, err
+	}
+	if encoded := string(buf[start:]); encoded[0] == '"' {
+		// JSON strings (times, formatted durations) are re-quoted logfmt style
+		if s, err := strconv.Unquote(encoded); err == nil {
+			buf = appendLogfmtString(buf[:start], s)
+		}
+	} else if needsLogfmtQuote(encoded) {
+		buf = appendLogfmtString(buf[:start], encoded)
+	}
+	return buf, nil
+}
+
+// appendLogfmtKey appends key with spaces, '=' and '"' replaced by '_'
+func appendLogfmtKey(buf []byte, key string) []byte {
+	if key == "" {
+		return append(buf, '_')
+	}
+	for i := 0; i < len(key); i++ {
+		switch b := key[i]; {
+		case b <= ' ' || b == '=' || b == '"' || b == 0x7f:
+			buf = append(buf, '_')
+		default:
+			buf = append(buf, b)
+		}
+	}
+	return buf
+}
+
+// appendLogfmtString appends s, quoted when it is empty or contains
+// spaces, '=', quotes, control characters or invalid UTF-8
+func appendLogfmtString(buf []byte, s string) []byte {
+	if needsLogfmtQuote(s) {
+		return strconv.AppendQuote(buf, s)
+	}
+	return append(buf, s...)
+}
+
+// needsLogfmtQuote reports whether s must be quoted as a logfmt value
+func needsLogfmtQuote(s string) bool {
+	if s == "" || !utf8.ValidString(s) {
+		return true
+	}
+	for _, r := range s {
+		if r <= ' ' || r == '=' || r == '"' || r == '\\' || r == 0x7f || r == '\u2028' || r == '\u2029' {
+			return true
+		}
+	}
+	return false
+}
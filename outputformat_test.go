@@ -0,0 +1,168 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+var updateGolden = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// goldenRecords covers every value kind the encoders distinguish. Changing
+// the output for any of them requires a new FormatVersion.
+func goldenRecords() []slog.Record {
+	at := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	record := func(level slog.Level, msg string, attrs ...slog.Attr) slog.Record {
+		r := slog.NewRecord(at, level, msg, 0)
+		r.AddAttrs(attrs...)
+		return r
+	}
+	return []slog.Record{
+		record(LevelInfo, "server started", slog.Int("port", 8080), slog.String("host", "0.0.0.0")),
+		record(LevelWarn, "slow query",
+			slog.Duration("duration", 1500*time.Millisecond),
+			slog.Duration("timeout", 2*time.Second),
+			slog.String("query", "SELECT * FROM t WHERE a = 'x'"),
+			slog.Uint64("rows", 3)),
+		record(LevelError, `payment "failed"`,
+			slog.String("error", "card declined: <code 51>"),
+			slog.Float64("amount", 12.5),
+			slog.Bool("retry", false)),
+		record(LevelDebug, "",
+			slog.Group("user", slog.Int("id", 7), slog.String("name", "ana")),
+			slog.Any("tags", []string{"a", "b"}),
+			slog.Group("empty"),
+			slog.String("k", "first"),
+			slog.String("k", "second")),
+		record(LevelAudit, "login",
+			slog.String("actor", "žofia"),
+			slog.Time("when", at.Add(time.Hour)),
+			slog.String("note", "line one\nline two"),
+			slog.String("blank", "")),
+		record(LevelTrace, "tick"),
+		record(slog.Level(1), "custom level", slog.Float64("ratio", 1e-9)),
+		record(LevelWarn, "login failed for bob\n2026-01-02 03:04:05 INFO \x1b[31mforged\x1b[0m\r\x00",
+			slog.String("user", "bob\n\x1b[0m")),
+	}
+}
+
+func TestOutputFormatGolden(t *testing.T) {
+	for version := FormatV1; version <= CurrentFormatVersion; version++ {
+		for _, tc := range []struct {
+			name string
+			cfg  func(*Config)
+		}{
+			{"pretty", func(c *Config) {}},
+			{"pretty_indented", func(c *Config) { c.CompactJSON = false }},
+			{"json", func(c *Config) { c.Format = FormatJSON }},
+			{"logfmt", func(c *Config) { c.Format = FormatLogfmt }},
+			{"console", func(c *Config) { c.Format = FormatConsole }},
+		} {
+			t.Run(tc.name+"_"+version.String(), func(t *testing.T) {
+				testOutputFormatGolden(t, tc.name, version, tc.cfg)
+			})
+		}
+	}
+}
+
+// testOutputFormatGolden compares the golden records written in version
+// with testdata/<name>_<version>.golden
+func testOutputFormatGolden(t *testing.T, name string, version FormatVersion, configure func(*Config)) {
+	cfg := defaultConfig
+	cfg.EnableColor = false
+	cfg.FormatVersion = version
+	configure(&cfg)
+
+	var buf bytes.Buffer
+	h := newPrettyHandler(&buf, prettyHandlerOptions{Config: cfg})
+	for _, r := range goldenRecords() {
+		if err := h.Handle(context.Background(), r); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	path := filepath.Join("testdata", name+"_"+version.String()+".golden")
+	if *updateGolden {
+		if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("%v (run go test -update to create it)", err)
+	}
+	if got := buf.String(); got != string(want) {
+		t.Errorf("output differs from %s; a layout change needs a new FormatVersion\ngot:\n%s\nwant:\n%s", path, got, want)
+	}
+}
+
+func TestJSONFormatLinesParse(t *testing.T) {
+	cfg := defaultConfig
+	cfg.Format = FormatJSON
+	cfg.EnableCaller = true
+
+	var buf bytes.Buffer
+	h := newPrettyHandler(&buf, prettyHandlerOptions{Config: cfg})
+	for _, r := range goldenRecords() {
+		r.PC = callerPC()
+		if err := h.Handle(context.Background(), r); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for line := range strings.Lines(buf.String()) {
+		var entry map[string]any
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("invalid JSON line %q: %v", line, err)
+		}
+		if src, _ := entry["source"].(string); !strings.HasPrefix(src, "outputformat_test.go:") {
+			t.Errorf("source = %q", src)
+		}
+		if strings.Contains(line, "\x1b[") {
+			t.Errorf("unexpected color in %q", line)
+		}
+	}
+}
+
+func TestLogfmtFormatRedaction(t *testing.T) {
+	var buf bytes.Buffer
+	SetConfig(Config{Output: &buf, Level: slog.LevelInfo, LevelSet: true, Format: FormatLogfmt, RedactPatterns: []string{`\d{4}-\d{4}`}})
+	defer SetConfig(Config{Output: io.Discard, Level: LevelTrace})
+
+	LogInfo("charged", "card", "1234-5678", "password", "hunter2", "user", "ana smith")
+
+	got := buf.String()
+	for _, want := range []string{" level=INFO msg=charged ", "card=***", "password=***", `user="ana smith"`} {
+		if !strings.Contains(got, want) {
+			t.Errorf("expected %q in %q", want, got)
+		}
+	}
+}
+
+func TestFormatValidate(t *testing.T) {
+	cfg := defaultConfig
+	cfg.Format = FormatConsole + 1
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected an error for an unknown Format")
+	}
+	cfg = defaultConfig
+	cfg.FormatVersion = CurrentFormatVersion + 1
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected an error for a future FormatVersion")
+	}
+}
+
+func callerPC() uintptr {
+	var pcs [1]uintptr
+	runtime.Callers(2, pcs[:])
+	return pcs[0]
+}
@@ -0,0 +1,165 @@
+package logger
+
+import (
+	"math/big"
+	"net/netip"
+	"regexp"
+	"strings"
+)
+
+// PIIDetector selects the kinds of personal data DetectPII scrubs from
+// string values. Detectors combine with |.
+type PIIDetector uint
+
+const (
+	PIIEmail      PIIDetector = 1 << iota // bob@example.com
+	PIIPhone                              // +421 905 123 456, (555) 123-4567
+	PIIIPAddress                          // IPv4 and IPv6 addresses
+	PIIIBAN                               // IBANs with a valid checksum
+	PIICreditCard                         // 13-19 digit card numbers passing the Luhn check
+	PIIJWT                                // JSON Web Tokens
+
+	// PIIAll enables every detector
+	PIIAll = PIIEmail | PIIPhone | PIIIPAddress | PIIIBAN | PIICreditCard | PIIJWT
+)
+
+// String returns the enabled detectors joined with "|", "" for none
+func (d PIIDetector) String() string {
+	var names []string
+	for _, n := range []struct {
+		kind PIIDetector
+		name string
+	}{{PIIEmail, "email"}, {PIIPhone, "phone"}, {PIIIPAddress, "ip"}, {PIIIBAN, "iban"}, {PIICreditCard, "credit_card"}, {PIIJWT, "jwt"}} {
+		if d&n.kind != 0 {
+			names = append(names, n.name)
+		}
+	}
+	return strings.Join(names, "|")
+}
+
+// piiDetector finds one kind of personal data. valid, when set, confirms a
+// regexp match (checksums, address parsing); hint is a cheap precondition.
+type piiDetector struct {
+	kind  PIIDetector
+	re    *regexp.Regexp
+	valid func(string) bool
+	hint  func(string) bool
+}
+
+// piiDetectors run in order, so a JWT is masked before its dots look like
+// anything else and a card number before its digits look like a phone
+var piiDetectors = []piiDetector{
+	{PIIJWT, regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{4,}\.[A-Za-z0-9_-]{4,}\.[A-Za-z0-9_-]*`), nil, hasSubstring("eyJ")},
+	{PIIEmail, regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9-]+(?:\.[A-Za-z0-9-]+)*\.[A-Za-z]{2,}`), nil, hasSubstring("@")},
+	{PIIIBAN, regexp.MustCompile(`\b[A-Z]{2}\d{2}(?: ?[A-Z0-9]{4}){2,7}(?: ?[A-Z0-9]{1,3})?\b`), validIBAN, hasDigit},
+	{PIICreditCard, regexp.MustCompile(`\b\d(?:[ -]?\d){12,18}\b`), validLuhn, hasDigit},
+	{PIIIPAddress, regexp.MustCompile(`(?i)(?:[0-9a-f]{0,4}:){2,7}[0-9a-f]{0,4}|\b(?:\d{1,3}\.){3}\d{1,3}\b`), validIP, hasDigit},
+	{PIIPhone, regexp.MustCompile(`\+\d{1,3}(?:[ .-]?\(?\d{1,4}\)?){2,5}\b|\(\d{3}\) ?\d{3}[ .-]\d{4}\b|\b\d{3}[ .-]\d{3}[ .-]\d{4}\b`), validPhone, hasDigit},
+}
+
+// scrubPII replaces the personal data of the enabled kinds found in s with
+// mask, and reports how many values it replaced. A match must stand on its
+// own: one running into letters or digits, like the "d::" of "std::map",
+// is not personal data.
+func scrubPII(s string, enabled PIIDetector, mask string) (string, int) {
+	n := 0
+	for _, d := range piiDetectors {
+		if enabled&d.kind == 0 || !d.hint(s) {
+			continue
+		}
+		var b strings.Builder
+		last := 0
+		for _, loc := range d.re.FindAllStringIndex(s, -1) {
+			m := s[loc[0]:loc[1]]
+			if loc[0] > 0 && isAlnum(s[loc[0]-1]) || loc[1] < len(s) && isAlnum(s[loc[1]]) ||
+				d.valid != nil && !d.valid(m) {
+				continue
+			}
+			b.WriteString(s[last:loc[0]])
+			b.WriteString(mask)
+			last = loc[1]
+			n++
+		}
+		if last > 0 {
+			b.WriteString(s[last:])
+			s = b.String()
+		}
+	}
+	return s, n
+}
+
+func isAlnum(c byte) bool {
+	return '0' <= c && c <= '9' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'
+}
+
+func hasSubstring(sub string) func(string) bool {
+	return func(s string) bool { return strings.Contains(s, sub) }
+}
+
+func hasDigit(s string) bool {
+	return strings.ContainsAny(s, "0123456789")
+}
+
+// digitsOf returns the digits of s
+func digitsOf(s string) []byte {
+	digits := make([]byte, 0, len(s))
+	for i := range len(s) {
+		if '0' <= s[i] && s[i] <= '9' {
+			digits = append(digits, s[i])
+		}
+	}
+	return digits
+}
+
+// validLuhn reports whether a card number passes the Luhn check
+func validLuhn(s string) bool {
+	digits := digitsOf(s)
+	if len(digits) < 13 || len(digits) > 19 {
+		return false
+	}
+	sum := 0
+	for i := range digits {
+		d := int(digits[len(digits)-1-i] - '0')
+		if i%2 == 1 {
+			if d *= 2; d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+	}
+	return sum%10 == 0
+}
+
+// validIBAN reports whether s has a valid ISO 13616 checksum
+func validIBAN(s string) bool {
+	s = strings.ReplaceAll(s, " ", "")
+	if len(s) < 15 || len(s) > 34 {
+		return false
+	}
+	// Move the country code and check digits to the end, letters to numbers
+	var b strings.Builder
+	for _, r := range s[4:] + s[:4] {
+		switch {
+		case '0' <= r && r <= '9':
+			b.WriteRune(r)
+		case 'A' <= r && r <= 'Z':
+			b.WriteString(big.NewInt(int64(r - 'A' + 10)).String())
+		default:
+			return false
+		}
+	}
+	n, ok := new(big.Int).SetString(b.String(), 10)
+	return ok && new(big.Int).Mod(n, big.NewInt(97)).Int64() == 1
+}
+
+// validIP reports whether s parses as an IP address
+func validIP(s string) bool {
+	addr, err := netip.ParseAddr(s)
+	return err == nil && !addr.IsUnspecified()
+}
+
+// validPhone reports whether s has a plausible number of digits
+func validPhone(s string) bool {
+	n := len(digitsOf(s))
+	return n >= 8 && n <= 15
+}
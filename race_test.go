@@ -0,0 +1,7 @@
+//go:build race
+
+package logger
+
+func init() {
+	raceEnabled = true
+}
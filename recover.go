@@ -0,0 +1,39 @@
+package logger
+
+import (
+	"fmt"
+	"runtime/debug"
+)
+
+// Recover logs a panic in the calling goroutine at Error, with its value,
+// stack and keyValues, and lets the goroutine return normally. Defer it
+// directly; recover has no effect in a nested call:
+//
+//	go func() {
+//		defer logger.Recover("worker", id)
+//		process(job)
+//	}()
+func Recover(keyValues ...any) {
+	if r := recover(); r != nil {
+		logPanic(r, keyValues)
+	}
+}
+
+// RecoverRepanic is Recover re-raising the panic once it is logged, for
+// goroutines that must still crash the process or reach an outer handler
+func RecoverRepanic(keyValues ...any) {
+	if r := recover(); r != nil {
+		logPanic(r, keyValues)
+		panic(r)
+	}
+}
+
+// logPanic logs a recovered panic value, attributed to the frame that
+// panicked
+func logPanic(r any, keyValues []any) {
+	kv := make([]any, 0, len(keyValues)+6)
+	kv = append(kv, "panic", fmt.Sprint(r), "panic_type", fmt.Sprintf("%T", r), "stack", string(debug.Stack()))
+	kv = append(kv, keyValues...)
+	// Skip logPanic, Recover and the runtime's panic frame
+	logModule(nil, 5, Error, "Panic recovered", kv...)
+}
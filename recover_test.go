@@ -0,0 +1,68 @@
+package logger
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestRecover(t *testing.T) {
+	sw := newSyncWriter()
+	SetConfig(Config{Output: sw, Level: LevelTrace, Format: FormatJSON, CompactJSON: true, EnableCaller: true})
+	defer SetConfig(Config{Output: io.Discard, Level: LevelTrace})
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		defer Recover("worker", 3)
+		panic("boom")
+	}()
+	<-done
+
+	var rec map[string]any
+	if err := json.Unmarshal([]byte(sw.String()), &rec); err != nil {
+		t.Fatalf("Expected one JSON record, got %q: %v", sw.String(), err)
+	}
+	if rec["msg"] != "Panic recovered" || rec["level"] != "ERROR" {
+		t.Errorf("Unexpected record %v", rec)
+	}
+	if rec["panic"] != "boom" || rec["panic_type"] != "string" || rec["worker"] != float64(3) {
+		t.Errorf("Expected the panic value and fields, got %v", rec)
+	}
+	if stack, _ := rec["stack"].(string); !strings.Contains(stack, "TestRecover") {
+		t.Errorf("Expected the panicking goroutine's stack, got %q", stack)
+	}
+	if src, _ := rec["source"].(string); !strings.Contains(src, "recover_test.go") {
+		t.Errorf("Expected the record to be attributed to the panic site, got %v", rec["source"])
+	}
+}
+
+func TestRecoverRepanic(t *testing.T) {
+	sw := newSyncWriter()
+	SetConfig(Config{Output: sw, Level: LevelTrace, CompactJSON: true})
+	defer SetConfig(Config{Output: io.Discard, Level: LevelTrace})
+
+	var repanicked any
+	func() {
+		defer func() { repanicked = recover() }()
+		defer RecoverRepanic()
+		panic("again")
+	}()
+
+	if repanicked != "again" {
+		t.Errorf("Expected the panic to be re-raised, got %v", repanicked)
+	}
+	if !strings.Contains(sw.String(), "Panic recovered") {
+		t.Errorf("Expected the panic to be logged first, got %q", sw.String())
+	}
+
+	// No panic, no record
+	n := len(sw.String())
+	func() {
+		defer Recover()
+	}()
+	if len(sw.String()) != n {
+		t.Errorf("Expected nothing logged without a panic, got %q", sw.String())
+	}
+}
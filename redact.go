@@ -0,0 +1,264 @@
+package logger
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+// RedactStrategy selects how the value of a redacted key is written
+type RedactStrategy int
+
+const (
+	// RedactReplace writes RedactMask in place of the value (default)
+	RedactReplace RedactStrategy = iota
+	// RedactHash writes "sha256:" and the first 16 hex digits of the
+	// value's SHA-256, or of its HMAC-SHA-256 under RedactHashKey, so equal
+	// values can still be joined across records
+	RedactHash
+	// RedactPartial masks all but the last 4 characters: "************1111"
+	RedactPartial
+	// RedactPreserveLength writes one * per character of the value
+	RedactPreserveLength
+	// RedactRemove leaves the attribute out of the record
+	RedactRemove
+)
+
+// String returns the strategy name
+func (s RedactStrategy) String() string {
+	switch s {
+	case RedactReplace:
+		return "replace"
+	case RedactHash:
+		return "hash"
+	case RedactPartial:
+		return "partial"
+	case RedactPreserveLength:
+		return "preserve_length"
+	case RedactRemove:
+		return "remove"
+	default:
+		return "unknown"
+	}
+}
+
+// redactPartialVisible is how many trailing characters RedactPartial keeps
+const redactPartialVisible = 4
+
+// redactField redacts the value of key: Config.Redactor first, then
+// RedactRules and RedactKeys. redacted is false when none of them applies
+// and keep is false when the attribute is to be removed. A lazy value is
+// only evaluated for a Redactor or a strategy that needs it.
+func redactField(key string, value any, cfg *Config) (out any, keep, redacted bool) {
+	if cfg.Redactor != nil {
+		value = resolveLazy(value)
+		if v, ok := cfg.Redactor(key, value); ok {
+			recordRedacted()
+			return v, true, true
+		}
+	}
+	if s, ok := redactRule(key, cfg); ok {
+		out, keep = redactValue(s, value, cfg)
+		return out, keep, true
+	}
+	return value, true, false
+}
+
+// redactComposite applies redactField to the fields of a struct, map or
+// slice value, which convertToSlogAttr turns into maps and slices, so a
+// logged struct's Password field is masked like a top-level password
+func redactComposite(a slog.Attr, cfg *Config) slog.Attr {
+	if a.Value.Kind() != slog.KindAny || cfg.Redactor == nil && len(cfg.RedactKeys) == 0 && len(cfg.RedactRules) == 0 {
+		return a
+	}
+	if v, changed := redactNested(a.Value.Any(), cfg, 0); changed {
+		a.Value = slog.AnyValue(v)
+	}
+	return a
+}
+
+// redactNested redacts the fields of the maps in v at any depth. The maps
+// and slices may be shared, by a Group or the caller, so a changed one is
+// copied instead of modified; an unchanged v costs no allocation.
+func redactNested(v any, cfg *Config, depth int) (any, bool) {
+	if depth > maxConvertDepth {
+		return v, false
+	}
+	switch v := v.(type) {
+	case map[string]any:
+		var out map[string]any
+		for k, e := range v {
+			nv, keep, redacted := redactField(k, e, cfg)
+			if !redacted {
+				var changed bool
+				if nv, changed = redactNested(e, cfg, depth+1); !changed {
+					continue
+				}
+			}
+			if out == nil {
+				out = maps.Clone(v)
+			}
+			if keep {
+				out[k] = nv
+			} else {
+				delete(out, k)
+			}
+		}
+		if out != nil {
+			return out, true
+		}
+	case []any:
+		var out []any
+		for i, e := range v {
+			if nv, changed := redactNested(e, cfg, depth+1); changed {
+				if out == nil {
+					out = slices.Clone(v)
+				}
+				out[i] = nv
+			}
+		}
+		if out != nil {
+			return out, true
+		}
+	}
+	return v, false
+}
+
+// redactRule returns the strategy for key, and false when key is not
+// redacted. RedactRules takes precedence over RedactKeys; within it, a
+// rule for the exact key over the glob and regexp rules, of which the one
+// picked is the first in sorted order.
+func redactRule(key string, cfg *Config) (RedactStrategy, bool) {
+	var best string
+	var strategy RedactStrategy
+	found := false
+	for k, s := range cfg.RedactRules {
+		if strings.EqualFold(k, key) {
+			return s, true
+		}
+		if (!found || k < best) && matchKey(k, key) {
+			best, strategy, found = k, s, true
+		}
+	}
+	if found {
+		return strategy, true
+	}
+	if isSensitiveKey(key, cfg.RedactKeys) {
+		return RedactReplace, true
+	}
+	return RedactReplace, false
+}
+
+// redactIgnoresValue reports whether redactField replaces or removes the
+// value of key without evaluating it
+func redactIgnoresValue(key string, cfg *Config) bool {
+	if cfg.Redactor != nil {
+		return false
+	}
+	s, ok := redactRule(key, cfg)
+	return ok && (s == RedactReplace || s == RedactRemove)
+}
+
+// redactValue returns value written with strategy s, and false when the
+// attribute is to be removed. RedactReplace and RedactRemove never look at
+// the value, so a lazy value behind them is not evaluated.
+func redactValue(s RedactStrategy, value any, cfg *Config) (any, bool) {
+	recordRedacted()
+	switch s {
+	case RedactRemove:
+		return nil, false
+	case RedactHash, RedactPartial, RedactPreserveLength:
+		text := redactText(resolveLazy(value))
+		switch s {
+		case RedactHash:
+			return hashRedacted(text, cfg.RedactHashKey), true
+		case RedactPartial:
+			return partialRedacted(text, cfg.RedactMask), true
+		default:
+			return strings.Repeat("*", utf8.RuneCountInString(text)), true
+		}
+	default:
+		return cfg.RedactMask, true
+	}
+}
+
+// redactText returns the text a strategy works on
+func redactText(v any) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case slog.Value:
+		return v.Resolve().String()
+	case []byte:
+		return string(v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// hashRedacted returns the RedactHash form of text
+func hashRedacted(text string, key []byte) string {
+	var sum []byte
+	if len(key) > 0 {
+		mac := hmac.New(sha256.New, key)
+		mac.Write([]byte(text))
+		sum = mac.Sum(nil)
+	} else {
+		h := sha256.Sum256([]byte(text))
+		sum = h[:]
+	}
+	return "sha256:" + hex.EncodeToString(sum[:8])
+}
+
+// partialRedacted masks text except its last characters. Values too short
+// to hide anything by showing 4 characters are masked entirely.
+func partialRedacted(text, mask string) string {
+	n := utf8.RuneCountInString(text)
+	if n <= 2*redactPartialVisible {
+		return mask
+	}
+	runes := []rune(text)
+	return strings.Repeat("*", n-redactPartialVisible) + string(runes[n-redactPartialVisible:])
+}
+
+// valueRedactor masks string values: a value matching RedactPatterns is
+// replaced whole, and the personal data DetectPII finds in it in place
+type valueRedactor struct {
+	patterns []*regexp.Regexp
+	pii      PIIDetector
+	mask     string
+}
+
+// newValueRedactor compiles the string redaction of cfg
+func newValueRedactor(cfg Config) valueRedactor {
+	return valueRedactor{patterns: compileRedactPatterns(cfg.RedactPatterns), pii: cfg.DetectPII, mask: cfg.RedactMask}
+}
+
+// enabled reports whether r can change a value
+func (r valueRedactor) enabled() bool {
+	return len(r.patterns) > 0 || r.pii != 0
+}
+
+// redact returns s with RedactPatterns and DetectPII applied
+func (r valueRedactor) redact(s string) string {
+	for _, re := range r.patterns {
+		if re.MatchString(s) {
+			recordRedacted()
+			return r.mask
+		}
+	}
+	if r.pii != 0 {
+		var n int
+		if s, n = scrubPII(s, r.pii, r.mask); n > 0 {
+			recordRedacted()
+		}
+	}
+	return s
+}
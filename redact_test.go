@@ -0,0 +1,189 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestRedactRules(t *testing.T) {
+	defer SetConfig(Config{Output: io.Discard, Level: LevelTrace})
+
+	var buf bytes.Buffer
+	SetConfig(NewConfig(WithOutput(&buf), WithLevel(LevelTrace), WithJSON(),
+		WithRedactRule("card_number", RedactPartial),
+		WithRedactRule("email", RedactHash),
+		WithRedactRule("pin", RedactPreserveLength),
+		WithRedactRule("ssn", RedactRemove),
+		WithRedactRule("Password", RedactHash), // Overrides RedactKeys
+	))
+	LogInfo("checkout",
+		"card_number", "4111111111111111",
+		"email", "bob@example.com",
+		"pin", "1234",
+		"ssn", "078-05-1120",
+		"password", "hunter2",
+		"token", "abc",
+		slog.Group("user", slog.String("ssn", "078-05-1120"), slog.String("email", "bob@example.com")),
+	)
+
+	var rec map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &rec); err != nil {
+		t.Fatalf("invalid JSON %q: %v", buf.String(), err)
+	}
+	if rec["card_number"] != "************1111" {
+		t.Errorf("expected the last 4 digits kept, got %v", rec["card_number"])
+	}
+	hash, _ := rec["email"].(string)
+	if !strings.HasPrefix(hash, "sha256:") || len(hash) != len("sha256:")+16 {
+		t.Errorf("expected a truncated SHA-256, got %q", hash)
+	}
+	if rec["pin"] != "****" {
+		t.Errorf("expected a length-preserving mask, got %v", rec["pin"])
+	}
+	if _, ok := rec["ssn"]; ok || strings.Contains(buf.String(), "078-05-1120") {
+		t.Errorf("expected ssn removed: %s", buf.String())
+	}
+	if p, _ := rec["password"].(string); !strings.HasPrefix(p, "sha256:") {
+		t.Errorf("expected RedactRules to override RedactKeys, got %v", rec["password"])
+	}
+	if rec["token"] != "***" {
+		t.Errorf("expected RedactKeys to keep the mask, got %v", rec["token"])
+	}
+	user, _ := rec["user"].(map[string]any)
+	if _, ok := user["ssn"]; ok || user["email"] != hash {
+		t.Errorf("expected rules applied in groups, with joinable hashes: %v", user)
+	}
+}
+
+func TestRedactStrategies(t *testing.T) {
+	cfg := &Config{RedactMask: "***"}
+	tests := []struct {
+		s     RedactStrategy
+		value any
+		want  any
+	}{
+		{RedactReplace, "secret", "***"},
+		{RedactPartial, "12345678", "***"}, // Too short to show 4 characters
+		{RedactPartial, "123456789", "*****6789"},
+		{RedactPreserveLength, "héllo", "*****"},
+		{RedactPreserveLength, 12345, "*****"},
+	}
+	for _, tt := range tests {
+		if got, keep := redactValue(tt.s, tt.value, cfg); !keep || got != tt.want {
+			t.Errorf("%s(%v) = %v, %v; want %v", tt.s, tt.value, got, keep, tt.want)
+		}
+	}
+
+	plain, _ := redactValue(RedactHash, "bob@example.com", cfg)
+	cfg.RedactHashKey = []byte("pepper")
+	keyed, _ := redactValue(RedactHash, "bob@example.com", cfg)
+	if plain == keyed {
+		t.Error("expected RedactHashKey to change the hash")
+	}
+
+	if err := SetConfigE(Config{Output: io.Discard, RedactRules: map[string]RedactStrategy{"x": 99}}); err == nil {
+		t.Error("expected an error for an unknown strategy")
+	}
+}
+
+func TestRedactor(t *testing.T) {
+	defer SetConfig(Config{Output: io.Discard, Level: LevelTrace})
+
+	// Slovak birth numbers: 6 digits, a slash and 3 or 4 digits
+	birthNumber := regexp.MustCompile(`^\d{6}/\d{3,4}$`)
+	var buf bytes.Buffer
+	SetConfig(NewConfig(WithOutput(&buf), WithLevel(LevelTrace), WithJSON(),
+		WithRedactRule("email", RedactRemove),
+		WithRedactor(func(key string, value any) (any, bool) {
+			if s, ok := value.(string); ok && birthNumber.MatchString(s) {
+				return "[birth number]", true
+			}
+			if key == "email" {
+				return "kept by the redactor", true
+			}
+			return nil, false
+		}),
+	))
+	LogInfo("patient",
+		"id", "900101/1234",
+		"note", Lazy(func() any { return "850202/123" }),
+		"email", "bob@example.com",
+		"password", "hunter2",
+		slog.Group("guardian", slog.String("id", "650303/4321")),
+	)
+
+	out := buf.String()
+	for _, want := range []string{`"id":"[birth number]"`, `"note":"[birth number]"`, `"email":"kept by the redactor"`, `"password":"***"`, `"guardian":{"id":"[birth number]"}`} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %s in %s", want, out)
+		}
+	}
+}
+
+func TestRedactNested(t *testing.T) {
+	defer SetConfig(Config{Output: io.Discard, Level: LevelTrace})
+
+	type credentials struct {
+		User     string `json:"user"`
+		Password string
+	}
+	type request struct {
+		Creds  credentials
+		Tokens []map[string]any `json:"tokens"`
+		SSN    string           `json:"ssn"`
+	}
+	var buf bytes.Buffer
+	SetConfig(NewConfig(WithOutput(&buf), WithLevel(LevelTrace), WithJSON(), WithRedactRule("ssn", RedactRemove)))
+
+	shared := map[string]any{"token": "t1", "kind": "bearer"}
+	req := request{Creds: credentials{User: "bob", Password: "hunter2"}, Tokens: []map[string]any{shared}, SSN: "078-05-1120"}
+	LogInfo("login", "req", req, "plain", shared, Group("g", "req", &req))
+
+	out := buf.String()
+	for _, secret := range []string{"hunter2", "t1", "078-05-1120", `"ssn"`} {
+		if strings.Contains(out, secret) {
+			t.Errorf("expected %s redacted: %s", secret, out)
+		}
+	}
+	if strings.Count(out, `"Password":"***"`) != 2 || strings.Count(out, `"token":"***"`) != 3 || !strings.Contains(out, `"user":"bob"`) {
+		t.Errorf("expected nested fields masked: %s", out)
+	}
+	if shared["token"] != "t1" {
+		t.Error("expected the caller's map left unchanged")
+	}
+}
+
+func BenchmarkRedactNested(b *testing.B) {
+	defer SetConfig(Config{Output: io.Discard, Level: LevelTrace})
+	SetConfig(Config{Output: io.Discard, Level: LevelInfo})
+
+	type address struct {
+		Street, City string
+	}
+	type user struct {
+		Name    string
+		Email   string
+		Tags    []string
+		Address address
+	}
+	clean := user{Name: "alice", Email: "alice@example.com", Tags: []string{"a", "b"}, Address: address{"Main", "Bratislava"}}
+	secret := map[string]any{"user": clean, "password": "hunter2", "session": map[string]any{"token": "abc"}}
+
+	b.Run("clean", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			LogInfo("user", "user", clean)
+		}
+	})
+	b.Run("redacted", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			LogInfo("user", "data", secret)
+		}
+	})
+}
@@ -0,0 +1,93 @@
+package logger
+
+import (
+	"os"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// DefaultRuntimeStatsInterval is the reporting interval used by
+// StartRuntimeStats when none is given
+const DefaultRuntimeStatsInterval = time.Minute
+
+// StartRuntimeStats logs a "Runtime stats" record at level every interval
+// (default: one minute) until the returned function is called:
+//
+//	goroutines   number of goroutines
+//	heap_alloc   bytes of live heap objects
+//	heap_sys     bytes of heap memory obtained from the OS
+//	alloc_bytes  bytes allocated since the previous report
+//	gc_runs      garbage collections since the previous report
+//	gc_pause     total GC pause since the previous report
+//	open_fds     open file descriptors (Linux and macOS only)
+//
+//	stop := logger.StartRuntimeStats(30*time.Second, logger.Debug)
+//	defer stop()
+func StartRuntimeStats(interval time.Duration, level LogLevel) (stop func()) {
+	if interval <= 0 {
+		interval = DefaultRuntimeStatsInterval
+	}
+
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Go(func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		var prev runtime.MemStats
+		runtime.ReadMemStats(&prev)
+		for {
+			select {
+			case <-ticker.C:
+				var ms runtime.MemStats
+				runtime.ReadMemStats(&ms)
+				logInternal(level, "Runtime stats", runtimeStats(&prev, &ms)...)
+				prev = ms
+			case <-done:
+				return
+			}
+		}
+	})
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			close(done)
+			wg.Wait()
+		})
+	}
+}
+
+// runtimeStats returns the fields of a runtime stats record, with counters
+// relative to prev
+func runtimeStats(prev, ms *runtime.MemStats) []any {
+	// PauseNs is a ring of the most recent 256 pauses
+	var pause time.Duration
+	for n := max(prev.NumGC, ms.NumGC-min(ms.NumGC, 256)); n < ms.NumGC; n++ {
+		pause += time.Duration(ms.PauseNs[n%256])
+	}
+
+	kv := []any{
+		"goroutines", runtime.NumGoroutine(),
+		"heap_alloc", ms.HeapAlloc,
+		"heap_sys", ms.HeapSys,
+		"alloc_bytes", ms.TotalAlloc - prev.TotalAlloc,
+		"gc_runs", ms.NumGC - prev.NumGC,
+		"gc_pause", pause,
+	}
+	if fds, ok := openFDs(); ok {
+		kv = append(kv, "open_fds", fds)
+	}
+	return kv
+}
+
+// openFDs counts the open file descriptors where the OS lists them
+func openFDs() (int, bool) {
+	for _, dir := range []string{"/proc/self/fd", "/dev/fd"} {
+		if entries, err := os.ReadDir(dir); err == nil {
+			return len(entries) - 1, true // The directory being read
+		}
+	}
+	return 0, false
+}
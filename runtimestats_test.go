@@ -0,0 +1,66 @@
+package logger
+
+import (
+	"encoding/json"
+	"io"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStartRuntimeStats(t *testing.T) {
+	sw := newSyncWriter()
+	SetConfig(Config{Output: sw, Level: LevelTrace, Format: FormatJSON, CompactJSON: true})
+	defer SetConfig(Config{Output: io.Discard, Level: LevelTrace})
+
+	stop := StartRuntimeStats(5*time.Millisecond, Debug)
+	runtime.GC()
+	deadline := time.Now().Add(2 * time.Second)
+	for !strings.Contains(sw.String(), "Runtime stats") && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	stop()
+	stop()
+
+	line, _, _ := strings.Cut(sw.String(), "\n")
+	var rec map[string]any
+	if err := json.Unmarshal([]byte(line), &rec); err != nil {
+		t.Fatalf("Expected a runtime stats record, got %q: %v", sw.String(), err)
+	}
+	if rec["level"] != "DEBUG" {
+		t.Errorf("Expected the configured level, got %v", rec["level"])
+	}
+	for _, key := range []string{"goroutines", "heap_alloc", "heap_sys", "alloc_bytes", "gc_runs", "gc_pause"} {
+		if _, ok := rec[key]; !ok {
+			t.Errorf("Expected %s in %v", key, rec)
+		}
+	}
+
+	// Nothing is logged after stop returns
+	n := len(sw.String())
+	time.Sleep(20 * time.Millisecond)
+	if len(sw.String()) != n {
+		t.Error("Expected no records after stop")
+	}
+}
+
+func TestRuntimeStatsGCPause(t *testing.T) {
+	var prev, ms runtime.MemStats
+	runtime.ReadMemStats(&prev)
+	runtime.GC()
+	runtime.GC()
+	runtime.ReadMemStats(&ms)
+
+	kv := runtimeStats(&prev, &ms)
+	fields := make(map[string]any)
+	for i := 0; i+1 < len(kv); i += 2 {
+		fields[kv[i].(string)] = kv[i+1]
+	}
+	if runs := fields["gc_runs"].(uint32); runs < 2 {
+		t.Errorf("Expected at least 2 GC runs, got %d", runs)
+	}
+	if pause := fields["gc_pause"].(time.Duration); pause <= 0 {
+		t.Errorf("Expected a positive GC pause, got %v", pause)
+	}
+}
@@ -0,0 +1,88 @@
+package logger
+
+import (
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"sync/atomic"
+)
+
+// SampleMode selects how SampleRate decides which records to keep
+type SampleMode int
+
+const (
+	// SampleByMessage keeps or drops each message text as a whole: a
+	// sampled-out message is always dropped (default)
+	SampleByMessage SampleMode = iota
+	// SampleRandom keeps each record independently with probability SampleRate
+	SampleRandom
+	// SampleCounter keeps exactly SampleRate of the records, evenly spaced
+	// (every 10th at 0.1)
+	SampleCounter
+	// SampleByKey hashes the value of the SampleKey attribute, so all the
+	// records of one request (or other key) are kept or dropped together.
+	// Records without the key are sampled like SampleRandom.
+	SampleByKey
+)
+
+// String returns the mode name
+func (m SampleMode) String() string {
+	switch m {
+	case SampleByMessage:
+		return "message"
+	case SampleRandom:
+		return "random"
+	case SampleCounter:
+		return "counter"
+	case SampleByKey:
+		return "key"
+	default:
+		return "unknown"
+	}
+}
+
+// sampleCounter numbers the records seen by SampleCounter
+var sampleCounter atomic.Uint64
+
+// sampleAdmits applies cfg's sampling to a record
+func sampleAdmits(cfg Config, message string, keyValues []any) bool {
+	rate := cfg.SampleRate
+	if rate >= 1.0 {
+		return true
+	}
+	if rate <= 0.0 {
+		return false
+	}
+
+	switch cfg.SampleMode {
+	case SampleRandom:
+		return rand.Float64() < rate
+	case SampleCounter:
+		n := sampleCounter.Add(1)
+		return uint64(float64(n)*rate) != uint64(float64(n-1)*rate)
+	case SampleByKey:
+		if v, ok := sampleKeyValue(cfg.SampleKey, keyValues); ok {
+			return shouldSample(v, rate, cfg.SampleSeed)
+		}
+		return rand.Float64() < rate
+	default:
+		return shouldSample(message, rate, cfg.SampleSeed)
+	}
+}
+
+// sampleKeyValue returns the value of key among keyValues, as a string
+func sampleKeyValue(key string, keyValues []any) (string, bool) {
+	for i := 0; i < len(keyValues); i++ {
+		if a, ok := keyValues[i].(slog.Attr); ok {
+			if a.Key == key {
+				return a.Value.String(), true
+			}
+			continue
+		}
+		if i+1 < len(keyValues) && attrKey(keyValues[i]) == key {
+			return fmt.Sprint(keyValues[i+1]), true
+		}
+		i++
+	}
+	return "", false
+}
@@ -0,0 +1,68 @@
+package logger
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestSampleModes(t *testing.T) {
+	defer SetConfig(Config{Output: io.Discard, Level: LevelTrace})
+
+	count := func(mode SampleMode, log func(i int)) int {
+		sw := newSyncWriter()
+		SetConfig(Config{Output: sw, Level: LevelTrace, CompactJSON: true, Format: FormatJSON,
+			SampleRate: 0.25, SampleMode: mode, SampleKey: "request_id"})
+		for i := range 400 {
+			log(i)
+		}
+		return strings.Count(sw.String(), "\n")
+	}
+
+	same := func(int) { LogInfo("Same message") }
+	if n := count(SampleByMessage, same); n != 0 && n != 400 {
+		t.Errorf("SampleByMessage: expected all or nothing for one message, got %d", n)
+	}
+	if n := count(SampleCounter, same); n != 100 {
+		t.Errorf("SampleCounter: expected exactly 100 of 400, got %d", n)
+	}
+	if n := count(SampleRandom, same); n < 40 || n > 160 {
+		t.Errorf("SampleRandom: expected about 100 of 400, got %d", n)
+	}
+
+	// Four records per request: each request is kept or dropped whole
+	sw := newSyncWriter()
+	SetConfig(Config{Output: sw, Level: LevelTrace, CompactJSON: true, Format: FormatJSON, SampleRate: 0.25, SampleMode: SampleByKey, SampleKey: "request_id"})
+	for r := range 100 {
+		l := With("request_id", fmt.Sprintf("req-%d", r))
+		for i := range 4 {
+			l.LogInfo("step", "i", i)
+		}
+	}
+	kept := 0
+	for r := range 100 {
+		switch n := strings.Count(sw.String(), fmt.Sprintf(`"request_id":"req-%d"`, r)); n {
+		case 0:
+		case 4:
+			kept++
+		default:
+			t.Fatalf("SampleByKey: request %d kept %d of 4 records", r, n)
+		}
+	}
+	if kept < 10 || kept > 40 {
+		t.Errorf("SampleByKey: expected about 25 of 100 requests, got %d", kept)
+	}
+}
+
+func TestSampleModeValidation(t *testing.T) {
+	cfg := GetConfig()
+	cfg.SampleMode = SampleByKey
+	if err := cfg.Validate(); err == nil {
+		t.Error("Expected SampleByKey without SampleKey to be rejected")
+	}
+	cfg.SampleMode = SampleMode(9)
+	if err := cfg.Validate(); err == nil {
+		t.Error("Expected an unknown SampleMode to be rejected")
+	}
+}
@@ -3,31 +3,69 @@ package logger
 import (
 	"context"
 	"errors"
+	"fmt"
+	"io"
+	"log/slog"
 )
 
+// ShutdownError is returned by Shutdown when ctx ends before the async
+// queue is drained
+type ShutdownError struct {
+	Dropped int   // Queued entries abandoned at the deadline
+	Err     error // The context's error
+}
+
+func (e *ShutdownError) Error() string {
+	return fmt.Sprintf("logger: shutdown: %d queued entries dropped: %v", e.Dropped, e.Err)
+}
+
+func (e *ShutdownError) Unwrap() error {
+	return e.Err
+}
+
+// flusher is implemented by outputs and handlers that buffer records, such
+// as sink.LokiSink
+type flusher interface {
+	Flush() error
+}
+
 // Shutdown gracefully shuts down the logger, flushing all buffers and closing resources.
 // It respects the context deadline for timeout control.
+//
+// Async mode stops accepting entries (later calls log synchronously) and the
+// queue is drained. If ctx ends first, the entries still queued are discarded
+// and reported as a *ShutdownError, which unwraps to ctx.Err(); entries in an
+// OverflowSpill file are kept for the next run. Outputs, AdditionalHandlers
+// and the Handler with a Flush() error method are then flushed.
 func Shutdown(ctx context.Context) error {
 	var errs []error
 
 	// Stop async logger and drain buffers
-	done := make(chan struct{})
-	go func() {
-		stopAsyncLogger()
-		close(done)
-	}()
+	if dropped, err := stopAsyncLoggerContext(ctx); err != nil {
+		errs = append(errs, &ShutdownError{Dropped: dropped, Err: err})
+	}
 
-	select {
-	case <-done:
-	case <-ctx.Done():
-		errs = append(errs, ctx.Err())
+	// Detach the dedup manager, burst sampler and audit logger before
+	// closing them
+	configWriteMu.Lock()
+	st := *loadState()
+	dedup, burst, auditLogger := st.dedup, st.burst, st.audit
+	st.dedup, st.burst, st.audit = nil, nil, nil
+	current.Store(&st)
+	configWriteMu.Unlock()
+
+	// Flush dedup and burst sampling summaries
+	if dedup != nil {
+		dedup.Flush()
+		dedup.Stop()
+	}
+	if burst != nil {
+		burst.Flush()
+		burst.Stop()
 	}
 
-	// Flush dedup summaries
-	if dedupMgr != nil {
-		dedupMgr.Flush()
-		dedupMgr.Stop()
-		dedupMgr = nil
+	if err := flushOutputs(ctx, st.config); err != nil {
+		errs = append(errs, err)
 	}
 
 	// Close audit logger with context deadline awareness
@@ -45,8 +83,43 @@ func Shutdown(ctx context.Context) error {
 		case <-ctx.Done():
 			errs = append(errs, ctx.Err())
 		}
-		auditLogger = nil
 	}
 
 	return errors.Join(errs...)
 }
+
+// flushOutputs flushes every writer and handler of cfg that buffers records
+func flushOutputs(ctx context.Context, cfg Config) error {
+	var targets []flusher
+	for _, w := range []io.Writer{cfg.Output, cfg.FallbackOutput, cfg.AuditOutput} {
+		if f, ok := w.(flusher); ok {
+			targets = append(targets, f)
+		}
+	}
+	for _, h := range append([]slog.Handler{cfg.Handler}, cfg.AdditionalHandlers...) {
+		if f, ok := h.(flusher); ok {
+			targets = append(targets, f)
+		}
+	}
+	if len(targets) == 0 {
+		return nil
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		var errs []error
+		for _, f := range targets {
+			if err := f.Flush(); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		done <- errors.Join(errs...)
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
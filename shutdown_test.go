@@ -0,0 +1,130 @@
+package logger
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestShutdownDrainsAsync(t *testing.T) {
+	sw := newSyncWriter()
+	SetConfig(Config{Output: sw, Level: LevelTrace, AsyncMode: true, FlushTimeout: time.Hour})
+	defer SetConfig(Config{Output: sw, Level: LevelTrace})
+
+	for range 100 {
+		LogInfo("queued")
+	}
+	if err := Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown() = %v", err)
+	}
+	if n := strings.Count(sw.String(), "queued"); n != 100 {
+		t.Errorf("Expected 100 entries after Shutdown, got %d", n)
+	}
+
+	// Logging keeps working, synchronously
+	LogInfo("after")
+	if !strings.Contains(sw.String(), "after") {
+		t.Error("Expected entries logged after Shutdown to be written")
+	}
+}
+
+func TestShutdownDeadlineReportsDropped(t *testing.T) {
+	bw := newBlockingWriter()
+	SetConfig(Config{Output: bw, Level: LevelTrace, AsyncMode: true, FlushTimeout: time.Hour, EnableMetrics: true})
+	defer SetConfig(Config{Output: bw, Level: LevelTrace})
+
+	// Park the worker inside Write so the queue cannot drain
+	LogInfo("first")
+	<-bw.started
+	for range 5 {
+		LogInfo("queued")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	err := Shutdown(ctx)
+
+	var se *ShutdownError
+	if !errors.As(err, &se) {
+		t.Fatalf("Expected a *ShutdownError, got %v", err)
+	}
+	if se.Dropped != 5 {
+		t.Errorf("Expected 5 dropped entries, got %d", se.Dropped)
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected the error to wrap context.DeadlineExceeded, got %v", err)
+	}
+
+	close(bw.release)
+	deadline := time.Now().Add(time.Second)
+	for GetMetrics()["dropped_logs"].(int64) < 5 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if n := GetMetrics()["dropped_logs"].(int64); n != 5 {
+		t.Errorf("Expected 5 entries counted as dropped, got %d", n)
+	}
+	if strings.Contains(bw.String(), "queued") {
+		t.Error("Expected abandoned entries not to be written")
+	}
+}
+
+func TestShutdownDeadlineWithBlockedSender(t *testing.T) {
+	bw := newBlockingWriter()
+	SetConfig(Config{Output: bw, Level: LevelTrace, AsyncMode: true, BufferSize: 1,
+		FlushTimeout: time.Hour, AsyncOverflowPolicy: OverflowBlock})
+	defer SetConfig(Config{Output: bw, Level: LevelTrace})
+
+	// Park the worker, fill the queue and block a sender on it
+	LogInfo("first")
+	<-bw.started
+	LogInfo("queued")
+	sent := make(chan struct{})
+	go func() {
+		defer close(sent)
+		LogInfo("blocked")
+	}()
+	time.Sleep(10 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	returned := make(chan error, 1)
+	go func() { returned <- Shutdown(ctx) }()
+
+	select {
+	case err := <-returned:
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("Expected the error to wrap context.DeadlineExceeded, got %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Shutdown() did not return after its deadline")
+	}
+	close(bw.release)
+	<-sent
+}
+
+// flushWriter counts Flush calls
+type flushWriter struct {
+	*syncWriter
+	flushes atomic.Int64
+}
+
+func (w *flushWriter) Flush() error {
+	w.flushes.Add(1)
+	return nil
+}
+
+func TestShutdownFlushesOutputs(t *testing.T) {
+	fw := &flushWriter{syncWriter: newSyncWriter()}
+	SetConfig(Config{Output: fw, Level: LevelTrace})
+	defer SetConfig(Config{Output: fw, Level: LevelTrace})
+
+	if err := Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown() = %v", err)
+	}
+	if n := fw.flushes.Load(); n != 1 {
+		t.Errorf("Expected Output to be flushed once, got %d", n)
+	}
+}
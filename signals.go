@@ -0,0 +1,71 @@
+package logger
+
+import (
+	"io"
+	"os"
+	"os/signal"
+	"sync"
+)
+
+// Reopener is implemented by outputs that can reopen their underlying file,
+// such as RotatingWriter.
+type Reopener interface {
+	Reopen() error
+}
+
+// HandleSignals reopens the configured Output and AuditOutput whenever the
+// process receives one of sigs (default: SIGHUP), for deployments where
+// logrotate moves or truncates the log file. Outputs that do not implement
+// Reopener are left untouched. Without sigs, it does nothing on platforms
+// that have no SIGHUP. Call the returned function to stop handling
+// signals.
+//
+//	stop := logger.HandleSignals()
+//	defer stop()
+func HandleSignals(sigs ...os.Signal) (stop func()) {
+	if len(sigs) == 0 {
+		sigs = reopenSignals
+	}
+	if len(sigs) == 0 {
+		return func() {}
+	}
+
+	ch := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(ch, sigs...)
+
+	go func() {
+		for {
+			select {
+			case sig := <-ch:
+				reopenOutput(sig)
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			signal.Stop(ch)
+			close(done)
+		})
+	}
+}
+
+// reopenOutput reopens the current Output and AuditOutput if they support it
+func reopenOutput(sig os.Signal) {
+	cfg := loadConfig()
+	for _, out := range []io.Writer{cfg.Output, cfg.AuditOutput} {
+		r, ok := out.(Reopener)
+		if !ok {
+			continue
+		}
+		if err := r.Reopen(); err != nil {
+			LogError("Failed to reopen log output", "__error", err, "signal", sig.String())
+			continue
+		}
+		LogDebug("Reopened log output", "signal", sig.String())
+	}
+}
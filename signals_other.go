@@ -0,0 +1,8 @@
+//go:build !unix
+
+package logger
+
+import "os"
+
+// reopenSignals is empty: there is no SIGHUP to listen for by default
+var reopenSignals []os.Signal
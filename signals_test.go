@@ -0,0 +1,73 @@
+package logger
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRotatingWriterReopen(t *testing.T) {
+	tmpDir := t.TempDir()
+	logFile := filepath.Join(tmpDir, "reopen.log")
+
+	writer, err := NewRotatingWriter(logFile, &RotationConfig{})
+	if err != nil {
+		t.Fatalf("Failed to create rotating writer: %v", err)
+	}
+	defer func() { _ = writer.Close() }()
+
+	_, _ = writer.Write([]byte("before\n"))
+
+	// Simulate logrotate moving the file away
+	moved := logFile + ".1"
+	if err := os.Rename(logFile, moved); err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.Reopen(); err != nil {
+		t.Fatalf("Reopen failed: %v", err)
+	}
+	_, _ = writer.Write([]byte("after\n"))
+
+	movedData, _ := os.ReadFile(moved)
+	newData, _ := os.ReadFile(logFile)
+	if string(movedData) != "before\n" {
+		t.Errorf("Expected moved file to keep old content, got %q", movedData)
+	}
+	if string(newData) != "after\n" {
+		t.Errorf("Expected new writes in reopened file, got %q", newData)
+	}
+}
+
+// Test that a failed Reopen keeps the size and age of the file in use
+func TestRotatingWriterReopenFailure(t *testing.T) {
+	tmpDir := t.TempDir()
+	logFile := filepath.Join(tmpDir, "reopen.log")
+
+	writer, err := NewRotatingWriter(logFile, &RotationConfig{MaxSize: 1 << 20})
+	if err != nil {
+		t.Fatalf("Failed to create rotating writer: %v", err)
+	}
+	defer func() { _ = writer.Close() }()
+
+	_, _ = writer.Write([]byte("before\n"))
+	openTime := writer.openTime
+
+	// A directory in place of the file makes the open fail
+	if err := os.Rename(logFile, logFile+".1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(logFile, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.Reopen(); err == nil {
+		t.Fatal("Expected Reopen to fail")
+	}
+	if writer.size != int64(len("before\n")) || !writer.openTime.Equal(openTime) {
+		t.Errorf("Expected size 7 and the original open time, got %d and %v", writer.size, writer.openTime)
+	}
+
+	_, _ = writer.Write([]byte("after\n"))
+	if data, _ := os.ReadFile(logFile + ".1"); string(data) != "before\nafter\n" {
+		t.Errorf("Expected writes to continue in the previous file, got %q", data)
+	}
+}
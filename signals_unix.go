@@ -0,0 +1,11 @@
+//go:build unix
+
+package logger
+
+import (
+	"os"
+	"syscall"
+)
+
+// reopenSignals are the signals HandleSignals listens for by default
+var reopenSignals = []os.Signal{syscall.SIGHUP}
@@ -0,0 +1,54 @@
+//go:build unix
+
+package logger
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestHandleSignalsReopensOutput(t *testing.T) {
+	tmpDir := t.TempDir()
+	logFile := filepath.Join(tmpDir, "signal.log")
+
+	writer, err := NewRotatingWriter(logFile, &RotationConfig{})
+	if err != nil {
+		t.Fatalf("Failed to create rotating writer: %v", err)
+	}
+	defer func() { _ = writer.Close() }()
+
+	SetConfig(Config{Output: writer, Level: LevelInfo, LevelSet: true, EnableColor: false})
+	defer SetConfig(Config{Output: os.Stdout, Level: LevelTrace})
+
+	stop := HandleSignals()
+	defer stop()
+
+	if err := os.Rename(logFile, logFile+".1"); err != nil {
+		t.Fatal(err)
+	}
+	p, _ := os.FindProcess(os.Getpid())
+	if err := p.Signal(syscall.SIGHUP); err != nil {
+		t.Skipf("cannot deliver SIGHUP on this platform: %v", err)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if _, err := os.Stat(logFile); err == nil {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	LogInfo("after signal")
+	data, err := os.ReadFile(logFile)
+	if err != nil {
+		t.Fatalf("Expected log file to be recreated after SIGHUP: %v", err)
+	}
+	if !strings.Contains(string(data), "after signal") {
+		t.Errorf("Expected new entries in reopened file, got %q", data)
+	}
+}
@@ -0,0 +1,351 @@
+package sink
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"text/template"
+	"time"
+
+	"github.com/jozefvalachovic/logger/v4"
+)
+
+// Default payload templates. SlackAlertTemplate is used for hooks.slack.com
+// URLs, JSONAlertTemplate for everything else.
+const (
+	SlackAlertTemplate = `{"text": {{ json .Text }}}`
+	JSONAlertTemplate  = `{"level": {{ json .Level }}, "message": {{ json .Message }}, "time": {{ json .Time }}, "attrs": {{ json .Attrs }}, "suppressed": {{ .Suppressed }}}`
+)
+
+// Alert is the data passed to an AlertSink payload template
+type Alert struct {
+	Level      string         // Upper-case level name (ERROR, AUDIT, ...)
+	Message    string         // Log message
+	Time       time.Time      // Record time
+	Attrs      map[string]any // Flattened record attributes
+	Suppressed int64          // Alerts dropped by the rate limit since the last delivered one
+	Text       string         // Ready-made one-line summary, e.g. for Slack's "text"
+}
+
+// AlertSinkConfig configures an alert sink
+type AlertSinkConfig struct {
+	URL          string            // Slack incoming webhook or any HTTP endpoint
+	Headers      map[string]string // Extra request headers (auth, ...)
+	Template     string            // text/template for the request body; {{ json .X }} JSON-encodes a value
+	ContentType  string            // Request content type (default: application/json)
+	Level        slog.Leveler      // Minimum level to alert on (default: logger.LevelError)
+	RateLimit    int               // Alerts allowed per RateWindow (default: 5)
+	RateWindow   time.Duration     // Rate limit window (default: 1m)
+	BufferSize   int               // Alerts queued before new ones are dropped (default: 100)
+	Timeout      time.Duration     // Per-request timeout (default: 10s)
+	CloseTimeout time.Duration     // How long Close waits to deliver queued alerts (default: 5s)
+}
+
+// AlertSinkStats is a snapshot of AlertSink counters
+type AlertSinkStats struct {
+	Sent       int64 // Alerts delivered
+	Suppressed int64 // Alerts skipped by the rate limit
+	Dropped    int64 // Alerts lost to a full queue or a failed request
+}
+
+// AlertSink is a slog.Handler that posts high-severity records (Error and
+// Audit by default) to a Slack or generic webhook, rate limited so an
+// error storm produces a handful of messages instead of thousands.
+type AlertSink struct {
+	core   *alertCore
+	attrs  []slog.Attr
+	prefix string
+}
+
+// alertCore is the state shared by an AlertSink and its WithAttrs/WithGroup clones
+type alertCore struct {
+	cfg    AlertSinkConfig
+	tmpl   *template.Template
+	client *http.Client
+
+	mu          sync.Mutex // Guards the rate limit window and closed
+	windowStart time.Time
+	windowCount int
+	pendingSupp int64
+	closed      bool
+
+	queue chan []byte
+	stop  chan struct{}
+	done  chan struct{}
+
+	sent       atomic.Int64
+	suppressed atomic.Int64
+	dropped    atomic.Int64
+}
+
+// NewAlertSink creates an alert sink from cfg and starts its sender
+func NewAlertSink(cfg AlertSinkConfig) (*AlertSink, error) {
+	u, err := url.Parse(cfg.URL)
+	if err != nil {
+		return nil, fmt.Errorf("sink: invalid alert URL: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("sink: alert URL must use http or https scheme, got %q", u.Scheme)
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("sink: alert URL must include a host")
+	}
+
+	if cfg.Template == "" {
+		cfg.Template = JSONAlertTemplate
+		if u.Host == "hooks.slack.com" {
+			cfg.Template = SlackAlertTemplate
+		}
+	}
+	tmpl, err := template.New("alert").Funcs(template.FuncMap{"json": templateJSON}).Parse(cfg.Template)
+	if err != nil {
+		return nil, fmt.Errorf("sink: invalid alert template: %w", err)
+	}
+
+	if cfg.ContentType == "" {
+		cfg.ContentType = "application/json"
+	}
+	if cfg.Level == nil {
+		cfg.Level = logger.LevelError
+	}
+	if cfg.RateLimit <= 0 {
+		cfg.RateLimit = 5
+	}
+	if cfg.RateWindow <= 0 {
+		cfg.RateWindow = time.Minute
+	}
+	if cfg.BufferSize <= 0 {
+		cfg.BufferSize = 100
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 10 * time.Second
+	}
+	if cfg.CloseTimeout <= 0 {
+		cfg.CloseTimeout = 5 * time.Second
+	}
+
+	core := &alertCore{
+		cfg:    cfg,
+		tmpl:   tmpl,
+		client: &http.Client{Timeout: cfg.Timeout},
+		queue:  make(chan []byte, cfg.BufferSize),
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	go core.run()
+
+	return &AlertSink{core: core}, nil
+}
+
+// Enabled reports whether level meets the configured minimum
+func (s *AlertSink) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= s.core.cfg.Level.Level()
+}
+
+// Handle renders the record through the template and queues it, unless the
+// rate limit for the current window is used up
+func (s *AlertSink) Handle(_ context.Context, r slog.Record) error {
+	c := s.core
+
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return fmt.Errorf("sink: alert sink is closed")
+	}
+	now := time.Now()
+	if now.Sub(c.windowStart) >= c.cfg.RateWindow {
+		c.windowStart = now
+		c.windowCount = 0
+	}
+	if c.windowCount >= c.cfg.RateLimit {
+		c.pendingSupp++
+		c.mu.Unlock()
+		c.suppressed.Add(1)
+		return nil
+	}
+	c.windowCount++
+	suppressed := c.pendingSupp
+	c.pendingSupp = 0
+	c.mu.Unlock()
+
+	attrs := make(map[string]any, len(s.attrs)+r.NumAttrs())
+	for _, a := range s.attrs {
+		flattenAttr(attrs, "", a)
+	}
+	r.Attrs(func(a slog.Attr) bool {
+		flattenAttr(attrs, s.prefix, a)
+		return true
+	})
+
+	alert := Alert{
+		Level:      logger.LevelString(r.Level),
+		Message:    r.Message,
+		Time:       r.Time,
+		Attrs:      attrs,
+		Suppressed: suppressed,
+	}
+	alert.Text = alertText(alert)
+
+	var buf bytes.Buffer
+	if err := c.tmpl.Execute(&buf, alert); err != nil {
+		c.dropped.Add(1)
+		return fmt.Errorf("sink: failed to render alert: %w", err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return fmt.Errorf("sink: alert sink is closed")
+	}
+	select {
+	case c.queue <- buf.Bytes():
+	default:
+		c.dropped.Add(1)
+	}
+	return nil
+}
+
+// WithAttrs returns a sink that adds attrs to every alert
+func (s *AlertSink) WithAttrs(attrs []slog.Attr) slog.Handler {
+	clone := *s
+	clone.attrs = slices.Clip(s.attrs)
+	for _, a := range attrs {
+		a.Key = s.prefix + a.Key
+		clone.attrs = append(clone.attrs, a)
+	}
+	return &clone
+}
+
+// WithGroup returns a sink that qualifies subsequent attribute keys with name
+func (s *AlertSink) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return s
+	}
+	clone := *s
+	clone.prefix = s.prefix + name + "."
+	return &clone
+}
+
+// Stats returns the current delivery counters
+func (s *AlertSink) Stats() AlertSinkStats {
+	return AlertSinkStats{
+		Sent:       s.core.sent.Load(),
+		Suppressed: s.core.suppressed.Load(),
+		Dropped:    s.core.dropped.Load(),
+	}
+}
+
+// Close stops accepting alerts and waits up to CloseTimeout for queued
+// alerts to be delivered
+func (s *AlertSink) Close() error {
+	c := s.core
+
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return nil
+	}
+	c.closed = true
+	close(c.queue)
+	c.mu.Unlock()
+
+	timer := time.NewTimer(c.cfg.CloseTimeout)
+	defer timer.Stop()
+
+	select {
+	case <-c.done:
+		return nil
+	case <-timer.C:
+		close(c.stop)
+		<-c.done
+		return fmt.Errorf("sink: alert sink close timed out, %d alerts dropped", c.dropped.Load())
+	}
+}
+
+// run delivers queued payloads one at a time
+func (c *alertCore) run() {
+	defer close(c.done)
+
+	for payload := range c.queue {
+		select {
+		case <-c.stop:
+			c.dropped.Add(1 + int64(len(c.queue)))
+			for range c.queue {
+			}
+			return
+		default:
+		}
+
+		if err := c.send(payload); err != nil {
+			c.dropped.Add(1)
+			continue
+		}
+		c.sent.Add(1)
+	}
+}
+
+// send posts a single payload
+func (c *alertCore) send(payload []byte) error {
+	ctx, cancel := context.WithTimeout(context.Background(), c.cfg.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.cfg.URL, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("sink: failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", c.cfg.ContentType)
+	for k, v := range c.cfg.Headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("sink: request failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("sink: alert webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// alertText formats a one-line summary: "[ERROR] message key=value ..."
+func alertText(a Alert) string {
+	keys := make([]string, 0, len(a.Attrs))
+	for k := range a.Attrs {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+
+	var b strings.Builder
+	b.WriteString("[")
+	b.WriteString(a.Level)
+	b.WriteString("] ")
+	b.WriteString(a.Message)
+	for _, k := range keys {
+		fmt.Fprintf(&b, " %s=%v", k, a.Attrs[k])
+	}
+	if a.Suppressed > 0 {
+		fmt.Fprintf(&b, " (%d more alerts suppressed)", a.Suppressed)
+	}
+	return b.String()
+}
+
+// templateJSON is the "json" template function
+func templateJSON(v any) (string, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
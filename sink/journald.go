@@ -0,0 +1,229 @@
+package sink
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/jozefvalachovic/logger/v4"
+)
+
+// DefaultJournaldSocket is the journald native protocol socket
+const DefaultJournaldSocket = "/run/systemd/journal/socket"
+
+// JournaldSinkConfig configures a journald sink
+type JournaldSinkConfig struct {
+	Identifier string       // SYSLOG_IDENTIFIER (default: program name)
+	Level      slog.Leveler // Minimum level to send (default: all levels)
+	Socket     string       // Journal socket path (default: DefaultJournaldSocket)
+}
+
+// JournaldSink is a slog.Handler that writes records to systemd-journald
+// using its native protocol, so journalctl can filter by priority
+// (journalctl -p warning) and by attribute (journalctl REQUEST_ID=...).
+// Attribute keys become upper-case journal fields, prefixed with ATTR_
+// where they would repeat a field the sink sets itself (ATTR_MESSAGE,
+// ATTR_PRIORITY, ATTR_LEVEL, ...). On systems without
+// journald the sink is a no-op; see Available.
+type JournaldSink struct {
+	conn       journalConn
+	cfg        JournaldSinkConfig
+	identifier string
+	attrs      []slog.Attr
+	prefix     string
+}
+
+// journalConn delivers one encoded journal entry
+type journalConn interface {
+	send(payload []byte) error
+	close() error
+}
+
+// NewJournaldSink connects to the journal socket. When journald is not
+// running (or the platform is not Linux) it returns a sink that discards
+// everything instead of an error.
+func NewJournaldSink(cfg JournaldSinkConfig) (*JournaldSink, error) {
+	if cfg.Identifier == "" {
+		cfg.Identifier = filepath.Base(os.Args[0])
+	}
+	if cfg.Level == nil {
+		cfg.Level = logger.LevelTrace
+	}
+	if cfg.Socket == "" {
+		cfg.Socket = DefaultJournaldSocket
+	}
+
+	conn, err := dialJournal(cfg.Socket)
+	if err != nil {
+		return nil, fmt.Errorf("sink: failed to connect to journald: %w", err)
+	}
+
+	return &JournaldSink{conn: conn, cfg: cfg, identifier: cfg.Identifier}, nil
+}
+
+// Available reports whether records actually reach journald
+func (s *JournaldSink) Available() bool {
+	return s.conn != nil
+}
+
+// Enabled reports whether level meets the configured minimum and journald is available
+func (s *JournaldSink) Enabled(_ context.Context, level slog.Level) bool {
+	return s.conn != nil && level >= s.cfg.Level.Level()
+}
+
+// Handle sends the record as a single journal entry
+func (s *JournaldSink) Handle(_ context.Context, r slog.Record) error {
+	if s.conn == nil {
+		return nil
+	}
+
+	fields := make(map[string]any, len(s.attrs)+r.NumAttrs())
+	for _, a := range s.attrs {
+		flattenAttr(fields, "", a)
+	}
+	r.Attrs(func(a slog.Attr) bool {
+		flattenAttr(fields, s.prefix, a)
+		return true
+	})
+
+	var buf bytes.Buffer
+	appendJournalField(&buf, "MESSAGE", r.Message)
+	appendJournalField(&buf, "PRIORITY", strconv.Itoa(journalPriority(r.Level)))
+	appendJournalField(&buf, "SYSLOG_IDENTIFIER", s.identifier)
+	appendJournalField(&buf, "LEVEL", logger.LevelString(r.Level))
+	if r.PC != 0 {
+		f, _ := runtime.CallersFrames([]uintptr{r.PC}).Next()
+		appendJournalField(&buf, "CODE_FILE", f.File)
+		appendJournalField(&buf, "CODE_LINE", strconv.Itoa(f.Line))
+		appendJournalField(&buf, "CODE_FUNC", f.Function)
+	}
+
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	for _, k := range keys {
+		name := journalFieldName(k)
+		if name == "" {
+			continue
+		}
+		if journalSinkFields[name] {
+			name = "ATTR_" + name
+		}
+		appendJournalField(&buf, name, fmt.Sprint(fields[k]))
+	}
+
+	if err := s.conn.send(buf.Bytes()); err != nil {
+		return fmt.Errorf("sink: journald write failed: %w", err)
+	}
+	return nil
+}
+
+// WithAttrs returns a sink that adds attrs to every entry
+func (s *JournaldSink) WithAttrs(attrs []slog.Attr) slog.Handler {
+	clone := *s
+	clone.attrs = slices.Clip(s.attrs)
+	for _, a := range attrs {
+		a.Key = s.prefix + a.Key
+		clone.attrs = append(clone.attrs, a)
+	}
+	return &clone
+}
+
+// WithGroup returns a sink that qualifies subsequent attribute keys with name
+func (s *JournaldSink) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return s
+	}
+	clone := *s
+	clone.prefix = s.prefix + name + "."
+	return &clone
+}
+
+// Close releases the journal socket
+func (s *JournaldSink) Close() error {
+	if s.conn == nil {
+		return nil
+	}
+	return s.conn.close()
+}
+
+// journalSinkFields are the fields Handle writes itself; attributes that
+// map onto them are prefixed with ATTR_ so each field appears once
+var journalSinkFields = map[string]bool{
+	"MESSAGE":           true,
+	"PRIORITY":          true,
+	"SYSLOG_IDENTIFIER": true,
+	"LEVEL":             true,
+	"CODE_FILE":         true,
+	"CODE_LINE":         true,
+	"CODE_FUNC":         true,
+}
+
+// journalPriority maps a slog level onto syslog priorities (0 emerg .. 7 debug)
+func journalPriority(level slog.Level) int {
+	switch {
+	case level == logger.LevelAudit:
+		return 5 // notice
+	case level >= logger.LevelError:
+		return 3 // err
+	case level >= logger.LevelWarn:
+		return 4 // warning
+	case level >= logger.LevelNotice:
+		return 5 // notice
+	case level >= logger.LevelInfo:
+		return 6 // info
+	default:
+		return 7 // debug
+	}
+}
+
+// journalFieldName converts an attribute key into a valid journal field
+// name: upper-case [A-Z0-9_], no leading underscore (those are reserved for
+// trusted fields) or digit, at most 64 bytes. It returns "" if nothing is left.
+func journalFieldName(key string) string {
+	b := make([]byte, 0, len(key))
+	for i := 0; i < len(key); i++ {
+		ch := key[i]
+		switch {
+		case ch >= 'a' && ch <= 'z':
+			b = append(b, ch-'a'+'A')
+		case ch >= 'A' && ch <= 'Z', ch >= '0' && ch <= '9':
+			b = append(b, ch)
+		default:
+			b = append(b, '_')
+		}
+	}
+	name := strings.TrimLeft(string(b), "_0123456789")
+	if len(name) > 64 {
+		name = name[:64]
+	}
+	return name
+}
+
+// appendJournalField encodes one field. Values containing a newline use the
+// binary form: NAME\n, a little-endian uint64 length, the value, \n.
+func appendJournalField(buf *bytes.Buffer, name, value string) {
+	buf.WriteString(name)
+	if !strings.Contains(value, "\n") {
+		buf.WriteByte('=')
+		buf.WriteString(value)
+		buf.WriteByte('\n')
+		return
+	}
+	buf.WriteByte('\n')
+	var size [8]byte
+	binary.LittleEndian.PutUint64(size[:], uint64(len(value)))
+	buf.Write(size[:])
+	buf.WriteString(value)
+	buf.WriteByte('\n')
+}
@@ -0,0 +1,62 @@
+//go:build linux
+
+package sink
+
+import (
+	"errors"
+	"io/fs"
+	"net"
+	"os"
+	"syscall"
+)
+
+// unixJournal sends entries as datagrams to the journal socket
+type unixJournal struct {
+	conn *net.UnixConn
+	addr *net.UnixAddr
+}
+
+// dialJournal opens an unbound datagram socket for path. A missing socket
+// means journald is not running and yields a nil connection.
+func dialJournal(path string) (journalConn, error) {
+	if _, err := os.Stat(path); errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	// An empty name autobinds, leaving the socket unconnected so a
+	// journald restart does not strand it
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Net: "unixgram"})
+	if err != nil {
+		return nil, err
+	}
+	return &unixJournal{conn: conn, addr: &net.UnixAddr{Name: path, Net: "unixgram"}}, nil
+}
+
+// send writes payload as one datagram. Entries larger than the socket
+// allows are passed as a file descriptor, as journald's protocol expects.
+func (j *unixJournal) send(payload []byte) error {
+	_, _, err := j.conn.WriteMsgUnix(payload, nil, j.addr)
+	if err == nil {
+		return nil
+	}
+	if !errors.Is(err, syscall.EMSGSIZE) && !errors.Is(err, syscall.ENOBUFS) {
+		return err
+	}
+
+	f, err := os.CreateTemp("/dev/shm", "journal.*")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = f.Close() }()
+	if err := os.Remove(f.Name()); err != nil {
+		return err
+	}
+	if _, err := f.Write(payload); err != nil {
+		return err
+	}
+	_, _, err = j.conn.WriteMsgUnix(nil, syscall.UnixRights(int(f.Fd())), j.addr)
+	return err
+}
+
+func (j *unixJournal) close() error {
+	return j.conn.Close()
+}
@@ -0,0 +1,8 @@
+//go:build !linux
+
+package sink
+
+// dialJournal reports journald as unavailable outside Linux
+func dialJournal(string) (journalConn, error) {
+	return nil, nil
+}
@@ -0,0 +1,507 @@
+// Package sink provides slog handlers that ship application logs to external
+// services. Add them to logger.Config.AdditionalHandlers.
+package sink
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/jozefvalachovic/logger/v4"
+)
+
+// lokiPushPath is the Loki HTTP push endpoint appended to LokiSinkConfig.URL
+const lokiPushPath = "/loki/api/v1/push"
+
+// LokiSinkConfig configures a Loki sink
+type LokiSinkConfig struct {
+	URL         string            // Loki base URL, e.g. http://loki:3100
+	TenantID    string            // Sent as X-Scope-OrgID for multi-tenant Loki
+	Headers     map[string]string // Extra request headers (auth, ...)
+	Labels      map[string]string // Static labels added to every stream
+	LabelKeys   []string          // Record attributes promoted to stream labels, e.g. "service"
+	LevelLabel  string            // Label name for the record level (default: "level", "-" disables)
+	Level       slog.Leveler      // Minimum level to ship (default: all levels)
+	BatchSize   int               // Max entries per push (default: 500)
+	BatchWait   time.Duration     // Max age of a buffered entry before it is pushed (default: 1s)
+	MaxBuffered int               // Entries held while Loki is unreachable before new ones are dropped (default: 10000)
+	Timeout     time.Duration     // Per-request timeout (default: 10s)
+	MaxRetries  int               // Retries per batch on network errors, 429 and 5xx (default: 5)
+	MinBackoff  time.Duration     // First retry delay (default: 500ms)
+	MaxBackoff  time.Duration     // Retry delay cap (default: 30s)
+}
+
+// LokiSinkStats is a snapshot of LokiSink counters
+type LokiSinkStats struct {
+	Sent    int64 // Entries accepted by Loki
+	Dropped int64 // Entries discarded (buffer full, rejected or retries exhausted)
+	Pending int   // Entries waiting to be pushed
+}
+
+// LokiSink is a slog.Handler that batches records and pushes them to
+// Grafana Loki. Add it to logger.Config.AdditionalHandlers. Records are
+// grouped into streams by their label set; pushing happens in the
+// background so logging never waits on the network.
+type LokiSink struct {
+	core   *lokiCore
+	attrs  []slog.Attr
+	prefix string
+}
+
+// lokiCore is the state shared by a LokiSink and its WithAttrs/WithGroup clones
+type lokiCore struct {
+	cfg       LokiSinkConfig
+	pushURL   string
+	client    *http.Client
+	labelKeys map[string]string // attribute key -> sanitized label name
+
+	mu      sync.Mutex
+	pending []lokiEntry
+	closed  bool
+
+	sendMu  sync.Mutex // Serializes pushes so streams stay in timestamp order
+	flushCh chan struct{}
+	stopCh  chan struct{}
+	doneCh  chan struct{}
+
+	sent    atomic.Int64
+	dropped atomic.Int64
+}
+
+// lokiEntry is a single buffered log line and the labels of its stream
+type lokiEntry struct {
+	labels map[string]string
+	ts     time.Time
+	line   string
+}
+
+// NewLokiSink creates a Loki sink and starts its background pusher
+func NewLokiSink(cfg LokiSinkConfig) (*LokiSink, error) {
+	u, err := url.Parse(cfg.URL)
+	if err != nil {
+		return nil, fmt.Errorf("sink: invalid loki URL: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("sink: loki URL must use http or https scheme, got %q", u.Scheme)
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("sink: loki URL must include a host")
+	}
+	if len(cfg.Labels) == 0 && len(cfg.LabelKeys) == 0 && cfg.LevelLabel == "-" {
+		return nil, fmt.Errorf("sink: loki streams need at least one label")
+	}
+
+	if cfg.LevelLabel == "" {
+		cfg.LevelLabel = "level"
+	}
+	if cfg.Level == nil {
+		cfg.Level = logger.LevelTrace
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 500
+	}
+	if cfg.BatchWait <= 0 {
+		cfg.BatchWait = time.Second
+	}
+	if cfg.MaxBuffered <= 0 {
+		cfg.MaxBuffered = 10000
+	}
+	if cfg.MaxBuffered < cfg.BatchSize {
+		cfg.MaxBuffered = cfg.BatchSize
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 10 * time.Second
+	}
+	if cfg.MaxRetries == 0 {
+		cfg.MaxRetries = 5
+	}
+	if cfg.MinBackoff <= 0 {
+		cfg.MinBackoff = 500 * time.Millisecond
+	}
+	if cfg.MaxBackoff <= 0 {
+		cfg.MaxBackoff = 30 * time.Second
+	}
+
+	labels := make(map[string]string, len(cfg.Labels))
+	for k, v := range cfg.Labels {
+		labels[sanitizeLabelName(k)] = v
+	}
+	cfg.Labels = labels
+
+	labelKeys := make(map[string]string, len(cfg.LabelKeys))
+	for _, k := range cfg.LabelKeys {
+		labelKeys[k] = sanitizeLabelName(k)
+	}
+
+	core := &lokiCore{
+		cfg:       cfg,
+		pushURL:   strings.TrimRight(cfg.URL, "/") + lokiPushPath,
+		client:    &http.Client{Timeout: cfg.Timeout},
+		labelKeys: labelKeys,
+		flushCh:   make(chan struct{}, 1),
+		stopCh:    make(chan struct{}),
+		doneCh:    make(chan struct{}),
+	}
+	go core.flushLoop()
+
+	return &LokiSink{core: core}, nil
+}
+
+// Enabled reports whether level meets the configured minimum
+func (s *LokiSink) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= s.core.cfg.Level.Level()
+}
+
+// Handle buffers the record for the next push. It only fails once the
+// sink is closed; a full buffer drops the record and counts it.
+func (s *LokiSink) Handle(_ context.Context, r slog.Record) error {
+	c := s.core
+
+	labels := make(map[string]string, len(c.cfg.Labels)+len(c.labelKeys)+1)
+	for k, v := range c.cfg.Labels {
+		labels[k] = v
+	}
+	if c.cfg.LevelLabel != "-" {
+		labels[sanitizeLabelName(c.cfg.LevelLabel)] = strings.ToLower(logger.LevelString(r.Level))
+	}
+
+	fields := make(map[string]any, len(s.attrs)+r.NumAttrs()+2)
+	fields["msg"] = r.Message
+	fields["level"] = logger.LevelString(r.Level)
+	for _, a := range s.attrs {
+		flattenAttr(fields, "", a)
+	}
+	r.Attrs(func(a slog.Attr) bool {
+		flattenAttr(fields, s.prefix, a)
+		return true
+	})
+	for key, name := range c.labelKeys {
+		if v, ok := fields[key]; ok {
+			labels[name] = fmt.Sprint(v)
+		}
+	}
+
+	line, err := json.Marshal(fields)
+	if err != nil {
+		return fmt.Errorf("sink: failed to marshal loki line: %w", err)
+	}
+
+	ts := r.Time
+	if ts.IsZero() {
+		ts = time.Now()
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return fmt.Errorf("sink: loki sink is closed")
+	}
+	if len(c.pending) >= c.cfg.MaxBuffered {
+		c.dropped.Add(1)
+		return nil
+	}
+
+	c.pending = append(c.pending, lokiEntry{labels: labels, ts: ts, line: string(line)})
+	if len(c.pending) >= c.cfg.BatchSize {
+		select {
+		case c.flushCh <- struct{}{}:
+		default:
+		}
+	}
+	return nil
+}
+
+// WithAttrs returns a sink that adds attrs to every record
+func (s *LokiSink) WithAttrs(attrs []slog.Attr) slog.Handler {
+	clone := *s
+	clone.attrs = slices.Clip(s.attrs)
+	for _, a := range attrs {
+		a.Key = s.prefix + a.Key
+		clone.attrs = append(clone.attrs, a)
+	}
+	return &clone
+}
+
+// WithGroup returns a sink that qualifies subsequent attribute keys with name
+func (s *LokiSink) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return s
+	}
+	clone := *s
+	clone.prefix = s.prefix + name + "."
+	return &clone
+}
+
+// Flush pushes every buffered entry, retrying failed batches
+func (s *LokiSink) Flush() error {
+	return s.core.flush(true)
+}
+
+// Stats returns the current delivery counters
+func (s *LokiSink) Stats() LokiSinkStats {
+	c := s.core
+	c.mu.Lock()
+	pending := len(c.pending)
+	c.mu.Unlock()
+
+	return LokiSinkStats{
+		Sent:    c.sent.Load(),
+		Dropped: c.dropped.Load(),
+		Pending: pending,
+	}
+}
+
+// Close stops the background pusher and makes one final attempt to push
+// whatever is still buffered
+func (s *LokiSink) Close() error {
+	c := s.core
+
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return nil
+	}
+	c.closed = true
+	c.mu.Unlock()
+
+	close(c.stopCh)
+	<-c.doneCh
+
+	return c.flush(true)
+}
+
+// attrFieldValue converts v into something encoding/json renders readably
+func attrFieldValue(v slog.Value) any {
+	switch v.Kind() {
+	case slog.KindDuration:
+		return v.Duration().String()
+	case slog.KindTime:
+		return v.Time().Format(time.RFC3339Nano)
+	case slog.KindAny:
+		if err, ok := v.Any().(error); ok {
+			return err.Error()
+		}
+		return v.Any()
+	default:
+		return v.Any()
+	}
+}
+
+// flattenAttr stores a resolved attribute in fields, joining group keys with dots
+func flattenAttr(fields map[string]any, prefix string, a slog.Attr) {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return
+	}
+	if a.Value.Kind() == slog.KindGroup {
+		if a.Key != "" {
+			prefix += a.Key + "."
+		}
+		for _, ga := range a.Value.Group() {
+			flattenAttr(fields, prefix, ga)
+		}
+		return
+	}
+	fields[prefix+a.Key] = attrFieldValue(a.Value)
+}
+
+// flushLoop pushes when a batch fills up or the oldest entry reaches BatchWait
+func (c *lokiCore) flushLoop() {
+	defer close(c.doneCh)
+
+	ticker := time.NewTicker(c.cfg.BatchWait)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.flushCh:
+			_ = c.flush(false)
+		case <-ticker.C:
+			_ = c.flush(true)
+		case <-c.stopCh:
+			return
+		}
+	}
+}
+
+// flush pushes buffered entries in BatchSize chunks. Unless all is set, a
+// trailing partial batch is left for the ticker so it can fill up.
+func (c *lokiCore) flush(all bool) error {
+	c.sendMu.Lock()
+	defer c.sendMu.Unlock()
+
+	var firstErr error
+	for {
+		c.mu.Lock()
+		n := min(len(c.pending), c.cfg.BatchSize)
+		if n == 0 || (!all && n < c.cfg.BatchSize) {
+			c.mu.Unlock()
+			return firstErr
+		}
+		batch := c.pending[:n:n]
+		c.pending = c.pending[n:]
+		if len(c.pending) == 0 {
+			c.pending = nil
+		}
+		c.mu.Unlock()
+
+		if err := c.sendWithRetry(batch); err != nil {
+			c.dropped.Add(int64(len(batch)))
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		c.sent.Add(int64(len(batch)))
+	}
+}
+
+// sendWithRetry pushes batch, backing off exponentially on retryable errors.
+// Once the sink is closing each batch gets a single attempt.
+func (c *lokiCore) sendWithRetry(batch []lokiEntry) error {
+	payload, err := encodeLokiPush(batch)
+	if err != nil {
+		return err
+	}
+
+	backoff := c.cfg.MinBackoff
+	var lastErr error
+	for attempt := 0; attempt <= c.cfg.MaxRetries; attempt++ {
+		if attempt > 0 {
+			if !c.sleep(backoff) {
+				break
+			}
+			backoff = min(backoff*2, c.cfg.MaxBackoff)
+		}
+
+		retry, err := c.send(payload)
+		if err == nil {
+			return nil
+		}
+		lastErr = err
+		if !retry {
+			return err
+		}
+	}
+
+	return fmt.Errorf("sink: loki push failed after retries: %w", lastErr)
+}
+
+// send performs one push and reports whether a failure is worth retrying
+func (c *lokiCore) send(payload []byte) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.cfg.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.pushURL, bytes.NewReader(payload))
+	if err != nil {
+		return false, fmt.Errorf("sink: failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	if c.cfg.TenantID != "" {
+		req.Header.Set("X-Scope-OrgID", c.cfg.TenantID)
+	}
+	for k, v := range c.cfg.Headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return true, fmt.Errorf("sink: request failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	switch {
+	case resp.StatusCode < 300:
+		return false, nil
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return true, fmt.Errorf("sink: loki returned status %d", resp.StatusCode)
+	default:
+		return false, fmt.Errorf("sink: loki rejected push with status %d", resp.StatusCode)
+	}
+}
+
+// sleep waits for d, reporting false if the sink is closing
+func (c *lokiCore) sleep(d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-c.stopCh:
+		return false
+	}
+}
+
+// encodeLokiPush groups entries into streams by label set, preserving order
+func encodeLokiPush(batch []lokiEntry) ([]byte, error) {
+	type stream struct {
+		Stream map[string]string `json:"stream"`
+		Values [][2]string       `json:"values"`
+	}
+
+	var streams []*stream
+	index := make(map[string]*stream)
+	for _, e := range batch {
+		key := labelSetKey(e.labels)
+		st, ok := index[key]
+		if !ok {
+			st = &stream{Stream: e.labels}
+			index[key] = st
+			streams = append(streams, st)
+		}
+		st.Values = append(st.Values, [2]string{strconv.FormatInt(e.ts.UnixNano(), 10), e.line})
+	}
+
+	data, err := json.Marshal(struct {
+		Streams []*stream `json:"streams"`
+	}{Streams: streams})
+	if err != nil {
+		return nil, fmt.Errorf("sink: failed to marshal loki payload: %w", err)
+	}
+	return data, nil
+}
+
+// labelSetKey returns a canonical string for a label set
+func labelSetKey(labels map[string]string) string {
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+
+	var b strings.Builder
+	for _, k := range keys {
+		b.WriteString(k)
+		b.WriteByte('=')
+		b.WriteString(strconv.Quote(labels[k]))
+		b.WriteByte(',')
+	}
+	return b.String()
+}
+
+// sanitizeLabelName maps name onto Loki's label charset [a-zA-Z_][a-zA-Z0-9_]*
+func sanitizeLabelName(name string) string {
+	b := []byte(name)
+	for i, ch := range b {
+		valid := ch == '_' || (ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z') || (i > 0 && ch >= '0' && ch <= '9')
+		if !valid {
+			b[i] = '_'
+		}
+	}
+	if len(b) == 0 {
+		return "_"
+	}
+	return string(b)
+}
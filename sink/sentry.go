@@ -0,0 +1,535 @@
+package sink
+
+import (
+	"bytes"
+	"context"
+	crand "crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"os"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/jozefvalachovic/logger/v4"
+)
+
+// SentrySinkConfig configures a Sentry sink
+type SentrySinkConfig struct {
+	DSN          string                                              // Project DSN, e.g. https://<key>@o0.ingest.sentry.io/<project>
+	Environment  string                                              // Sentry environment (production, staging, ...)
+	Release      string                                              // Release identifier, e.g. a git SHA
+	ServerName   string                                              // Host name reported with events (default: os.Hostname)
+	Level        slog.Leveler                                        // Minimum level to forward (default: logger.LevelError)
+	SampleRate   float64                                             // Fraction of events sent, 0 < rate <= 1 (default: 1)
+	TagKeys      []string                                            // Attributes sent as searchable tags (default: request_id, requestId, trace_id, service)
+	Fingerprint  func(message string, attrs map[string]any) []string // Custom grouping; nil uses Sentry's default
+	BufferSize   int                                                 // Events queued before new ones are dropped (default: 100)
+	Timeout      time.Duration                                       // Per-request timeout (default: 5s)
+	CloseTimeout time.Duration                                       // How long Close waits to deliver queued events (default: 5s)
+}
+
+// SentrySinkStats is a snapshot of SentrySink counters
+type SentrySinkStats struct {
+	Sent    int64 // Events accepted by Sentry
+	Sampled int64 // Events skipped by SampleRate
+	Dropped int64 // Events lost to a full queue or a failed request
+}
+
+// SentrySink is a slog.Handler that forwards Error and higher records to
+// Sentry as events, including the call stack of the log site, the error
+// attribute (if any) as the exception, and the request metadata added by
+// the HTTP middleware. Audit records are never forwarded.
+//
+// The stack starts at the record's PC, which this logger only sets with
+// EnableCaller; records without one are sent without a stack. The full
+// stack is only known when records are handled on the goroutine that
+// logged them, so in AsyncMode events carry the single frame of the PC.
+type SentrySink struct {
+	core   *sentryCore
+	attrs  []slog.Attr
+	prefix string
+}
+
+// sentryCore is the state shared by a SentrySink and its WithAttrs/WithGroup clones
+type sentryCore struct {
+	cfg       SentrySinkConfig
+	endpoint  string
+	dsn       string
+	auth      string
+	client    *http.Client
+	tagKeys   map[string]bool
+	frameSkip []string
+
+	mu     sync.RWMutex // Guards closed against concurrent Handle/Close
+	closed bool
+	queue  chan []byte
+	stop   chan struct{}
+	done   chan struct{}
+
+	sent    atomic.Int64
+	sampled atomic.Int64
+	dropped atomic.Int64
+}
+
+// NewSentrySink creates a Sentry sink from cfg and starts its sender
+func NewSentrySink(cfg SentrySinkConfig) (*SentrySink, error) {
+	u, err := url.Parse(cfg.DSN)
+	if err != nil {
+		return nil, fmt.Errorf("sink: invalid sentry DSN: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("sink: sentry DSN must use http or https scheme, got %q", u.Scheme)
+	}
+	if u.Host == "" || u.User == nil || u.User.Username() == "" {
+		return nil, fmt.Errorf("sink: sentry DSN must include a public key and host")
+	}
+	idx := strings.LastIndex(u.Path, "/")
+	projectID := u.Path[idx+1:]
+	if idx < 0 || projectID == "" {
+		return nil, fmt.Errorf("sink: sentry DSN must end with a project ID")
+	}
+	if cfg.SampleRate < 0 || cfg.SampleRate > 1 {
+		return nil, fmt.Errorf("sink: sentry sample rate must be between 0 and 1")
+	}
+
+	if cfg.Level == nil {
+		cfg.Level = logger.LevelError
+	}
+	if cfg.SampleRate == 0 {
+		cfg.SampleRate = 1
+	}
+	if cfg.TagKeys == nil {
+		cfg.TagKeys = []string{"request_id", "requestId", "trace_id", "service"}
+	}
+	if cfg.ServerName == "" {
+		cfg.ServerName, _ = os.Hostname()
+	}
+	if cfg.BufferSize <= 0 {
+		cfg.BufferSize = 100
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 5 * time.Second
+	}
+	if cfg.CloseTimeout <= 0 {
+		cfg.CloseTimeout = 5 * time.Second
+	}
+
+	tagKeys := make(map[string]bool, len(cfg.TagKeys))
+	for _, k := range cfg.TagKeys {
+		tagKeys[k] = true
+	}
+
+	core := &sentryCore{
+		cfg:      cfg,
+		endpoint: fmt.Sprintf("%s://%s%s/api/%s/envelope/", u.Scheme, u.Host, u.Path[:idx], projectID),
+		dsn:      cfg.DSN,
+		auth: fmt.Sprintf("Sentry sentry_version=7, sentry_client=jozefvalachovic-logger/%s, sentry_key=%s",
+			logger.Version, u.User.Username()),
+		client:  &http.Client{Timeout: cfg.Timeout},
+		tagKeys: tagKeys,
+		frameSkip: []string{
+			"runtime.",
+			"log/slog.",
+			"github.com/jozefvalachovic/logger/v4.",
+			"github.com/jozefvalachovic/logger/v4/sink.(*SentrySink).",
+		},
+		queue: make(chan []byte, cfg.BufferSize),
+		stop:  make(chan struct{}),
+		done:  make(chan struct{}),
+	}
+	go core.run()
+
+	return &SentrySink{core: core}, nil
+}
+
+// Enabled reports whether level meets the configured minimum
+func (s *SentrySink) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= s.core.cfg.Level.Level() && level != logger.LevelAudit
+}
+
+// Handle builds a Sentry event from the record and queues it for delivery.
+// It never blocks on the network; a full queue drops the event.
+func (s *SentrySink) Handle(_ context.Context, r slog.Record) error {
+	c := s.core
+	if r.Level == logger.LevelAudit {
+		return nil
+	}
+	if c.cfg.SampleRate < 1 && rand.Float64() >= c.cfg.SampleRate {
+		c.sampled.Add(1)
+		return nil
+	}
+
+	// The stack is only the log site's when Handle runs on the logging
+	// goroutine, which holds record.PC. Records handled elsewhere (async
+	// mode) get the single frame of record.PC, and records without a PC
+	// no stack at all.
+	var pcs []uintptr
+	if r.PC != 0 {
+		pcs = make([]uintptr, 64)
+		pcs = pcs[:runtime.Callers(1, pcs)]
+		if i := slices.Index(pcs, r.PC); i >= 0 {
+			pcs = pcs[i:]
+		} else {
+			pcs = []uintptr{r.PC}
+		}
+	}
+
+	var errValue error
+	attrs := make(map[string]any, len(s.attrs)+r.NumAttrs())
+	collect := func(prefix string, a slog.Attr) {
+		if err, ok := a.Value.Resolve().Any().(error); ok && errValue == nil {
+			errValue = err
+		}
+		flattenAttr(attrs, prefix, a)
+	}
+	for _, a := range s.attrs {
+		collect("", a)
+	}
+	r.Attrs(func(a slog.Attr) bool {
+		collect(s.prefix, a)
+		return true
+	})
+
+	envelope, err := c.buildEnvelope(r, attrs, errValue, pcs)
+	if err != nil {
+		return err
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if c.closed {
+		return fmt.Errorf("sink: sentry sink is closed")
+	}
+	select {
+	case c.queue <- envelope:
+	default:
+		c.dropped.Add(1)
+	}
+	return nil
+}
+
+// WithAttrs returns a sink that adds attrs to every event
+func (s *SentrySink) WithAttrs(attrs []slog.Attr) slog.Handler {
+	clone := *s
+	clone.attrs = slices.Clip(s.attrs)
+	for _, a := range attrs {
+		a.Key = s.prefix + a.Key
+		clone.attrs = append(clone.attrs, a)
+	}
+	return &clone
+}
+
+// WithGroup returns a sink that qualifies subsequent attribute keys with name
+func (s *SentrySink) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return s
+	}
+	clone := *s
+	clone.prefix = s.prefix + name + "."
+	return &clone
+}
+
+// Stats returns the current delivery counters
+func (s *SentrySink) Stats() SentrySinkStats {
+	return SentrySinkStats{
+		Sent:    s.core.sent.Load(),
+		Sampled: s.core.sampled.Load(),
+		Dropped: s.core.dropped.Load(),
+	}
+}
+
+// Close stops accepting events and waits up to CloseTimeout for queued
+// events to be delivered
+func (s *SentrySink) Close() error {
+	c := s.core
+
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return nil
+	}
+	c.closed = true
+	close(c.queue)
+	c.mu.Unlock()
+
+	timer := time.NewTimer(c.cfg.CloseTimeout)
+	defer timer.Stop()
+
+	select {
+	case <-c.done:
+		return nil
+	case <-timer.C:
+		close(c.stop)
+		<-c.done
+		return fmt.Errorf("sink: sentry sink close timed out, %d events dropped", c.dropped.Load())
+	}
+}
+
+// sentryFrame is a single frame of a Sentry stack trace
+type sentryFrame struct {
+	Function string `json:"function"`
+	Module   string `json:"module,omitempty"`
+	Filename string `json:"filename"`
+	AbsPath  string `json:"abs_path"`
+	Lineno   int    `json:"lineno"`
+	InApp    bool   `json:"in_app"`
+}
+
+// sentryStacktrace lists frames oldest first, as Sentry expects
+type sentryStacktrace struct {
+	Frames []sentryFrame `json:"frames"`
+}
+
+// buildEnvelope encodes the event for the envelope endpoint
+func (c *sentryCore) buildEnvelope(r slog.Record, attrs map[string]any, errValue error, pcs []uintptr) ([]byte, error) {
+	id := make([]byte, 16)
+	_, _ = crand.Read(id)
+	eventID := hex.EncodeToString(id)
+
+	ts := r.Time
+	if ts.IsZero() {
+		ts = time.Now()
+	}
+
+	event := map[string]any{
+		"event_id":  eventID,
+		"timestamp": ts.UTC().Format(time.RFC3339Nano),
+		"platform":  "go",
+		"level":     sentryLevel(r.Level),
+		"logger":    "logger",
+		"message":   map[string]string{"formatted": r.Message},
+	}
+	if c.cfg.Environment != "" {
+		event["environment"] = c.cfg.Environment
+	}
+	if c.cfg.Release != "" {
+		event["release"] = c.cfg.Release
+	}
+	if c.cfg.ServerName != "" {
+		event["server_name"] = c.cfg.ServerName
+	}
+
+	tags := make(map[string]string)
+	for k, v := range attrs {
+		if c.tagKeys[k] {
+			tags[k] = fmt.Sprint(v)
+		}
+	}
+	if len(tags) > 0 {
+		event["tags"] = tags
+	}
+	if len(attrs) > 0 {
+		event["extra"] = attrs
+	}
+
+	// Request metadata added by the HTTP middleware
+	if method, ok := attrs["__method"].(string); ok {
+		req := map[string]string{"method": method}
+		if path, ok := attrs["__path"].(string); ok {
+			req["url"] = path
+		}
+		event["request"] = req
+	}
+
+	if c.cfg.Fingerprint != nil {
+		if fp := c.cfg.Fingerprint(r.Message, attrs); len(fp) > 0 {
+			event["fingerprint"] = fp
+		}
+	}
+
+	var exception map[string]any
+	if errValue != nil {
+		exception = map[string]any{"type": errorType(errValue), "value": errValue.Error()}
+		event["exception"] = map[string]any{"values": []map[string]any{exception}}
+	}
+	if len(pcs) > 0 {
+		stack := c.stacktrace(pcs)
+		if exception != nil {
+			exception["stacktrace"] = stack
+		} else {
+			event["threads"] = map[string]any{
+				"values": []map[string]any{{
+					"current":    true,
+					"stacktrace": stack,
+				}},
+			}
+		}
+	}
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return nil, fmt.Errorf("sink: failed to marshal sentry event: %w", err)
+	}
+	header, err := json.Marshal(map[string]string{
+		"event_id": eventID,
+		"dsn":      c.dsn,
+		"sent_at":  time.Now().UTC().Format(time.RFC3339Nano),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("sink: failed to marshal sentry envelope: %w", err)
+	}
+
+	var buf bytes.Buffer
+	buf.Grow(len(header) + len(payload) + 64)
+	buf.Write(header)
+	fmt.Fprintf(&buf, "\n{\"type\":\"event\",\"length\":%d}\n", len(payload))
+	buf.Write(payload)
+	buf.WriteByte('\n')
+	return buf.Bytes(), nil
+}
+
+// stacktrace converts pcs (innermost first) into Sentry frames, dropping
+// the logging machinery above the log site
+func (c *sentryCore) stacktrace(pcs []uintptr) sentryStacktrace {
+	var frames []sentryFrame
+	skipping := true
+	it := runtime.CallersFrames(pcs)
+	for {
+		f, more := it.Next()
+		if skipping && c.isLoggingFrame(f.Function) {
+			if !more {
+				break
+			}
+			continue
+		}
+		skipping = false
+		if f.Function != "" {
+			module, function := splitFunctionName(f.Function)
+			frames = append(frames, sentryFrame{
+				Function: function,
+				Module:   module,
+				Filename: shortFilename(f.File),
+				AbsPath:  f.File,
+				Lineno:   f.Line,
+				InApp:    !isStdlib(module),
+			})
+		}
+		if !more {
+			break
+		}
+	}
+	slices.Reverse(frames)
+	return sentryStacktrace{Frames: frames}
+}
+
+// isLoggingFrame reports whether function belongs to slog or this logger
+func (c *sentryCore) isLoggingFrame(function string) bool {
+	for _, prefix := range c.frameSkip {
+		if strings.HasPrefix(function, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// run delivers queued envelopes one at a time
+func (c *sentryCore) run() {
+	defer close(c.done)
+
+	for envelope := range c.queue {
+		select {
+		case <-c.stop:
+			c.dropped.Add(1 + int64(len(c.queue)))
+			for range c.queue {
+			}
+			return
+		default:
+		}
+
+		if err := c.send(envelope); err != nil {
+			c.dropped.Add(1)
+			continue
+		}
+		c.sent.Add(1)
+	}
+}
+
+// send posts a single envelope
+func (c *sentryCore) send(envelope []byte) error {
+	ctx, cancel := context.WithTimeout(context.Background(), c.cfg.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoint, bytes.NewReader(envelope))
+	if err != nil {
+		return fmt.Errorf("sink: failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-sentry-envelope")
+	req.Header.Set("X-Sentry-Auth", c.auth)
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("sink: request failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("sink: sentry returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// sentryLevel maps a slog level onto Sentry's severity names
+func sentryLevel(level slog.Level) string {
+	switch {
+	case level > logger.LevelError:
+		return "fatal"
+	case level >= logger.LevelError:
+		return "error"
+	case level >= logger.LevelWarn:
+		return "warning"
+	case level >= logger.LevelInfo:
+		return "info"
+	default:
+		return "debug"
+	}
+}
+
+// errorType names the innermost wrapped error's type, which groups better
+// than *fmt.wrapError
+func errorType(err error) string {
+	for {
+		next := errors.Unwrap(err)
+		if next == nil {
+			return fmt.Sprintf("%T", err)
+		}
+		err = next
+	}
+}
+
+// splitFunctionName splits "pkg/path.(*T).Method" into package and function
+func splitFunctionName(name string) (string, string) {
+	slash := strings.LastIndex(name, "/")
+	dot := strings.Index(name[slash+1:], ".")
+	if dot < 0 {
+		return "", name
+	}
+	return name[:slash+1+dot], name[slash+1+dot+1:]
+}
+
+// shortFilename trims a file path to its last two elements
+func shortFilename(file string) string {
+	if i := strings.LastIndex(file, "/"); i >= 0 {
+		if j := strings.LastIndex(file[:i], "/"); j >= 0 {
+			return file[j+1:]
+		}
+	}
+	return file
+}
+
+// isStdlib reports whether module is a standard library package (no dot in
+// its first path element)
+func isStdlib(module string) bool {
+	first, _, _ := strings.Cut(module, "/")
+	return !strings.Contains(first, ".")
+}
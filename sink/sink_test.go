@@ -0,0 +1,619 @@
+package sink
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jozefvalachovic/logger/v4"
+)
+
+type lokiPush struct {
+	Streams []struct {
+		Stream map[string]string `json:"stream"`
+		Values [][2]string       `json:"values"`
+	} `json:"streams"`
+}
+
+// lokiServer records every push it receives
+type lokiServer struct {
+	mu     sync.Mutex
+	pushes []lokiPush
+	tenant string
+}
+
+func (s *lokiServer) handler(t *testing.T) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/loki/api/v1/push" {
+			t.Errorf("unexpected path %q", r.URL.Path)
+		}
+		var p lokiPush
+		if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
+			t.Errorf("decode push: %v", err)
+		}
+		s.mu.Lock()
+		s.pushes = append(s.pushes, p)
+		s.tenant = r.Header.Get("X-Scope-OrgID")
+		s.mu.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func TestLokiSinkLabelsAndBatching(t *testing.T) {
+	var srv lokiServer
+	ts := httptest.NewServer(srv.handler(t))
+	defer ts.Close()
+
+	s, err := NewLokiSink(LokiSinkConfig{
+		URL:       ts.URL,
+		TenantID:  "team-a",
+		Labels:    map[string]string{"app": "api"},
+		LabelKeys: []string{"service"},
+		BatchSize: 3,
+		BatchWait: time.Hour,
+	})
+	if err != nil {
+		t.Fatalf("NewLokiSink() error: %v", err)
+	}
+	defer func() { _ = s.Close() }()
+
+	log := slog.New(s)
+	log.Info("one", "service", "billing", "n", 1)
+	log.Error("two", "service", "billing")
+	log.With("service", "auth").Info("three")
+
+	deadline := time.Now().Add(2 * time.Second)
+	for s.Stats().Sent < 3 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if st := s.Stats(); st.Sent != 3 || st.Pending != 0 {
+		t.Fatalf("stats = %+v, want 3 sent after a full batch", st)
+	}
+
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+
+	if srv.tenant != "team-a" {
+		t.Errorf("X-Scope-OrgID = %q, want team-a", srv.tenant)
+	}
+	if len(srv.pushes) != 1 {
+		t.Fatalf("got %d pushes, want 1", len(srv.pushes))
+	}
+	streams := srv.pushes[0].Streams
+	if len(streams) != 3 {
+		t.Fatalf("got %d streams, want 3 (billing/info, billing/error, auth/info)", len(streams))
+	}
+
+	first := streams[0]
+	want := map[string]string{"app": "api", "level": "info", "service": "billing"}
+	for k, v := range want {
+		if first.Stream[k] != v {
+			t.Errorf("stream label %s = %q, want %q", k, first.Stream[k], v)
+		}
+	}
+	var line map[string]any
+	if err := json.Unmarshal([]byte(first.Values[0][1]), &line); err != nil {
+		t.Fatalf("line is not JSON: %v", err)
+	}
+	if line["msg"] != "one" || line["n"] != float64(1) {
+		t.Errorf("unexpected line %v", line)
+	}
+	if streams[2].Stream["service"] != "auth" {
+		t.Errorf("WithAttrs label not promoted: %v", streams[2].Stream)
+	}
+}
+
+func TestLokiSinkFlushesByAge(t *testing.T) {
+	var srv lokiServer
+	ts := httptest.NewServer(srv.handler(t))
+	defer ts.Close()
+
+	s, err := NewLokiSink(LokiSinkConfig{URL: ts.URL, BatchWait: 20 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("NewLokiSink() error: %v", err)
+	}
+	defer func() { _ = s.Close() }()
+
+	_ = s.Handle(context.Background(), slog.NewRecord(time.Now(), slog.LevelWarn, "aged", 0))
+
+	deadline := time.Now().Add(2 * time.Second)
+	for s.Stats().Sent < 1 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if s.Stats().Sent != 1 {
+		t.Fatal("expected the partial batch to be pushed after BatchWait")
+	}
+}
+
+func TestLokiSinkRetriesServerErrors(t *testing.T) {
+	var calls atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer ts.Close()
+
+	s, err := NewLokiSink(LokiSinkConfig{
+		URL:        ts.URL,
+		BatchWait:  time.Hour,
+		MinBackoff: time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("NewLokiSink() error: %v", err)
+	}
+	defer func() { _ = s.Close() }()
+
+	_ = s.Handle(context.Background(), slog.NewRecord(time.Now(), slog.LevelInfo, "retry", 0))
+	if err := s.Flush(); err != nil {
+		t.Fatalf("Flush() error: %v", err)
+	}
+	if calls.Load() != 3 {
+		t.Errorf("got %d attempts, want 3", calls.Load())
+	}
+	if st := s.Stats(); st.Sent != 1 || st.Dropped != 0 {
+		t.Errorf("stats = %+v", st)
+	}
+}
+
+func TestLokiSinkDoesNotRetryClientErrors(t *testing.T) {
+	var calls atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer ts.Close()
+
+	s, err := NewLokiSink(LokiSinkConfig{URL: ts.URL, BatchWait: time.Hour, MinBackoff: time.Millisecond})
+	if err != nil {
+		t.Fatalf("NewLokiSink() error: %v", err)
+	}
+	defer func() { _ = s.Close() }()
+
+	_ = s.Handle(context.Background(), slog.NewRecord(time.Now(), slog.LevelInfo, "bad", 0))
+	if err := s.Flush(); err == nil {
+		t.Error("expected Flush() to report the rejected push")
+	}
+	if calls.Load() != 1 {
+		t.Errorf("got %d attempts, want 1", calls.Load())
+	}
+	if s.Stats().Dropped != 1 {
+		t.Errorf("expected the rejected entry to be counted as dropped")
+	}
+}
+
+func TestLokiSinkMaxBuffered(t *testing.T) {
+	s, err := NewLokiSink(LokiSinkConfig{
+		URL:         "http://127.0.0.1:1",
+		BatchSize:   2,
+		BatchWait:   time.Hour,
+		MaxBuffered: 3,
+	})
+	if err != nil {
+		t.Fatalf("NewLokiSink() error: %v", err)
+	}
+
+	// Hold the push lock so nothing leaves the buffer while it fills
+	s.core.sendMu.Lock()
+	for range 5 {
+		_ = s.Handle(context.Background(), slog.NewRecord(time.Now(), slog.LevelInfo, "x", 0))
+	}
+	st := s.Stats()
+	s.core.sendMu.Unlock()
+	if st.Pending != 3 || st.Dropped != 2 {
+		t.Errorf("stats = %+v, want 3 pending and 2 dropped", st)
+	}
+
+	_ = s.Close()
+	if st := s.Stats(); st.Dropped != 5 || st.Pending != 0 {
+		t.Errorf("after Close stats = %+v, want everything dropped", st)
+	}
+	if err := s.Handle(context.Background(), slog.NewRecord(time.Now(), slog.LevelInfo, "late", 0)); err == nil {
+		t.Error("expected Handle() after Close() to fail")
+	}
+}
+
+func TestNewLokiSinkValidation(t *testing.T) {
+	for _, u := range []string{"", "file:///tmp/x", "http://"} {
+		if _, err := NewLokiSink(LokiSinkConfig{URL: u}); err == nil {
+			t.Errorf("NewLokiSink(%q) should fail", u)
+		}
+	}
+	if got := sanitizeLabelName("http.route-1"); got != "http_route_1" {
+		t.Errorf("sanitizeLabelName = %q", got)
+	}
+}
+
+// sentryServer decodes the event item of every envelope it receives
+type sentryServer struct {
+	mu     sync.Mutex
+	events []map[string]any
+	auth   string
+	path   string
+}
+
+func (s *sentryServer) handler(t *testing.T) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		lines := strings.Split(strings.TrimSpace(string(body)), "\n")
+		if len(lines) != 3 {
+			t.Errorf("envelope has %d lines, want 3", len(lines))
+			return
+		}
+		var event map[string]any
+		if err := json.Unmarshal([]byte(lines[2]), &event); err != nil {
+			t.Errorf("decode event: %v", err)
+		}
+		s.mu.Lock()
+		s.events = append(s.events, event)
+		s.auth = r.Header.Get("X-Sentry-Auth")
+		s.path = r.URL.Path
+		s.mu.Unlock()
+	}
+}
+
+func TestSentrySinkForwardsErrors(t *testing.T) {
+	var srv sentryServer
+	ts := httptest.NewServer(srv.handler(t))
+	defer ts.Close()
+
+	dsn := strings.Replace(ts.URL, "http://", "http://publickey@", 1) + "/prefix/42"
+	s, err := NewSentrySink(SentrySinkConfig{
+		DSN:         dsn,
+		Environment: "test",
+		Fingerprint: func(message string, attrs map[string]any) []string {
+			return []string{message, fmt.Sprint(attrs["__path"])}
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewSentrySink() error: %v", err)
+	}
+
+	log := slog.New(s)
+	log.Info("ignored")
+	log.Log(context.Background(), logger.LevelAudit, "audit records are never forwarded")
+	log.Error("GET /orders [500]",
+		"__method", "GET",
+		"__path", "/orders",
+		"request_id", "req-1",
+		"error", fmt.Errorf("load orders: %w", io.ErrUnexpectedEOF),
+	)
+
+	if err := s.Close(); err != nil {
+		t.Fatalf("Close() error: %v", err)
+	}
+	if st := s.Stats(); st.Sent != 1 || st.Dropped != 0 {
+		t.Fatalf("stats = %+v, want exactly one event sent", st)
+	}
+
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+
+	if srv.path != "/prefix/api/42/envelope/" {
+		t.Errorf("path = %q", srv.path)
+	}
+	if !strings.Contains(srv.auth, "sentry_key=publickey") {
+		t.Errorf("X-Sentry-Auth = %q", srv.auth)
+	}
+
+	event := srv.events[0]
+	if event["level"] != "error" || event["environment"] != "test" {
+		t.Errorf("unexpected event header fields: %v", event)
+	}
+	if tags, _ := event["tags"].(map[string]any); tags["request_id"] != "req-1" {
+		t.Errorf("tags = %v", event["tags"])
+	}
+	if req, _ := event["request"].(map[string]any); req["method"] != "GET" || req["url"] != "/orders" {
+		t.Errorf("request = %v", event["request"])
+	}
+	if fp, _ := event["fingerprint"].([]any); len(fp) != 2 || fp[1] != "/orders" {
+		t.Errorf("fingerprint = %v", event["fingerprint"])
+	}
+
+	exc := event["exception"].(map[string]any)["values"].([]any)[0].(map[string]any)
+	if exc["type"] != "*errors.errorString" || exc["value"] != "load orders: unexpected EOF" {
+		t.Errorf("exception = %v", exc)
+	}
+	frames := exc["stacktrace"].(map[string]any)["frames"].([]any)
+	top := frames[len(frames)-1].(map[string]any)
+	if top["function"] != "TestSentrySinkForwardsErrors" || top["in_app"] != true {
+		t.Errorf("top frame = %v, want the log call site", top)
+	}
+}
+
+// Test that a record handled on another goroutine, as in async mode, is
+// reported at its PC rather than with the handling goroutine's stack
+func TestSentrySinkRecordFromOtherGoroutine(t *testing.T) {
+	var srv sentryServer
+	ts := httptest.NewServer(srv.handler(t))
+	defer ts.Close()
+
+	s, err := NewSentrySink(SentrySinkConfig{DSN: strings.Replace(ts.URL, "http://", "http://publickey@", 1) + "/42"})
+	if err != nil {
+		t.Fatalf("NewSentrySink() error: %v", err)
+	}
+
+	var pcs [1]uintptr
+	runtime.Callers(1, pcs[:])
+	r := slog.NewRecord(time.Now(), slog.LevelError, "queued", pcs[0])
+	done := make(chan error)
+	go func() { done <- s.Handle(context.Background(), r) }()
+	if err := <-done; err != nil {
+		t.Fatalf("Handle() error: %v", err)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatalf("Close() error: %v", err)
+	}
+
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	if len(srv.events) != 1 {
+		t.Fatalf("got %d events, want 1", len(srv.events))
+	}
+	thread := srv.events[0]["threads"].(map[string]any)["values"].([]any)[0].(map[string]any)
+	frames := thread["stacktrace"].(map[string]any)["frames"].([]any)
+	if len(frames) != 1 || frames[0].(map[string]any)["function"] != "TestSentrySinkRecordFromOtherGoroutine" {
+		t.Errorf("frames = %v, want only the record's call site", frames)
+	}
+}
+
+// Test that records logged in async mode without EnableCaller carry no
+// stack rather than the stack of the worker goroutine
+func TestSentrySinkAsyncWithoutCaller(t *testing.T) {
+	var srv sentryServer
+	ts := httptest.NewServer(srv.handler(t))
+	defer ts.Close()
+
+	s, err := NewSentrySink(SentrySinkConfig{DSN: strings.Replace(ts.URL, "http://", "http://publickey@", 1) + "/42"})
+	if err != nil {
+		t.Fatalf("NewSentrySink() error: %v", err)
+	}
+	logger.SetConfig(logger.Config{Output: io.Discard, AsyncMode: true, AdditionalHandlers: []slog.Handler{s}})
+	defer logger.SetConfig(logger.Config{Output: io.Discard})
+
+	logger.LogError("queued")
+	if err := logger.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown() error: %v", err)
+	}
+	r := slog.NewRecord(time.Now(), slog.LevelError, "no caller", 0)
+	r.AddAttrs(slog.Any("error", io.ErrUnexpectedEOF))
+	if err := s.Handle(context.Background(), r); err != nil {
+		t.Fatalf("Handle() error: %v", err)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatalf("Close() error: %v", err)
+	}
+
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	if len(srv.events) != 2 {
+		t.Fatalf("got %d events, want 2", len(srv.events))
+	}
+	for _, event := range srv.events {
+		if threads, ok := event["threads"]; ok {
+			t.Errorf("threads = %v, want no stacktrace", threads)
+		}
+		if exc, ok := event["exception"].(map[string]any); ok {
+			if v := exc["values"].([]any)[0].(map[string]any); v["stacktrace"] != nil {
+				t.Errorf("exception = %v, want no stacktrace", v)
+			}
+		}
+	}
+}
+
+func TestSentrySinkSampling(t *testing.T) {
+	var srv sentryServer
+	ts := httptest.NewServer(srv.handler(t))
+	defer ts.Close()
+
+	s, err := NewSentrySink(SentrySinkConfig{
+		DSN:        strings.Replace(ts.URL, "http://", "http://k@", 1) + "/1",
+		SampleRate: 0.000001,
+	})
+	if err != nil {
+		t.Fatalf("NewSentrySink() error: %v", err)
+	}
+	for range 100 {
+		_ = s.Handle(context.Background(), slog.NewRecord(time.Now(), slog.LevelError, "boom", 0))
+	}
+	_ = s.Close()
+
+	if st := s.Stats(); st.Sampled+st.Sent != 100 || st.Sampled < 99 {
+		t.Errorf("stats = %+v, want nearly everything sampled out", st)
+	}
+}
+
+func TestNewSentrySinkValidation(t *testing.T) {
+	for _, dsn := range []string{"", "https://sentry.io/1", "https://key@sentry.io/", "ftp://key@sentry.io/1"} {
+		if _, err := NewSentrySink(SentrySinkConfig{DSN: dsn}); err == nil {
+			t.Errorf("NewSentrySink(%q) should fail", dsn)
+		}
+	}
+	if _, err := NewSentrySink(SentrySinkConfig{DSN: "https://key@sentry.io/1", SampleRate: 2}); err == nil {
+		t.Error("expected an out-of-range sample rate to fail")
+	}
+}
+
+func TestAlertSinkRateLimitAndTemplate(t *testing.T) {
+	var mu sync.Mutex
+	var bodies []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		bodies = append(bodies, string(body))
+		mu.Unlock()
+	}))
+	defer ts.Close()
+
+	s, err := NewAlertSink(AlertSinkConfig{
+		URL:        ts.URL,
+		Template:   `{"summary": {{ json .Text }}, "missed": {{ .Suppressed }}}`,
+		RateLimit:  2,
+		RateWindow: 50 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("NewAlertSink() error: %v", err)
+	}
+
+	log := slog.New(s)
+	log.Warn("below threshold")
+	for i := range 5 {
+		log.Error("db down", "attempt", i)
+	}
+	time.Sleep(60 * time.Millisecond)
+	log.Log(context.Background(), logger.LevelAudit, "role changed", "actor", "admin")
+
+	if err := s.Close(); err != nil {
+		t.Fatalf("Close() error: %v", err)
+	}
+	if st := s.Stats(); st.Sent != 3 || st.Suppressed != 3 {
+		t.Fatalf("stats = %+v, want 3 sent and 3 suppressed", st)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	var last struct {
+		Summary string `json:"summary"`
+		Missed  int    `json:"missed"`
+	}
+	if err := json.Unmarshal([]byte(bodies[2]), &last); err != nil {
+		t.Fatalf("payload is not JSON: %v (%s)", err, bodies[2])
+	}
+	if last.Missed != 3 || !strings.HasPrefix(last.Summary, "[AUDIT] role changed actor=admin") {
+		t.Errorf("unexpected payload %+v", last)
+	}
+}
+
+func TestAlertSinkDefaultTemplates(t *testing.T) {
+	s, err := NewAlertSink(AlertSinkConfig{URL: "https://hooks.slack.com/services/T/B/X"})
+	if err != nil {
+		t.Fatalf("NewAlertSink() error: %v", err)
+	}
+	defer func() { _ = s.Close() }()
+
+	var buf strings.Builder
+	if err := s.core.tmpl.Execute(&buf, Alert{Text: `quote " and newline` + "\n"}); err != nil {
+		t.Fatalf("Execute() error: %v", err)
+	}
+	var payload map[string]string
+	if err := json.Unmarshal([]byte(buf.String()), &payload); err != nil || payload["text"] == "" {
+		t.Errorf("slack payload %q is not valid: %v", buf.String(), err)
+	}
+
+	if _, err := NewAlertSink(AlertSinkConfig{URL: "https://example.com", Template: "{{ .Broken"}); err == nil {
+		t.Error("expected an invalid template to fail")
+	}
+}
+
+func TestJournaldSinkNativeFields(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("journald is Linux-only")
+	}
+
+	path := filepath.Join(t.TempDir(), "journal.sock")
+	server, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer func() { _ = server.Close() }()
+
+	s, err := NewJournaldSink(JournaldSinkConfig{Identifier: "billing", Socket: path})
+	if err != nil {
+		t.Fatalf("NewJournaldSink() error: %v", err)
+	}
+	defer func() { _ = s.Close() }()
+	if !s.Available() {
+		t.Fatal("expected the sink to be available")
+	}
+
+	slog.New(s).With("__method", "GET").Warn("slow query", "request_id", "r-1", "sql", "SELECT 1\nFROM t",
+		"message", "shadow", "priority", 0, "level", "debug", "syslog_identifier", "other")
+
+	buf := make([]byte, 4096)
+	_ = server.SetReadDeadline(time.Now().Add(2 * time.Second))
+	n, err := server.Read(buf)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	entry := string(buf[:n])
+
+	for _, want := range []string{
+		"MESSAGE=slow query\n",
+		"PRIORITY=4\n",
+		"SYSLOG_IDENTIFIER=billing\n",
+		"METHOD=GET\n",
+		"REQUEST_ID=r-1\n",
+		"SQL\n\x0f\x00\x00\x00\x00\x00\x00\x00SELECT 1\nFROM t\n",
+		"ATTR_MESSAGE=shadow\n",
+		"ATTR_PRIORITY=0\n",
+		"ATTR_LEVEL=debug\n",
+		"ATTR_SYSLOG_IDENTIFIER=other\n",
+	} {
+		if !strings.Contains(entry, want) {
+			t.Errorf("entry missing %q:\n%q", want, entry)
+		}
+	}
+	// Each field the sink sets appears exactly once
+	for _, field := range []string{"MESSAGE=", "PRIORITY=", "LEVEL=", "SYSLOG_IDENTIFIER="} {
+		if n := strings.Count("\n"+entry, "\n"+field); n != 1 {
+			t.Errorf("entry has %d %s fields:\n%q", n, field, entry)
+		}
+	}
+}
+
+func TestJournaldSinkUnavailable(t *testing.T) {
+	s, err := NewJournaldSink(JournaldSinkConfig{Socket: filepath.Join(t.TempDir(), "missing.sock")})
+	if err != nil {
+		t.Fatalf("NewJournaldSink() error: %v", err)
+	}
+	if s.Available() || s.Enabled(context.Background(), slog.LevelError) {
+		t.Error("expected a no-op sink without journald")
+	}
+	if err := s.Handle(context.Background(), slog.NewRecord(time.Now(), slog.LevelError, "x", 0)); err != nil {
+		t.Errorf("Handle() error: %v", err)
+	}
+}
+
+func TestJournalFieldNameAndPriority(t *testing.T) {
+	cases := map[string]string{
+		"request_id": "REQUEST_ID",
+		"__method":   "METHOD",
+		"http.route": "HTTP_ROUTE",
+		"2fa":        "FA",
+		"___":        "",
+	}
+	for in, want := range cases {
+		if got := journalFieldName(in); got != want {
+			t.Errorf("journalFieldName(%q) = %q, want %q", in, got, want)
+		}
+	}
+
+	levels := map[slog.Level]int{
+		logger.LevelTrace:  7,
+		logger.LevelInfo:   6,
+		logger.LevelNotice: 5,
+		logger.LevelWarn:   4,
+		logger.LevelError:  3,
+		logger.LevelAudit:  5,
+	}
+	for level, want := range levels {
+		if got := journalPriority(level); got != want {
+			t.Errorf("journalPriority(%v) = %d, want %d", level, got, want)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package logger
+
+import (
+	"context"
+	"log/slog"
+	"math/rand/v2"
+)
+
+// SinkOptions selects the records one destination receives, on top of the
+// global Level and sampling, which decide what reaches any destination
+type SinkOptions struct {
+	Level       slog.Leveler           // Minimum level (default: every level the logger admits)
+	SampleRates map[slog.Level]float64 // Fraction kept per level, e.g. {slog.LevelInfo: 0.1}; other levels are all kept
+}
+
+// SinkHandler applies SinkOptions to one of AdditionalHandlers, so each
+// destination can have its own level and sampling:
+//
+//	loki, _ := sink.NewLokiSink(sink.LokiSinkConfig{URL: lokiURL})
+//	logger.SetConfig(logger.Config{
+//		Output: os.Stdout, // Everything
+//		AdditionalHandlers: []slog.Handler{
+//			logger.NewSinkHandler(loki, logger.SinkOptions{
+//				Level:       slog.LevelInfo,
+//				SampleRates: map[slog.Level]float64{slog.LevelInfo: 0.1}, // 10% of Info, all of Notice and above
+//			}),
+//		},
+//	})
+type SinkHandler struct {
+	opts  SinkOptions
+	inner slog.Handler
+}
+
+// NewSinkHandler wraps inner with opts
+func NewSinkHandler(inner slog.Handler, opts SinkOptions) *SinkHandler {
+	return &SinkHandler{opts: opts, inner: inner}
+}
+
+func (h *SinkHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	if h.opts.Level != nil && level < h.opts.Level.Level() {
+		return false
+	}
+	return h.inner.Enabled(ctx, level)
+}
+
+func (h *SinkHandler) Handle(ctx context.Context, record slog.Record) error {
+	if h.opts.Level != nil && record.Level < h.opts.Level.Level() {
+		return nil
+	}
+	if rate, ok := h.opts.SampleRates[record.Level]; ok && rate < 1 && rand.Float64() >= rate {
+		return nil
+	}
+	return h.inner.Handle(ctx, record)
+}
+
+func (h *SinkHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &SinkHandler{opts: h.opts, inner: h.inner.WithAttrs(attrs)}
+}
+
+func (h *SinkHandler) WithGroup(name string) slog.Handler {
+	return &SinkHandler{opts: h.opts, inner: h.inner.WithGroup(name)}
+}
+
+// Flush flushes the wrapped handler if it buffers, so Shutdown still
+// reaches it
+func (h *SinkHandler) Flush() error {
+	if f, ok := h.inner.(flusher); ok {
+		return f.Flush()
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+package logger
+
+import (
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestSinkHandler(t *testing.T) {
+	defer SetConfig(Config{Output: io.Discard, Level: LevelTrace})
+
+	primary, network := newSyncWriter(), newSyncWriter()
+	SetConfig(Config{Output: primary, Level: LevelTrace, CompactJSON: true, Format: FormatJSON,
+		AdditionalHandlers: []slog.Handler{
+			NewSinkHandler(slog.NewJSONHandler(network, &slog.HandlerOptions{Level: LevelTrace}), SinkOptions{
+				Level:       slog.LevelInfo,
+				SampleRates: map[slog.Level]float64{slog.LevelInfo: 0.1},
+			}),
+		},
+	})
+	for range 500 {
+		LogDebug("debug")
+		LogInfo("info")
+	}
+	LogWarn("warn")
+
+	if n := strings.Count(primary.String(), "\n"); n != 1001 {
+		t.Errorf("expected every record on the primary output, got %d", n)
+	}
+	out := network.String()
+	if strings.Contains(out, `"msg":"debug"`) {
+		t.Error("expected Debug below the sink level to be dropped")
+	}
+	if n := strings.Count(out, `"msg":"info"`); n < 20 || n > 100 {
+		t.Errorf("expected about 50 of 500 Info records, got %d", n)
+	}
+	if !strings.Contains(out, `"msg":"warn"`) {
+		t.Error("expected unsampled levels to be kept")
+	}
+}
@@ -0,0 +1,268 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"slices"
+	"sync"
+	"sync/atomic"
+)
+
+// DefaultSpillMaxBytes bounds the spill file when Config.SpillMaxBytes is zero
+const DefaultSpillMaxBytes = 64 << 20
+
+// spillHeaderSize is the size of a spilled record's header: the level as a
+// little-endian int32 followed by the record length as a uint32
+const spillHeaderSize = 8
+
+// spillReplayBatch is how many spilled records a worker replays before
+// checking the queue for new entries again
+const spillReplayBatch = 256
+
+// spillQueue is a bounded FIFO of rendered records kept in a file. Records
+// are appended at writeOff and replayed from readOff; the file is truncated
+// whenever the reader catches up.
+type spillQueue struct {
+	mu       sync.Mutex
+	path     string
+	file     *os.File // nil once closed
+	out      *batchWriter
+	maxBytes int64
+	readOff  int64
+	writeOff int64
+	buf      []byte // Replay scratch space
+
+	size      atomic.Int64  // writeOff - readOff, checked by enqueueAsync without mu
+	wake      chan struct{} // Signals the worker that records were spilled
+	abandoned atomic.Bool   // Set by Shutdown on timeout: stop replaying, keep the file
+}
+
+// openSpillQueue opens or creates the spill file at path. Records left
+// behind by a process that exited before replaying them are kept and
+// replayed first; a torn record at the end is cut off. Symbolic links and
+// other non-regular files are refused, so a link planted at path cannot
+// make the truncation hit another file.
+func openSpillQueue(path string, maxBytes int64, out *batchWriter) (*spillQueue, error) {
+	f, err := openSpillFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("logger: failed to open spill file: %w", err)
+	}
+
+	q := &spillQueue{
+		path:     path,
+		file:     f,
+		out:      out,
+		maxBytes: maxBytes,
+		wake:     make(chan struct{}, 1),
+	}
+	end, err := q.scan()
+	if err == nil {
+		err = f.Truncate(end)
+	}
+	if err != nil {
+		_ = f.Close()
+		return nil, fmt.Errorf("logger: failed to read spill file: %w", err)
+	}
+	q.writeOff = end
+	q.size.Store(end)
+	return q, nil
+}
+
+// openSpillFile opens the regular file at path for reading and writing,
+// creating it if it does not exist, without following symbolic links
+func openSpillFile(path string) (*os.File, error) {
+	before, err := os.Lstat(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		// O_EXCL fails on any link created since
+		return os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0o600)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !before.Mode().IsRegular() {
+		return nil, fmt.Errorf("%s is not a regular file", path)
+	}
+	f, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		return nil, err
+	}
+	// The path may have been swapped for a link between Lstat and OpenFile
+	if after, err := f.Stat(); err != nil || !os.SameFile(before, after) {
+		_ = f.Close()
+		return nil, fmt.Errorf("%s changed while being opened", path)
+	}
+	return f, nil
+}
+
+// scan returns the offset just past the last complete record in the file
+func (q *spillQueue) scan() (int64, error) {
+	info, err := q.file.Stat()
+	if err != nil {
+		return 0, err
+	}
+	var hdr [spillHeaderSize]byte
+	var off int64
+	for off+spillHeaderSize <= info.Size() {
+		if _, err := q.file.ReadAt(hdr[:], off); err != nil {
+			return 0, err
+		}
+		next := off + spillHeaderSize + int64(binary.LittleEndian.Uint32(hdr[4:]))
+		if next > info.Size() {
+			break
+		}
+		off = next
+	}
+	return off, nil
+}
+
+// pending reports whether spilled records are waiting to be replayed
+func (q *spillQueue) pending() bool {
+	return q.size.Load() > 0
+}
+
+// push appends a rendered record. It reports false when the record would
+// grow the spill past maxBytes or the queue is closed.
+func (q *spillQueue) push(level slog.Level, p []byte) (bool, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	n := int64(spillHeaderSize + len(p))
+	if q.file == nil || q.writeOff-q.readOff+n > q.maxBytes {
+		return false, nil
+	}
+
+	rec := make([]byte, spillHeaderSize, n)
+	binary.LittleEndian.PutUint32(rec[0:], uint32(int32(level)))
+	binary.LittleEndian.PutUint32(rec[4:], uint32(len(p)))
+	rec = append(rec, p...)
+	if _, err := q.file.WriteAt(rec, q.writeOff); err != nil {
+		return false, fmt.Errorf("logger: failed to write spill file: %w", err)
+	}
+	q.writeOff += n
+	q.size.Store(q.writeOff - q.readOff)
+
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+	return true, nil
+}
+
+// replay writes up to limit spilled records to the output, or all of them
+// when limit is zero
+func (q *spillQueue) replay(limit int) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.file == nil {
+		return
+	}
+	var hdr [spillHeaderSize]byte
+	for i := 0; (limit == 0 || i < limit) && q.readOff < q.writeOff && !q.abandoned.Load(); i++ {
+		if _, err := q.file.ReadAt(hdr[:], q.readOff); err != nil {
+			q.discard(err)
+			return
+		}
+		n := int(binary.LittleEndian.Uint32(hdr[4:]))
+		q.buf = slices.Grow(q.buf[:0], n)[:n]
+		if _, err := q.file.ReadAt(q.buf, q.readOff+spillHeaderSize); err != nil {
+			q.discard(err)
+			return
+		}
+		// The batch writer reports output failures and uses FallbackOutput
+		level := slog.Level(int32(binary.LittleEndian.Uint32(hdr[0:])))
+		_, _ = q.out.WriteLevel(level, q.buf)
+		q.readOff += spillHeaderSize + int64(n)
+	}
+	if q.readOff == q.writeOff {
+		q.reset()
+	}
+	q.size.Store(q.writeOff - q.readOff)
+}
+
+// discard drops the unreadable remainder of the spill
+func (q *spillQueue) discard(err error) {
+	reportWriteError(*loadConfig(), fmt.Errorf("logger: failed to read spill file: %w", err))
+	q.reset()
+	q.size.Store(0)
+}
+
+// reset empties the file once everything in it has been replayed
+func (q *spillQueue) reset() {
+	q.readOff, q.writeOff = 0, 0
+	_ = q.file.Truncate(0)
+}
+
+// close replays what is left and removes the spill file. An abandoned
+// queue keeps its file, trimmed to the records not yet replayed.
+func (q *spillQueue) close() {
+	q.replay(0)
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.file == nil {
+		return
+	}
+	if q.abandoned.Load() && q.readOff < q.writeOff {
+		q.compact()
+		_ = q.file.Close()
+	} else {
+		_ = q.file.Close()
+		_ = os.Remove(q.path)
+	}
+	q.file = nil
+}
+
+// compact moves the records not yet replayed to the start of the file
+func (q *spillQueue) compact() {
+	if q.readOff == 0 {
+		return
+	}
+	rest := make([]byte, q.writeOff-q.readOff)
+	if _, err := q.file.ReadAt(rest, q.readOff); err != nil {
+		return
+	}
+	if _, err := q.file.WriteAt(rest, 0); err != nil {
+		return
+	}
+	_ = q.file.Truncate(int64(len(rest)))
+	q.readOff, q.writeOff = 0, int64(len(rest))
+}
+
+// spillEntry renders entry and appends it to q, counting it as dropped when
+// the spill is full. A custom Handler writes while rendering, leaving
+// nothing to spill.
+func spillEntry(q *spillQueue, entry *logEntry, cfg Config) {
+	buf := renderBufferPool.Get().(*bytes.Buffer)
+	buf.Reset()
+	defer renderBufferPool.Put(buf)
+
+	entry.render(buf)
+	if buf.Len() == 0 {
+		return
+	}
+	ok, err := q.push(slogLevelFromLogLevel(entry.level), buf.Bytes())
+	if err != nil {
+		reportWriteError(cfg, err)
+	}
+	if !ok {
+		recordDropped(cfg)
+		return
+	}
+	if m := currentMetrics(); cfg.EnableMetrics && m != nil {
+		atomic.AddInt64(&m.SpilledLogs, 1)
+	}
+}
+
+// drainSpill replays spilled records while the async queue is empty
+func drainSpill(q *spillQueue, entries <-chan *logEntry) {
+	for q.pending() && len(entries) == 0 && !q.abandoned.Load() {
+		q.replay(spillReplayBatch)
+	}
+	q.out.flushIfDue(len(entries) == 0)
+}
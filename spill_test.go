@@ -0,0 +1,170 @@
+package logger
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAsyncSpillReplaysInOrder(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "spill")
+	bw := newBlockingWriter()
+	SetConfig(Config{
+		Output:              bw,
+		Level:               LevelTrace,
+		AsyncMode:           true,
+		BufferSize:          2,
+		FlushTimeout:        time.Hour,
+		AsyncOverflowPolicy: OverflowSpill,
+		SpillPath:           path,
+		CompactJSON:         true,
+		EnableMetrics:       true,
+	})
+	defer SetConfig(Config{Output: bw, Level: LevelTrace})
+
+	// Park the worker inside Write so the buffer cannot drain
+	LogInfo("first")
+	<-bw.started
+
+	for i := range 20 {
+		LogInfo("overflow", "n", i)
+	}
+
+	if n := GetMetrics()["spilled_logs"].(int64); n == 0 {
+		t.Fatal("Expected entries to be spilled")
+	}
+	if info, err := os.Stat(path); err != nil || info.Size() == 0 {
+		t.Fatalf("Expected a non-empty spill file, got %v, %v", info, err)
+	}
+
+	close(bw.release)
+	SetConfig(Config{Output: bw, Level: LevelTrace, EnableMetrics: true})
+
+	out := bw.String()
+	last := strings.Index(out, "first")
+	if last < 0 {
+		t.Fatalf("Expected the first entry, got %q", out)
+	}
+	for i := range 20 {
+		idx := strings.Index(out, fmt.Sprintf(`"n":%d}`, i))
+		if idx < 0 {
+			t.Fatalf("Missing entry %d in %q", i, out)
+		}
+		if idx < last {
+			t.Errorf("Entry %d written out of order", i)
+		}
+		last = idx
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("Expected the spill file to be removed, got %v", err)
+	}
+}
+
+func TestAsyncSpillLimit(t *testing.T) {
+	bw := newBlockingWriter()
+	SetConfig(Config{
+		Output:              bw,
+		Level:               LevelTrace,
+		AsyncMode:           true,
+		BufferSize:          1,
+		FlushTimeout:        time.Hour,
+		AsyncOverflowPolicy: OverflowSpill,
+		SpillPath:           filepath.Join(t.TempDir(), "spill"),
+		SpillMaxBytes:       512,
+		EnableMetrics:       true,
+	})
+	defer SetConfig(Config{Output: bw, Level: LevelTrace})
+
+	LogInfo("first")
+	<-bw.started
+	for i := range 50 {
+		LogInfo("overflow", "n", i)
+	}
+	close(bw.release)
+	SetConfig(Config{Output: bw, Level: LevelTrace, EnableMetrics: true})
+
+	m := GetMetrics()
+	if m["spilled_logs"].(int64) == 0 || m["dropped_logs"].(int64) == 0 {
+		t.Errorf("Expected both spilled and dropped entries, got %v and %v", m["spilled_logs"], m["dropped_logs"])
+	}
+}
+
+func TestSpillQueueRecoversLeftovers(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "spill")
+	var out bytes.Buffer
+	batch := newBatchWriter(&out, 1, time.Second)
+
+	q, err := openSpillQueue(path, DefaultSpillMaxBytes, batch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{"one\n", "two\n"} {
+		if ok, err := q.push(LevelInfo, []byte(line)); !ok || err != nil {
+			t.Fatalf("push(%q) = %v, %v", line, ok, err)
+		}
+	}
+	// Simulate a crash mid-write: the file keeps both records and a torn third
+	_ = q.file.Close()
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = f.Write([]byte{1, 2, 3})
+	_ = f.Close()
+
+	q, err = openSpillQueue(path, DefaultSpillMaxBytes, batch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !q.pending() {
+		t.Fatal("Expected leftover records to be pending")
+	}
+	q.close()
+
+	if got := out.String(); got != "one\ntwo\n" {
+		t.Errorf("Expected leftover records replayed, got %q", got)
+	}
+}
+
+func TestSpillConfigValidate(t *testing.T) {
+	cfg := defaultConfig
+	cfg.AsyncOverflowPolicy = OverflowSpill
+	if err := cfg.Validate(); err == nil {
+		t.Error("Expected error for OverflowSpill without SpillPath")
+	}
+	cfg.SpillPath = "/var/spool/app/log.spill"
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Expected OverflowSpill to be valid, got %v", err)
+	}
+	cfg.SpillMaxBytes = -1
+	if err := cfg.Validate(); err == nil {
+		t.Error("Expected error for negative SpillMaxBytes")
+	}
+	if s := OverflowSpill.String(); s != "spill" {
+		t.Errorf("Expected \"spill\", got %q", s)
+	}
+}
+
+func TestSpillRefusesSymlinks(t *testing.T) {
+	dir := t.TempDir()
+	target := filepath.Join(dir, "victim")
+	if err := os.WriteFile(target, []byte("keep me"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	link := filepath.Join(dir, "spill")
+	if err := os.Symlink(target, link); err != nil {
+		t.Skipf("symlinks unsupported: %v", err)
+	}
+
+	if q, err := openSpillQueue(link, DefaultSpillMaxBytes, nil); err == nil {
+		q.close()
+		t.Fatal("Expected a symlinked spill path to be refused")
+	}
+	if data, _ := os.ReadFile(target); string(data) != "keep me" {
+		t.Errorf("Expected the link target untouched, got %q", data)
+	}
+}
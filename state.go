@@ -0,0 +1,56 @@
+package logger
+
+import (
+	"log/slog"
+	"sync/atomic"
+
+	"github.com/jozefvalachovic/logger/v4/audit"
+)
+
+// runtimeState is everything a log call reads: the configuration and the
+// handler and collectors built from it. Changes publish a new state with a
+// single atomic store, so a concurrent log call sees either the old or the
+// new configuration, never a mix of both.
+type runtimeState struct {
+	config   Config
+	handler  slog.Handler     // Built-in formatter or Config.Handler, with failover, audit split and AdditionalHandlers
+	metrics  *LogMetrics      // nil unless EnableMetrics
+	dedup    *dedupManager    // nil unless EnableDedup
+	adaptive *adaptiveSampler // nil unless SampleBudget
+	burst    *burstSampler    // nil unless SampleFirst
+	tail     *tailBuffer      // nil unless TailSize
+	audit    *audit.Logger    // Enterprise audit logger, nil unless Config.Audit
+}
+
+// current is the published runtime state. It is replaced, never modified,
+// and writers hold configWriteMu.
+var current atomic.Pointer[runtimeState]
+
+// loadState returns the published runtime state
+func loadState() *runtimeState {
+	return current.Load()
+}
+
+// loadConfig returns the published configuration. It must not be modified.
+func loadConfig() *Config {
+	return &current.Load().config
+}
+
+// currentMetrics returns the metrics collector, nil when metrics are disabled
+func currentMetrics() *LogMetrics {
+	return current.Load().metrics
+}
+
+// publishState builds the handler for cfg and publishes it together with
+// the collectors in st. Callers hold configWriteMu.
+func publishState(cfg Config, st runtimeState) {
+	st.config = cfg
+	handlerLevel.Set(minLevel(cfg))
+	setFilters(cfg)
+	st.handler = newHandler(cfg)
+	current.Store(&st)
+
+	// Sync the stdlib log package level with our configured level
+	// so log.Print/log.Printf respect the same threshold (Go 1.26+).
+	slog.SetLogLoggerLevel(cfg.Level)
+}
@@ -0,0 +1,106 @@
+package logger
+
+import (
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestConcurrentSetConfigAndLog(t *testing.T) {
+	defer SetConfig(Config{Output: io.Discard, Level: LevelTrace})
+
+	configs := []Config{
+		{Output: io.Discard, Level: LevelTrace, EnableMetrics: true},
+		{Output: io.Discard, Level: LevelTrace, EnableDedup: true, DedupWindow: time.Millisecond},
+		{Output: io.Discard, Level: LevelTrace, AsyncMode: true, BufferSize: 4, EnableMetrics: true},
+		{Output: io.Discard, Level: LevelTrace, Format: FormatJSON, SampleRate: 0.5, SampleRateSet: true},
+		{Output: io.Discard, Level: LevelTrace, Handler: slog.NewJSONHandler(io.Discard, nil)},
+	}
+
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := range 8 {
+		wg.Go(func() {
+			for n := 0; ; n++ {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				LogInfo("stress", "worker", i, "n", n)
+				Named("db").LogDebug("query", "n", n)
+				_ = GetMetrics()
+			}
+		})
+	}
+	wg.Go(func() {
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			SetLevel(LevelDebug)
+			SetModuleLevel("db", LevelTrace)
+			_ = DebugSnapshot()
+			_ = HealthCheck()
+		}
+	})
+
+	for i := range 200 {
+		SetConfig(configs[i%len(configs)])
+	}
+	close(stop)
+	wg.Wait()
+}
+
+func TestSetConfigPublishesConfigWithHandler(t *testing.T) {
+	sw := newSyncWriter()
+	defer SetConfig(Config{Output: io.Discard, Level: LevelTrace})
+
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Go(func() {
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			SetConfig(Config{Output: sw, Level: LevelTrace, Format: FormatJSON})
+			SetConfig(Config{Output: sw, Level: LevelTrace, Format: FormatLogfmt})
+		}
+	})
+	for range 500 {
+		LogInfo("tick")
+	}
+	close(stop)
+	wg.Wait()
+
+	// Every line is complete in one format or the other
+	for line := range strings.Lines(sw.String()) {
+		if !strings.HasPrefix(line, "{") && !strings.HasPrefix(line, "time=") {
+			t.Fatalf("Unexpected line %q", line)
+		}
+	}
+}
+
+func TestMetricsSurviveSetConfig(t *testing.T) {
+	SetConfig(Config{Output: io.Discard, Level: LevelTrace, EnableMetrics: true})
+	defer SetConfig(Config{Output: io.Discard, Level: LevelTrace})
+
+	LogInfo("counted")
+	before := GetMetrics()["total_logs"].(int64)
+	SetConfig(Config{Output: io.Discard, Level: LevelTrace, EnableMetrics: true, Format: FormatJSON})
+	if after := GetMetrics()["total_logs"].(int64); after != before {
+		t.Errorf("Expected metrics to persist across SetConfig, got %d then %d", before, after)
+	}
+
+	SetConfig(Config{Output: io.Discard, Level: LevelTrace})
+	if m := GetMetrics(); len(m) != 0 {
+		t.Errorf("Expected no metrics once disabled, got %v", m)
+	}
+}
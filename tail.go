@@ -0,0 +1,176 @@
+package logger
+
+import (
+	"encoding/json"
+	"fmt"
+	"iter"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// TailEntry is a record kept in memory by Config.TailSize
+type TailEntry struct {
+	Time    time.Time      `json:"time"`
+	Level   slog.Level     `json:"level"`
+	Message string         `json:"msg"`
+	Attrs   map[string]any `json:"attrs,omitempty"` // Groups are nested maps
+}
+
+// MarshalJSON writes the level by its name (TRACE, NOTICE, ...)
+func (e TailEntry) MarshalJSON() ([]byte, error) {
+	type entry TailEntry
+	return json.Marshal(struct {
+		entry
+		Level string `json:"level"`
+	}{entry(e), LevelString(e.Level)})
+}
+
+// tailBuffer is a ring of the most recent records. The attributes it gets
+// are redacted by key already; values applies RedactPatterns and DetectPII,
+// which the handler would apply when writing them.
+type tailBuffer struct {
+	mu      sync.Mutex
+	values  valueRedactor
+	entries []TailEntry
+	next    int // Slot of the next record, the oldest once full
+	full    bool
+}
+
+func newTailBuffer(size int) *tailBuffer {
+	return &tailBuffer{entries: make([]TailEntry, size)}
+}
+
+// setValues replaces the string redaction after a configuration change
+func (t *tailBuffer) setValues(values valueRedactor) {
+	t.mu.Lock()
+	t.values = values
+	t.mu.Unlock()
+}
+
+// add keeps r, replacing the oldest record when the ring is full
+func (t *tailBuffer) add(r slog.Record) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	e := TailEntry{Time: r.Time, Level: r.Level, Message: r.Message}
+	if r.NumAttrs() > 0 {
+		e.Attrs = make(map[string]any, r.NumAttrs())
+		r.Attrs(func(a slog.Attr) bool {
+			e.Attrs[a.Key] = t.value(a.Value)
+			return true
+		})
+	}
+	t.entries[t.next] = e
+	if t.next++; t.next == len(t.entries) {
+		t.next, t.full = 0, true
+	}
+}
+
+// last returns up to n of the most recent records, oldest first; n <= 0
+// returns all of them
+func (t *tailBuffer) last(n int) []TailEntry {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	kept := t.next
+	if t.full {
+		kept = len(t.entries)
+	}
+	if n <= 0 || n > kept {
+		n = kept
+	}
+	out := make([]TailEntry, n)
+	for i := range out {
+		out[i] = t.entries[(t.next-n+i+len(t.entries))%len(t.entries)]
+	}
+	return out
+}
+
+// value returns the Go value of v, groups as maps
+func (t *tailBuffer) value(v slog.Value) any {
+	v = v.Resolve()
+	switch v.Kind() {
+	case slog.KindGroup:
+		group := make(map[string]any, len(v.Group()))
+		for _, a := range v.Group() {
+			group[a.Key] = t.value(a.Value)
+		}
+		return group
+	case slog.KindString:
+		if t.values.enabled() {
+			return t.values.redact(v.String())
+		}
+	}
+	return v.Any()
+}
+
+// Tail iterates over the last n records kept by Config.TailSize, oldest
+// first, or all of them when n <= 0. It yields nothing when TailSize is 0.
+// The records are copied when Tail is called, so logging from the loop is
+// safe:
+//
+//	for e := range logger.Tail(20) {
+//		fmt.Println(e.Time.Format(time.TimeOnly), logger.LevelString(e.Level), e.Message)
+//	}
+func Tail(n int) iter.Seq[TailEntry] {
+	var entries []TailEntry
+	if t := loadState().tail; t != nil {
+		entries = t.last(n)
+	}
+	return func(yield func(TailEntry) bool) {
+		for _, e := range entries {
+			if !yield(e) {
+				return
+			}
+		}
+	}
+}
+
+// TailHandler returns an http.Handler serving the records kept by
+// Config.TailSize as a JSON array, oldest first. The n query parameter
+// limits the count and level skips records below a level:
+//
+//	GET /debug/logs?n=50&level=warn
+//
+// Like DebugHandler, mount it on an internal/admin port only.
+func TailHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			writeLevelError(w, http.StatusMethodNotAllowed, fmt.Errorf("logger: method %s not allowed", r.Method))
+			return
+		}
+		query := r.URL.Query()
+		n := 0
+		if s := query.Get("n"); s != "" {
+			var err error
+			if n, err = strconv.Atoi(s); err != nil || n < 0 {
+				writeLevelError(w, http.StatusBadRequest, fmt.Errorf("logger: invalid n %q", s))
+				return
+			}
+		}
+		minLevel := slog.Level(-1 << 31)
+		if s := query.Get("level"); s != "" {
+			var err error
+			if minLevel, err = ParseLevel(s); err != nil {
+				writeLevelError(w, http.StatusBadRequest, err)
+				return
+			}
+		}
+
+		entries := []TailEntry{}
+		for e := range Tail(0) {
+			if e.Level >= minLevel {
+				entries = append(entries, e)
+			}
+		}
+		if n > 0 && len(entries) > n {
+			entries = entries[len(entries)-n:]
+		}
+		w.Header().Set("Content-Type", "application/json")
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		_ = enc.Encode(entries)
+	})
+}
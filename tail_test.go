@@ -0,0 +1,83 @@
+package logger
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+)
+
+func TestTail(t *testing.T) {
+	defer SetConfig(Config{Output: io.Discard, Level: LevelTrace})
+
+	SetConfig(NewConfig(WithOutput(io.Discard), WithLevel(LevelTrace), WithTail(3), WithDetectPII(PIIEmail)))
+	for _, msg := range []string{"one", "two", "three", "four"} {
+		LogInfo(msg, "password", "hunter2", "contact", "bob@example.com", slog.Group("req", slog.Int("n", 1)))
+	}
+
+	var msgs []string
+	for e := range Tail(0) {
+		msgs = append(msgs, e.Message)
+	}
+	if !slices.Equal(msgs, []string{"two", "three", "four"}) {
+		t.Errorf("expected the last 3 records oldest first, got %v", msgs)
+	}
+	for e := range Tail(1) {
+		if e.Message != "four" || e.Attrs["password"] != "***" || e.Attrs["contact"] != "***" {
+			t.Errorf("expected the newest record redacted, got %+v", e)
+		}
+		if req, _ := e.Attrs["req"].(map[string]any); req["n"] != int64(1) {
+			t.Errorf("expected groups as maps, got %v", e.Attrs["req"])
+		}
+	}
+
+	// The records survive a change that keeps the size
+	SetConfig(NewConfig(WithOutput(io.Discard), WithLevel(LevelTrace), WithTail(3), WithJSON()))
+	if n := len(slices.Collect(Tail(0))); n != 3 {
+		t.Errorf("expected 3 records kept across SetConfig, got %d", n)
+	}
+	SetConfig(Config{Output: io.Discard, Level: LevelTrace})
+	if n := len(slices.Collect(Tail(0))); n != 0 {
+		t.Errorf("expected no records with TailSize 0, got %d", n)
+	}
+
+	if err := SetConfigE(Config{Output: io.Discard, TailSize: -1}); err == nil {
+		t.Error("expected an error for a negative TailSize")
+	}
+}
+
+func TestTailHandler(t *testing.T) {
+	defer SetConfig(Config{Output: io.Discard, Level: LevelTrace})
+
+	SetConfig(NewConfig(WithOutput(io.Discard), WithLevel(LevelTrace), WithTail(10)))
+	LogDebug("cache miss")
+	LogWarn("slow query", "ms", 900)
+	LogError("query failed")
+	LogInfo("served")
+
+	rec := httptest.NewRecorder()
+	TailHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/?level=warn&n=1", nil))
+	var entries []map[string]any
+	if err := json.Unmarshal(rec.Body.Bytes(), &entries); err != nil {
+		t.Fatalf("invalid JSON %q: %v", rec.Body.String(), err)
+	}
+	if len(entries) != 1 || entries[0]["msg"] != "query failed" || entries[0]["level"] != "ERROR" {
+		t.Errorf("expected the newest record at warn or above, got %v", entries)
+	}
+
+	for target, status := range map[string]int{"/?n=x": http.StatusBadRequest, "/?level=loud": http.StatusBadRequest} {
+		rec = httptest.NewRecorder()
+		TailHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		if rec.Code != status {
+			t.Errorf("%s: expected %d, got %d", target, status, rec.Code)
+		}
+	}
+	rec = httptest.NewRecorder()
+	TailHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/", nil))
+	if rec.Code != http.StatusMethodNotAllowed || rec.Header().Get("Allow") != "GET, HEAD" {
+		t.Errorf("expected 405 with Allow, got %d %q", rec.Code, rec.Header().Get("Allow"))
+	}
+}
@@ -0,0 +1,197 @@
+package logger
+
+import "log/slog"
+
+// Style is the ANSI styling of one output component. The zero Style
+// leaves text unstyled.
+type Style struct {
+	Color     color // Blue, Cyan, ...; NoColor keeps the terminal's color
+	Bold      bool
+	Faint     bool
+	Underline bool
+}
+
+// isZero reports whether s adds no escape codes
+func (s Style) isZero() bool {
+	return s == Style{}
+}
+
+// appendStyled appends text wrapped in s's escape codes
+func (s Style) appendStyled(buf []byte, text string) []byte {
+	if s.isZero() {
+		return append(buf, text...)
+	}
+	if s.Bold {
+		buf = append(buf, "\033[1m"...)
+	}
+	if s.Faint {
+		buf = append(buf, "\033[2m"...)
+	}
+	if s.Underline {
+		buf = append(buf, "\033[4m"...)
+	}
+	buf = append(buf, colorCodes[s.Color]...)
+	buf = append(buf, text...)
+	return append(buf, "\033[0m"...)
+}
+
+// render returns text wrapped in s's escape codes
+func (s Style) render(text string) string {
+	if s.isZero() {
+		return text
+	}
+	return string(s.appendStyled(nil, text))
+}
+
+// Theme maps the components of the pretty format and the HTTP status codes
+// to styles. Set Config.Theme to use one; components with a zero Style are
+// written without escape codes.
+type Theme struct {
+	Levels       map[slog.Level]Style // Level labels
+	UnknownLevel Style                // Levels missing from Levels
+	Timestamp    Style
+	Source       Style // [file:line] with EnableCaller
+	Message      Style
+	Key          Style // Attribute keys (requires ColorizeJSON)
+	Value        Style // Attribute values (requires ColorizeJSON)
+	Status2xx    Style
+	Status3xx    Style
+	Status4xx    Style
+	Status5xx    Style
+}
+
+// DarkTheme returns the default theme, designed for dark backgrounds
+func DarkTheme() *Theme {
+	return &Theme{
+		Levels: map[slog.Level]Style{
+			LevelTrace:  {Color: Gray},
+			LevelDebug:  {Color: Purple},
+			LevelInfo:   {Color: Blue},
+			LevelNotice: {Color: Green},
+			LevelWarn:   {Color: Yellow},
+			LevelError:  {Color: Red},
+			LevelAudit:  {Color: BrightCyan},
+		},
+		UnknownLevel: Style{Color: Gray},
+		Source:       Style{Color: Gray},
+		Message:      Style{Color: Cyan},
+		Key:          Style{Color: Blue},
+		Status2xx:    Style{Color: Green},
+		Status3xx:    Style{Color: Blue},
+		Status4xx:    Style{Color: Red},
+		Status5xx:    Style{Color: Red},
+	}
+}
+
+// LightTheme returns a theme for light backgrounds: no bright or cyan text,
+// bold for the severe levels
+func LightTheme() *Theme {
+	return &Theme{
+		Levels: map[slog.Level]Style{
+			LevelTrace:  {Color: Gray},
+			LevelDebug:  {Color: Purple},
+			LevelInfo:   {Color: Blue},
+			LevelNotice: {Color: Green},
+			LevelWarn:   {Color: Yellow, Bold: true},
+			LevelError:  {Color: Red, Bold: true},
+			LevelAudit:  {Color: Purple, Bold: true, Underline: true},
+		},
+		UnknownLevel: Style{Color: Gray},
+		Timestamp:    Style{Color: Gray},
+		Source:       Style{Color: Gray},
+		Key:          Style{Color: Blue},
+		Value:        Style{Color: Green},
+		Status2xx:    Style{Color: Green},
+		Status3xx:    Style{Color: Blue},
+		Status4xx:    Style{Color: Red},
+		Status5xx:    Style{Color: Red, Bold: true},
+	}
+}
+
+// defaultTheme is used when Config.Theme is nil
+var defaultTheme = DarkTheme()
+
+// configTheme returns cfg's theme, falling back to the dark theme
+func configTheme(cfg Config) *Theme {
+	if cfg.Theme != nil {
+		return cfg.Theme
+	}
+	return defaultTheme
+}
+
+// levelLabels precomputes the styled label of every named level
+func (t *Theme) levelLabels() map[slog.Level]string {
+	labels := make(map[slog.Level]string, len(plainLevelLabels))
+	for level, label := range plainLevelLabels {
+		style, ok := t.Levels[level]
+		if !ok {
+			style = t.UnknownLevel
+		}
+		labels[level] = style.render(label)
+	}
+	return labels
+}
+
+// statusStyle returns the style for an HTTP status code
+func (t *Theme) statusStyle(code int) Style {
+	switch code / 100 {
+	case 2:
+		return t.Status2xx
+	case 3:
+		return t.Status3xx
+	case 4:
+		return t.Status4xx
+	case 5:
+		return t.Status5xx
+	default:
+		return Style{}
+	}
+}
+
+// appendColorizedJSON appends the JSON document src with keys and values
+// styled by t
+func (t *Theme) appendColorizedJSON(buf, src []byte) []byte {
+	for i := 0; i < len(src); {
+		switch c := src[i]; {
+		case c == '"':
+			end := i + 1
+			for end < len(src) && src[end] != '"' {
+				if src[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			end = min(end+1, len(src))
+			next := end
+			for next < len(src) && (src[next] == ' ' || src[next] == '\n' || src[next] == '\t') {
+				next++
+			}
+			style := t.Value
+			if next < len(src) && src[next] == ':' {
+				style = t.Key
+			}
+			buf = style.appendStyled(buf, string(src[i:end]))
+			i = end
+		case c == '-' || c >= '0' && c <= '9' || c == 't' || c == 'f' || c == 'n':
+			end := i + 1
+			for end < len(src) && !isJSONDelimiter(src[end]) {
+				end++
+			}
+			buf = t.Value.appendStyled(buf, string(src[i:end]))
+			i = end
+		default:
+			buf = append(buf, c)
+			i++
+		}
+	}
+	return buf
+}
+
+// isJSONDelimiter reports whether c ends a number or literal
+func isJSONDelimiter(c byte) bool {
+	switch c {
+	case ',', ':', '}', ']', ' ', '\n', '\t', '\r':
+		return true
+	}
+	return false
+}
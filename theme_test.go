@@ -0,0 +1,71 @@
+package logger
+
+import (
+	"bytes"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestThemeDefaultMatchesDark(t *testing.T) {
+	var buf bytes.Buffer
+	SetConfig(Config{Output: &buf, Level: slog.LevelInfo, LevelSet: true, CompactJSON: true, Color: ColorAlways})
+	defer SetConfig(Config{Output: io.Discard, Level: LevelTrace})
+
+	LogInfo("hello")
+	if want := "\x1b[34mINFO\x1b[0m \x1b[36mhello\x1b[0m\n"; !strings.HasSuffix(buf.String(), want) {
+		t.Errorf("got %q, want suffix %q", buf.String(), want)
+	}
+}
+
+func TestCustomTheme(t *testing.T) {
+	theme := LightTheme()
+	theme.Levels[LevelInfo] = Style{Color: Green, Underline: true}
+	theme.Timestamp = Style{}
+	theme.Message = Style{Bold: true}
+
+	var buf bytes.Buffer
+	SetConfig(Config{
+		Output:       &buf,
+		Level:        slog.LevelInfo,
+		LevelSet:     true,
+		CompactJSON:  true,
+		ColorizeJSON: true,
+		Color:        ColorAlways,
+		Theme:        theme,
+		TimeFormat:   "T",
+	})
+	defer SetConfig(Config{Output: io.Discard, Level: LevelTrace})
+
+	LogInfo("hello", "user", "ana", "n", 3, "ok", true)
+	want := "T \x1b[4m\x1b[32mINFO\x1b[0m \x1b[1mhello\x1b[0m {" +
+		"\x1b[34m\"n\"\x1b[0m:\x1b[32m3\x1b[0m," +
+		"\x1b[34m\"ok\"\x1b[0m:\x1b[32mtrue\x1b[0m," +
+		"\x1b[34m\"user\"\x1b[0m:\x1b[32m\"ana\"\x1b[0m}\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got  %q\nwant %q", got, want)
+	}
+}
+
+func TestThemeColorizedJSONEscapes(t *testing.T) {
+	theme := &Theme{Key: Style{Color: Blue}}
+	src := `{"a\"b": "x:\"y\"", "list": [1, -2.5e3, null]}`
+	got := string(theme.appendColorizedJSON(nil, []byte(src)))
+	want := "{\x1b[34m\"a\\\"b\"\x1b[0m: \"x:\\\"y\\\"\", \x1b[34m\"list\"\x1b[0m: [1, -2.5e3, null]}"
+	if got != want {
+		t.Errorf("got  %q\nwant %q", got, want)
+	}
+}
+
+func TestThemeStatusCodes(t *testing.T) {
+	SetConfig(Config{Output: io.Discard, Theme: &Theme{Status5xx: Style{Color: Red, Bold: true}}})
+	defer SetConfig(Config{Output: io.Discard, Level: LevelTrace})
+
+	if got, level := FormatStatusCode(503); got != "\x1b[1m\x1b[31m503\x1b[0m" || level != Error {
+		t.Errorf("FormatStatusCode(503) = %q, %v", got, level)
+	}
+	if got, level := FormatStatusCode(200); got != "200" || level != Info {
+		t.Errorf("FormatStatusCode(200) = %q, %v", got, level)
+	}
+}
@@ -0,0 +1,70 @@
+package logger
+
+import (
+	"context"
+	"slices"
+	"sync/atomic"
+	"time"
+)
+
+// Timer logs the time elapsed since it was started; see StartTimer and Span
+type Timer struct {
+	log       Logger
+	level     LogLevel
+	message   string
+	keyValues []any
+	start     time.Time
+	stopped   atomic.Bool
+}
+
+// StartTimer starts a timer that logs message at Info with the elapsed
+// "duration" when stopped:
+//
+//	defer logger.StartTimer("Rebuilt search index", "docs", n).Stop()
+func StartTimer(message string, keyValues ...any) *Timer {
+	return &Timer{log: DefaultLogger(), level: Info, message: message, keyValues: keyValues, start: time.Now()}
+}
+
+// Span logs "<name> started" at Debug and, when the returned timer is
+// stopped, "<name> finished" at Info with the elapsed "duration". Both go
+// through FromContext(ctx), so they carry the request's fields and level.
+//
+//	defer logger.Span(ctx, "load user", "user_id", id).Stop()
+func Span(ctx context.Context, name string, keyValues ...any) *Timer {
+	l := FromContext(ctx)
+	logVia(l, Debug, name+" started", append([]any{"span", name}, keyValues...))
+	return &Timer{
+		log:       l,
+		level:     Info,
+		message:   name + " finished",
+		keyValues: append([]any{"span", name}, keyValues...),
+		start:     time.Now(),
+	}
+}
+
+// Stop logs the elapsed time and returns it. Only the first call logs.
+func (t *Timer) Stop() time.Duration {
+	elapsed := time.Since(t.start)
+	if t.stopped.CompareAndSwap(false, true) {
+		logVia(t.log, t.level, t.message, append(slices.Clip(t.keyValues), "duration", elapsed))
+	}
+	return elapsed
+}
+
+// Elapsed returns the time since the timer started without stopping it
+func (t *Timer) Elapsed() time.Duration {
+	return time.Since(t.start)
+}
+
+// logVia logs through l, attributing the record to the caller of the
+// function that called logVia
+func logVia(l Logger, level LogLevel, message string, keyValues []any) {
+	switch l := l.(type) {
+	case *defaultLoggerImpl:
+		logModule(nil, 4, level, message, keyValues...)
+	case *childLogger:
+		logModule(l, 4, level, message, mergeKV(l.fields, keyValues...)...)
+	default:
+		l.Log(level, message, keyValues...)
+	}
+}
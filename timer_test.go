@@ -0,0 +1,63 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStartTimer(t *testing.T) {
+	var buf bytes.Buffer
+	SetConfig(Config{Output: &buf, Level: LevelTrace, CompactJSON: true, Format: FormatJSON, EnableCaller: true})
+	defer SetConfig(Config{Output: io.Discard, Level: LevelTrace})
+
+	timer := StartTimer("Rebuilt index", "docs", 3)
+	time.Sleep(time.Millisecond)
+	elapsed := timer.Stop()
+	timer.Stop()
+
+	if elapsed < time.Millisecond {
+		t.Errorf("Expected at least 1ms elapsed, got %v", elapsed)
+	}
+	if n := strings.Count(buf.String(), "Rebuilt index"); n != 1 {
+		t.Fatalf("Expected one record from two Stop calls, got %d:\n%s", n, buf.String())
+	}
+	var rec map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &rec); err != nil {
+		t.Fatal(err)
+	}
+	if rec["docs"] != float64(3) || rec["duration"] == nil {
+		t.Errorf("Expected docs and duration fields, got %v", rec)
+	}
+	if src, _ := rec["source"].(string); !strings.Contains(src, "timer_test.go") {
+		t.Errorf("Expected the caller to be attributed, got %v", rec["source"])
+	}
+}
+
+func TestSpan(t *testing.T) {
+	var buf bytes.Buffer
+	SetConfig(Config{Output: &buf, Level: LevelTrace, CompactJSON: true, Format: FormatJSON})
+	defer SetConfig(Config{Output: io.Discard, Level: LevelTrace})
+
+	ctx := NewContext(context.Background(), With("request_id", "r1"))
+	func() {
+		defer Span(ctx, "load user", "user_id", 7).Stop()
+	}()
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected start and end records, got:\n%s", buf.String())
+	}
+	if !strings.Contains(lines[0], `"msg":"load user started"`) || !strings.Contains(lines[0], `"level":"DEBUG"`) {
+		t.Errorf("Unexpected start record %s", lines[0])
+	}
+	for _, want := range []string{`"msg":"load user finished"`, `"span":"load user"`, `"user_id":7`, `"request_id":"r1"`, `"duration":`} {
+		if !strings.Contains(lines[1], want) {
+			t.Errorf("Expected %s in end record %s", want, lines[1])
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package logger
+
+import (
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+// FieldProcessor transforms one attribute before it is encoded. It returns
+// false to remove the attribute. Config.FieldProcessors run in order on
+// every attribute, including those inside groups, after redaction and hooks.
+type FieldProcessor func(a slog.Attr) (slog.Attr, bool)
+
+// RenameKey renames attributes called from to to, e.g. to match an
+// org-wide schema. With AuditFormatJSON it also renames the built-in
+// "time", "level" and "msg" keys (RenameKey("msg", "message")).
+func RenameKey(from, to string) FieldProcessor {
+	return func(a slog.Attr) (slog.Attr, bool) {
+		if a.Key == from {
+			a.Key = to
+		}
+		return a, true
+	}
+}
+
+// LowercaseKeys lowercases every attribute key
+func LowercaseKeys() FieldProcessor {
+	return func(a slog.Attr) (slog.Attr, bool) {
+		a.Key = strings.ToLower(a.Key)
+		return a, true
+	}
+}
+
+// TruncateValues cuts string values longer than maxBytes at a UTF-8
+// boundary and marks them with a trailing "…"
+func TruncateValues(maxBytes int) FieldProcessor {
+	return func(a slog.Attr) (slog.Attr, bool) {
+		if a.Value.Kind() != slog.KindString || len(a.Value.String()) <= maxBytes {
+			return a, true
+		}
+		return slog.String(a.Key, truncateUTF8(a.Value.String(), maxBytes)), true
+	}
+}
+
+// truncateUTF8 cuts s, longer than maxBytes, at a UTF-8 boundary and marks
+// it with a trailing "…"
+func truncateUTF8(s string, maxBytes int) string {
+	cut := maxBytes
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+	return s[:cut] + "…"
+}
+
+// CoerceString writes the values of the given keys as strings, e.g. so
+// numeric and string IDs share one type in the log index
+func CoerceString(keys ...string) FieldProcessor {
+	return func(a slog.Attr) (slog.Attr, bool) {
+		if a.Value.Kind() == slog.KindString || a.Value.Kind() == slog.KindGroup || !slices.Contains(keys, a.Key) {
+			return a, true
+		}
+		return slog.String(a.Key, fmt.Sprint(a.Value.Any())), true
+	}
+}
+
+// DropKeys removes attributes with the given keys
+func DropKeys(keys ...string) FieldProcessor {
+	return func(a slog.Attr) (slog.Attr, bool) {
+		return a, !slices.Contains(keys, a.Key)
+	}
+}
+
+// processAttrs applies processors to attrs in place and returns the kept ones
+func processAttrs(processors []FieldProcessor, attrs []slog.Attr) []slog.Attr {
+	n := 0
+	for _, a := range attrs {
+		if a, ok := processAttr(processors, a); ok {
+			attrs[n] = a
+			n++
+		}
+	}
+	return attrs[:n]
+}
+
+// processAttr applies processors to a, descending into groups
+func processAttr(processors []FieldProcessor, a slog.Attr) (slog.Attr, bool) {
+	a.Value = a.Value.Resolve()
+	for _, p := range processors {
+		var ok bool
+		if a, ok = p(a); !ok {
+			return a, false
+		}
+	}
+	if a.Value.Kind() == slog.KindGroup {
+		group := a.Value.Group()
+		kept := make([]slog.Attr, 0, len(group))
+		for _, ga := range group {
+			if ga, ok := processAttr(processors, ga); ok {
+				kept = append(kept, ga)
+			}
+		}
+		a.Value = slog.GroupValue(kept...)
+	}
+	return a, true
+}
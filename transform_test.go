@@ -0,0 +1,66 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestFieldProcessors(t *testing.T) {
+	var buf bytes.Buffer
+	SetConfig(Config{
+		Output:      &buf,
+		Level:       slog.LevelInfo,
+		LevelSet:    true,
+		CompactJSON: true,
+		FieldProcessors: []FieldProcessor{
+			LowercaseKeys(),
+			RenameKey("userid", "user_id"),
+			TruncateValues(5),
+			CoerceString("user_id"),
+			DropKeys("internal"),
+		},
+	})
+	defer SetConfig(Config{Output: io.Discard, Level: LevelTrace})
+
+	LogInfo("signed in", "UserID", 42, "Note", "héllo world", "internal", true)
+
+	out := buf.String()
+	for _, want := range []string{`"user_id":"42"`, `"note":"héll…"`} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %s in:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "internal") {
+		t.Errorf("expected internal to be dropped:\n%s", out)
+	}
+}
+
+func TestFieldProcessorsAuditJSONBuiltinKeys(t *testing.T) {
+	var audit bytes.Buffer
+	SetConfig(Config{
+		Output:          io.Discard,
+		AuditOutput:     &audit,
+		AuditFormat:     AuditFormatJSON,
+		Level:           slog.LevelInfo,
+		LevelSet:        true,
+		FieldProcessors: []FieldProcessor{RenameKey("msg", "message"), RenameKey("level", "severity")},
+	})
+	defer SetConfig(Config{Output: io.Discard, Level: LevelTrace})
+
+	LogAudit("action", "delete")
+
+	var record map[string]any
+	if err := json.Unmarshal(audit.Bytes(), &record); err != nil {
+		t.Fatalf("expected one JSON object, got %q: %v", audit.String(), err)
+	}
+	if _, ok := record["message"]; !ok || record["severity"] != "AUDIT" || record["action"] != "delete" {
+		t.Errorf("expected renamed built-in keys, got %v", record)
+	}
+	if _, ok := record["msg"]; ok {
+		t.Errorf("expected msg to be renamed, got %v", record)
+	}
+}
@@ -0,0 +1,142 @@
+package logger
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"log/slog"
+	"strconv"
+	"time"
+)
+
+// DurationFormat selects how time.Duration values are written
+type DurationFormat int
+
+const (
+	// DurationDefault writes integer nanoseconds, except the "duration"
+	// key, which is written as seconds ("1.500000000s") (default)
+	DurationDefault DurationFormat = iota
+	// DurationNanos writes integer nanoseconds
+	DurationNanos
+	// DurationMillis writes fractional milliseconds (1500.25)
+	DurationMillis
+	// DurationString writes the human form of time.Duration.String ("1.5s")
+	DurationString
+)
+
+// String returns the format name
+func (f DurationFormat) String() string {
+	switch f {
+	case DurationDefault:
+		return "default"
+	case DurationNanos:
+		return "nanos"
+	case DurationMillis:
+		return "millis"
+	case DurationString:
+		return "string"
+	default:
+		return "unknown"
+	}
+}
+
+// TimeValueFormat selects how time.Time attribute values are written. The
+// record timestamp itself uses Config.TimeFormat.
+type TimeValueFormat int
+
+const (
+	// TimeRFC3339 writes RFC 3339 with nanoseconds (default)
+	TimeRFC3339 TimeValueFormat = iota
+	// TimeUnix writes integer seconds since the Unix epoch
+	TimeUnix
+	// TimeUnixMillis writes integer milliseconds since the Unix epoch
+	TimeUnixMillis
+)
+
+// String returns the format name
+func (f TimeValueFormat) String() string {
+	switch f {
+	case TimeRFC3339:
+		return "rfc3339"
+	case TimeUnix:
+		return "unix"
+	case TimeUnixMillis:
+		return "unix_millis"
+	default:
+		return "unknown"
+	}
+}
+
+// BytesFormat selects how []byte values are written
+type BytesFormat int
+
+const (
+	// BytesBase64 writes standard base64, like encoding/json (default)
+	BytesBase64 BytesFormat = iota
+	// BytesHex writes lower-case hex
+	BytesHex
+	// BytesLength writes only the length, e.g. "12 bytes", so payloads
+	// never reach the log
+	BytesLength
+)
+
+// String returns the format name
+func (f BytesFormat) String() string {
+	switch f {
+	case BytesBase64:
+		return "base64"
+	case BytesHex:
+		return "hex"
+	case BytesLength:
+		return "length"
+	default:
+		return "unknown"
+	}
+}
+
+// formatAttrValue applies the configured duration, time and byte slice
+// formats to a, descending into groups
+func formatAttrValue(a slog.Attr, cfg Config) slog.Attr {
+	switch a.Value.Kind() {
+	case slog.KindDuration:
+		d := a.Value.Duration()
+		switch cfg.DurationFormat {
+		case DurationNanos:
+			return slog.Int64(a.Key, int64(d))
+		case DurationMillis:
+			return slog.Float64(a.Key, float64(d)/float64(time.Millisecond))
+		case DurationString:
+			return slog.String(a.Key, d.String())
+		}
+	case slog.KindTime:
+		switch cfg.TimeValueFormat {
+		case TimeUnix:
+			return slog.Int64(a.Key, a.Value.Time().Unix())
+		case TimeUnixMillis:
+			return slog.Int64(a.Key, a.Value.Time().UnixMilli())
+		}
+	case slog.KindAny:
+		if b, ok := a.Value.Any().([]byte); ok {
+			return slog.String(a.Key, formatBytes(b, cfg.BytesFormat))
+		}
+	case slog.KindGroup:
+		group := a.Value.Group()
+		formatted := make([]slog.Attr, len(group))
+		for i, ga := range group {
+			formatted[i] = formatAttrValue(ga, cfg)
+		}
+		a.Value = slog.GroupValue(formatted...)
+	}
+	return a
+}
+
+// formatBytes renders b in format
+func formatBytes(b []byte, format BytesFormat) string {
+	switch format {
+	case BytesHex:
+		return hex.EncodeToString(b)
+	case BytesLength:
+		return strconv.Itoa(len(b)) + " bytes"
+	default:
+		return base64.StdEncoding.EncodeToString(b)
+	}
+}
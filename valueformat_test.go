@@ -0,0 +1,103 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValueFormats(t *testing.T) {
+	at := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	payload := []byte("hello")
+
+	tests := []struct {
+		name string
+		cfg  Config
+		want []string
+	}{
+		{
+			name: "defaults",
+			want: []string{`"duration":"1.500000000s"`, `"took":1500000000`, `"at":"2026-01-02T03:04:05Z"`, `"body":"aGVsbG8="`, `"nested":{"at":"2026-01-02T03:04:05Z"}`},
+		},
+		{
+			name: "millis, unix, hex",
+			cfg:  Config{DurationFormat: DurationMillis, TimeValueFormat: TimeUnix, BytesFormat: BytesHex},
+			want: []string{`"duration":1500`, `"took":1500`, `"at":1767323045`, `"body":"68656c6c6f"`, `"nested":{"at":1767323045}`},
+		},
+		{
+			name: "string, unix millis, length",
+			cfg:  Config{DurationFormat: DurationString, TimeValueFormat: TimeUnixMillis, BytesFormat: BytesLength},
+			want: []string{`"duration":"1.5s"`, `"took":"1.5s"`, `"at":1767323045000`, `"body":"5 bytes"`},
+		},
+		{
+			name: "nanos",
+			cfg:  Config{DurationFormat: DurationNanos},
+			want: []string{`"duration":1500000000`, `"took":1500000000`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			cfg := tt.cfg
+			cfg.Output = &buf
+			cfg.Level = slog.LevelInfo
+			cfg.LevelSet = true
+			cfg.CompactJSON = true
+			SetConfig(cfg)
+			defer SetConfig(Config{Output: io.Discard, Level: LevelTrace})
+
+			LogInfo("formats", "duration", 1500*time.Millisecond, Dur("took", 1500*time.Millisecond),
+				"at", at, "body", payload, Group("nested", "at", at))
+
+			out := buf.String()
+			for _, want := range tt.want {
+				if !strings.Contains(out, want) {
+					t.Errorf("expected %s in:\n%s", want, out)
+				}
+			}
+		})
+	}
+}
+
+func TestValueFormatsAuditJSON(t *testing.T) {
+	var audit bytes.Buffer
+	SetConfig(Config{
+		Output:          io.Discard,
+		AuditOutput:     &audit,
+		AuditFormat:     AuditFormatJSON,
+		Level:           slog.LevelInfo,
+		LevelSet:        true,
+		DurationFormat:  DurationMillis,
+		TimeValueFormat: TimeUnix,
+		BytesFormat:     BytesHex,
+	})
+	defer SetConfig(Config{Output: io.Discard, Level: LevelTrace})
+
+	LogAudit("took", 2*time.Second, "at", time.Unix(1700000000, 0), "digest", []byte{0xde, 0xad})
+
+	var record map[string]any
+	if err := json.Unmarshal(audit.Bytes(), &record); err != nil {
+		t.Fatalf("expected one JSON object, got %q: %v", audit.String(), err)
+	}
+	if record["took"] != 2000.0 || record["at"] != 1700000000.0 || record["digest"] != "dead" {
+		t.Errorf("expected formatted values, got %v", record)
+	}
+}
+
+func TestValueFormatsValidate(t *testing.T) {
+	for _, cfg := range []Config{
+		{DurationFormat: DurationString + 1},
+		{TimeValueFormat: TimeUnixMillis + 1},
+		{BytesFormat: BytesLength + 1},
+	} {
+		cfg.Output, cfg.TimeFormat, cfg.RedactMask = io.Discard, "15:04:05", "***"
+		if err := cfg.Validate(); err == nil {
+			t.Errorf("expected an error for %+v", cfg)
+		}
+	}
+}